@@ -8,12 +8,15 @@ import (
 	"ozon-posts/internal/repositories/inmemory"
 	"ozon-posts/internal/repositories/postgres"
 	"ozon-posts/internal/services"
+	"ozon-posts/pkg/auth"
 	"ozon-posts/pkg/logger"
+	"ozon-posts/pkg/metrics"
 	"syscall"
 	"time"
 
 	"ozon-posts/internal/config"
 	"ozon-posts/internal/handlers/graphql"
+	"ozon-posts/internal/handlers/health"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
@@ -24,15 +27,35 @@ func main() {
 
 	l := logger.NewLogger(cfg)
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, l, os.Args[2:]); err != nil {
+			l.WithError(err).Fatal("Ошибка выполнения миграций")
+		}
+		return
+	}
+
 	l.WithFields(logrus.Fields{
-		"config": cfg,
+		"config": cfg.Redacted(),
 	}).Info("Запуск приложения")
 
+	eventBusOptions := services.EventBusOptions{
+		ReplaySize:       cfg.Events.ReplaySize,
+		SubscriberBuffer: cfg.Events.SubscriberBuffer,
+		StreamRetention:  cfg.Events.StreamRetention,
+	}
+
 	var (
-		userRepo    services.UserRepository
-		postRepo    services.PostRepository
-		commentRepo services.CommentRepository
-		db          *sqlx.DB
+		userRepo         services.UserRepository
+		postRepo         services.PostRepository
+		commentRepo      services.CommentRepository
+		reactionRepo     services.ReactionRepository
+		notificationRepo services.NotificationRepository
+		followRepo       services.FollowRepository
+		reportRepo       services.ReportRepository
+		unitOfWork       services.UnitOfWork
+		reconciler       services.CounterReconciler
+		eventBus         services.EventBus
+		db               *sqlx.DB
 	)
 
 	if cfg.Database.IsPostgresMode() {
@@ -45,33 +68,133 @@ func main() {
 		}
 		defer db.Close()
 
+		if err := prepareSchema(cfg, db, l); err != nil {
+			l.WithError(err).Fatal("Схема базы данных не готова")
+		}
+
 		userRepo = postgres.NewUserRepository(db, l)
 		postRepo = postgres.NewPostRepository(db, l)
 		commentRepo = postgres.NewCommentRepository(db, l)
+		reactionRepo = postgres.NewReactionRepository(db, l)
+		notificationRepo = postgres.NewNotificationRepository(db, l)
+		followRepo = postgres.NewFollowRepository(db, l)
+		reportRepo = postgres.NewReportRepository(db, l)
+		unitOfWork = postgres.NewUnitOfWork(db, l)
+		reconciler = postgres.NewCounterReconciler(db, l)
+
+		pgEventBus, err := postgres.NewEventBus(db, cfg.Database.GetPostgresDSN(), commentRepo, eventBusOptions, l)
+		if err != nil {
+			l.WithError(err).Fatal("Ошибка запуска шины событий PostgreSQL")
+		}
+		defer pgEventBus.Close()
+
+		eventBus = pgEventBus
 	} else {
 		l.Info("Инициализация in-memory репозиториев")
 
-		userRepo = inmemory.NewUserRepository(l)
-		postRepo = inmemory.NewPostRepository(l)
-		commentRepo = inmemory.NewCommentRepository(l)
+		if cfg.Database.IsDurableMemory() {
+			storage, err := inmemory.OpenStorage(inmemory.StorageOptions{
+				Dir:              cfg.Database.Memory.DataDir,
+				Fsync:            inmemory.FsyncPolicy(cfg.Database.Memory.Fsync),
+				FsyncInterval:    cfg.Database.Memory.FsyncInterval,
+				SnapshotInterval: cfg.Database.Memory.SnapshotInterval,
+			}, l)
+			if err != nil {
+				l.WithError(err).Fatal("Ошибка восстановления in-memory хранилища")
+			}
+			defer func() {
+				if err := storage.Close(); err != nil {
+					l.WithError(err).Error("Ошибка закрытия in-memory хранилища")
+				}
+			}()
+
+			userRepo = storage.Users()
+			postRepo = storage.Posts()
+			commentRepo = storage.Comments()
+			reactionRepo = storage.Reactions()
+			notificationRepo = storage.Notifications()
+			followRepo = storage.Follows()
+			reportRepo = storage.Reports()
+		} else {
+			userRepo = inmemory.NewUserRepository(l)
+			postRepo = inmemory.NewPostRepository(l)
+			commentRepo = inmemory.NewCommentRepository(postRepo, l)
+			reactionRepo = inmemory.NewReactionRepository(l)
+			notificationRepo = inmemory.NewNotificationRepository(l)
+			followRepo = inmemory.NewFollowRepository(l)
+			reportRepo = inmemory.NewReportRepository(l)
+		}
+		unitOfWork = inmemory.NewUnitOfWork(userRepo, postRepo, commentRepo, reactionRepo, notificationRepo, reportRepo)
+
+		var err error
+		reconciler, err = inmemory.NewCounterReconciler(postRepo, commentRepo, reactionRepo, unitOfWork)
+		if err != nil {
+			l.WithError(err).Fatal("Ошибка инициализации пересчета счетчиков")
+		}
+		eventBus = services.NewInProcessEventBus(eventBusOptions, l)
 
 		l.Info("In-memory репозитории успешно инициализированы")
 	}
 
-	userService := services.NewUserService(userRepo, l)
-	postService := services.NewPostService(postRepo, userRepo, l)
+	if cfg.Auth.Secret == config.DefaultAuthSecret {
+		l.Warn("Используется секрет подписи токенов по умолчанию, задайте AUTH_SECRET")
+	}
+
+	tokenManager := auth.NewTokenManager(cfg.Auth.Secret, cfg.Auth.TokenTTL)
+
+	userService := services.NewUserService(userRepo, tokenManager, l)
+	if cfg.Auth.BootstrapAdminUsername != "" {
+		if _, err := userService.EnsureAdmin(context.Background(), cfg.Auth.BootstrapAdminUsername, cfg.Auth.BootstrapAdminEmail, cfg.Auth.BootstrapAdminPassword); err != nil {
+			l.WithError(err).Fatal("Ошибка назначения администратора из BOOTSTRAP_ADMIN_USERNAME")
+		}
+	}
 	commentService := services.NewCommentService(
 		commentRepo,
 		postRepo,
 		userRepo,
+		unitOfWork,
+		eventBus,
 		l,
 	)
+	postService := services.NewPostService(postRepo, userRepo, unitOfWork, eventBus, l)
+	reactionService := services.NewReactionService(reactionRepo, unitOfWork, eventBus, l)
+	notificationService := services.NewNotificationService(notificationRepo, eventBus, l)
+	followService := services.NewFollowService(followRepo, userRepo, postRepo, l)
+	moderationService := services.NewModerationService(reportRepo, postRepo, commentRepo, userRepo, unitOfWork, eventBus, l)
+
+	queryLimits := graphql.QueryLimits{
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+		Timeout:       cfg.GraphQL.Timeout,
+	}
+
+	registry := metrics.NewRegistry()
+	registerEventBusMetrics(registry, eventBus)
+
+	healthHandler := health.NewHandler(l)
+	if db != nil {
+		registerDBMetrics(registry, db)
+		healthHandler.AddCheck("postgres", db.PingContext)
+	}
+
+	rateLimiter, err := newRateLimiter(cfg.RateLimit, db, l)
+	if err != nil {
+		l.WithError(err).Fatal("Некорректная конфигурация лимитов")
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	counterJob := services.NewCounterReconciliationJob(reconciler, cfg.Counters.ReconcileInterval, l)
+	go counterJob.Run(jobsCtx)
 
-	srv := graphql.InitGraphQLServer(userService, postService, commentService, l)
+	srv := graphql.InitGraphQLServer(userService, postService, commentService, reactionService, notificationService, followService, moderationService, queryLimits, rateLimiter, registry, l)
 
 	mux := http.NewServeMux()
 
-	mux.Handle("/query", srv)
+	mux.Handle("/query", graphql.ClientIPMiddleware(cfg.RateLimit.TrustProxy, graphql.AuthMiddleware(userService, l, srv)))
+	mux.Handle("/metrics", registry.Handler())
+	healthHandler.Register(mux)
 
 	httpServer := &http.Server{
 		Addr:    cfg.GetServerAddr(),
@@ -91,6 +214,8 @@ func main() {
 	<-quit
 
 	l.Info("Завершение работы сервера...")
+	healthHandler.SetShuttingDown()
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
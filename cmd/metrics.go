@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"ozon-posts/internal/services"
+	"ozon-posts/pkg/metrics"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// registerEventBusMetrics публикует подписки по постам и пользователям и события, потерянные при отключении
+// медленных подписчиков. Значения читаются из шины в момент запроса /metrics.
+func registerEventBusMetrics(registry *metrics.Registry, bus services.EventBus) {
+	registry.NewFunc(
+		"graphql_active_subscriptions",
+		"Активные подписки на комментарии по постам",
+		metrics.TypeGauge,
+		[]string{"post_id"},
+		func() []metrics.Sample {
+			stats := bus.Stats()
+			samples := make([]metrics.Sample, 0, len(stats.Subscribers))
+			for postID, count := range stats.Subscribers {
+				samples = append(samples, metrics.Sample{
+					LabelValues: []string{postID.String()},
+					Value:       float64(count),
+				})
+			}
+			return samples
+		},
+	)
+
+	registry.NewFunc(
+		"graphql_active_user_subscriptions",
+		"Активные подписки пользователей на адресованные им события",
+		metrics.TypeGauge,
+		nil,
+		func() []metrics.Sample {
+			return []metrics.Sample{{Value: float64(bus.Stats().UserSubscribers)}}
+		},
+	)
+
+	registry.NewFunc(
+		"comment_events_dropped_total",
+		"События комментариев, не доставленные подписчикам из-за переполнения буфера",
+		metrics.TypeCounter,
+		nil,
+		func() []metrics.Sample {
+			return []metrics.Sample{{Value: float64(bus.Stats().DroppedEvents)}}
+		},
+	)
+}
+
+// registerDBMetrics публикует статистику пула соединений PostgreSQL
+func registerDBMetrics(registry *metrics.Registry, db *sqlx.DB) {
+	stat := func(name, help string, typ metrics.Type, value func(sql.DBStats) float64) {
+		registry.NewFunc(name, help, typ, nil, func() []metrics.Sample {
+			return []metrics.Sample{{Value: value(db.Stats())}}
+		})
+	}
+
+	stat("db_pool_max_open_connections", "Максимальное число открытых соединений", metrics.TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	stat("db_pool_open_connections", "Открытые соединения", metrics.TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	stat("db_pool_in_use_connections", "Соединения, занятые запросами", metrics.TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	stat("db_pool_idle_connections", "Простаивающие соединения", metrics.TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	stat("db_pool_wait_count_total", "Сколько раз запрос ждал свободное соединение", metrics.TypeCounter,
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	stat("db_pool_wait_duration_seconds_total", "Суммарное время ожидания свободного соединения", metrics.TypeCounter,
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	stat("db_pool_max_idle_closed_total", "Соединения, закрытые из-за лимита простаивающих", metrics.TypeCounter,
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	stat("db_pool_max_lifetime_closed_total", "Соединения, закрытые по истечении времени жизни", metrics.TypeCounter,
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
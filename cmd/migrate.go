@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"ozon-posts/internal/config"
+	"ozon-posts/internal/repositories/postgres"
+	"ozon-posts/migrations"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const migrateUsage = `Использование: main migrate <команда> [флаги]
+
+Команды:
+  up      [-steps N] [-dry-run]          применить ожидающие миграции (по умолчанию все)
+  down    [-steps N] [-all] [-dry-run]   откатить миграции (по умолчанию одну)
+  status                                 показать версию схемы и ожидающие миграции
+  force   VERSION                        записать версию без выполнения миграций и снять dirty`
+
+// runMigrate выполняет подкоманду migrate над базой из конфигурации PostgreSQL
+func runMigrate(cfg *config.Config, l *logrus.Logger, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), migrateUsage)
+	}
+
+	if len(args) == 0 {
+		flags.Usage()
+		return fmt.Errorf("не указана команда миграций")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "up", "down", "status", "force":
+	default:
+		flags.Usage()
+		return fmt.Errorf("неизвестная команда миграций: %s", command)
+	}
+
+	steps := flags.Int("steps", 0, "число миграций")
+	all := flags.Bool("all", false, "откатить все миграции")
+	dryRun := flags.Bool("dry-run", false, "только показать миграции, не изменяя схему")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := postgres.InitPostgres(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := postgres.NewMigrator(db, migrations.FS, l)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		_, err = migrator.Up(ctx, postgres.MigrateOptions{Steps: *steps, DryRun: *dryRun})
+		return err
+
+	case "down":
+		opts := postgres.MigrateOptions{Steps: *steps, DryRun: *dryRun}
+		if !*all && opts.Steps <= 0 {
+			opts.Steps = 1
+		}
+		_, err = migrator.Down(ctx, opts)
+		return err
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		l.WithFields(logrus.Fields{
+			"version": status.Version,
+			"dirty":   status.Dirty,
+			"pending": len(status.Pending),
+		}).Info("Состояние схемы базы данных")
+
+		for _, migration := range status.Pending {
+			l.WithField("migration", migration.String()).Info("Ожидает применения")
+		}
+		return nil
+
+	case "force":
+		if flags.NArg() != 1 {
+			flags.Usage()
+			return fmt.Errorf("не указана версия схемы")
+		}
+
+		version, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("некорректная версия: %s", flags.Arg(0))
+		}
+		return migrator.Force(ctx, version)
+	}
+
+	return nil
+}
+
+// prepareSchema приводит схему к версии приложения при запуске или, если автоматические
+// миграции выключены, проверяет, что она уже актуальна
+func prepareSchema(cfg *config.Config, db *sqlx.DB, l *logrus.Logger) error {
+	migrator, err := postgres.NewMigrator(db, migrations.FS, l)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	if !cfg.Database.AutoMigrate {
+		return migrator.EnsureUpToDate(ctx)
+	}
+
+	_, err = migrator.Up(ctx, postgres.MigrateOptions{})
+	return err
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"ozon-posts/internal/config"
+	"ozon-posts/internal/repositories/postgres"
+	"ozon-posts/internal/services"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// newRateLimiter собирает лимиты мутаций из конфигурации. Возвращает nil, если лимиты выключены.
+// db равен nil в режиме memory.
+func newRateLimiter(cfg config.RateLimitConfig, db *sqlx.DB, l *logrus.Logger) (*services.RateLimiter, error) {
+	if !cfg.Enabled {
+		l.Warn("Лимиты частоты мутаций отключены")
+		return nil, nil
+	}
+
+	userLimit, err := services.ParseRateLimit(cfg.User)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_USER: %w", err)
+	}
+
+	ipLimit, err := services.ParseRateLimit(cfg.IP)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_IP: %w", err)
+	}
+
+	rules, err := services.ParseRateLimitRules(cfg.Rules, services.RateLimitRule{User: userLimit, IP: ipLimit})
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_RULES: %w", err)
+	}
+
+	store := cfg.Store
+	if store == "" {
+		store = "memory"
+		if db != nil {
+			store = "postgres"
+		}
+	}
+
+	var rateLimitStore services.RateLimitStore
+	switch store {
+	case "memory":
+		rateLimitStore = services.NewInProcessRateLimitStore()
+	case "postgres":
+		if db == nil {
+			return nil, fmt.Errorf("RATE_LIMIT_STORE=postgres доступно только при DB_TYPE=postgres")
+		}
+		rateLimitStore = postgres.NewRateLimitStore(db, l)
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище лимитов: %s", store)
+	}
+
+	l.WithFields(logrus.Fields{
+		"store":     store,
+		"user":      userLimit.String(),
+		"ip":        ipLimit.String(),
+		"overrides": len(rules.Mutations),
+	}).Info("Лимиты частоты мутаций включены")
+
+	return services.NewRateLimiter(rules, rateLimitStore, l), nil
+}
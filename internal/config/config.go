@@ -5,12 +5,26 @@ import (
 	"os"
 	"ozon-posts/internal/repositories"
 	"strconv"
+	"time"
 )
 
+const DefaultAuthSecret = "change-me"
+
+// redactedValue заменяет секреты в конфигурации, выводимой в лог
+const redactedValue = "***"
+
+// DefaultRateLimitRules ограничивает мутации, которыми проще всего заспамить или подобрать пароль
+const DefaultRateLimitRules = "createComment:user=10/1m,createPost:user=5/1m,register:ip=5/1m,login:ip=10/1m"
+
 type Config struct {
-	Server   ServerConfig         `json:"server"`
-	Database *repositories.Config `json:"database"`
-	Log      LogConfig            `json:"log"`
+	Server    ServerConfig         `json:"server"`
+	Database  *repositories.Config `json:"database"`
+	Log       LogConfig            `json:"log"`
+	Auth      AuthConfig           `json:"-"`
+	Events    EventsConfig         `json:"events"`
+	GraphQL   GraphQLConfig        `json:"graphql"`
+	RateLimit RateLimitConfig      `json:"rate_limit"`
+	Counters  CountersConfig       `json:"counters"`
 }
 
 type ServerConfig struct {
@@ -23,6 +37,47 @@ type LogConfig struct {
 	Format string `json:"format"`
 }
 
+// AuthConfig содержит секрет подписи токенов и пароль первого администратора, поэтому
+// в лог конфигурация выводится только через Redacted. BootstrapAdmin* назначают администратора при запуске; если пользователя нет, он создается
+// с указанными email и паролем.
+type AuthConfig struct {
+	Secret                 string
+	TokenTTL               time.Duration
+	BootstrapAdminUsername string
+	BootstrapAdminEmail    string
+	BootstrapAdminPassword string
+}
+
+type EventsConfig struct {
+	ReplaySize       int           `json:"replay_size"`
+	SubscriberBuffer int           `json:"subscriber_buffer"`
+	StreamRetention  time.Duration `json:"stream_retention"`
+}
+
+// GraphQLConfig - ограничения на запросы к GraphQL API, 0 отключает ограничение
+type GraphQLConfig struct {
+	MaxDepth      int           `json:"max_depth"`
+	MaxComplexity int           `json:"max_complexity"`
+	Timeout       time.Duration `json:"timeout"`
+}
+
+// RateLimitConfig - лимиты частоты мутаций. Лимиты задаются в формате N/период, "off" отключает лимит.
+// Rules переопределяет лимиты отдельных мутаций: "createComment:user=10/1m,login:ip=10/1m".
+// Store - memory или postgres; по умолчанию postgres в режиме postgres, иначе memory.
+type RateLimitConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Store      string `json:"store"`
+	User       string `json:"user"`
+	IP         string `json:"ip"`
+	Rules      string `json:"rules"`
+	TrustProxy bool   `json:"trust_proxy"`
+}
+
+// CountersConfig - фоновый пересчет счетчиков комментариев, ответов и реакций, 0 отключает пересчет
+type CountersConfig struct {
+	ReconcileInterval time.Duration `json:"reconcile_interval"`
+}
+
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
@@ -34,9 +89,62 @@ func Load() *Config {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		Auth: AuthConfig{
+			Secret:   getEnv("AUTH_SECRET", DefaultAuthSecret),
+			TokenTTL: getEnvAsDuration("AUTH_TOKEN_TTL", 24*time.Hour),
+
+			BootstrapAdminUsername: getEnv("BOOTSTRAP_ADMIN_USERNAME", ""),
+			BootstrapAdminEmail:    getEnv("BOOTSTRAP_ADMIN_EMAIL", ""),
+			BootstrapAdminPassword: getEnv("BOOTSTRAP_ADMIN_PASSWORD", ""),
+		},
+		Events: EventsConfig{
+			ReplaySize:       getEnvAsInt("EVENTS_REPLAY_SIZE", 100),
+			SubscriberBuffer: getEnvAsInt("EVENTS_SUBSCRIBER_BUFFER", 64),
+			StreamRetention:  getEnvAsDuration("EVENTS_STREAM_RETENTION", time.Hour),
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      getEnvAsInt("GRAPHQL_MAX_DEPTH", 12),
+			MaxComplexity: getEnvAsInt("GRAPHQL_MAX_COMPLEXITY", 10000),
+			Timeout:       getEnvAsDuration("GRAPHQL_TIMEOUT", 10*time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:    getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Store:      getEnv("RATE_LIMIT_STORE", ""),
+			User:       getEnv("RATE_LIMIT_USER", "60/1m"),
+			IP:         getEnv("RATE_LIMIT_IP", "120/1m"),
+			Rules:      getEnv("RATE_LIMIT_RULES", DefaultRateLimitRules),
+			TrustProxy: getEnvAsBool("RATE_LIMIT_TRUST_PROXY", false),
+		},
+		Counters: CountersConfig{
+			ReconcileInterval: getEnvAsDuration("COUNTERS_RECONCILE_INTERVAL", time.Hour),
+		},
 	}
 }
 
+// Redacted возвращает копию конфигурации для лога с замененными секретами: пароль PostgreSQL,
+// секрет подписи токенов и пароль первого администратора. Теги json скрывают поля только
+// от JSON-форматтера, а копию можно вывести любым форматтером logrus.
+func (c *Config) Redacted() Config {
+	redacted := *c
+
+	if c.Database != nil {
+		database := *c.Database
+		database.Postgres.Password = redactSecret(database.Postgres.Password)
+		redacted.Database = &database
+	}
+
+	redacted.Auth.Secret = redactSecret(c.Auth.Secret)
+	redacted.Auth.BootstrapAdminPassword = redactSecret(c.Auth.BootstrapAdminPassword)
+	return redacted
+}
+
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
 func (c *Config) GetServerAddr() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
@@ -55,3 +163,19 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if value, err := time.ParseDuration(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
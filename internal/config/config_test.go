@@ -0,0 +1,42 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"ozon-posts/internal/repositories"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := &Config{
+		Database: &repositories.Config{
+			Type:     "postgres",
+			Postgres: repositories.PostgresConfig{Host: "db", Password: "db-password"},
+		},
+		Auth: AuthConfig{
+			Secret:                 "token-secret",
+			BootstrapAdminUsername: "founder",
+			BootstrapAdminPassword: "founder-password",
+		},
+	}
+
+	for _, formatter := range []logrus.Formatter{&logrus.TextFormatter{}, &logrus.JSONFormatter{}} {
+		var out bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&out)
+		logger.SetFormatter(formatter)
+
+		logger.WithField("config", cfg.Redacted()).Info("Запуск приложения")
+
+		assert.NotContains(t, out.String(), "db-password")
+		assert.NotContains(t, out.String(), "token-secret")
+		assert.NotContains(t, out.String(), "founder-password")
+	}
+
+	// Исходная конфигурация не меняется
+	assert.Equal(t, "db-password", cfg.Database.Postgres.Password)
+	assert.Equal(t, "token-secret", cfg.Auth.Secret)
+}
@@ -11,8 +11,13 @@ import (
 
 const (
 	MaxCommentLength = 2000
+
+	// DeletedCommentPlaceholder заменяет текст удаленного комментария, ответы на него сохраняются
+	DeletedCommentPlaceholder = "[deleted]"
 )
 
+// Comment - комментарий к посту. AuthorID равен uuid.Nil, если автор удален: комментарий
+// остается в ветке без автора.
 type Comment struct {
 	ID        uuid.UUID  `json:"id" db:"id"`
 	PostID    uuid.UUID  `json:"post_id" db:"post_id"`
@@ -23,6 +28,15 @@ type Comment struct {
 	Level     int        `json:"level" db:"level"`
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy *uuid.UUID `json:"deleted_by,omitempty" db:"deleted_by"`
+	EditCount int        `json:"edit_count" db:"edit_count"`
+	EditedAt  *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	EditedBy  *uuid.UUID `json:"edited_by,omitempty" db:"edited_by"`
+
+	// Счетчики ведут репозитории: число прямых ответов и число реакций на комментарий
+	ReplyCount    int64 `json:"reply_count" db:"reply_count"`
+	ReactionCount int64 `json:"reaction_count" db:"reaction_count"`
 
 	Author   *User      `json:"author,omitempty"`
 	Post     *Post      `json:"post,omitempty"`
@@ -30,6 +44,44 @@ type Comment struct {
 	Children []*Comment `json:"children,omitempty"`
 }
 
+// CommentSort - порядок выдачи комментариев поста и ответов на комментарий
+type CommentSort string
+
+const (
+	CommentSortOldest CommentSort = "oldest"
+	CommentSortNewest CommentSort = "newest"
+	// CommentSortTop упорядочивает по числу реакций, CommentSortMostReplied - по числу прямых ответов;
+	// при равенстве счетчиков раньше идут более старые комментарии
+	CommentSortTop         CommentSort = "top"
+	CommentSortMostReplied CommentSort = "most_replied"
+)
+
+func (s CommentSort) IsValid() bool {
+	switch s {
+	case CommentSortOldest, CommentSortNewest, CommentSortTop, CommentSortMostReplied:
+		return true
+	}
+	return false
+}
+
+// Less сообщает, идет ли комментарий a раньше b в этом порядке. Порядок полный:
+// совпадающие счетчики и время создания различаются по id, как и в PostgreSQL.
+func (s CommentSort) Less(a, b *Comment) bool {
+	switch s {
+	case CommentSortNewest:
+		return b.Cursor().Less(a.Cursor())
+	case CommentSortTop:
+		if a.ReactionCount != b.ReactionCount {
+			return a.ReactionCount > b.ReactionCount
+		}
+	case CommentSortMostReplied:
+		if a.ReplyCount != b.ReplyCount {
+			return a.ReplyCount > b.ReplyCount
+		}
+	}
+	return a.Cursor().Less(b.Cursor())
+}
+
 func NewComment(postID, authorID uuid.UUID, content string, parent *Comment) (*Comment, error) {
 	if len(content) > MaxCommentLength {
 		return nil, errors.NewCommentTooLongError(MaxCommentLength)
@@ -61,3 +113,35 @@ func NewComment(postID, authorID uuid.UUID, content string, parent *Comment) (*C
 
 	return comment, nil
 }
+
+func (c *Comment) Cursor() Cursor {
+	return NewCursor(c.CreatedAt, c.ID)
+}
+
+func (c *Comment) IsEdited() bool {
+	return c.EditCount > 0
+}
+
+// ApplyEdit заменяет текст комментария и отмечает правку; прежнюю версию
+// нужно сохранить через NewCommentRevision до вызова
+func (c *Comment) ApplyEdit(content string, editorID uuid.UUID) {
+	now := time.Now()
+	c.Content = content
+	c.EditCount++
+	c.EditedAt = &now
+	c.EditedBy = &editorID
+	c.UpdatedAt = now
+}
+
+func (c *Comment) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// MarkDeleted помечает комментарий удаленным и стирает его текст
+func (c *Comment) MarkDeleted(deletedBy uuid.UUID) {
+	now := time.Now()
+	c.DeletedAt = &now
+	c.DeletedBy = &deletedBy
+	c.Content = DeletedCommentPlaceholder
+	c.UpdatedAt = now
+}
@@ -2,6 +2,7 @@ package entities
 
 import (
 	"ozon-posts/pkg/errors"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -152,3 +153,52 @@ func TestCommentContentVariations(t *testing.T) {
 		})
 	}
 }
+
+func TestComment_MarkDeleted(t *testing.T) {
+	comment, err := NewComment(uuid.New(), uuid.New(), "Original text", nil)
+	assert.NoError(t, err)
+	assert.False(t, comment.IsDeleted())
+
+	moderatorID := uuid.New()
+	comment.MarkDeleted(moderatorID)
+
+	assert.True(t, comment.IsDeleted())
+	assert.Equal(t, DeletedCommentPlaceholder, comment.Content)
+	assert.Equal(t, moderatorID, *comment.DeletedBy)
+	assert.True(t, time.Since(*comment.DeletedAt) < time.Second)
+}
+
+func TestCommentSort_Less(t *testing.T) {
+	base := time.Now()
+	newComment := func(offset time.Duration, replies, reactions int64) *Comment {
+		return &Comment{ID: uuid.New(), CreatedAt: base.Add(offset), ReplyCount: replies, ReactionCount: reactions}
+	}
+
+	first := newComment(0, 1, 5)
+	second := newComment(time.Second, 3, 5)
+	third := newComment(2*time.Second, 3, 1)
+
+	testCases := []struct {
+		sort     CommentSort
+		expected []*Comment
+	}{
+		{CommentSortOldest, []*Comment{first, second, third}},
+		{CommentSortNewest, []*Comment{third, second, first}},
+		{CommentSortTop, []*Comment{first, second, third}},
+		{CommentSortMostReplied, []*Comment{second, third, first}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.sort), func(t *testing.T) {
+			assert.True(t, tc.sort.IsValid())
+
+			comments := []*Comment{third, first, second}
+			sort.Slice(comments, func(i, j int) bool {
+				return tc.sort.Less(comments[i], comments[j])
+			})
+			assert.Equal(t, tc.expected, comments)
+		})
+	}
+
+	assert.False(t, CommentSort("random").IsValid())
+}
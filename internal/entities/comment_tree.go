@@ -0,0 +1,59 @@
+package entities
+
+const (
+	DefaultCommentTreeDepth = 10
+	MaxCommentTreeDepth     = 50
+	MaxCommentTreeNodes     = 500
+	MaxCommentTreeChildren  = 100
+)
+
+// DefaultCommentTreeChildLimits - лимиты детей на первом, втором и всех последующих уровнях дерева
+var DefaultCommentTreeChildLimits = []int{20, 10, 5}
+
+// CommentTreeNode - узел дерева комментариев, собранного на сервере
+type CommentTreeNode struct {
+	Comment         *Comment           `json:"comment"`
+	Children        []*CommentTreeNode `json:"children"`
+	HasMoreChildren bool               `json:"has_more_children"`
+	ChildCount      int                `json:"child_count"`
+}
+
+// CommentTreeRequest ограничивает размер дерева: глубину, число детей у узла на каждом уровне
+// и общее число узлов в ответе
+type CommentTreeRequest struct {
+	MaxDepth    int
+	ChildLimits []int
+	MaxNodes    int
+}
+
+func NewCommentTreeRequest(maxDepth int, childLimits []int) *CommentTreeRequest {
+	if maxDepth <= 0 || maxDepth > MaxCommentTreeDepth {
+		maxDepth = DefaultCommentTreeDepth
+	}
+
+	limits := make([]int, 0, len(childLimits))
+	for _, limit := range childLimits {
+		if limit <= 0 || limit > MaxCommentTreeChildren {
+			limit = DefaultCommentTreeChildLimits[0]
+		}
+		limits = append(limits, limit)
+	}
+	if len(limits) == 0 {
+		limits = append(limits, DefaultCommentTreeChildLimits...)
+	}
+
+	return &CommentTreeRequest{
+		MaxDepth:    maxDepth,
+		ChildLimits: limits,
+		MaxNodes:    MaxCommentTreeNodes,
+	}
+}
+
+// ChildLimit возвращает лимит детей для узлов на глубине depth относительно корня;
+// последний заданный лимит действует на всех более глубоких уровнях
+func (r *CommentTreeRequest) ChildLimit(depth int) int {
+	if depth >= len(r.ChildLimits) {
+		return r.ChildLimits[len(r.ChildLimits)-1]
+	}
+	return r.ChildLimits[depth]
+}
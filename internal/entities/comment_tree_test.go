@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCommentTreeRequest(t *testing.T) {
+	testCases := []struct {
+		name           string
+		maxDepth       int
+		childLimits    []int
+		expectedDepth  int
+		expectedLimits []int
+	}{
+		{"valid_values", 3, []int{5, 2}, 3, []int{5, 2}},
+		{"defaults", 0, nil, DefaultCommentTreeDepth, DefaultCommentTreeChildLimits},
+		{"too_deep", MaxCommentTreeDepth + 1, []int{5}, DefaultCommentTreeDepth, []int{5}},
+		{"invalid_limits", 2, []int{0, 500, 3}, 2, []int{20, 20, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := NewCommentTreeRequest(tc.maxDepth, tc.childLimits)
+
+			assert.Equal(t, tc.expectedDepth, req.MaxDepth)
+			assert.Equal(t, tc.expectedLimits, req.ChildLimits)
+			assert.Equal(t, MaxCommentTreeNodes, req.MaxNodes)
+		})
+	}
+}
+
+func TestCommentTreeRequest_ChildLimit(t *testing.T) {
+	req := NewCommentTreeRequest(5, []int{10, 4})
+
+	assert.Equal(t, 10, req.ChildLimit(0))
+	assert.Equal(t, 4, req.ChildLimit(1))
+	assert.Equal(t, 4, req.ChildLimit(4))
+}
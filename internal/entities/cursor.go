@@ -0,0 +1,137 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/base64"
+	"ozon-posts/pkg/errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor - позиция элемента в выборке, упорядоченной по (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func NewCursor(createdAt time.Time, id uuid.UUID) Cursor {
+	return Cursor{CreatedAt: createdAt, ID: id}
+}
+
+// Less сравнивает курсоры в порядке возрастания (created_at, id)
+func (c Cursor) Less(other Cursor) bool {
+	if !c.CreatedAt.Equal(other.CreatedAt) {
+		return c.CreatedAt.Before(other.CreatedAt)
+	}
+	return bytes.Compare(c.ID[:], other.ID[:]) < 0
+}
+
+// Encode возвращает непрозрачное строковое представление курсора для клиентов
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.NewInvalidRequestError("некорректный курсор")
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, errors.NewInvalidRequestError("некорректный курсор")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errors.NewInvalidRequestError("некорректный курсор")
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, errors.NewInvalidRequestError("некорректный курсор")
+	}
+
+	return &Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// CursorRequest - параметры keyset-пагинации в терминах Relay.
+// After и Before задаются относительно порядка выдачи списка, FromEnd соответствует аргументу last.
+type CursorRequest struct {
+	Limit   int
+	After   *Cursor
+	Before  *Cursor
+	FromEnd bool
+}
+
+// PageInfo - сведения о соседних страницах для Relay-соединений.
+// StartCursor и EndCursor заполняются при формировании ребер соединения.
+type PageInfo struct {
+	HasNextPage     bool    `json:"has_next_page"`
+	HasPreviousPage bool    `json:"has_previous_page"`
+	StartCursor     *string `json:"start_cursor,omitempty"`
+	EndCursor       *string `json:"end_cursor,omitempty"`
+}
+
+func NewCursorRequest(first, last *int, after, before *string) (*CursorRequest, error) {
+	if first != nil && last != nil {
+		return nil, errors.NewInvalidRequestError("нельзя одновременно передавать first и last")
+	}
+
+	req := &CursorRequest{Limit: 20}
+
+	limit := first
+	if last != nil {
+		limit = last
+		req.FromEnd = true
+	}
+	if limit != nil && *limit > 0 && *limit <= 100 {
+		req.Limit = *limit
+	}
+
+	if after != nil {
+		cursor, err := DecodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		req.After = cursor
+	}
+
+	if before != nil {
+		cursor, err := DecodeCursor(*before)
+		if err != nil {
+			return nil, err
+		}
+		req.Before = cursor
+	}
+
+	return req, nil
+}
+
+// NewCursorPage приводит выборку из Limit+1 элементов к странице в порядке выдачи списка.
+// При FromEnd элементы ожидаются в обратном порядке, как их возвращает запрос "с конца".
+func NewCursorPage[T any](items []T, req *CursorRequest) ([]T, *PageInfo) {
+	hasMore := len(items) > req.Limit
+	if hasMore {
+		items = items[:req.Limit]
+	}
+
+	if !req.FromEnd {
+		return items, &PageInfo{
+			HasNextPage:     hasMore,
+			HasPreviousPage: req.After != nil,
+		}
+	}
+
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+
+	return items, &PageInfo{
+		HasNextPage:     req.Before != nil,
+		HasPreviousPage: hasMore,
+	}
+}
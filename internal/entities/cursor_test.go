@@ -0,0 +1,134 @@
+package entities
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	appErrors "ozon-posts/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	original := NewCursor(time.Date(2025, 6, 1, 12, 30, 0, 123456789, time.UTC), uuid.New())
+
+	decoded, err := DecodeCursor(original.Encode())
+
+	require.NoError(t, err)
+	assert.True(t, original.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, original.ID, decoded.ID)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		encoded string
+	}{
+		{"not_base64", "!!!"},
+		{"missing_separator", "MjAyNQ"},
+		{"bad_time", base64.RawURLEncoding.EncodeToString([]byte("yesterday|" + uuid.NewString()))},
+		{"bad_id", base64.RawURLEncoding.EncodeToString([]byte("2025-06-01T12:30:00Z|42"))},
+		{"empty", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cursor, err := DecodeCursor(tc.encoded)
+
+			assert.Nil(t, cursor)
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, appErrors.ErrInvalidRequest, appErr.Code)
+		})
+	}
+}
+
+func TestCursor_Less(t *testing.T) {
+	now := time.Now()
+	low := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	high := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+	assert.True(t, NewCursor(now, high).Less(NewCursor(now.Add(time.Second), low)))
+	assert.True(t, NewCursor(now, low).Less(NewCursor(now, high)))
+	assert.False(t, NewCursor(now, high).Less(NewCursor(now, low)))
+	assert.False(t, NewCursor(now, low).Less(NewCursor(now, low)))
+}
+
+func TestNewCursorRequest(t *testing.T) {
+	cursor := NewCursor(time.Now(), uuid.New()).Encode()
+	invalid := "!!!"
+
+	testCases := []struct {
+		name            string
+		first           *int
+		last            *int
+		after           *string
+		before          *string
+		expectedLimit   int
+		expectedFromEnd bool
+		expectError     bool
+	}{
+		{"defaults", nil, nil, nil, nil, 20, false, false},
+		{"first", intPtr(5), nil, &cursor, nil, 5, false, false},
+		{"last", nil, intPtr(7), nil, &cursor, 7, true, false},
+		{"too_high_limit", intPtr(101), nil, nil, nil, 20, false, false},
+		{"negative_limit", nil, intPtr(-1), nil, nil, 20, true, false},
+		{"first_and_last", intPtr(5), intPtr(5), nil, nil, 0, false, true},
+		{"invalid_after", intPtr(5), nil, &invalid, nil, 0, false, true},
+		{"invalid_before", nil, intPtr(5), nil, &invalid, 0, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := NewCursorRequest(tc.first, tc.last, tc.after, tc.before)
+
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, req)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedLimit, req.Limit)
+			assert.Equal(t, tc.expectedFromEnd, req.FromEnd)
+			assert.Equal(t, tc.after != nil, req.After != nil)
+			assert.Equal(t, tc.before != nil, req.Before != nil)
+		})
+	}
+}
+
+func TestNewCursorPage(t *testing.T) {
+	after := NewCursor(time.Now(), uuid.New())
+
+	testCases := []struct {
+		name            string
+		items           []int
+		req             *CursorRequest
+		expectedItems   []int
+		expectedHasNext bool
+		expectedHasPrev bool
+	}{
+		{"forward_has_more", []int{1, 2, 3}, &CursorRequest{Limit: 2}, []int{1, 2}, true, false},
+		{"forward_last_page", []int{1, 2}, &CursorRequest{Limit: 2, After: &after}, []int{1, 2}, false, true},
+		{"backward_has_more", []int{3, 2, 1}, &CursorRequest{Limit: 2, FromEnd: true}, []int{2, 3}, false, true},
+		{"backward_first_page", []int{2, 1}, &CursorRequest{Limit: 2, FromEnd: true, Before: &after}, []int{1, 2}, true, false},
+		{"empty", []int{}, &CursorRequest{Limit: 2}, []int{}, false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			items, pageInfo := NewCursorPage(tc.items, tc.req)
+
+			assert.Equal(t, tc.expectedItems, items)
+			assert.Equal(t, tc.expectedHasNext, pageInfo.HasNextPage)
+			assert.Equal(t, tc.expectedHasPrev, pageInfo.HasPreviousPage)
+		})
+	}
+}
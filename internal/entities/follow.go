@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Follow - подписка пользователя FollowerID на публикации пользователя FolloweeID.
+// Follower и Followee заполняются сервисом для выдачи списков подписчиков и подписок.
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id" db:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id" db:"followee_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+
+	Follower *User `json:"follower,omitempty"`
+	Followee *User `json:"followee,omitempty"`
+}
+
+func NewFollow(followerID, followeeID uuid.UUID) *Follow {
+	return &Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// FollowerCursor - позиция в списке подписчиков пользователя FolloweeID
+func (f *Follow) FollowerCursor() Cursor {
+	return NewCursor(f.CreatedAt, f.FollowerID)
+}
+
+// FolloweeCursor - позиция в списке подписок пользователя FollowerID
+func (f *Follow) FolloweeCursor() Cursor {
+	return NewCursor(f.CreatedAt, f.FolloweeID)
+}
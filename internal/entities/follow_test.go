@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollow_Cursors(t *testing.T) {
+	followerID := uuid.New()
+	followeeID := uuid.New()
+
+	follow := NewFollow(followerID, followeeID)
+	assert.Equal(t, followerID, follow.FollowerID)
+	assert.Equal(t, followeeID, follow.FolloweeID)
+	assert.False(t, follow.CreatedAt.IsZero())
+
+	assert.Equal(t, NewCursor(follow.CreatedAt, followerID), follow.FollowerCursor())
+	assert.Equal(t, NewCursor(follow.CreatedAt, followeeID), follow.FolloweeCursor())
+}
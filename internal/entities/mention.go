@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxMentionsPerComment ограничивает число упоминаний, по которым рассылаются оповещения:
+// остальные упоминания в тексте остаются обычным текстом
+const MaxMentionsPerComment = 10
+
+// CommentMention - упоминание пользователя в комментарии
+type CommentMention struct {
+	CommentID uuid.UUID `json:"comment_id" db:"comment_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// mentionPattern находит @username, перед которым нет буквы, цифры или @: адреса почты
+// вида user@example.com упоминаниями не считаются
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_.\-]+)`)
+
+// ParseMentions возвращает имена упомянутых пользователей в порядке первого упоминания, без повторов.
+// Точки и дефисы в конце имени считаются знаками препинания, имена короче или длиннее допустимых
+// для пользователя пропускаются.
+func ParseMentions(content string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if len(username) < MinUsernameLength || len(username) > MaxUsernameLength || seen[username] {
+			continue
+		}
+
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == MaxMentionsPerComment {
+			break
+		}
+	}
+
+	return usernames
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"single", "@alice привет", []string{"alice"}},
+		{"order_and_duplicates", "@bob, @alice и снова @bob", []string{"bob", "alice"}},
+		{"punctuation", "Спасибо, @alice. А ты, @bob-?", []string{"alice", "bob"}},
+		{"dots_inside_name", "(@john.doe)", []string{"john.doe"}},
+		{"cyrillic", "@иван согласен", []string{"иван"}},
+		{"email_ignored", "пишите на support@example.com", nil},
+		{"double_at_ignored", "@@alice", nil},
+		{"too_short", "@al и @", nil},
+		{"too_long", "@" + strings.Repeat("a", MaxUsernameLength+1), nil},
+		{"no_mentions", "просто текст", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseMentions(tc.content))
+		})
+	}
+}
+
+func TestParseMentions_Limit(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < MaxMentionsPerComment+5; i++ {
+		fmt.Fprintf(&content, "@user%02d ", i)
+	}
+
+	mentions := ParseMentions(content.String())
+	assert.Len(t, mentions, MaxMentionsPerComment)
+	assert.Equal(t, "user00", mentions[0])
+}
@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationType - повод оповещения пользователя
+type NotificationType string
+
+const (
+	// NotificationCommentReply - ответ на комментарий пользователя
+	NotificationCommentReply NotificationType = "comment_reply"
+	// NotificationPostComment - новый корневой комментарий или ответ в посте пользователя
+	NotificationPostComment NotificationType = "post_comment"
+)
+
+func (t NotificationType) IsValid() bool {
+	return t == NotificationCommentReply || t == NotificationPostComment
+}
+
+// Notification - запись во входящих пользователя UserID о действии ActorID.
+// Пост, комментарий и автор действия загружаются резолверами по идентификаторам.
+type Notification struct {
+	ID        uuid.UUID        `json:"id" db:"id"`
+	UserID    uuid.UUID        `json:"user_id" db:"user_id"`
+	Type      NotificationType `json:"type" db:"type"`
+	ActorID   uuid.UUID        `json:"actor_id" db:"actor_id"`
+	PostID    uuid.UUID        `json:"post_id" db:"post_id"`
+	CommentID uuid.UUID        `json:"comment_id" db:"comment_id"`
+	ReadAt    *time.Time       `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+}
+
+func NewNotification(userID uuid.UUID, notificationType NotificationType, comment *Comment) *Notification {
+	return &Notification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      notificationType,
+		ActorID:   comment.AuthorID,
+		PostID:    comment.PostID,
+		CommentID: comment.ID,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// NotificationsForComment возвращает оповещения о новом комментарии: автору родительского
+// комментария об ответе и автору поста о комментарии в его посте. Пользователь получает
+// не больше одного оповещения и не оповещается о собственных комментариях; у комментария
+// удаленного пользователя получателя нет.
+func NotificationsForComment(comment *Comment, post *Post, parent *Comment) []*Notification {
+	var notifications []*Notification
+	notified := map[uuid.UUID]bool{comment.AuthorID: true, uuid.Nil: true}
+
+	if parent != nil && !notified[parent.AuthorID] {
+		notified[parent.AuthorID] = true
+		notifications = append(notifications, NewNotification(parent.AuthorID, NotificationCommentReply, comment))
+	}
+
+	if !notified[post.AuthorID] {
+		notifications = append(notifications, NewNotification(post.AuthorID, NotificationPostComment, comment))
+	}
+
+	return notifications
+}
+
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+func (n *Notification) Cursor() Cursor {
+	return NewCursor(n.CreatedAt, n.ID)
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationsForComment(t *testing.T) {
+	postAuthor := uuid.New()
+	parentAuthor := uuid.New()
+	commenter := uuid.New()
+
+	post, err := NewPost(postAuthor, "Пост", "Текст")
+	require.NoError(t, err)
+	parent, err := NewComment(post.ID, parentAuthor, "Родитель", nil)
+	require.NoError(t, err)
+
+	recipients := func(notifications []*Notification) map[uuid.UUID]NotificationType {
+		result := make(map[uuid.UUID]NotificationType, len(notifications))
+		for _, notification := range notifications {
+			result[notification.UserID] = notification.Type
+		}
+		return result
+	}
+
+	t.Run("reply", func(t *testing.T) {
+		reply, err := NewComment(post.ID, commenter, "Ответ", parent)
+		require.NoError(t, err)
+
+		notifications := NotificationsForComment(reply, post, parent)
+		assert.Equal(t, map[uuid.UUID]NotificationType{
+			parentAuthor: NotificationCommentReply,
+			postAuthor:   NotificationPostComment,
+		}, recipients(notifications))
+
+		for _, notification := range notifications {
+			assert.Equal(t, commenter, notification.ActorID)
+			assert.Equal(t, post.ID, notification.PostID)
+			assert.Equal(t, reply.ID, notification.CommentID)
+			assert.False(t, notification.IsRead())
+		}
+	})
+
+	t.Run("reply_to_post_author", func(t *testing.T) {
+		ownParent, err := NewComment(post.ID, postAuthor, "Комментарий автора поста", nil)
+		require.NoError(t, err)
+		reply, err := NewComment(post.ID, commenter, "Ответ", ownParent)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[uuid.UUID]NotificationType{
+			postAuthor: NotificationCommentReply,
+		}, recipients(NotificationsForComment(reply, post, ownParent)))
+	})
+
+	t.Run("own_content", func(t *testing.T) {
+		root, err := NewComment(post.ID, postAuthor, "Свой комментарий", nil)
+		require.NoError(t, err)
+		assert.Empty(t, NotificationsForComment(root, post, nil))
+
+		reply, err := NewComment(post.ID, parentAuthor, "Ответ себе", parent)
+		require.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]NotificationType{
+			postAuthor: NotificationPostComment,
+		}, recipients(NotificationsForComment(reply, post, parent)))
+	})
+
+	t.Run("reply_to_deleted_user", func(t *testing.T) {
+		orphan, err := NewComment(post.ID, uuid.Nil, "Комментарий удаленного пользователя", nil)
+		require.NoError(t, err)
+		reply, err := NewComment(post.ID, commenter, "Ответ", orphan)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[uuid.UUID]NotificationType{
+			postAuthor: NotificationPostComment,
+		}, recipients(NotificationsForComment(reply, post, orphan)))
+	})
+}
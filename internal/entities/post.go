@@ -9,13 +9,23 @@ import (
 )
 
 type Post struct {
-	ID               uuid.UUID `json:"id" db:"id"`
-	AuthorID         uuid.UUID `json:"author_id" db:"author_id"`
-	Title            string    `json:"title" db:"title"`
-	Content          string    `json:"content" db:"content"`
-	CommentsDisabled bool      `json:"comments_disabled" db:"comments_disabled"`
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID  `json:"id" db:"id"`
+	AuthorID         uuid.UUID  `json:"author_id" db:"author_id"`
+	Title            string     `json:"title" db:"title"`
+	Content          string     `json:"content" db:"content"`
+	CommentsDisabled bool       `json:"comments_disabled" db:"comments_disabled"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	EditCount        int        `json:"edit_count" db:"edit_count"`
+	EditedAt         *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	EditedBy         *uuid.UUID `json:"edited_by,omitempty" db:"edited_by"`
+	// RootCommentCount - число корневых комментариев без ответов, ведется репозиториями как счетчики
+	// комментария. Поле JSON и колонка сохранили прежнее имя comment_count.
+	RootCommentCount int64 `json:"comment_count" db:"comment_count"`
+	// HiddenAt и HiddenBy заполнены у поста, скрытого модератором: он пропадает из выдачи,
+	// но сохраняется вместе с комментариями и может быть восстановлен
+	HiddenAt *time.Time `json:"hidden_at,omitempty" db:"hidden_at"`
+	HiddenBy *uuid.UUID `json:"hidden_by,omitempty" db:"hidden_by"`
 
 	Author *User `json:"author,omitempty"`
 }
@@ -47,6 +57,44 @@ func (p *Post) EnableComments() {
 	p.UpdatedAt = time.Now()
 }
 
+func (p *Post) IsEdited() bool {
+	return p.EditCount > 0
+}
+
+// ApplyEdit заменяет заголовок и текст поста и отмечает правку; прежнюю версию
+// нужно сохранить через NewPostRevision до вызова
+func (p *Post) ApplyEdit(title, content string, editorID uuid.UUID) {
+	now := time.Now()
+	p.Title = title
+	p.Content = content
+	p.EditCount++
+	p.EditedAt = &now
+	p.EditedBy = &editorID
+	p.UpdatedAt = now
+}
+
+func (p *Post) IsHidden() bool {
+	return p.HiddenAt != nil
+}
+
+// Hide скрывает пост решением модератора moderatorID
+func (p *Post) Hide(moderatorID uuid.UUID, at time.Time) {
+	p.HiddenAt = &at
+	p.HiddenBy = &moderatorID
+	p.UpdatedAt = at
+}
+
+// Restore возвращает скрытый пост в выдачу
+func (p *Post) Restore(at time.Time) {
+	p.HiddenAt = nil
+	p.HiddenBy = nil
+	p.UpdatedAt = at
+}
+
+func (p *Post) Cursor() Cursor {
+	return NewCursor(p.CreatedAt, p.ID)
+}
+
 func validatePostData(title, content string) error {
 	if strings.TrimSpace(title) == "" {
 		return errors.NewInvalidPostDataError("заголовок поста не может быть пустым")
@@ -121,3 +121,24 @@ func TestPostCommentsToggle(t *testing.T) {
 	post.DisableComments()
 	assert.True(t, post.CommentsDisabled)
 }
+
+func TestPostHideRestore(t *testing.T) {
+	post, err := NewPost(uuid.New(), "Test", "Content")
+	assert.NoError(t, err)
+	assert.False(t, post.IsHidden())
+
+	moderatorID := uuid.New()
+	hiddenAt := time.Now()
+	post.Hide(moderatorID, hiddenAt)
+
+	assert.True(t, post.IsHidden())
+	assert.Equal(t, hiddenAt, *post.HiddenAt)
+	assert.Equal(t, moderatorID, *post.HiddenBy)
+	assert.Equal(t, "Content", post.Content, "скрытие не меняет содержимое")
+
+	post.Restore(hiddenAt.Add(time.Minute))
+
+	assert.False(t, post.IsHidden())
+	assert.Nil(t, post.HiddenBy)
+	assert.Equal(t, hiddenAt.Add(time.Minute), post.UpdatedAt)
+}
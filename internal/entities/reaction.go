@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReactionKind - вид реакции. Порядок в ReactionKinds задает порядок вывода сводки.
+type ReactionKind string
+
+const (
+	ReactionLike  ReactionKind = "like"
+	ReactionLove  ReactionKind = "love"
+	ReactionLaugh ReactionKind = "laugh"
+	ReactionWow   ReactionKind = "wow"
+	ReactionSad   ReactionKind = "sad"
+	ReactionAngry ReactionKind = "angry"
+)
+
+var ReactionKinds = []ReactionKind{ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad, ReactionAngry}
+
+func (k ReactionKind) IsValid() bool {
+	switch k {
+	case ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad, ReactionAngry:
+		return true
+	}
+	return false
+}
+
+// ReactionTargetType - вид объекта, на который ставится реакция
+type ReactionTargetType string
+
+const (
+	ReactionTargetPost    ReactionTargetType = "post"
+	ReactionTargetComment ReactionTargetType = "comment"
+)
+
+func (t ReactionTargetType) IsValid() bool {
+	return t == ReactionTargetPost || t == ReactionTargetComment
+}
+
+// Reaction - реакция пользователя на пост или комментарий. Пользователь может поставить
+// на один объект несколько реакций разных видов, но каждую - один раз.
+type Reaction struct {
+	ID         uuid.UUID          `json:"id" db:"id"`
+	TargetType ReactionTargetType `json:"target_type" db:"target_type"`
+	TargetID   uuid.UUID          `json:"target_id" db:"target_id"`
+	UserID     uuid.UUID          `json:"user_id" db:"user_id"`
+	Kind       ReactionKind       `json:"kind" db:"kind"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+}
+
+func NewReaction(targetType ReactionTargetType, targetID, userID uuid.UUID, kind ReactionKind) *Reaction {
+	return &Reaction{
+		ID:         uuid.New(),
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     userID,
+		Kind:       kind,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// ReactionSummary - число реакций одного вида на объект и есть ли среди них реакция пользователя, делающего запрос
+type ReactionSummary struct {
+	Kind          ReactionKind `json:"kind"`
+	Count         int64        `json:"count"`
+	ViewerReacted bool         `json:"viewer_reacted"`
+}
+
+// SummarizeReactions собирает сводку по числу реакций каждого вида. Виды без реакций
+// в сводку не попадают, остальные идут в порядке ReactionKinds.
+func SummarizeReactions(counts map[ReactionKind]int64, viewerKinds []ReactionKind) []*ReactionSummary {
+	reacted := make(map[ReactionKind]bool, len(viewerKinds))
+	for _, kind := range viewerKinds {
+		reacted[kind] = true
+	}
+
+	summaries := make([]*ReactionSummary, 0, len(counts))
+	for _, kind := range ReactionKinds {
+		if counts[kind] <= 0 {
+			continue
+		}
+		summaries = append(summaries, &ReactionSummary{
+			Kind:          kind,
+			Count:         counts[kind],
+			ViewerReacted: reacted[kind],
+		})
+	}
+
+	return summaries
+}
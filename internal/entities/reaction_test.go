@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionKind_IsValid(t *testing.T) {
+	for _, kind := range ReactionKinds {
+		assert.True(t, kind.IsValid(), kind)
+	}
+
+	assert.False(t, ReactionKind("").IsValid())
+	assert.False(t, ReactionKind("dislike").IsValid())
+}
+
+func TestReactionTargetType_IsValid(t *testing.T) {
+	assert.True(t, ReactionTargetPost.IsValid())
+	assert.True(t, ReactionTargetComment.IsValid())
+	assert.False(t, ReactionTargetType("user").IsValid())
+}
+
+func TestNewReaction(t *testing.T) {
+	targetID := uuid.New()
+	userID := uuid.New()
+
+	reaction := NewReaction(ReactionTargetComment, targetID, userID, ReactionWow)
+
+	assert.NotEqual(t, uuid.Nil, reaction.ID)
+	assert.Equal(t, ReactionTargetComment, reaction.TargetType)
+	assert.Equal(t, targetID, reaction.TargetID)
+	assert.Equal(t, userID, reaction.UserID)
+	assert.Equal(t, ReactionWow, reaction.Kind)
+	assert.False(t, reaction.CreatedAt.IsZero())
+}
+
+func TestSummarizeReactions(t *testing.T) {
+	counts := map[ReactionKind]int64{
+		ReactionSad:  1,
+		ReactionLike: 3,
+		ReactionWow:  0,
+	}
+
+	summaries := SummarizeReactions(counts, []ReactionKind{ReactionSad})
+
+	require.Len(t, summaries, 2)
+	assert.Equal(t, ReactionSummary{Kind: ReactionLike, Count: 3}, *summaries[0])
+	assert.Equal(t, ReactionSummary{Kind: ReactionSad, Count: 1, ViewerReacted: true}, *summaries[1])
+
+	assert.Empty(t, SummarizeReactions(nil, nil))
+	assert.NotNil(t, SummarizeReactions(nil, nil), "пустая сводка - пустой список, а не nil")
+}
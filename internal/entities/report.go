@@ -0,0 +1,190 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxModerationNoteLength ограничивает комментарий модератора к решению по жалобам
+const MaxModerationNoteLength = 1000
+
+// ReportReason - причина жалобы. Порядок в ReportReasons задает порядок вывода сводки причин.
+type ReportReason string
+
+const (
+	ReportSpam           ReportReason = "spam"
+	ReportHarassment     ReportReason = "harassment"
+	ReportHateSpeech     ReportReason = "hate_speech"
+	ReportViolence       ReportReason = "violence"
+	ReportMisinformation ReportReason = "misinformation"
+	ReportOther          ReportReason = "other"
+)
+
+var ReportReasons = []ReportReason{ReportSpam, ReportHarassment, ReportHateSpeech, ReportViolence, ReportMisinformation, ReportOther}
+
+func (r ReportReason) IsValid() bool {
+	switch r {
+	case ReportSpam, ReportHarassment, ReportHateSpeech, ReportViolence, ReportMisinformation, ReportOther:
+		return true
+	}
+	return false
+}
+
+// ReportTargetType - вид объекта, на который жалуются
+type ReportTargetType string
+
+const (
+	ReportTargetPost    ReportTargetType = "post"
+	ReportTargetComment ReportTargetType = "comment"
+)
+
+func (t ReportTargetType) IsValid() bool {
+	return t == ReportTargetPost || t == ReportTargetComment
+}
+
+// ReportStatus - состояние жалобы: открытая ждет решения модератора
+type ReportStatus string
+
+const (
+	ReportOpen     ReportStatus = "open"
+	ReportResolved ReportStatus = "resolved"
+)
+
+// ModerationAction - решение модератора по открытым жалобам на объект
+type ModerationAction string
+
+const (
+	// ModerationDismiss закрывает жалобы без изменения содержимого
+	ModerationDismiss ModerationAction = "dismiss"
+	// ModerationHideContent скрывает объект: комментарий удаляется мягко, пост скрывается из выдачи
+	ModerationHideContent ModerationAction = "hide_content"
+	// ModerationBanAuthor скрывает объект и блокирует его автора
+	ModerationBanAuthor ModerationAction = "ban_author"
+	// ModerationRestoreContent возвращает скрытый пост в выдачу. Это не решение по жалобам:
+	// действие попадает только в журнал модерации.
+	ModerationRestoreContent ModerationAction = "restore_content"
+)
+
+// IsValid сообщает, является ли действие решением по жалобам
+func (a ModerationAction) IsValid() bool {
+	switch a {
+	case ModerationDismiss, ModerationHideContent, ModerationBanAuthor:
+		return true
+	}
+	return false
+}
+
+// HidesContent сообщает, скрывает ли решение объект жалобы
+func (a ModerationAction) HidesContent() bool {
+	return a == ModerationHideContent || a == ModerationBanAuthor
+}
+
+// Report - жалоба пользователя ReporterID на пост или комментарий. Автор объекта сохраняется
+// при подаче жалобы, чтобы очередь и журнал модерации не зависели от того, жив ли объект.
+type Report struct {
+	ID         uuid.UUID         `json:"id" db:"id"`
+	TargetType ReportTargetType  `json:"target_type" db:"target_type"`
+	TargetID   uuid.UUID         `json:"target_id" db:"target_id"`
+	AuthorID   uuid.UUID         `json:"author_id" db:"author_id"`
+	ReporterID uuid.UUID         `json:"reporter_id" db:"reporter_id"`
+	Reason     ReportReason      `json:"reason" db:"reason"`
+	Status     ReportStatus      `json:"status" db:"status"`
+	Resolution *ModerationAction `json:"resolution,omitempty" db:"resolution"`
+	ResolvedBy *uuid.UUID        `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt *time.Time        `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt  time.Time         `json:"created_at" db:"created_at"`
+}
+
+func NewReport(targetType ReportTargetType, targetID, authorID, reporterID uuid.UUID, reason ReportReason) *Report {
+	return &Report{
+		ID:         uuid.New(),
+		TargetType: targetType,
+		TargetID:   targetID,
+		AuthorID:   authorID,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Status:     ReportOpen,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func (r *Report) IsOpen() bool {
+	return r.Status == ReportOpen
+}
+
+// Resolve закрывает жалобу решением модератора moderatorID
+func (r *Report) Resolve(action ModerationAction, moderatorID uuid.UUID, at time.Time) {
+	r.Status = ReportResolved
+	r.Resolution = &action
+	r.ResolvedBy = &moderatorID
+	r.ResolvedAt = &at
+}
+
+// ReportReasonCount - число открытых жалоб на объект по одной причине
+type ReportReasonCount struct {
+	Reason ReportReason `json:"reason"`
+	Count  int64        `json:"count"`
+}
+
+// SummarizeReportReasons собирает сводку причин жалоб. Причины без жалоб в сводку
+// не попадают, остальные идут в порядке ReportReasons.
+func SummarizeReportReasons(counts map[ReportReason]int64) []*ReportReasonCount {
+	summary := make([]*ReportReasonCount, 0, len(counts))
+	for _, reason := range ReportReasons {
+		if counts[reason] <= 0 {
+			continue
+		}
+		summary = append(summary, &ReportReasonCount{Reason: reason, Count: counts[reason]})
+	}
+
+	return summary
+}
+
+// ModerationQueueItem - открытые жалобы на один объект. Очередь упорядочена по первой жалобе:
+// дольше всех ждущие решения объекты идут первыми. Reasons заполняется сервисом.
+type ModerationQueueItem struct {
+	TargetType      ReportTargetType `json:"target_type" db:"target_type"`
+	TargetID        uuid.UUID        `json:"target_id" db:"target_id"`
+	AuthorID        uuid.UUID        `json:"author_id" db:"author_id"`
+	ReportCount     int64            `json:"report_count" db:"report_count"`
+	FirstReportedAt time.Time        `json:"first_reported_at" db:"first_reported_at"`
+	LastReportedAt  time.Time        `json:"last_reported_at" db:"last_reported_at"`
+
+	Reasons []*ReportReasonCount `json:"reasons,omitempty"`
+}
+
+func (i *ModerationQueueItem) Cursor() Cursor {
+	return NewCursor(i.FirstReportedAt, i.TargetID)
+}
+
+// ModerationAuditEntry - запись журнала модерации о решении ModeratorID по жалобам на объект
+type ModerationAuditEntry struct {
+	ID              uuid.UUID        `json:"id" db:"id"`
+	ModeratorID     uuid.UUID        `json:"moderator_id" db:"moderator_id"`
+	Action          ModerationAction `json:"action" db:"action"`
+	TargetType      ReportTargetType `json:"target_type" db:"target_type"`
+	TargetID        uuid.UUID        `json:"target_id" db:"target_id"`
+	AuthorID        uuid.UUID        `json:"author_id" db:"author_id"`
+	ReportsResolved int64            `json:"reports_resolved" db:"reports_resolved"`
+	Note            *string          `json:"note,omitempty" db:"note"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+}
+
+func NewModerationAuditEntry(moderatorID uuid.UUID, action ModerationAction, item *ModerationQueueItem, note *string, at time.Time) *ModerationAuditEntry {
+	return &ModerationAuditEntry{
+		ID:              uuid.New(),
+		ModeratorID:     moderatorID,
+		Action:          action,
+		TargetType:      item.TargetType,
+		TargetID:        item.TargetID,
+		AuthorID:        item.AuthorID,
+		ReportsResolved: item.ReportCount,
+		Note:            note,
+		CreatedAt:       at,
+	}
+}
+
+func (e *ModerationAuditEntry) Cursor() Cursor {
+	return NewCursor(e.CreatedAt, e.ID)
+}
@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportReason_IsValid(t *testing.T) {
+	for _, reason := range ReportReasons {
+		assert.True(t, reason.IsValid(), reason)
+	}
+
+	assert.False(t, ReportReason("").IsValid())
+	assert.False(t, ReportReason("boring").IsValid())
+}
+
+func TestModerationAction(t *testing.T) {
+	assert.True(t, ModerationDismiss.IsValid())
+	assert.True(t, ModerationHideContent.IsValid())
+	assert.True(t, ModerationBanAuthor.IsValid())
+	assert.False(t, ModerationAction("warn").IsValid())
+	assert.False(t, ModerationRestoreContent.IsValid(), "восстановление не закрывает жалобы")
+
+	assert.False(t, ModerationDismiss.HidesContent())
+	assert.True(t, ModerationHideContent.HidesContent())
+	assert.True(t, ModerationBanAuthor.HidesContent())
+}
+
+func TestReport_Resolve(t *testing.T) {
+	targetID := uuid.New()
+	authorID := uuid.New()
+	reporterID := uuid.New()
+
+	report := NewReport(ReportTargetComment, targetID, authorID, reporterID, ReportSpam)
+	assert.NotEqual(t, uuid.Nil, report.ID)
+	assert.Equal(t, targetID, report.TargetID)
+	assert.Equal(t, authorID, report.AuthorID)
+	assert.Equal(t, reporterID, report.ReporterID)
+	assert.True(t, report.IsOpen())
+	assert.Nil(t, report.Resolution)
+
+	moderatorID := uuid.New()
+	resolvedAt := time.Now()
+	report.Resolve(ModerationHideContent, moderatorID, resolvedAt)
+
+	assert.False(t, report.IsOpen())
+	assert.Equal(t, ModerationHideContent, *report.Resolution)
+	assert.Equal(t, moderatorID, *report.ResolvedBy)
+	assert.Equal(t, resolvedAt, *report.ResolvedAt)
+}
+
+func TestSummarizeReportReasons(t *testing.T) {
+	summary := SummarizeReportReasons(map[ReportReason]int64{
+		ReportOther:      1,
+		ReportSpam:       2,
+		ReportHarassment: 0,
+	})
+
+	require.Len(t, summary, 2)
+	assert.Equal(t, ReportReasonCount{Reason: ReportSpam, Count: 2}, *summary[0])
+	assert.Equal(t, ReportReasonCount{Reason: ReportOther, Count: 1}, *summary[1])
+
+	assert.NotNil(t, SummarizeReportReasons(nil))
+}
+
+func TestNewModerationAuditEntry(t *testing.T) {
+	item := &ModerationQueueItem{
+		TargetType:  ReportTargetPost,
+		TargetID:    uuid.New(),
+		AuthorID:    uuid.New(),
+		ReportCount: 3,
+	}
+	moderatorID := uuid.New()
+	note := "реклама"
+	at := time.Now()
+
+	entry := NewModerationAuditEntry(moderatorID, ModerationBanAuthor, item, &note, at)
+
+	assert.NotEqual(t, uuid.Nil, entry.ID)
+	assert.Equal(t, moderatorID, entry.ModeratorID)
+	assert.Equal(t, ModerationBanAuthor, entry.Action)
+	assert.Equal(t, item.TargetType, entry.TargetType)
+	assert.Equal(t, item.TargetID, entry.TargetID)
+	assert.Equal(t, item.AuthorID, entry.AuthorID)
+	assert.Equal(t, int64(3), entry.ReportsResolved)
+	assert.Equal(t, &note, entry.Note)
+	assert.Equal(t, NewCursor(at, entry.ID), entry.Cursor())
+}
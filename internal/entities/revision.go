@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostRevision - прежняя версия поста. Version 0 - исходный текст автора,
+// EditorID и CreatedAt указывают, кто и когда написал именно эту версию.
+type PostRevision struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	PostID    uuid.UUID `json:"post_id" db:"post_id"`
+	Version   int       `json:"version" db:"version"`
+	Title     string    `json:"title" db:"title"`
+	Content   string    `json:"content" db:"content"`
+	EditorID  uuid.UUID `json:"editor_id" db:"editor_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	Editor *User `json:"editor,omitempty"`
+}
+
+// CommentRevision - прежняя версия комментария, устроена так же, как PostRevision
+type CommentRevision struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	CommentID uuid.UUID `json:"comment_id" db:"comment_id"`
+	Version   int       `json:"version" db:"version"`
+	Content   string    `json:"content" db:"content"`
+	EditorID  uuid.UUID `json:"editor_id" db:"editor_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	Editor *User `json:"editor,omitempty"`
+}
+
+// NewPostRevision снимает текущую версию поста перед правкой
+func NewPostRevision(post *Post) *PostRevision {
+	revision := &PostRevision{
+		ID:        uuid.New(),
+		PostID:    post.ID,
+		Version:   post.EditCount,
+		Title:     post.Title,
+		Content:   post.Content,
+		EditorID:  post.AuthorID,
+		CreatedAt: post.CreatedAt,
+	}
+
+	if post.EditedBy != nil && post.EditedAt != nil {
+		revision.EditorID = *post.EditedBy
+		revision.CreatedAt = *post.EditedAt
+	}
+
+	return revision
+}
+
+// NewCommentRevision снимает текущую версию комментария перед правкой
+func NewCommentRevision(comment *Comment) *CommentRevision {
+	revision := &CommentRevision{
+		ID:        uuid.New(),
+		CommentID: comment.ID,
+		Version:   comment.EditCount,
+		Content:   comment.Content,
+		EditorID:  comment.AuthorID,
+		CreatedAt: comment.CreatedAt,
+	}
+
+	if comment.EditedBy != nil && comment.EditedAt != nil {
+		revision.EditorID = *comment.EditedBy
+		revision.CreatedAt = *comment.EditedAt
+	}
+
+	return revision
+}
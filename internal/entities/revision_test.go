@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRevisions(t *testing.T) {
+	authorID := uuid.New()
+	moderatorID := uuid.New()
+
+	post, err := NewPost(authorID, "Original title", "Original content")
+	require.NoError(t, err)
+	assert.False(t, post.IsEdited())
+
+	first := NewPostRevision(post)
+	post.ApplyEdit("Second title", "Second content", authorID)
+
+	assert.Equal(t, 0, first.Version)
+	assert.Equal(t, "Original title", first.Title)
+	assert.Equal(t, authorID, first.EditorID)
+	assert.Equal(t, post.CreatedAt, first.CreatedAt)
+	assert.True(t, post.IsEdited())
+	assert.Equal(t, 1, post.EditCount)
+
+	second := NewPostRevision(post)
+	post.ApplyEdit("Third title", "Third content", moderatorID)
+
+	assert.Equal(t, 1, second.Version)
+	assert.Equal(t, "Second content", second.Content)
+	assert.Equal(t, authorID, second.EditorID)
+	assert.Equal(t, 2, post.EditCount)
+	assert.Equal(t, moderatorID, *post.EditedBy)
+	assert.Equal(t, "Third title", post.Title)
+}
+
+func TestCommentRevisions(t *testing.T) {
+	authorID := uuid.New()
+	moderatorID := uuid.New()
+
+	comment, err := NewComment(uuid.New(), authorID, "Original", nil)
+	require.NoError(t, err)
+
+	first := NewCommentRevision(comment)
+	comment.ApplyEdit("Moderated", moderatorID)
+	second := NewCommentRevision(comment)
+
+	assert.Equal(t, 0, first.Version)
+	assert.Equal(t, "Original", first.Content)
+	assert.Equal(t, authorID, first.EditorID)
+	assert.Equal(t, 1, second.Version)
+	assert.Equal(t, "Moderated", second.Content)
+	assert.Equal(t, moderatorID, second.EditorID)
+	assert.Equal(t, *comment.EditedAt, second.CreatedAt)
+	assert.True(t, comment.IsEdited())
+}
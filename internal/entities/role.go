@@ -0,0 +1,25 @@
+package entities
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (r Role) IsModerator() bool {
+	return r == RoleModerator || r == RoleAdmin
+}
+
+func (r Role) IsAdmin() bool {
+	return r == RoleAdmin
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_IsValid(t *testing.T) {
+	testCases := []struct {
+		role  Role
+		valid bool
+	}{
+		{RoleUser, true},
+		{RoleModerator, true},
+		{RoleAdmin, true},
+		{Role(""), false},
+		{Role("root"), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.role), func(t *testing.T) {
+			assert.Equal(t, tc.valid, tc.role.IsValid())
+		})
+	}
+}
+
+func TestRole_Privileges(t *testing.T) {
+	assert.False(t, RoleUser.IsModerator())
+	assert.False(t, RoleUser.IsAdmin())
+
+	assert.True(t, RoleModerator.IsModerator())
+	assert.False(t, RoleModerator.IsAdmin())
+
+	assert.True(t, RoleAdmin.IsModerator())
+	assert.True(t, RoleAdmin.IsAdmin())
+}
@@ -0,0 +1,133 @@
+package entities
+
+import (
+	"ozon-posts/pkg/errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxSearchQueryLength = 200
+	MaxSearchTerms       = 10
+
+	// Найденные слова в сниппетах обрамляются этими метками в обоих хранилищах
+	SearchHighlightStart = "<mark>"
+	SearchHighlightStop  = "</mark>"
+
+	SearchSnippetWords       = 30
+	searchSnippetWordsBefore = 5
+)
+
+// SearchQuery - разобранный поисковый запрос. Text передается в PostgreSQL как есть,
+// Terms используются in-memory индексом; результат должен содержать все термы
+type SearchQuery struct {
+	Text   string
+	Terms  []string
+	PostID *uuid.UUID
+}
+
+func NewSearchQuery(text string, postID *uuid.UUID) (*SearchQuery, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.NewInvalidRequestError("поисковый запрос не может быть пустым")
+	}
+
+	if utf8.RuneCountInString(text) > MaxSearchQueryLength {
+		return nil, errors.NewInvalidRequestError("поисковый запрос слишком длинный")
+	}
+
+	terms := uniqueTerms(Tokenize(text))
+	if len(terms) == 0 {
+		return nil, errors.NewInvalidRequestError("поисковый запрос не содержит слов")
+	}
+
+	if len(terms) > MaxSearchTerms {
+		return nil, errors.NewInvalidRequestError("поисковый запрос содержит слишком много слов")
+	}
+
+	return &SearchQuery{
+		Text:   text,
+		Terms:  terms,
+		PostID: postID,
+	}, nil
+}
+
+// PostSearchResult - найденный пост с релевантностью и подсвеченными фрагментами
+type PostSearchResult struct {
+	Post           *Post   `json:"post"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+// CommentSearchResult - найденный комментарий с релевантностью и подсвеченным фрагментом
+type CommentSearchResult struct {
+	Comment *Comment `json:"comment"`
+	Rank    float64  `json:"rank"`
+	Snippet string   `json:"snippet"`
+}
+
+// Tokenize разбивает текст на слова в нижнем регистре; ё приводится к е
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = strings.ReplaceAll(word, "ё", "е")
+	}
+
+	return words
+}
+
+// Highlight подсвечивает в тексте слова, совпадающие с термами запроса.
+// При maxWords > 0 возвращается окно из maxWords слов вокруг первого совпадения.
+func Highlight(text string, terms []string, maxWords int) string {
+	termSet := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		termSet[term] = true
+	}
+
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		for _, token := range Tokenize(word) {
+			if termSet[token] {
+				words[i] = SearchHighlightStart + word + SearchHighlightStop
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+
+	if maxWords <= 0 || len(words) <= maxWords {
+		return strings.Join(words, " ")
+	}
+
+	start := 0
+	if first > searchSnippetWordsBefore {
+		start = first - searchSnippetWordsBefore
+	}
+	if start+maxWords > len(words) {
+		start = len(words) - maxWords
+	}
+
+	return strings.Join(words[start:start+maxWords], " ")
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			result = append(result, token)
+		}
+	}
+	return result
+}
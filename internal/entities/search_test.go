@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+
+	appErrors "ozon-posts/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"привет", "мир", "go", "1", "24"}, Tokenize("Привет, мир! Go 1.24"))
+	assert.Equal(t, []string{"еж", "елка"}, Tokenize("Ёж — ёлка"))
+	assert.Empty(t, Tokenize("  ...!!! "))
+}
+
+func TestNewSearchQuery(t *testing.T) {
+	query, err := NewSearchQuery("  Golang golang GraphQL ", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Golang golang GraphQL", query.Text)
+	assert.Equal(t, []string{"golang", "graphql"}, query.Terms)
+
+	testCases := []struct {
+		name string
+		text string
+	}{
+		{"empty", "   "},
+		{"punctuation_only", "?!.,"},
+		{"too_long", strings.Repeat("а", MaxSearchQueryLength+1)},
+		{"too_many_terms", "a b c d e f g h i j k"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := NewSearchQuery(tc.text, nil)
+
+			assert.Nil(t, query)
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, appErrors.ErrInvalidRequest, appErr.Code)
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t,
+		"Пишем <mark>GraphQL</mark> сервер на <mark>Go,</mark> быстро",
+		Highlight("Пишем GraphQL сервер на Go, быстро", []string{"graphql", "go"}, 0))
+
+	words := make([]string, 50)
+	for i := range words {
+		words[i] = "слово"
+	}
+	words[40] = "цель"
+
+	snippet := Highlight(strings.Join(words, " "), []string{"цель"}, 10)
+	snippetWords := strings.Fields(snippet)
+	assert.Len(t, snippetWords, 10)
+	assert.Equal(t, SearchHighlightStart+"цель"+SearchHighlightStop, snippetWords[5])
+
+	tail := Highlight(strings.Join(words[:45], " ")+" финал", []string{"финал"}, 10)
+	assert.True(t, strings.HasSuffix(tail, SearchHighlightStart+"финал"+SearchHighlightStop))
+	assert.Len(t, strings.Fields(tail), 10)
+}
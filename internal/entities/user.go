@@ -8,12 +8,23 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 50
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
 type User struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Username  string    `json:"username" db:"username"`
-	Email     string    `json:"email" db:"email"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID           uuid.UUID `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	Email        string    `json:"email" db:"email"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         Role      `json:"role" db:"role"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	// BannedAt - время блокировки модератором; заблокированный пользователь не может войти
+	BannedAt *time.Time `json:"banned_at,omitempty" db:"banned_at"`
 }
 
 func NewUser(username, email string) (*User, error) {
@@ -26,21 +37,35 @@ func NewUser(username, email string) (*User, error) {
 		ID:        uuid.New(),
 		Username:  username,
 		Email:     email,
+		Role:      RoleUser,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}, nil
 }
 
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
+// Ban отмечает пользователя заблокированным; повторная блокировка сохраняет первоначальное время
+func (u *User) Ban(at time.Time) {
+	if u.BannedAt != nil {
+		return
+	}
+	u.BannedAt = &at
+	u.UpdatedAt = at
+}
+
 func validateUserData(username, email string) error {
 	if username == "" {
 		return errors.NewInvalidUserDataError("имя пользователя не может быть пустым")
 	}
 
-	if len(username) < 3 {
+	if len(username) < MinUsernameLength {
 		return errors.NewInvalidUserDataError("имя пользователя должно содержать минимум 3 символа")
 	}
 
-	if len(username) > 50 {
+	if len(username) > MaxUsernameLength {
 		return errors.NewInvalidUserDataError("имя пользователя не должно превышать 50 символов")
 	}
 
@@ -58,3 +83,16 @@ func validateUserData(username, email string) error {
 
 	return nil
 }
+
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return errors.NewInvalidUserDataError("пароль должен содержать минимум 8 символов")
+	}
+
+	// bcrypt учитывает только первые 72 байта пароля
+	if len(password) > MaxPasswordLength {
+		return errors.NewInvalidUserDataError("пароль не должен превышать 72 символа")
+	}
+
+	return nil
+}
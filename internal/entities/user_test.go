@@ -2,6 +2,7 @@ package entities
 
 import (
 	"ozon-posts/pkg/errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -79,3 +80,49 @@ func TestUserTimestamps(t *testing.T) {
 	assert.Equal(t, originalCreatedAt, user.CreatedAt)
 	assert.Equal(t, originalUpdatedAt, user.UpdatedAt)
 }
+
+func TestValidatePassword(t *testing.T) {
+	testCases := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"valid", "secret-password", false},
+		{"min_length", strings.Repeat("a", MinPasswordLength), false},
+		{"max_length", strings.Repeat("a", MaxPasswordLength), false},
+		{"too_short", strings.Repeat("a", MinPasswordLength-1), true},
+		{"too_long", strings.Repeat("a", MaxPasswordLength+1), true},
+		{"empty", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePassword(tc.password)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+
+				appErr, ok := err.(*errors.AppError)
+				assert.True(t, ok)
+				assert.Equal(t, errors.ErrInvalidUserData, appErr.Code)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUser_Ban(t *testing.T) {
+	user, err := NewUser("testuser", "test@example.com")
+	assert.NoError(t, err)
+	assert.False(t, user.IsBanned())
+
+	bannedAt := time.Now()
+	user.Ban(bannedAt)
+	assert.True(t, user.IsBanned())
+	assert.Equal(t, bannedAt, *user.BannedAt)
+	assert.Equal(t, bannedAt, user.UpdatedAt)
+
+	user.Ban(bannedAt.Add(time.Hour))
+	assert.Equal(t, bannedAt, *user.BannedAt)
+}
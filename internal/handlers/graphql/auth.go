@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"ozon-posts/internal/services"
+	"ozon-posts/pkg/auth"
+	"ozon-posts/pkg/errors"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// AuthMiddleware проверяет bearer-токен из заголовка Authorization и помещает ID пользователя в контекст.
+// Запросы без заголовка выполняются анонимно, запросы с некорректным токеном отклоняются.
+func AuthMiddleware(userService *services.UserService, logger *logrus.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := authenticate(r.Context(), userService, header)
+		if err != nil {
+			logger.WithError(err).WithField("remote_addr", r.RemoteAddr).Warn("Запрос с некорректным токеном отклонен")
+			writeAuthError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// websocketAuthInit аутентифицирует websocket-соединение по полю Authorization из connection_init
+func websocketAuthInit(userService *services.UserService, logger *logrus.Logger) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		header := initPayload.Authorization()
+		if header == "" {
+			return ctx, &initPayload, nil
+		}
+
+		ctx, err := authenticate(ctx, userService, header)
+		if err != nil {
+			logger.WithError(err).Warn("Websocket соединение с некорректным токеном отклонено")
+			return ctx, nil, err
+		}
+
+		return ctx, &initPayload, nil
+	}
+}
+
+func authenticate(ctx context.Context, userService *services.UserService, header string) (context.Context, error) {
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return ctx, errors.NewUnauthorizedError()
+	}
+
+	userID, err := userService.Authenticate(ctx, token)
+	if err != nil {
+		return ctx, err
+	}
+
+	return auth.WithUserID(ctx, userID), nil
+}
+
+// writeAuthError отвечает статусом и кодом ошибки аутентификации: 401 на недействительный токен,
+// 403 с кодом USER_BANNED на блокировку, чтобы клиент не пытался войти заново. Сбой хранилища
+// отдается как 500: токен при этом может быть действительным, и сбрасывать его клиенту не нужно.
+func writeAuthError(w http.ResponseWriter, err error) {
+	appErr, ok := errors.AsAppError(err)
+	if !ok {
+		appErr = errors.NewInternalError(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(appErr.StatusCode)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]any{{
+			"message":    appErr.Message,
+			"extensions": map[string]any{"code": appErr.Code},
+		}},
+	})
+}
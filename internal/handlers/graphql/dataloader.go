@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLoaderWait     = 2 * time.Millisecond
+	defaultLoaderMaxBatch = 100
+)
+
+// batchFunc загружает значения для набора ключей одним обращением к хранилищу.
+// Ключи, отсутствующие в результате, считаются ненайденными (нулевое значение без ошибки).
+type batchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	results map[K]*loaderResult[V]
+}
+
+// dataLoader собирает ключи, запрошенные резолверами за короткое окно wait, и загружает их
+// одним вызовом fetch. Результаты кешируются на время жизни загрузчика, то есть одного ответа.
+type dataLoader[K comparable, V any] struct {
+	fetch    batchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mutex sync.Mutex
+	cache map[K]*loaderResult[V]
+	batch *loaderBatch[K, V]
+}
+
+func newDataLoader[K comparable, V any](fetch batchFunc[K, V]) *dataLoader[K, V] {
+	return &dataLoader[K, V]{
+		fetch:    fetch,
+		wait:     defaultLoaderWait,
+		maxBatch: defaultLoaderMaxBatch,
+		cache:    make(map[K]*loaderResult[V]),
+	}
+}
+
+func (l *dataLoader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mutex.Lock()
+
+	result, cached := l.cache[key]
+	if !cached {
+		result = &loaderResult[V]{done: make(chan struct{})}
+		l.cache[key] = result
+
+		if l.batch == nil {
+			l.batch = &loaderBatch[K, V]{results: make(map[K]*loaderResult[V])}
+			go l.dispatchAfterWait(ctx, l.batch)
+		}
+
+		l.batch.keys = append(l.batch.keys, key)
+		l.batch.results[key] = result
+
+		if len(l.batch.keys) >= l.maxBatch {
+			full := l.batch
+			l.batch = nil
+			go l.dispatch(ctx, full)
+		}
+	}
+
+	l.mutex.Unlock()
+
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Prime кладет в кеш уже известное значение, чтобы не загружать его повторно
+func (l *dataLoader[K, V]) Prime(key K, value V) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, cached := l.cache[key]; cached {
+		return
+	}
+
+	result := &loaderResult[V]{done: make(chan struct{}), value: value}
+	close(result.done)
+	l.cache[key] = result
+}
+
+func (l *dataLoader[K, V]) dispatchAfterWait(ctx context.Context, batch *loaderBatch[K, V]) {
+	time.Sleep(l.wait)
+
+	l.mutex.Lock()
+	if l.batch != batch {
+		// Пакет уже отправлен, потому что набрал maxBatch ключей
+		l.mutex.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mutex.Unlock()
+
+	l.dispatch(ctx, batch)
+}
+
+func (l *dataLoader[K, V]) dispatch(ctx context.Context, batch *loaderBatch[K, V]) {
+	values, err := l.fetch(ctx, batch.keys)
+
+	for key, result := range batch.results {
+		if err != nil {
+			result.err = err
+		} else {
+			result.value = values[key]
+		}
+		close(result.done)
+	}
+
+	if err != nil {
+		// Ошибку не кешируем: следующий запрос ключа попробует загрузить его снова
+		l.mutex.Lock()
+		for key, result := range batch.results {
+			if l.cache[key] == result {
+				delete(l.cache, key)
+			}
+		}
+		l.mutex.Unlock()
+	}
+}
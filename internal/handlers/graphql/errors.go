@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime/debug"
+
+	"ozon-posts/pkg/auth"
+	"ozon-posts/pkg/errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// appErrorToGraphQL переносит код и детали ошибки приложения в extensions ошибки GraphQL.
+// У внутренних ошибок детали не передаются: причина остается только в логах.
+func appErrorToGraphQL(appErr *errors.AppError) *gqlerror.Error {
+	extensions := map[string]any{"code": string(appErr.Code)}
+	if appErr.Details != "" && !appErr.IsInternal() {
+		extensions["details"] = appErr.Details
+	}
+	// Целые секунды, как в заголовке Retry-After
+	if appErr.RetryAfter > 0 {
+		extensions["retryAfter"] = int(math.Ceil(appErr.RetryAfter.Seconds()))
+	}
+
+	return &gqlerror.Error{
+		Message:    appErr.Message,
+		Extensions: extensions,
+	}
+}
+
+// errorPresenter отдает клиенту сообщение и код AppError, найденной в цепочке ошибки резолвера,
+// вместо текста обертки вида "ошибка удаления поста: [POST_NOT_FOUND] ...".
+// Ошибки самого gqlgen (разбор запроса, типы аргументов) остаются без изменений.
+func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+
+	appErr, ok := errors.AsAppError(err)
+	if !ok {
+		return presented
+	}
+
+	gqlErr := appErrorToGraphQL(appErr)
+	gqlErr.Path = presented.Path
+	gqlErr.Locations = presented.Locations
+	gqlErr.Rule = presented.Rule
+	return gqlErr
+}
+
+// recoverFunc логирует панику резолвера вместе с операцией, полем и пользователем
+// и возвращает клиенту INTERNAL_ERROR без подробностей
+func recoverFunc(logger *logrus.Logger) graphql.RecoverFunc {
+	return func(ctx context.Context, recovered any) error {
+		fields := logrus.Fields{
+			"panic": recovered,
+			"stack": string(debug.Stack()),
+		}
+
+		if graphql.HasOperationContext(ctx) {
+			opCtx := graphql.GetOperationContext(ctx)
+			fields["operation"] = opCtx.OperationName
+			fields["query"] = opCtx.RawQuery
+		}
+
+		if fieldCtx := graphql.GetFieldContext(ctx); fieldCtx != nil {
+			fields["path"] = fieldCtx.Path().String()
+		}
+
+		if userID, ok := auth.UserIDFromContext(ctx); ok {
+			fields["user_id"] = userID
+		}
+
+		logger.WithFields(fields).Error("Паника при выполнении запроса GraphQL")
+
+		return errors.NewInternalError(fmt.Errorf("panic: %v", recovered))
+	}
+}
@@ -0,0 +1,261 @@
+package graphql
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/errors"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	defaultPageWeight = 20
+
+	// listWeight - оценка числа элементов в списках без аргумента limit
+	// (история правок, дочерние узлы дерева, уровень ветки комментариев)
+	listWeight = 10
+)
+
+func init() {
+	// Запросы, отклоненные до выполнения, получают HTTP 422, как ошибки валидации
+	errcode.RegisterErrorType(string(errors.ErrQueryTooDeep), errcode.KindProtocol)
+	errcode.RegisterErrorType(string(errors.ErrQueryTooComplex), errcode.KindProtocol)
+}
+
+// QueryLimits - ограничения на выполнение запросов GraphQL. Нулевое значение отключает ограничение.
+type QueryLimits struct {
+	MaxDepth      int
+	MaxComplexity int
+	Timeout       time.Duration
+}
+
+// depthLimit отклоняет операции, вложенность полей которых превышает maxDepth.
+// Служебные поля интроспекции (__schema, __type) не учитываются.
+type depthLimit struct {
+	maxDepth int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = depthLimit{}
+
+func (d depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d depthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d depthLimit) MutateOperationContext(_ context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	depth := selectionDepth(opCtx.Operation.SelectionSet, map[string]bool{})
+	if depth > d.maxDepth {
+		return appErrorToGraphQL(errors.NewQueryTooDeepError(depth, d.maxDepth))
+	}
+
+	return nil
+}
+
+// selectionDepth считает глубину набора полей; фрагменты раскрываются на месте,
+// visited защищает от циклических ссылок между фрагментами
+func selectionDepth(selectionSet ast.SelectionSet, visited map[string]bool) int {
+	maxDepth := 0
+
+	for _, selection := range selectionSet {
+		var depth int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = 1 + selectionDepth(selection.SelectionSet, visited)
+		case *ast.InlineFragment:
+			depth = selectionDepth(selection.SelectionSet, visited)
+		case *ast.FragmentSpread:
+			if visited[selection.Name] || selection.Definition == nil {
+				continue
+			}
+			visited[selection.Name] = true
+			depth = selectionDepth(selection.Definition.SelectionSet, visited)
+			delete(visited, selection.Name)
+		}
+
+		maxDepth = max(maxDepth, depth)
+	}
+
+	return maxDepth
+}
+
+// complexityLimit отклоняет операции, оценка стоимости которых превышает maxComplexity.
+// Стоимость полей задается в newComplexityRoot.
+type complexityLimit struct {
+	maxComplexity int
+	schema        graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &complexityLimit{}
+
+func (c *complexityLimit) ExtensionName() string {
+	return "ComplexityLimit"
+}
+
+func (c *complexityLimit) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema
+	return nil
+}
+
+func (c *complexityLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	cost := complexity.Calculate(ctx, c.schema, opCtx.Operation, opCtx.Variables)
+	if cost > c.maxComplexity {
+		return appErrorToGraphQL(errors.NewQueryTooComplexError(cost, c.maxComplexity))
+	}
+
+	return nil
+}
+
+// withTimeout ограничивает время выполнения запросов и мутаций. Подписки живут
+// дольше одного ответа, поэтому на них ограничение не распространяется.
+func withTimeout(timeout time.Duration) graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		opCtx := graphql.GetOperationContext(ctx)
+		if opCtx.Operation != nil && opCtx.Operation.Operation == ast.Subscription {
+			return next(ctx)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		responses := next(ctx)
+
+		return func(ctx context.Context) *graphql.Response {
+			response := responses(ctx)
+			if response == nil || response.HasNext == nil || !*response.HasNext {
+				defer cancel()
+			}
+
+			if response != nil && ctx.Err() == context.DeadlineExceeded {
+				response.Errors = append(response.Errors, appErrorToGraphQL(errors.NewQueryTimeoutError(timeout)))
+			}
+
+			return response
+		}
+	}
+}
+
+// newComplexityRoot задает стоимость полей: поле со списком стоит столько,
+// сколько элементов может вернуть, умноженное на стоимость одного элемента
+func newComplexityRoot() ComplexityRoot {
+	var root ComplexityRoot
+
+	pageCost := func(childComplexity int, limit *int, _ *int) int {
+		return listCost(childComplexity, pageSize(limit))
+	}
+	sortedPageCost := func(childComplexity int, limit *int, offset *int, _ *entities.CommentSort) int {
+		return pageCost(childComplexity, limit, offset)
+	}
+	cursorCost := func(childComplexity int, first *int, _ *string, last *int, _ *string) int {
+		return listCost(childComplexity, cursorPageSize(first, last))
+	}
+	forwardCursorCost := func(childComplexity int, first *int, after *string) int {
+		return cursorCost(childComplexity, first, after, nil, nil)
+	}
+	fixedListCost := func(childComplexity int) int {
+		return listCost(childComplexity, listWeight)
+	}
+
+	root.Query.Posts = pageCost
+	root.Query.PostsByAuthor = func(childComplexity int, _ string, limit *int, offset *int) int {
+		return pageCost(childComplexity, limit, offset)
+	}
+	root.Query.PostsConnection = cursorCost
+	root.Query.PostsByAuthorConnection = func(childComplexity int, _ string, first *int, after *string, last *int, before *string) int {
+		return cursorCost(childComplexity, first, after, last, before)
+	}
+	root.Query.PostComments = func(childComplexity int, _ string, limit *int, offset *int, sort *entities.CommentSort) int {
+		return sortedPageCost(childComplexity, limit, offset, sort)
+	}
+	root.Query.CommentReplies = root.Query.PostComments
+	root.Query.PostCommentsConnection = func(childComplexity int, _ string, first *int, after *string, last *int, before *string) int {
+		return cursorCost(childComplexity, first, after, last, before)
+	}
+	root.Query.CommentRepliesConnection = root.Query.PostCommentsConnection
+	root.Query.CommentThread = func(childComplexity int, _ string, maxDepth *int) int {
+		// Глубина ветки не ограничена сервисом, поэтому стоимость растет вместе с maxDepth
+		depth := entities.DefaultCommentTreeDepth
+		if maxDepth != nil && *maxDepth > 0 {
+			depth = min(*maxDepth, math.MaxInt/listWeight)
+		}
+		return listCost(childComplexity, depth*listWeight)
+	}
+	root.Query.SearchPosts = func(childComplexity int, _ string, limit *int, offset *int) int {
+		return pageCost(childComplexity, limit, offset)
+	}
+	root.Query.SearchComments = func(childComplexity int, _ string, _ *string, limit *int, offset *int) int {
+		return pageCost(childComplexity, limit, offset)
+	}
+	root.Query.Feed = forwardCursorCost
+	root.Query.Notifications = func(childComplexity int, _ *bool, first *int, after *string) int {
+		return forwardCursorCost(childComplexity, first, after)
+	}
+	root.Query.ModerationQueue = forwardCursorCost
+	root.Query.ModerationLog = forwardCursorCost
+
+	root.Post.Comments = sortedPageCost
+	root.Post.CommentsConnection = cursorCost
+	root.Post.Revisions = fixedListCost
+
+	root.Comment.Replies = sortedPageCost
+	root.Comment.RepliesConnection = cursorCost
+	root.Comment.Revisions = fixedListCost
+
+	root.CommentTreeNode.Children = fixedListCost
+
+	root.User.Followers = forwardCursorCost
+	root.User.Following = forwardCursorCost
+
+	return root
+}
+
+// pageSize повторяет правила entities.NewPaginationRequest
+func pageSize(limit *int) int {
+	if limit == nil || *limit <= 0 || *limit > 100 {
+		return defaultPageWeight
+	}
+	return *limit
+}
+
+// cursorPageSize повторяет правила entities.NewCursorRequest
+func cursorPageSize(first, last *int) int {
+	limit := first
+	if last != nil {
+		limit = last
+	}
+	return pageSize(limit)
+}
+
+// listCost - стоимость поля-списка из size элементов без переполнения int
+func listCost(childComplexity, size int) int {
+	if childComplexity > 0 && size > (math.MaxInt-1)/childComplexity {
+		return math.MaxInt
+	}
+	return 1 + childComplexity*size
+}
@@ -0,0 +1,157 @@
+package graphql
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// childPageKey - первая страница дочерних комментариев поста или комментария заданного размера
+type childPageKey struct {
+	ParentID uuid.UUID
+	Limit    int
+}
+
+// reactionTargetKey - объект, для которого загружается сводка реакций
+type reactionTargetKey struct {
+	TargetType entities.ReactionTargetType
+	TargetID   uuid.UUID
+}
+
+// Loaders - загрузчики связанных данных в пределах одного ответа GraphQL. Резолверы полей
+// author, post, parent, comments, replies, reactions и mentions обращаются к ним вместо сервисов, поэтому
+// список из N объектов стоит одного запроса к хранилищу на каждое поле, а не N.
+type Loaders struct {
+	users          *dataLoader[uuid.UUID, *entities.User]
+	posts          *dataLoader[uuid.UUID, *entities.Post]
+	comments       *dataLoader[uuid.UUID, *entities.Comment]
+	postComments   *dataLoader[childPageKey, *CommentConnection]
+	commentReplies *dataLoader[childPageKey, *CommentConnection]
+	reactions      *dataLoader[reactionTargetKey, []*entities.ReactionSummary]
+	mentions       *dataLoader[uuid.UUID, []*entities.User]
+}
+
+func NewLoaders(
+	userService *services.UserService,
+	postService *services.PostService,
+	commentService *services.CommentService,
+	reactionService *services.ReactionService,
+) *Loaders {
+	return &Loaders{
+		users: newDataLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*entities.User, error) {
+			users, err := userService.GetUsersByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			result := make(map[uuid.UUID]*entities.User, len(users))
+			for _, user := range users {
+				result[user.ID] = user
+			}
+			return result, nil
+		}),
+
+		posts: newDataLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*entities.Post, error) {
+			posts, err := postService.GetPostsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			result := make(map[uuid.UUID]*entities.Post, len(posts))
+			for _, post := range posts {
+				result[post.ID] = post
+			}
+			return result, nil
+		}),
+
+		comments: newDataLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*entities.Comment, error) {
+			comments, err := commentService.GetCommentsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			result := make(map[uuid.UUID]*entities.Comment, len(comments))
+			for _, comment := range comments {
+				result[comment.ID] = comment
+			}
+			return result, nil
+		}),
+
+		mentions: newDataLoader(commentService.GetMentionsByCommentIDs),
+
+		postComments:   newDataLoader(childPagesLoader(commentService.GetFirstCommentsByPostIDs)),
+		commentReplies: newDataLoader(childPagesLoader(commentService.GetFirstRepliesByParentIDs)),
+
+		// Ключи группируются по типу объекта: посты и комментарии загружаются отдельными вызовами
+		reactions: newDataLoader(func(ctx context.Context, keys []reactionTargetKey) (map[reactionTargetKey][]*entities.ReactionSummary, error) {
+			byType := make(map[entities.ReactionTargetType][]uuid.UUID)
+			for _, key := range keys {
+				byType[key.TargetType] = append(byType[key.TargetType], key.TargetID)
+			}
+
+			result := make(map[reactionTargetKey][]*entities.ReactionSummary, len(keys))
+			for targetType, targetIDs := range byType {
+				summaries, err := reactionService.GetReactionsByTargets(ctx, targetType, targetIDs)
+				if err != nil {
+					return nil, err
+				}
+
+				for targetID, targetSummaries := range summaries {
+					result[reactionTargetKey{TargetType: targetType, TargetID: targetID}] = targetSummaries
+				}
+			}
+			return result, nil
+		}),
+	}
+}
+
+// childPagesLoader группирует ключи по размеру страницы и загружает каждую группу одним вызовом fetch
+func childPagesLoader(
+	fetch func(ctx context.Context, parentIDs []uuid.UUID, limit int) (map[uuid.UUID][]*entities.Comment, map[uuid.UUID]int64, error),
+) batchFunc[childPageKey, *CommentConnection] {
+	return func(ctx context.Context, keys []childPageKey) (map[childPageKey]*CommentConnection, error) {
+		byLimit := make(map[int][]uuid.UUID)
+		for _, key := range keys {
+			byLimit[key.Limit] = append(byLimit[key.Limit], key.ParentID)
+		}
+
+		result := make(map[childPageKey]*CommentConnection, len(keys))
+		for limit, parentIDs := range byLimit {
+			children, counts, err := fetch(ctx, parentIDs, limit)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, parentID := range parentIDs {
+				comments := children[parentID]
+				if comments == nil {
+					comments = []*entities.Comment{}
+				}
+
+				result[childPageKey{ParentID: parentID, Limit: limit}] = &CommentConnection{
+					Comments:   comments,
+					Pagination: newPaginationInfo(entities.NewPaginationResponse(counts[parentID], limit, 0)),
+				}
+			}
+		}
+
+		return result, nil
+	}
+}
+
+type loadersContextKey struct{}
+
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, loadersContextKey{}, loaders)
+}
+
+// loaders возвращает загрузчики текущего ответа. Вне HTTP-обработчика (например, при прямом
+// вызове резолвера) создаются одноразовые загрузчики без общего кеша.
+func (r *Resolver) loaders(ctx context.Context) *Loaders {
+	if loaders, ok := ctx.Value(loadersContextKey{}).(*Loaders); ok {
+		return loaders
+	}
+	return NewLoaders(r.userService, r.postService, r.commentService, r.reactionService)
+}
@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"ozon-posts/pkg/metrics"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	otherOperation   = "other"
+	unknownErrorCode = "UNKNOWN"
+)
+
+// operationMetrics записывает длительность операций и ошибки по коду из extensions.code.
+// Ответ проходит через перехватчик уже после errorPresenter, поэтому у ошибок приложения
+// здесь код AppError, а у ошибок разбора и валидации - код gqlgen.
+type operationMetrics struct {
+	duration *metrics.Histogram
+	errors   *metrics.Counter
+	schema   *ast.Schema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &operationMetrics{}
+
+func newOperationMetrics(registry *metrics.Registry) *operationMetrics {
+	return &operationMetrics{
+		duration: registry.NewHistogram(
+			"graphql_operation_duration_seconds",
+			"Длительность выполнения операций GraphQL (кроме подписок)",
+			metrics.DefaultBuckets,
+			"operation", "type",
+		),
+		errors: registry.NewCounter(
+			"graphql_errors_total",
+			"Ошибки в ответах GraphQL по коду",
+			"operation", "code",
+		),
+	}
+}
+
+func (m *operationMetrics) ExtensionName() string {
+	return "OperationMetrics"
+}
+
+func (m *operationMetrics) Validate(schema graphql.ExecutableSchema) error {
+	m.schema = schema.Schema()
+	return nil
+}
+
+func (m *operationMetrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	name, kind := m.operationLabels(opCtx)
+
+	// Подписка отдает ответ на каждое событие, и ее длительность - это время жизни соединения
+	if kind != string(ast.Subscription) && !opCtx.Stats.OperationStart.IsZero() {
+		m.duration.Observe(time.Since(opCtx.Stats.OperationStart).Seconds(), name, kind)
+	}
+
+	if resp != nil {
+		for _, err := range resp.Errors {
+			code, _ := err.Extensions["code"].(string)
+			if code == "" {
+				code = unknownErrorCode
+			}
+			m.errors.Inc(name, code)
+		}
+	}
+
+	return resp
+}
+
+// operationLabels возвращает метки операции: первое поле верхнего уровня, найденное в схеме,
+// и тип операции. Имя операции выбирает клиент, поэтому в метки оно не попадает: число рядов
+// метрик ограничено полями схемы, а все остальное, включая запросы, которые не удалось разобрать,
+// и интроспекцию, учитывается как other.
+func (m *operationMetrics) operationLabels(opCtx *graphql.OperationContext) (string, string) {
+	if opCtx.Operation == nil {
+		return otherOperation, "unknown"
+	}
+	kind := string(opCtx.Operation.Operation)
+
+	var root *ast.Definition
+	if m.schema != nil {
+		switch opCtx.Operation.Operation {
+		case ast.Query:
+			root = m.schema.Query
+		case ast.Mutation:
+			root = m.schema.Mutation
+		case ast.Subscription:
+			root = m.schema.Subscription
+		}
+	}
+	if root == nil || len(opCtx.Operation.SelectionSet) == 0 {
+		return otherOperation, kind
+	}
+
+	field, ok := opCtx.Operation.SelectionSet[0].(*ast.Field)
+	if !ok || strings.HasPrefix(field.Name, "__") || root.Fields.ForName(field.Name) == nil {
+		return otherOperation, kind
+	}
+
+	return field.Name, kind
+}
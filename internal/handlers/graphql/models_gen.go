@@ -3,41 +3,101 @@
 package graphql
 
 import (
-	 "ozon-posts/internal/entities"
+	"bytes"
+	"fmt"
+	"io"
+	"ozon-posts/internal/entities"
+	"strconv"
 )
 
+type AuthPayload struct {
+	Token     string         `json:"token"`
+	ExpiresAt string         `json:"expiresAt"`
+	User      *entities.User `json:"user"`
+}
+
+type ChangeUserRoleInput struct {
+	UserID string        `json:"userId"`
+	Role   entities.Role `json:"role"`
+}
+
 type CommentConnection struct {
 	Comments   []*entities.Comment `json:"comments"`
 	Pagination *PaginationInfo     `json:"pagination"`
 }
 
+type CommentCursorConnection struct {
+	Edges    []*CommentEdge     `json:"edges"`
+	PageInfo *entities.PageInfo `json:"pageInfo"`
+}
+
+type CommentEdge struct {
+	Cursor string            `json:"cursor"`
+	Node   *entities.Comment `json:"node"`
+}
+
 type CommentEvent struct {
-	Type    string            `json:"type"`
-	PostID  string            `json:"postId"`
-	Comment *entities.Comment `json:"comment"`
+	Sequence int               `json:"sequence"`
+	Type     CommentEventType  `json:"type"`
+	PostID   string            `json:"postId"`
+	Comment  *entities.Comment `json:"comment,omitempty"`
+	Reaction *ReactionChange   `json:"reaction,omitempty"`
+}
+
+type CommentSearchConnection struct {
+	Results    []*entities.CommentSearchResult `json:"results"`
+	Pagination *PaginationInfo                 `json:"pagination"`
 }
 
 type CreateCommentInput struct {
 	PostID   string  `json:"postId"`
-	AuthorID string  `json:"authorId"`
 	Content  string  `json:"content"`
 	ParentID *string `json:"parentId,omitempty"`
 }
 
 type CreatePostInput struct {
-	AuthorID string `json:"authorId"`
-	Title    string `json:"title"`
-	Content  string `json:"content"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
 }
 
-type CreateUserInput struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
+type LoginInput struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type ModerationAuditConnection struct {
+	Edges    []*ModerationAuditEdge `json:"edges"`
+	PageInfo *entities.PageInfo     `json:"pageInfo"`
+}
+
+type ModerationAuditEdge struct {
+	Cursor string                         `json:"cursor"`
+	Node   *entities.ModerationAuditEntry `json:"node"`
+}
+
+type ModerationQueueConnection struct {
+	Edges    []*ModerationQueueEdge `json:"edges"`
+	PageInfo *entities.PageInfo     `json:"pageInfo"`
+}
+
+type ModerationQueueEdge struct {
+	Cursor string                        `json:"cursor"`
+	Node   *entities.ModerationQueueItem `json:"node"`
 }
 
 type Mutation struct {
 }
 
+type NotificationCursorConnection struct {
+	Edges    []*NotificationEdge `json:"edges"`
+	PageInfo *entities.PageInfo  `json:"pageInfo"`
+}
+
+type NotificationEdge struct {
+	Cursor string                 `json:"cursor"`
+	Node   *entities.Notification `json:"node"`
+}
+
 type PaginationInfo struct {
 	Total   int  `json:"total"`
 	Limit   int  `json:"limit"`
@@ -50,29 +110,68 @@ type PostConnection struct {
 	Pagination *PaginationInfo  `json:"pagination"`
 }
 
+type PostCursorConnection struct {
+	Edges    []*PostEdge        `json:"edges"`
+	PageInfo *entities.PageInfo `json:"pageInfo"`
+}
+
+type PostEdge struct {
+	Cursor string         `json:"cursor"`
+	Node   *entities.Post `json:"node"`
+}
+
+type PostSearchConnection struct {
+	Results    []*entities.PostSearchResult `json:"results"`
+	Pagination *PaginationInfo              `json:"pagination"`
+}
+
 type Query struct {
 }
 
+type ReactionChange struct {
+	TargetType entities.ReactionTargetType `json:"targetType"`
+	TargetID   string                      `json:"targetId"`
+	UserID     string                      `json:"userId"`
+	Kind       entities.ReactionKind       `json:"kind"`
+	Count      int                         `json:"count"`
+}
+
+type ReactionInput struct {
+	TargetType entities.ReactionTargetType `json:"targetType"`
+	TargetID   string                      `json:"targetId"`
+	Kind       entities.ReactionKind       `json:"kind"`
+}
+
+type RegisterInput struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type ResolveReportsInput struct {
+	TargetType entities.ReportTargetType `json:"targetType"`
+	TargetID   string                    `json:"targetId"`
+	Action     entities.ModerationAction `json:"action"`
+	Note       *string                   `json:"note,omitempty"`
+}
+
 type Subscription struct {
 }
 
 type ToggleCommentsInput struct {
-	PostID   string `json:"postId"`
-	AuthorID string `json:"authorId"`
-	Disable  bool   `json:"disable"`
+	PostID  string `json:"postId"`
+	Disable bool   `json:"disable"`
 }
 
 type UpdateCommentInput struct {
-	ID       string `json:"id"`
-	AuthorID string `json:"authorId"`
-	Content  string `json:"content"`
+	ID      string `json:"id"`
+	Content string `json:"content"`
 }
 
 type UpdatePostInput struct {
-	ID       string `json:"id"`
-	AuthorID string `json:"authorId"`
-	Title    string `json:"title"`
-	Content  string `json:"content"`
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
 }
 
 type UpdateUserInput struct {
@@ -80,3 +179,86 @@ type UpdateUserInput struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 }
+
+type UserCursorConnection struct {
+	Edges    []*UserEdge        `json:"edges"`
+	PageInfo *entities.PageInfo `json:"pageInfo"`
+}
+
+type UserEdge struct {
+	Cursor string         `json:"cursor"`
+	Node   *entities.User `json:"node"`
+}
+
+type CommentEventType string
+
+const (
+	CommentEventTypeCommentCreated       CommentEventType = "COMMENT_CREATED"
+	CommentEventTypeCommentUpdated       CommentEventType = "COMMENT_UPDATED"
+	CommentEventTypeCommentDeleted       CommentEventType = "COMMENT_DELETED"
+	CommentEventTypeCommentsDisabled     CommentEventType = "COMMENTS_DISABLED"
+	CommentEventTypeCommentsEnabled      CommentEventType = "COMMENTS_ENABLED"
+	CommentEventTypeCommentPurged        CommentEventType = "COMMENT_PURGED"
+	CommentEventTypeReactionAdded        CommentEventType = "REACTION_ADDED"
+	CommentEventTypeReactionRemoved      CommentEventType = "REACTION_REMOVED"
+	CommentEventTypePostHidden           CommentEventType = "POST_HIDDEN"
+	CommentEventTypePostRestored         CommentEventType = "POST_RESTORED"
+	CommentEventTypeSubscriptionOverflow CommentEventType = "SUBSCRIPTION_OVERFLOW"
+)
+
+var AllCommentEventType = []CommentEventType{
+	CommentEventTypeCommentCreated,
+	CommentEventTypeCommentUpdated,
+	CommentEventTypeCommentDeleted,
+	CommentEventTypeCommentsDisabled,
+	CommentEventTypeCommentsEnabled,
+	CommentEventTypeCommentPurged,
+	CommentEventTypeReactionAdded,
+	CommentEventTypeReactionRemoved,
+	CommentEventTypePostHidden,
+	CommentEventTypePostRestored,
+	CommentEventTypeSubscriptionOverflow,
+}
+
+func (e CommentEventType) IsValid() bool {
+	switch e {
+	case CommentEventTypeCommentCreated, CommentEventTypeCommentUpdated, CommentEventTypeCommentDeleted, CommentEventTypeCommentsDisabled, CommentEventTypeCommentsEnabled, CommentEventTypeCommentPurged, CommentEventTypeReactionAdded, CommentEventTypeReactionRemoved, CommentEventTypePostHidden, CommentEventTypePostRestored, CommentEventTypeSubscriptionOverflow:
+		return true
+	}
+	return false
+}
+
+func (e CommentEventType) String() string {
+	return string(e)
+}
+
+func (e *CommentEventType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CommentEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CommentEventType", str)
+	}
+	return nil
+}
+
+func (e CommentEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CommentEventType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CommentEventType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
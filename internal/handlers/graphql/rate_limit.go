@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"ozon-posts/internal/services"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type clientIPKey struct{}
+
+// ClientIPMiddleware помещает адрес клиента в контекст для лимитов по IP. Если trustProxy включен,
+// адрес берется из последнего элемента X-Forwarded-For (его добавляет ближайший прокси)
+// или из X-Real-IP; иначе заголовки игнорируются, чтобы клиент не мог подменить адрес.
+func ClientIPMiddleware(trustProxy bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPKey{}, clientIP(r, trustProxy))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func clientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := net.ParseIP(strings.TrimSpace(parts[len(parts)-1])); ip != nil {
+				return ip.String()
+			}
+		}
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimit проверяет лимиты перед выполнением каждой мутации. Ошибка RATE_LIMITED
+// возвращается как ошибка поля, поэтому остальные мутации запроса выполняются по своим лимитам.
+type rateLimit struct {
+	limiter *services.RateLimiter
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = rateLimit{}
+
+func (rateLimit) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (rateLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l rateLimit) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fieldCtx := graphql.GetFieldContext(ctx)
+	if fieldCtx == nil || fieldCtx.Object != "Mutation" {
+		return next(ctx)
+	}
+
+	if err := l.limiter.Allow(ctx, fieldCtx.Field.Name, clientIPFromContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	return next(ctx)
+}
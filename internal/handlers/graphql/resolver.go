@@ -16,40 +16,95 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	userService    *services.UserService
-	postService    *services.PostService
-	commentService *services.CommentService
-	logger         *logrus.Logger
+	userService         *services.UserService
+	postService         *services.PostService
+	commentService      *services.CommentService
+	reactionService     *services.ReactionService
+	notificationService *services.NotificationService
+	followService       *services.FollowService
+	moderationService   *services.ModerationService
+	logger              *logrus.Logger
 }
 
 func NewResolver(
 	userService *services.UserService,
 	postService *services.PostService,
 	commentService *services.CommentService,
+	reactionService *services.ReactionService,
+	notificationService *services.NotificationService,
+	followService *services.FollowService,
+	moderationService *services.ModerationService,
 	logger *logrus.Logger,
 ) *Resolver {
 	return &Resolver{
-		userService:    userService,
-		postService:    postService,
-		commentService: commentService,
-		logger:         logger,
+		userService:         userService,
+		postService:         postService,
+		commentService:      commentService,
+		reactionService:     reactionService,
+		notificationService: notificationService,
+		followService:       followService,
+		moderationService:   moderationService,
+		logger:              logger,
 	}
 }
 
-func (r *Resolver) CommentAddedSubscription(ctx context.Context, postID string) (<-chan *CommentEvent, error) {
+var commentEventTypes = map[services.CommentEventType]CommentEventType{
+	services.CommentEventCreated:          CommentEventTypeCommentCreated,
+	services.CommentEventUpdated:          CommentEventTypeCommentUpdated,
+	services.CommentEventDeleted:          CommentEventTypeCommentDeleted,
+	services.CommentEventCommentsDisabled: CommentEventTypeCommentsDisabled,
+	services.CommentEventCommentsEnabled:  CommentEventTypeCommentsEnabled,
+	services.CommentEventPurged:           CommentEventTypeCommentPurged,
+	services.CommentEventReactionAdded:    CommentEventTypeReactionAdded,
+	services.CommentEventReactionRemoved:  CommentEventTypeReactionRemoved,
+	services.CommentEventPostHidden:       CommentEventTypePostHidden,
+	services.CommentEventPostRestored:     CommentEventTypePostRestored,
+}
+
+func (r *Resolver) CommentAddedSubscription(ctx context.Context, postID string, afterSequence *int) (<-chan *CommentEvent, error) {
+	return r.CommentEventsSubscription(ctx, postID, []CommentEventType{CommentEventTypeCommentCreated}, afterSequence)
+}
+
+// CommentEventsSubscription подписывает клиента на события комментариев поста.
+// Пустой types означает все типы событий. Если клиент не успевает читать события,
+// последним отправляется SUBSCRIPTION_OVERFLOW с номером последнего полученного события.
+func (r *Resolver) CommentEventsSubscription(ctx context.Context, postID string, types []CommentEventType, afterSequence *int) (<-chan *CommentEvent, error) {
 	pid, err := uuid.Parse(postID)
 	if err != nil {
 		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка парсинга UUID поста для подписки")
 		return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
 	}
 
-	gqlEventChan := make(chan *CommentEvent, 10)
+	var after *int64
+	if afterSequence != nil {
+		if *afterSequence < 0 {
+			return nil, errors.NewInvalidRequestError("afterSequence не может быть отрицательным")
+		}
+		a := int64(*afterSequence)
+		after = &a
+	}
 
-	serviceEventChan := r.commentService.SubscribeToPost(pid)
+	wanted := make(map[CommentEventType]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	sub, err := r.commentService.SubscribeToPost(pid, after)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", pid).Warn("Ошибка создания подписки на комментарии")
+		return nil, fmt.Errorf("ошибка подписки на комментарии: %w", err)
+	}
+
+	gqlEventChan := make(chan *CommentEvent, 10)
 
 	go func() {
 		defer close(gqlEventChan)
-		defer r.commentService.UnsubscribeFromPost(pid, serviceEventChan)
+		defer r.commentService.UnsubscribeFromPost(sub)
+
+		var lastSequence int64
+		if after != nil {
+			lastSequence = *after
+		}
 
 		for {
 			select {
@@ -57,16 +112,47 @@ func (r *Resolver) CommentAddedSubscription(ctx context.Context, postID string)
 				r.logger.WithField("post_id", pid).Debug("Подписка на комментарии отменена")
 				return
 
-			case event, ok := <-serviceEventChan:
+			case event, ok := <-sub.Events():
 				if !ok {
+					if err := sub.Err(); err != nil {
+						r.logger.WithError(err).WithFields(logrus.Fields{
+							"post_id":       pid,
+							"last_sequence": lastSequence,
+						}).Warn("Подписка на комментарии закрыта сервером")
+
+						select {
+						case gqlEventChan <- &CommentEvent{
+							Sequence: int(lastSequence),
+							Type:     CommentEventTypeSubscriptionOverflow,
+							PostID:   pid.String(),
+						}:
+						case <-ctx.Done():
+						}
+						return
+					}
+
 					r.logger.WithField("post_id", pid).Debug("Канал событий комментариев закрыт")
 					return
 				}
 
+				lastSequence = event.Sequence
+
+				eventType, known := commentEventTypes[event.Type]
+				if !known {
+					r.logger.WithField("event_type", event.Type).Warn("Неизвестный тип события комментариев")
+					continue
+				}
+
+				if len(wanted) > 0 && !wanted[eventType] {
+					continue
+				}
+
 				gqlEvent := &CommentEvent{
-					Type:    event.Type,
-					PostID:  event.PostID.String(),
-					Comment: event.Comment,
+					Sequence: int(event.Sequence),
+					Type:     eventType,
+					PostID:   event.PostID.String(),
+					Comment:  event.Comment,
+					Reaction: newReactionChange(event.Reaction),
 				}
 
 				select {
@@ -74,7 +160,7 @@ func (r *Resolver) CommentAddedSubscription(ctx context.Context, postID string)
 					r.logger.WithFields(logrus.Fields{
 						"post_id":    pid,
 						"event_type": event.Type,
-						"comment_id": event.Comment.ID,
+						"sequence":   event.Sequence,
 					}).Debug("Событие комментария отправлено через GraphQL подписку")
 
 				case <-ctx.Done():
@@ -84,33 +170,282 @@ func (r *Resolver) CommentAddedSubscription(ctx context.Context, postID string)
 		}
 	}()
 
-	r.logger.WithField("post_id", pid).Info("Подписка на комментарии поста создана")
+	r.logger.WithFields(logrus.Fields{
+		"post_id":        pid,
+		"types":          types,
+		"after_sequence": afterSequence,
+	}).Info("Подписка на комментарии поста создана")
 	return gqlEventChan, nil
 }
 
-func (r *Resolver) DeleteCommentMutation(ctx context.Context, commentID string, authorID string) (bool, error) {
+// MentionedInSubscription оповещает аутентифицированного пользователя о комментариях, в которых
+// его упомянули. Пропущенные за время отключения упоминания не повторяются.
+func (r *Resolver) MentionedInSubscription(ctx context.Context) (<-chan *entities.Comment, error) {
+	sub, err := r.commentService.SubscribeToUserEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions := make(chan *entities.Comment, 10)
+
+	go func() {
+		defer close(mentions)
+		defer r.commentService.UnsubscribeFromUserEvents(sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				r.logger.WithField("user_id", sub.UserID).Debug("Подписка на упоминания отменена")
+				return
+
+			case event, ok := <-sub.Events():
+				if !ok {
+					if err := sub.Err(); err != nil {
+						r.logger.WithError(err).WithField("user_id", sub.UserID).Warn("Подписка на упоминания закрыта сервером")
+					}
+					return
+				}
+
+				if event.Type != services.UserEventMentioned {
+					continue
+				}
+
+				select {
+				case mentions <- event.Comment:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	r.logger.WithField("user_id", sub.UserID).Info("Подписка на упоминания создана")
+	return mentions, nil
+}
+
+func (r *Resolver) NotificationReceivedSubscription(ctx context.Context) (<-chan *entities.Notification, error) {
+	sub, err := r.notificationService.SubscribeToNotifications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make(chan *entities.Notification, 10)
+
+	go func() {
+		defer close(notifications)
+		defer r.notificationService.Unsubscribe(sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				r.logger.WithField("user_id", sub.UserID).Debug("Подписка на оповещения отменена")
+				return
+
+			case event, ok := <-sub.Events():
+				if !ok {
+					if err := sub.Err(); err != nil {
+						r.logger.WithError(err).WithField("user_id", sub.UserID).Warn("Подписка на оповещения закрыта сервером")
+					}
+					return
+				}
+
+				if event.Type != services.UserEventNotification {
+					continue
+				}
+
+				select {
+				case notifications <- event.Notification:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	r.logger.WithField("user_id", sub.UserID).Info("Подписка на оповещения создана")
+	return notifications, nil
+}
+
+func (r *Resolver) DeleteCommentMutation(ctx context.Context, commentID string) (bool, error) {
 	cid, err := uuid.Parse(commentID)
 	if err != nil {
 		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка парсинга UUID комментария для удаления")
 		return false, errors.NewInvalidRequestError("некорректный формат ID комментария")
 	}
 
-	aid, err := uuid.Parse(authorID)
+	if err := r.commentService.DeleteComment(ctx, cid); err != nil {
+		r.logger.WithError(err).WithField("comment_id", cid).Error("Ошибка удаления комментария")
+		return false, fmt.Errorf("ошибка удаления комментария: %w", err)
+	}
+
+	r.logger.WithField("comment_id", cid).Info("Комментарий успешно удален через GraphQL")
+	return true, nil
+}
+
+func (r *Resolver) PurgeCommentMutation(ctx context.Context, commentID string) (int, error) {
+	cid, err := uuid.Parse(commentID)
 	if err != nil {
-		r.logger.WithError(err).WithField("author_id", authorID).Error("Ошибка парсинга UUID автора для удаления комментария")
-		return false, errors.NewInvalidRequestError("некорректный формат ID автора")
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка парсинга UUID комментария для очистки")
+		return 0, errors.NewInvalidRequestError("некорректный формат ID комментария")
 	}
 
-	if err := r.commentService.DeleteComment(ctx, cid, aid); err != nil {
-		r.logger.WithError(err).WithFields(logrus.Fields{
-			"comment_id": cid,
-			"author_id":  aid,
-		}).Error("Ошибка удаления комментария")
-		return false, fmt.Errorf("ошибка удаления комментария: %v", err)
+	purged, err := r.commentService.PurgeComment(ctx, cid)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", cid).Error("Ошибка полной очистки комментария")
+		return 0, fmt.Errorf("ошибка полной очистки комментария: %w", err)
 	}
 
-	r.logger.WithField("comment_id", cid).Info("Комментарий успешно удален через GraphQL")
-	return true, nil
+	r.logger.WithFields(logrus.Fields{
+		"comment_id": cid,
+		"purged":     purged,
+	}).Info("Ветка комментария полностью удалена через GraphQL")
+	return int(purged), nil
+}
+
+func (r *Resolver) ReactMutation(ctx context.Context, input ReactionInput) ([]*entities.ReactionSummary, error) {
+	targetID, err := uuid.Parse(input.TargetID)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", input.TargetID).Error("Ошибка парсинга UUID объекта реакции")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID объекта реакции")
+	}
+
+	summaries, err := r.reactionService.React(ctx, input.TargetType, targetID, input.Kind)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", targetID).Error("Ошибка добавления реакции")
+		return nil, fmt.Errorf("ошибка добавления реакции: %w", err)
+	}
+
+	return summaries, nil
+}
+
+func (r *Resolver) UnreactMutation(ctx context.Context, input ReactionInput) ([]*entities.ReactionSummary, error) {
+	targetID, err := uuid.Parse(input.TargetID)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", input.TargetID).Error("Ошибка парсинга UUID объекта реакции")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID объекта реакции")
+	}
+
+	summaries, err := r.reactionService.Unreact(ctx, input.TargetType, targetID, input.Kind)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", targetID).Error("Ошибка удаления реакции")
+		return nil, fmt.Errorf("ошибка удаления реакции: %w", err)
+	}
+
+	return summaries, nil
+}
+
+func (r *Resolver) FollowMutation(ctx context.Context, userID string) (*entities.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка парсинга UUID пользователя для подписки")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID пользователя")
+	}
+
+	user, err := r.followService.Follow(ctx, uid)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", uid).Error("Ошибка подписки на пользователя")
+		return nil, fmt.Errorf("ошибка подписки на пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *Resolver) UnfollowMutation(ctx context.Context, userID string) (*entities.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка парсинга UUID пользователя для отмены подписки")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID пользователя")
+	}
+
+	user, err := r.followService.Unfollow(ctx, uid)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", uid).Error("Ошибка отмены подписки на пользователя")
+		return nil, fmt.Errorf("ошибка отмены подписки на пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *Resolver) MarkNotificationsReadMutation(ctx context.Context, ids []string) (int, error) {
+	notificationIDs := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		nid, err := uuid.Parse(id)
+		if err != nil {
+			r.logger.WithError(err).WithField("notification_id", id).Error("Ошибка парсинга UUID оповещения")
+			return 0, errors.NewInvalidRequestError("некорректный формат ID оповещения")
+		}
+		notificationIDs = append(notificationIDs, nid)
+	}
+
+	marked, err := r.notificationService.MarkNotificationsRead(ctx, notificationIDs)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка отметки оповещений прочитанными")
+		return 0, fmt.Errorf("ошибка отметки оповещений прочитанными: %w", err)
+	}
+
+	return int(marked), nil
+}
+
+func (r *Resolver) ReportMutation(ctx context.Context, targetType entities.ReportTargetType, targetID string, reason entities.ReportReason) (bool, error) {
+	tid, err := uuid.Parse(targetID)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", targetID).Error("Ошибка парсинга UUID объекта жалобы")
+		return false, errors.NewInvalidRequestError("некорректный формат ID объекта жалобы")
+	}
+
+	created, err := r.moderationService.Report(ctx, targetType, tid, reason)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", tid).Error("Ошибка подачи жалобы")
+		return false, fmt.Errorf("ошибка подачи жалобы: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *Resolver) ResolveReportsMutation(ctx context.Context, input ResolveReportsInput) (*entities.ModerationAuditEntry, error) {
+	targetID, err := uuid.Parse(input.TargetID)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", input.TargetID).Error("Ошибка парсинга UUID объекта жалобы")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID объекта жалобы")
+	}
+
+	entry, err := r.moderationService.ResolveReports(ctx, input.TargetType, targetID, input.Action, input.Note)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", targetID).Error("Ошибка решения по жалобам")
+		return nil, fmt.Errorf("ошибка решения по жалобам: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (r *Resolver) RestorePostMutation(ctx context.Context, postID string, note *string) (*entities.ModerationAuditEntry, error) {
+	pid, err := uuid.Parse(postID)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка парсинга UUID поста")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
+	}
+
+	entry, err := r.moderationService.RestorePost(ctx, pid, note)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", pid).Error("Ошибка восстановления поста")
+		return nil, fmt.Errorf("ошибка восстановления поста: %w", err)
+	}
+
+	return entry, nil
+}
+
+func newReactionChange(event *services.ReactionEvent) *ReactionChange {
+	if event == nil {
+		return nil
+	}
+
+	return &ReactionChange{
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID.String(),
+		UserID:     event.UserID.String(),
+		Kind:       event.Kind,
+		Count:      int(event.Count),
+	}
 }
 
 func (r *Resolver) GetPostsByAuthorQuery(ctx context.Context, authorID string, limit *int, offset *int) (*PostConnection, error) {
@@ -137,7 +472,7 @@ func (r *Resolver) GetPostsByAuthorQuery(ctx context.Context, authorID string, l
 	posts, paginationResponse, err := r.postService.GetPostsByAuthor(ctx, aid, pagination)
 	if err != nil {
 		r.logger.WithError(err).WithField("author_id", aid).Error("Ошибка получения постов автора")
-		return nil, fmt.Errorf("ошибка получения постов автора: %v", err)
+		return nil, fmt.Errorf("ошибка получения постов автора: %w", err)
 	}
 
 	return &PostConnection{
@@ -158,45 +493,29 @@ func (r *Resolver) UpdatePostMutation(ctx context.Context, input UpdatePostInput
 		return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
 	}
 
-	authorID, err := uuid.Parse(input.AuthorID)
-	if err != nil {
-		r.logger.WithError(err).WithField("author_id", input.AuthorID).Error("Ошибка парсинга UUID автора")
-		return nil, errors.NewInvalidRequestError("некорректный формат ID автора")
-	}
-
-	post, err := r.postService.UpdatePost(ctx, postID, authorID, input.Title, input.Content)
+	post, err := r.postService.UpdatePost(ctx, postID, input.Title, input.Content)
 	if err != nil {
 		r.logger.WithError(err).WithFields(logrus.Fields{
-			"post_id":   postID,
-			"author_id": authorID,
-			"title":     input.Title,
+			"post_id": postID,
+			"title":   input.Title,
 		}).Error("Ошибка обновления поста")
-		return nil, fmt.Errorf("ошибка обновления поста: %v", err)
+		return nil, fmt.Errorf("ошибка обновления поста: %w", err)
 	}
 
 	r.logger.WithField("post_id", postID).Info("Пост успешно обновлен через GraphQL")
 	return post, nil
 }
 
-func (r *Resolver) DeletePostMutation(ctx context.Context, postID string, authorID string) (bool, error) {
+func (r *Resolver) DeletePostMutation(ctx context.Context, postID string) (bool, error) {
 	pid, err := uuid.Parse(postID)
 	if err != nil {
 		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка парсинга UUID поста для удаления")
 		return false, errors.NewInvalidRequestError("некорректный формат ID поста")
 	}
 
-	aid, err := uuid.Parse(authorID)
-	if err != nil {
-		r.logger.WithError(err).WithField("author_id", authorID).Error("Ошибка парсинга UUID автора для удаления поста")
-		return false, errors.NewInvalidRequestError("некорректный формат ID автора")
-	}
-
-	if err := r.postService.DeletePost(ctx, pid, aid); err != nil {
-		r.logger.WithError(err).WithFields(logrus.Fields{
-			"post_id":   pid,
-			"author_id": aid,
-		}).Error("Ошибка удаления поста")
-		return false, fmt.Errorf("ошибка удаления поста: %v", err)
+	if err := r.postService.DeletePost(ctx, pid); err != nil {
+		r.logger.WithError(err).WithField("post_id", pid).Error("Ошибка удаления поста")
+		return false, fmt.Errorf("ошибка удаления поста: %w", err)
 	}
 
 	r.logger.WithField("post_id", pid).Info("Пост успешно удален через GraphQL")
@@ -210,19 +529,12 @@ func (r *Resolver) ToggleCommentsMutation(ctx context.Context, input ToggleComme
 		return false, errors.NewInvalidRequestError("некорректный формат ID поста")
 	}
 
-	authorID, err := uuid.Parse(input.AuthorID)
-	if err != nil {
-		r.logger.WithError(err).WithField("author_id", input.AuthorID).Error("Ошибка парсинга UUID автора")
-		return false, errors.NewInvalidRequestError("некорректный формат ID автора")
-	}
-
-	if err := r.postService.ToggleComments(ctx, postID, authorID, input.Disable); err != nil {
+	if err := r.postService.ToggleComments(ctx, postID, input.Disable); err != nil {
 		r.logger.WithError(err).WithFields(logrus.Fields{
-			"post_id":   postID,
-			"author_id": authorID,
-			"disable":   input.Disable,
+			"post_id": postID,
+			"disable": input.Disable,
 		}).Error("Ошибка переключения комментариев")
-		return false, fmt.Errorf("ошибка переключения комментариев: %v", err)
+		return false, fmt.Errorf("ошибка переключения комментариев: %w", err)
 	}
 
 	r.logger.WithFields(logrus.Fields{
@@ -245,13 +557,13 @@ func (r *Resolver) UpdateUserMutation(ctx context.Context, input UpdateUserInput
 			"username": input.Username,
 			"email":    input.Email,
 		}).Error("Ошибка обновления пользователя")
-		return nil, fmt.Errorf("ошибка обновления пользователя: %v", err)
+		return nil, fmt.Errorf("ошибка обновления пользователя: %w", err)
 	}
 
 	user, err := r.userService.GetUserByID(ctx, userID)
 	if err != nil {
 		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка получения обновленного пользователя")
-		return nil, fmt.Errorf("ошибка получения обновленного пользователя: %v", err)
+		return nil, fmt.Errorf("ошибка получения обновленного пользователя: %w", err)
 	}
 
 	r.logger.WithField("user_id", userID).Info("Пользователь успешно обновлен через GraphQL")
@@ -265,20 +577,13 @@ func (r *Resolver) UpdateCommentMutation(ctx context.Context, input UpdateCommen
 		return nil, errors.NewInvalidRequestError("некорректный формат ID комментария")
 	}
 
-	authorID, err := uuid.Parse(input.AuthorID)
-	if err != nil {
-		r.logger.WithError(err).WithField("author_id", input.AuthorID).Error("Ошибка парсинга UUID автора")
-		return nil, errors.NewInvalidRequestError("некорректный формат ID автора")
-	}
-
-	comment, err := r.commentService.UpdateComment(ctx, commentID, authorID, input.Content)
+	comment, err := r.commentService.UpdateComment(ctx, commentID, input.Content)
 	if err != nil {
 		r.logger.WithError(err).WithFields(logrus.Fields{
 			"comment_id": commentID,
-			"author_id":  authorID,
 			"content":    input.Content,
 		}).Error("Ошибка обновления комментария")
-		return nil, fmt.Errorf("ошибка обновления комментария: %v", err)
+		return nil, fmt.Errorf("ошибка обновления комментария: %w", err)
 	}
 
 	r.logger.WithField("comment_id", commentID).Info("Комментарий успешно обновлен через GraphQL")
@@ -294,9 +599,555 @@ func (r *Resolver) DeleteUserMutation(ctx context.Context, userID string) (bool,
 
 	if err := r.userService.DeleteUser(ctx, uid); err != nil {
 		r.logger.WithError(err).WithField("user_id", uid).Error("Ошибка удаления пользователя")
-		return false, fmt.Errorf("ошибка удаления пользователя: %v", err)
+		return false, fmt.Errorf("ошибка удаления пользователя: %w", err)
 	}
 
 	r.logger.WithField("user_id", uid).Info("Пользователь успешно удален через GraphQL")
 	return true, nil
 }
+
+func (r *Resolver) ChangeUserRoleMutation(ctx context.Context, input ChangeUserRoleInput) (*entities.User, error) {
+	userID, err := uuid.Parse(input.UserID)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", input.UserID).Error("Ошибка парсинга UUID пользователя")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID пользователя")
+	}
+
+	user, err := r.userService.ChangeUserRole(ctx, userID, input.Role)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id": userID,
+			"role":    input.Role,
+		}).Error("Ошибка изменения роли пользователя")
+		return nil, fmt.Errorf("ошибка изменения роли пользователя: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"role":    input.Role,
+	}).Info("Роль пользователя успешно изменена через GraphQL")
+	return user, nil
+}
+
+func (r *Resolver) RegisterMutation(ctx context.Context, input RegisterInput) (*AuthPayload, error) {
+	result, err := r.userService.Register(ctx, input.Username, input.Email, input.Password)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"username": input.Username,
+			"email":    input.Email,
+		}).Error("Ошибка регистрации пользователя")
+		return nil, fmt.Errorf("ошибка регистрации пользователя: %w", err)
+	}
+
+	r.logger.WithField("user_id", result.User.ID).Info("Пользователь успешно зарегистрирован через GraphQL")
+	return newAuthPayload(result), nil
+}
+
+func (r *Resolver) LoginMutation(ctx context.Context, input LoginInput) (*AuthPayload, error) {
+	result, err := r.userService.Login(ctx, input.Login, input.Password)
+	if err != nil {
+		r.logger.WithError(err).WithField("login", input.Login).Warn("Ошибка входа пользователя")
+		return nil, fmt.Errorf("ошибка входа: %w", err)
+	}
+
+	return newAuthPayload(result), nil
+}
+
+func newAuthPayload(result *services.AuthResult) *AuthPayload {
+	return &AuthPayload{
+		Token:     result.Token,
+		ExpiresAt: result.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+		User:      result.User,
+	}
+}
+
+func (r *Resolver) CommentTreeQuery(ctx context.Context, commentID string, maxDepth *int, childLimits []int) (*entities.CommentTreeNode, error) {
+	cid, err := uuid.Parse(commentID)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка парсинга UUID комментария")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID комментария")
+	}
+
+	depth := entities.DefaultCommentTreeDepth
+	if maxDepth != nil {
+		depth = *maxDepth
+	}
+
+	tree, err := r.commentService.GetCommentTree(ctx, cid, entities.NewCommentTreeRequest(depth, childLimits))
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", cid).Error("Ошибка получения дерева комментариев")
+		return nil, fmt.Errorf("ошибка получения дерева комментариев: %w", err)
+	}
+
+	return tree, nil
+}
+
+func (r *Resolver) PostRevisionsQuery(ctx context.Context, postID uuid.UUID) ([]*entities.PostRevision, error) {
+	revisions, err := r.postService.GetPostRevisions(ctx, postID)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Warn("Ошибка получения истории правок поста")
+		return nil, fmt.Errorf("ошибка получения истории правок поста: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *Resolver) CommentRevisionsQuery(ctx context.Context, commentID uuid.UUID) ([]*entities.CommentRevision, error) {
+	revisions, err := r.commentService.GetCommentRevisions(ctx, commentID)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Warn("Ошибка получения истории правок комментария")
+		return nil, fmt.Errorf("ошибка получения истории правок комментария: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *Resolver) SearchPostsQuery(ctx context.Context, query string, limit *int, offset *int) (*PostSearchConnection, error) {
+	results, paginationResponse, err := r.postService.SearchPosts(ctx, query, newSearchPagination(limit, offset))
+	if err != nil {
+		r.logger.WithError(err).WithField("query", query).Error("Ошибка поиска постов")
+		return nil, fmt.Errorf("ошибка поиска постов: %w", err)
+	}
+
+	return &PostSearchConnection{
+		Results:    results,
+		Pagination: newPaginationInfo(paginationResponse),
+	}, nil
+}
+
+func (r *Resolver) SearchCommentsQuery(ctx context.Context, query string, postID *string, limit *int, offset *int) (*CommentSearchConnection, error) {
+	var pid *uuid.UUID
+	if postID != nil {
+		parsed, err := uuid.Parse(*postID)
+		if err != nil {
+			r.logger.WithError(err).WithField("post_id", *postID).Error("Ошибка парсинга UUID поста для поиска")
+			return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
+		}
+		pid = &parsed
+	}
+
+	results, paginationResponse, err := r.commentService.SearchComments(ctx, query, pid, newSearchPagination(limit, offset))
+	if err != nil {
+		r.logger.WithError(err).WithField("query", query).Error("Ошибка поиска комментариев")
+		return nil, fmt.Errorf("ошибка поиска комментариев: %w", err)
+	}
+
+	return &CommentSearchConnection{
+		Results:    results,
+		Pagination: newPaginationInfo(paginationResponse),
+	}, nil
+}
+
+// newSearchPagination ограничивает размер страницы поиска так же, как NewPaginationRequest
+func newSearchPagination(limit *int, offset *int) *entities.PaginationRequest {
+	pagination := newFieldPagination(limit, offset)
+	return entities.NewPaginationRequest(pagination.Limit, pagination.Offset)
+}
+
+func newPaginationInfo(paginationResponse *entities.PaginationResponse) *PaginationInfo {
+	return &PaginationInfo{
+		Total:   int(paginationResponse.Total),
+		Limit:   paginationResponse.Limit,
+		Offset:  paginationResponse.Offset,
+		HasMore: paginationResponse.HasMore,
+	}
+}
+
+// UserField возвращает связанного пользователя; known - уже загруженное значение, если оно есть
+func (r *Resolver) UserField(ctx context.Context, userID uuid.UUID, known *entities.User) (*entities.User, error) {
+	if known != nil {
+		return known, nil
+	}
+
+	user, err := r.loaders(ctx).users.Load(ctx, userID)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка загрузки пользователя")
+		return nil, fmt.Errorf("ошибка загрузки пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *Resolver) PostField(ctx context.Context, postID uuid.UUID, known *entities.Post) (*entities.Post, error) {
+	if known != nil {
+		return known, nil
+	}
+
+	post, err := r.loaders(ctx).posts.Load(ctx, postID)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка загрузки поста")
+		return nil, fmt.Errorf("ошибка загрузки поста: %w", err)
+	}
+
+	return post, nil
+}
+
+func (r *Resolver) CommentField(ctx context.Context, commentID uuid.UUID, known *entities.Comment) (*entities.Comment, error) {
+	if known != nil {
+		return known, nil
+	}
+
+	comment, err := r.loaders(ctx).comments.Load(ctx, commentID)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка загрузки комментария")
+		return nil, fmt.Errorf("ошибка загрузки комментария: %w", err)
+	}
+
+	return comment, nil
+}
+
+// PostCommentsField отдает первую страницу комментариев через загрузчик, чтобы список постов
+// не порождал запрос на каждый пост; остальные страницы и другие порядки запрашиваются у сервиса напрямую
+func (r *Resolver) PostCommentsField(ctx context.Context, postID uuid.UUID, limit *int, offset *int, sort *entities.CommentSort) (*CommentConnection, error) {
+	pagination := newFieldPagination(limit, offset)
+	order := commentSortOrDefault(sort)
+
+	if order == entities.CommentSortOldest && isBatchedPage(pagination) {
+		connection, err := r.loaders(ctx).postComments.Load(ctx, childPageKey{ParentID: postID, Limit: pagination.Limit})
+		if err != nil {
+			r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения комментариев поста")
+			return nil, fmt.Errorf("ошибка получения комментариев поста: %w", err)
+		}
+		return connection, nil
+	}
+
+	comments, paginationResponse, err := r.commentService.GetPostComments(ctx, postID, order, pagination)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения комментариев поста")
+		return nil, fmt.Errorf("ошибка получения комментариев поста: %w", err)
+	}
+
+	return &CommentConnection{
+		Comments:   comments,
+		Pagination: newPaginationInfo(paginationResponse),
+	}, nil
+}
+
+func (r *Resolver) CommentRepliesField(ctx context.Context, commentID uuid.UUID, limit *int, offset *int, sort *entities.CommentSort) (*CommentConnection, error) {
+	pagination := newFieldPagination(limit, offset)
+	order := commentSortOrDefault(sort)
+
+	if order == entities.CommentSortOldest && isBatchedPage(pagination) {
+		connection, err := r.loaders(ctx).commentReplies.Load(ctx, childPageKey{ParentID: commentID, Limit: pagination.Limit})
+		if err != nil {
+			r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка получения ответов на комментарий")
+			return nil, fmt.Errorf("ошибка получения ответов на комментарий: %w", err)
+		}
+		return connection, nil
+	}
+
+	replies, paginationResponse, err := r.commentService.GetCommentReplies(ctx, commentID, order, pagination)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка получения ответов на комментарий")
+		return nil, fmt.Errorf("ошибка получения ответов на комментарий: %w", err)
+	}
+
+	return &CommentConnection{
+		Comments:   replies,
+		Pagination: newPaginationInfo(paginationResponse),
+	}, nil
+}
+
+// CommentMentionsField отдает упомянутых пользователей через загрузчик. У удаленного комментария
+// текст скрыт, поэтому и упоминания не показываются.
+func (r *Resolver) CommentMentionsField(ctx context.Context, comment *entities.Comment) ([]*entities.User, error) {
+	if comment.IsDeleted() {
+		return []*entities.User{}, nil
+	}
+
+	users, err := r.loaders(ctx).mentions.Load(ctx, comment.ID)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", comment.ID).Error("Ошибка загрузки упоминаний")
+		return nil, fmt.Errorf("ошибка загрузки упоминаний: %w", err)
+	}
+
+	if users == nil {
+		users = []*entities.User{}
+	}
+	return users, nil
+}
+
+// ReactionsField отдает сводку реакций через загрузчик: реакции списка постов или комментариев
+// загружаются одним запросом на тип объекта
+func (r *Resolver) ReactionsField(ctx context.Context, targetType entities.ReactionTargetType, targetID uuid.UUID) ([]*entities.ReactionSummary, error) {
+	summaries, err := r.loaders(ctx).reactions.Load(ctx, reactionTargetKey{TargetType: targetType, TargetID: targetID})
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", targetID).Error("Ошибка загрузки реакций")
+		return nil, fmt.Errorf("ошибка загрузки реакций: %w", err)
+	}
+
+	if summaries == nil {
+		summaries = []*entities.ReactionSummary{}
+	}
+	return summaries, nil
+}
+
+// maxBatchedPageLimit - наибольший размер страницы, который загружается пакетно
+const maxBatchedPageLimit = 100
+
+func newFieldPagination(limit *int, offset *int) *entities.PaginationRequest {
+	l, o := 20, 0
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+	return &entities.PaginationRequest{Limit: l, Offset: o}
+}
+
+// isBatchedPage сообщает, загружается ли страница пакетно: загрузчики отдают только
+// первую страницу в порядке создания
+func isBatchedPage(pagination *entities.PaginationRequest) bool {
+	return pagination.Offset == 0 && pagination.Limit > 0 && pagination.Limit <= maxBatchedPageLimit
+}
+
+func commentSortOrDefault(sort *entities.CommentSort) entities.CommentSort {
+	if sort == nil {
+		return entities.CommentSortOldest
+	}
+	return *sort
+}
+
+func (r *Resolver) PostsConnectionQuery(ctx context.Context, first *int, after *string, last *int, before *string) (*PostCursorConnection, error) {
+	req, err := entities.NewCursorRequest(first, last, after, before)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, pageInfo, err := r.postService.GetPostsPage(ctx, req)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы постов")
+		return nil, fmt.Errorf("ошибка получения постов: %w", err)
+	}
+
+	return newPostCursorConnection(posts, pageInfo), nil
+}
+
+func (r *Resolver) PostsByAuthorConnectionQuery(ctx context.Context, authorID string, first *int, after *string, last *int, before *string) (*PostCursorConnection, error) {
+	aid, err := uuid.Parse(authorID)
+	if err != nil {
+		r.logger.WithError(err).WithField("author_id", authorID).Error("Ошибка парсинга UUID автора")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID автора")
+	}
+
+	req, err := entities.NewCursorRequest(first, last, after, before)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, pageInfo, err := r.postService.GetPostsByAuthorPage(ctx, aid, req)
+	if err != nil {
+		r.logger.WithError(err).WithField("author_id", aid).Error("Ошибка получения страницы постов автора")
+		return nil, fmt.Errorf("ошибка получения постов автора: %w", err)
+	}
+
+	return newPostCursorConnection(posts, pageInfo), nil
+}
+
+func (r *Resolver) PostCommentsConnectionQuery(ctx context.Context, postID uuid.UUID, first *int, after *string, last *int, before *string) (*CommentCursorConnection, error) {
+	req, err := entities.NewCursorRequest(first, last, after, before)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, pageInfo, err := r.commentService.GetPostCommentsPage(ctx, postID, req)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения страницы комментариев поста")
+		return nil, fmt.Errorf("ошибка получения комментариев поста: %w", err)
+	}
+
+	return newCommentCursorConnection(comments, pageInfo), nil
+}
+
+func (r *Resolver) CommentRepliesConnectionQuery(ctx context.Context, parentID uuid.UUID, first *int, after *string, last *int, before *string) (*CommentCursorConnection, error) {
+	req, err := entities.NewCursorRequest(first, last, after, before)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, pageInfo, err := r.commentService.GetCommentRepliesPage(ctx, parentID, req)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", parentID).Error("Ошибка получения страницы ответов на комментарий")
+		return nil, fmt.Errorf("ошибка получения ответов на комментарий: %w", err)
+	}
+
+	return newCommentCursorConnection(replies, pageInfo), nil
+}
+
+func (r *Resolver) FeedConnectionQuery(ctx context.Context, first *int, after *string) (*PostCursorConnection, error) {
+	req, err := entities.NewCursorRequest(first, nil, after, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, pageInfo, err := r.followService.GetFeedPage(ctx, req)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы ленты")
+		return nil, fmt.Errorf("ошибка получения ленты: %w", err)
+	}
+
+	return newPostCursorConnection(posts, pageInfo), nil
+}
+
+func (r *Resolver) UserFollowersField(ctx context.Context, userID uuid.UUID, first *int, after *string) (*UserCursorConnection, error) {
+	req, err := entities.NewCursorRequest(first, nil, after, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	follows, pageInfo, err := r.followService.GetFollowersPage(ctx, userID, req)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка получения подписчиков")
+		return nil, fmt.Errorf("ошибка получения подписчиков: %w", err)
+	}
+
+	edges := make([]*UserEdge, 0, len(follows))
+	for _, follow := range follows {
+		edges = append(edges, &UserEdge{Cursor: follow.FollowerCursor().Encode(), Node: follow.Follower})
+	}
+	return newUserCursorConnection(edges, pageInfo), nil
+}
+
+func (r *Resolver) UserFollowingField(ctx context.Context, userID uuid.UUID, first *int, after *string) (*UserCursorConnection, error) {
+	req, err := entities.NewCursorRequest(first, nil, after, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	follows, pageInfo, err := r.followService.GetFollowingPage(ctx, userID, req)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка получения подписок")
+		return nil, fmt.Errorf("ошибка получения подписок: %w", err)
+	}
+
+	edges := make([]*UserEdge, 0, len(follows))
+	for _, follow := range follows {
+		edges = append(edges, &UserEdge{Cursor: follow.FolloweeCursor().Encode(), Node: follow.Followee})
+	}
+	return newUserCursorConnection(edges, pageInfo), nil
+}
+
+func (r *Resolver) NotificationsConnectionQuery(ctx context.Context, unreadOnly *bool, first *int, after *string) (*NotificationCursorConnection, error) {
+	req, err := entities.NewCursorRequest(first, nil, after, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, pageInfo, err := r.notificationService.GetNotificationsPage(ctx, unreadOnly != nil && *unreadOnly, req)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы оповещений")
+		return nil, fmt.Errorf("ошибка получения оповещений: %w", err)
+	}
+
+	return newNotificationCursorConnection(notifications, pageInfo), nil
+}
+
+func (r *Resolver) ModerationQueueQuery(ctx context.Context, first *int, after *string) (*ModerationQueueConnection, error) {
+	req, err := entities.NewCursorRequest(first, nil, after, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	items, pageInfo, err := r.moderationService.GetModerationQueuePage(ctx, req)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы очереди модерации")
+		return nil, fmt.Errorf("ошибка получения очереди модерации: %w", err)
+	}
+
+	return newModerationQueueConnection(items, pageInfo), nil
+}
+
+func (r *Resolver) ModerationLogQuery(ctx context.Context, first *int, after *string) (*ModerationAuditConnection, error) {
+	req, err := entities.NewCursorRequest(first, nil, after, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, pageInfo, err := r.moderationService.GetAuditLogPage(ctx, req)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы журнала модерации")
+		return nil, fmt.Errorf("ошибка получения журнала модерации: %w", err)
+	}
+
+	return newModerationAuditConnection(entries, pageInfo), nil
+}
+
+func newPostCursorConnection(posts []*entities.Post, pageInfo *entities.PageInfo) *PostCursorConnection {
+	edges := make([]*PostEdge, 0, len(posts))
+	for _, post := range posts {
+		edges = append(edges, &PostEdge{Cursor: post.Cursor().Encode(), Node: post})
+	}
+
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &PostCursorConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+func newCommentCursorConnection(comments []*entities.Comment, pageInfo *entities.PageInfo) *CommentCursorConnection {
+	edges := make([]*CommentEdge, 0, len(comments))
+	for _, comment := range comments {
+		edges = append(edges, &CommentEdge{Cursor: comment.Cursor().Encode(), Node: comment})
+	}
+
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &CommentCursorConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+func newUserCursorConnection(edges []*UserEdge, pageInfo *entities.PageInfo) *UserCursorConnection {
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &UserCursorConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+func newNotificationCursorConnection(notifications []*entities.Notification, pageInfo *entities.PageInfo) *NotificationCursorConnection {
+	edges := make([]*NotificationEdge, 0, len(notifications))
+	for _, notification := range notifications {
+		edges = append(edges, &NotificationEdge{Cursor: notification.Cursor().Encode(), Node: notification})
+	}
+
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &NotificationCursorConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+func newModerationQueueConnection(items []*entities.ModerationQueueItem, pageInfo *entities.PageInfo) *ModerationQueueConnection {
+	edges := make([]*ModerationQueueEdge, 0, len(items))
+	for _, item := range items {
+		edges = append(edges, &ModerationQueueEdge{Cursor: item.Cursor().Encode(), Node: item})
+	}
+
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &ModerationQueueConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+func newModerationAuditConnection(entries []*entities.ModerationAuditEntry, pageInfo *entities.PageInfo) *ModerationAuditConnection {
+	edges := make([]*ModerationAuditEdge, 0, len(entries))
+	for _, entry := range entries {
+		edges = append(edges, &ModerationAuditEdge{Cursor: entry.Cursor().Encode(), Node: entry})
+	}
+
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &ModerationAuditConnection{Edges: edges, PageInfo: pageInfo}
+}
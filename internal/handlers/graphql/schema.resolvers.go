@@ -48,52 +48,194 @@ func (r *commentResolver) UpdatedAt(ctx context.Context, obj *entities.Comment)
 	return obj.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"), nil
 }
 
-// Replies is the resolver for the replies field.
-func (r *commentResolver) Replies(ctx context.Context, obj *entities.Comment, limit *int, offset *int) (*CommentConnection, error) {
-	l := 20
-	if limit != nil {
-		l = *limit
+// DeletedAt is the resolver for the deletedAt field.
+func (r *commentResolver) DeletedAt(ctx context.Context, obj *entities.Comment) (*string, error) {
+	if obj.DeletedAt == nil {
+		return nil, nil
 	}
-	o := 0
-	if offset != nil {
-		o = *offset
+	deletedAt := obj.DeletedAt.Format("2006-01-02T15:04:05Z07:00")
+	return &deletedAt, nil
+}
+
+// DeletedBy is the resolver for the deletedBy field.
+func (r *commentResolver) DeletedBy(ctx context.Context, obj *entities.Comment) (*string, error) {
+	if obj.DeletedBy == nil {
+		return nil, nil
 	}
+	deletedByStr := obj.DeletedBy.String()
+	return &deletedByStr, nil
+}
 
-	pagination := &entities.PaginationRequest{
-		Limit:  l,
-		Offset: o,
+// EditedAt is the resolver for the editedAt field.
+func (r *commentResolver) EditedAt(ctx context.Context, obj *entities.Comment) (*string, error) {
+	if obj.EditedAt == nil {
+		return nil, nil
 	}
+	editedAt := obj.EditedAt.Format("2006-01-02T15:04:05Z07:00")
+	return &editedAt, nil
+}
 
-	comments, paginationResponse, err := r.commentService.GetCommentReplies(ctx, obj.ID, pagination)
-	if err != nil {
-		r.logger.WithError(err).WithField("comment_id", obj.ID).Error("Ошибка получения ответов на комментарий")
-		return nil, fmt.Errorf("ошибка получения ответов на комментарий: %v", err)
+// EditedBy is the resolver for the editedBy field.
+func (r *commentResolver) EditedBy(ctx context.Context, obj *entities.Comment) (*string, error) {
+	if obj.EditedBy == nil {
+		return nil, nil
 	}
+	editedByStr := obj.EditedBy.String()
+	return &editedByStr, nil
+}
 
-	return &CommentConnection{
-		Comments: comments,
-		Pagination: &PaginationInfo{
-			Total:   int(paginationResponse.Total),
-			Limit:   paginationResponse.Limit,
-			Offset:  paginationResponse.Offset,
-			HasMore: paginationResponse.HasMore,
-		},
-	}, nil
+// Author is the resolver for the author field.
+func (r *commentResolver) Author(ctx context.Context, obj *entities.Comment) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.AuthorID, obj.Author)
 }
 
-// CreateUser is the resolver for the createUser field.
-func (r *mutationResolver) CreateUser(ctx context.Context, input CreateUserInput) (*entities.User, error) {
-	user, err := r.userService.CreateUser(ctx, input.Username, input.Email)
-	if err != nil {
-		r.logger.WithError(err).WithFields(logrus.Fields{
-			"username": input.Username,
-			"email":    input.Email,
-		}).Error("Ошибка создания пользователя")
-		return nil, fmt.Errorf("ошибка создания пользователя: %v", err)
+// Post is the resolver for the post field.
+func (r *commentResolver) Post(ctx context.Context, obj *entities.Comment) (*entities.Post, error) {
+	return r.Resolver.PostField(ctx, obj.PostID, obj.Post)
+}
+
+// Parent is the resolver for the parent field.
+func (r *commentResolver) Parent(ctx context.Context, obj *entities.Comment) (*entities.Comment, error) {
+	if obj.ParentID == nil {
+		return nil, nil
 	}
+	return r.Resolver.CommentField(ctx, *obj.ParentID, obj.Parent)
+}
 
-	r.logger.WithField("user_id", user.ID).Info("Пользователь успешно создан через GraphQL")
-	return user, nil
+// Revisions is the resolver for the revisions field.
+func (r *commentResolver) Revisions(ctx context.Context, obj *entities.Comment) ([]*entities.CommentRevision, error) {
+	return r.Resolver.CommentRevisionsQuery(ctx, obj.ID)
+}
+
+// Reactions is the resolver for the reactions field.
+func (r *commentResolver) Reactions(ctx context.Context, obj *entities.Comment) ([]*entities.ReactionSummary, error) {
+	return r.Resolver.ReactionsField(ctx, entities.ReactionTargetComment, obj.ID)
+}
+
+// Mentions is the resolver for the mentions field.
+func (r *commentResolver) Mentions(ctx context.Context, obj *entities.Comment) ([]*entities.User, error) {
+	return r.CommentMentionsField(ctx, obj)
+}
+
+// Replies is the resolver for the replies field.
+func (r *commentResolver) Replies(ctx context.Context, obj *entities.Comment, limit *int, offset *int, sort *entities.CommentSort) (*CommentConnection, error) {
+	return r.Resolver.CommentRepliesField(ctx, obj.ID, limit, offset, sort)
+}
+
+// RepliesConnection is the resolver for the repliesConnection field.
+func (r *commentResolver) RepliesConnection(ctx context.Context, obj *entities.Comment, first *int, after *string, last *int, before *string) (*CommentCursorConnection, error) {
+	return r.Resolver.CommentRepliesConnectionQuery(ctx, obj.ID, first, after, last, before)
+}
+
+// ID is the resolver for the id field.
+func (r *commentRevisionResolver) ID(ctx context.Context, obj *entities.CommentRevision) (string, error) {
+	return obj.ID.String(), nil
+}
+
+// CommentID is the resolver for the commentId field.
+func (r *commentRevisionResolver) CommentID(ctx context.Context, obj *entities.CommentRevision) (string, error) {
+	return obj.CommentID.String(), nil
+}
+
+// EditorID is the resolver for the editorId field.
+func (r *commentRevisionResolver) EditorID(ctx context.Context, obj *entities.CommentRevision) (string, error) {
+	return obj.EditorID.String(), nil
+}
+
+// Editor is the resolver for the editor field.
+func (r *commentRevisionResolver) Editor(ctx context.Context, obj *entities.CommentRevision) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.EditorID, obj.Editor)
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *commentRevisionResolver) CreatedAt(ctx context.Context, obj *entities.CommentRevision) (string, error) {
+	return obj.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), nil
+}
+
+// ID is the resolver for the id field.
+func (r *moderationAuditEntryResolver) ID(ctx context.Context, obj *entities.ModerationAuditEntry) (string, error) {
+	return obj.ID.String(), nil
+}
+
+// ModeratorID is the resolver for the moderatorId field.
+func (r *moderationAuditEntryResolver) ModeratorID(ctx context.Context, obj *entities.ModerationAuditEntry) (string, error) {
+	return obj.ModeratorID.String(), nil
+}
+
+// TargetID is the resolver for the targetId field.
+func (r *moderationAuditEntryResolver) TargetID(ctx context.Context, obj *entities.ModerationAuditEntry) (string, error) {
+	return obj.TargetID.String(), nil
+}
+
+// AuthorID is the resolver for the authorId field.
+func (r *moderationAuditEntryResolver) AuthorID(ctx context.Context, obj *entities.ModerationAuditEntry) (string, error) {
+	return obj.AuthorID.String(), nil
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *moderationAuditEntryResolver) CreatedAt(ctx context.Context, obj *entities.ModerationAuditEntry) (string, error) {
+	return obj.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), nil
+}
+
+// Moderator is the resolver for the moderator field.
+func (r *moderationAuditEntryResolver) Moderator(ctx context.Context, obj *entities.ModerationAuditEntry) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.ModeratorID, nil)
+}
+
+// Author is the resolver for the author field.
+func (r *moderationAuditEntryResolver) Author(ctx context.Context, obj *entities.ModerationAuditEntry) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.AuthorID, nil)
+}
+
+// TargetID is the resolver for the targetId field.
+func (r *moderationQueueItemResolver) TargetID(ctx context.Context, obj *entities.ModerationQueueItem) (string, error) {
+	return obj.TargetID.String(), nil
+}
+
+// AuthorID is the resolver for the authorId field.
+func (r *moderationQueueItemResolver) AuthorID(ctx context.Context, obj *entities.ModerationQueueItem) (string, error) {
+	return obj.AuthorID.String(), nil
+}
+
+// FirstReportedAt is the resolver for the firstReportedAt field.
+func (r *moderationQueueItemResolver) FirstReportedAt(ctx context.Context, obj *entities.ModerationQueueItem) (string, error) {
+	return obj.FirstReportedAt.Format("2006-01-02T15:04:05Z07:00"), nil
+}
+
+// LastReportedAt is the resolver for the lastReportedAt field.
+func (r *moderationQueueItemResolver) LastReportedAt(ctx context.Context, obj *entities.ModerationQueueItem) (string, error) {
+	return obj.LastReportedAt.Format("2006-01-02T15:04:05Z07:00"), nil
+}
+
+// Author is the resolver for the author field.
+func (r *moderationQueueItemResolver) Author(ctx context.Context, obj *entities.ModerationQueueItem) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.AuthorID, nil)
+}
+
+// Post is the resolver for the post field.
+func (r *moderationQueueItemResolver) Post(ctx context.Context, obj *entities.ModerationQueueItem) (*entities.Post, error) {
+	if obj.TargetType != entities.ReportTargetPost {
+		return nil, nil
+	}
+	return r.Resolver.PostField(ctx, obj.TargetID, nil)
+}
+
+// Comment is the resolver for the comment field.
+func (r *moderationQueueItemResolver) Comment(ctx context.Context, obj *entities.ModerationQueueItem) (*entities.Comment, error) {
+	if obj.TargetType != entities.ReportTargetComment {
+		return nil, nil
+	}
+	return r.Resolver.CommentField(ctx, obj.TargetID, nil)
+}
+
+// Register is the resolver for the register field.
+func (r *mutationResolver) Register(ctx context.Context, input RegisterInput) (*AuthPayload, error) {
+	return r.Resolver.RegisterMutation(ctx, input)
+}
+
+// Login is the resolver for the login field.
+func (r *mutationResolver) Login(ctx context.Context, input LoginInput) (*AuthPayload, error) {
+	return r.Resolver.LoginMutation(ctx, input)
 }
 
 // UpdateUser is the resolver for the updateUser field.
@@ -106,21 +248,17 @@ func (r *mutationResolver) DeleteUser(ctx context.Context, userID string) (bool,
 	return r.Resolver.DeleteUserMutation(ctx, userID)
 }
 
+// ChangeUserRole is the resolver for the changeUserRole field.
+func (r *mutationResolver) ChangeUserRole(ctx context.Context, input ChangeUserRoleInput) (*entities.User, error) {
+	return r.Resolver.ChangeUserRoleMutation(ctx, input)
+}
+
 // CreatePost is the resolver for the createPost field.
 func (r *mutationResolver) CreatePost(ctx context.Context, input CreatePostInput) (*entities.Post, error) {
-	authorID, err := uuid.Parse(input.AuthorID)
-	if err != nil {
-		r.logger.WithError(err).WithField("author_id", input.AuthorID).Error("Ошибка парсинга UUID автора")
-		return nil, errors.NewInvalidRequestError("некорректный формат ID автора")
-	}
-
-	post, err := r.postService.CreatePost(ctx, authorID, input.Title, input.Content)
+	post, err := r.postService.CreatePost(ctx, input.Title, input.Content)
 	if err != nil {
-		r.logger.WithError(err).WithFields(logrus.Fields{
-			"author_id": authorID,
-			"title":     input.Title,
-		}).Error("Ошибка создания поста")
-		return nil, fmt.Errorf("ошибка создания поста: %v", err)
+		r.logger.WithError(err).WithField("title", input.Title).Error("Ошибка создания поста")
+		return nil, fmt.Errorf("ошибка создания поста: %w", err)
 	}
 
 	r.logger.WithField("post_id", post.ID).Info("Пост успешно создан через GraphQL")
@@ -133,8 +271,8 @@ func (r *mutationResolver) UpdatePost(ctx context.Context, input UpdatePostInput
 }
 
 // DeletePost is the resolver for the deletePost field.
-func (r *mutationResolver) DeletePost(ctx context.Context, postID string, authorID string) (bool, error) {
-	return r.Resolver.DeletePostMutation(ctx, postID, authorID)
+func (r *mutationResolver) DeletePost(ctx context.Context, postID string) (bool, error) {
+	return r.Resolver.DeletePostMutation(ctx, postID)
 }
 
 // ToggleComments is the resolver for the toggleComments field.
@@ -147,13 +285,7 @@ func (r *mutationResolver) CreateComment(ctx context.Context, input CreateCommen
 	postID, err := uuid.Parse(input.PostID)
 	if err != nil {
 		r.logger.WithError(err).WithField("post_id", input.PostID).Error("Ошибка парсинга UUID поста")
-		return nil, fmt.Errorf("некорректный формат ID поста: %v", err)
-	}
-
-	authorID, err := uuid.Parse(input.AuthorID)
-	if err != nil {
-		r.logger.WithError(err).WithField("author_id", input.AuthorID).Error("Ошибка парсинга UUID автора")
-		return nil, fmt.Errorf("некорректный формат ID автора: %v", err)
+		return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
 	}
 
 	var parentID *uuid.UUID
@@ -161,19 +293,18 @@ func (r *mutationResolver) CreateComment(ctx context.Context, input CreateCommen
 		pid, err := uuid.Parse(*input.ParentID)
 		if err != nil {
 			r.logger.WithError(err).WithField("parent_id", *input.ParentID).Error("Ошибка парсинга UUID родительского комментария")
-			return nil, fmt.Errorf("некорректный формат ID родительского комментария: %v", err)
+			return nil, errors.NewInvalidRequestError("некорректный формат ID родительского комментария")
 		}
 		parentID = &pid
 	}
 
-	comment, err := r.commentService.CreateComment(ctx, postID, authorID, input.Content, parentID)
+	comment, err := r.commentService.CreateComment(ctx, postID, input.Content, parentID)
 	if err != nil {
 		r.logger.WithError(err).WithFields(logrus.Fields{
 			"post_id":   postID,
-			"author_id": authorID,
 			"parent_id": parentID,
 		}).Error("Ошибка создания комментария")
-		return nil, fmt.Errorf("ошибка создания комментария: %v", err)
+		return nil, fmt.Errorf("ошибка создания комментария: %w", err)
 	}
 
 	r.logger.WithField("comment_id", comment.ID).Info("Комментарий успешно создан через GraphQL")
@@ -186,8 +317,107 @@ func (r *mutationResolver) UpdateComment(ctx context.Context, input UpdateCommen
 }
 
 // DeleteComment is the resolver for the deleteComment field.
-func (r *mutationResolver) DeleteComment(ctx context.Context, commentID string, authorID string) (bool, error) {
-	return r.Resolver.DeleteCommentMutation(ctx, commentID, authorID)
+func (r *mutationResolver) DeleteComment(ctx context.Context, commentID string) (bool, error) {
+	return r.Resolver.DeleteCommentMutation(ctx, commentID)
+}
+
+// PurgeComment is the resolver for the purgeComment field.
+func (r *mutationResolver) PurgeComment(ctx context.Context, commentID string) (int, error) {
+	return r.Resolver.PurgeCommentMutation(ctx, commentID)
+}
+
+// React is the resolver for the react field.
+func (r *mutationResolver) React(ctx context.Context, input ReactionInput) ([]*entities.ReactionSummary, error) {
+	return r.Resolver.ReactMutation(ctx, input)
+}
+
+// Unreact is the resolver for the unreact field.
+func (r *mutationResolver) Unreact(ctx context.Context, input ReactionInput) ([]*entities.ReactionSummary, error) {
+	return r.Resolver.UnreactMutation(ctx, input)
+}
+
+// Follow is the resolver for the follow field.
+func (r *mutationResolver) Follow(ctx context.Context, userID string) (*entities.User, error) {
+	return r.Resolver.FollowMutation(ctx, userID)
+}
+
+// Unfollow is the resolver for the unfollow field.
+func (r *mutationResolver) Unfollow(ctx context.Context, userID string) (*entities.User, error) {
+	return r.Resolver.UnfollowMutation(ctx, userID)
+}
+
+// MarkNotificationsRead is the resolver for the markNotificationsRead field.
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []string) (int, error) {
+	return r.Resolver.MarkNotificationsReadMutation(ctx, ids)
+}
+
+// ReportPost is the resolver for the reportPost field.
+func (r *mutationResolver) ReportPost(ctx context.Context, postID string, reason entities.ReportReason) (bool, error) {
+	return r.Resolver.ReportMutation(ctx, entities.ReportTargetPost, postID, reason)
+}
+
+// ReportComment is the resolver for the reportComment field.
+func (r *mutationResolver) ReportComment(ctx context.Context, commentID string, reason entities.ReportReason) (bool, error) {
+	return r.Resolver.ReportMutation(ctx, entities.ReportTargetComment, commentID, reason)
+}
+
+// ResolveReports is the resolver for the resolveReports field.
+func (r *mutationResolver) ResolveReports(ctx context.Context, input ResolveReportsInput) (*entities.ModerationAuditEntry, error) {
+	return r.Resolver.ResolveReportsMutation(ctx, input)
+}
+
+// RestorePost is the resolver for the restorePost field.
+func (r *mutationResolver) RestorePost(ctx context.Context, postID string, note *string) (*entities.ModerationAuditEntry, error) {
+	return r.Resolver.RestorePostMutation(ctx, postID, note)
+}
+
+// ID is the resolver for the id field.
+func (r *notificationResolver) ID(ctx context.Context, obj *entities.Notification) (string, error) {
+	return obj.ID.String(), nil
+}
+
+// ActorID is the resolver for the actorId field.
+func (r *notificationResolver) ActorID(ctx context.Context, obj *entities.Notification) (string, error) {
+	return obj.ActorID.String(), nil
+}
+
+// PostID is the resolver for the postId field.
+func (r *notificationResolver) PostID(ctx context.Context, obj *entities.Notification) (string, error) {
+	return obj.PostID.String(), nil
+}
+
+// CommentID is the resolver for the commentId field.
+func (r *notificationResolver) CommentID(ctx context.Context, obj *entities.Notification) (string, error) {
+	return obj.CommentID.String(), nil
+}
+
+// ReadAt is the resolver for the readAt field.
+func (r *notificationResolver) ReadAt(ctx context.Context, obj *entities.Notification) (*string, error) {
+	if obj.ReadAt == nil {
+		return nil, nil
+	}
+	readAt := obj.ReadAt.Format("2006-01-02T15:04:05Z07:00")
+	return &readAt, nil
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *notificationResolver) CreatedAt(ctx context.Context, obj *entities.Notification) (string, error) {
+	return obj.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), nil
+}
+
+// Actor is the resolver for the actor field.
+func (r *notificationResolver) Actor(ctx context.Context, obj *entities.Notification) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.ActorID, nil)
+}
+
+// Post is the resolver for the post field.
+func (r *notificationResolver) Post(ctx context.Context, obj *entities.Notification) (*entities.Post, error) {
+	return r.Resolver.PostField(ctx, obj.PostID, nil)
+}
+
+// Comment is the resolver for the comment field.
+func (r *notificationResolver) Comment(ctx context.Context, obj *entities.Notification) (*entities.Comment, error) {
+	return r.Resolver.CommentField(ctx, obj.CommentID, nil)
 }
 
 // ID is the resolver for the id field.
@@ -210,37 +440,83 @@ func (r *postResolver) UpdatedAt(ctx context.Context, obj *entities.Post) (strin
 	return obj.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"), nil
 }
 
-// Comments is the resolver for the comments field.
-func (r *postResolver) Comments(ctx context.Context, obj *entities.Post, limit *int, offset *int) (*CommentConnection, error) {
-	l := 20
-	if limit != nil {
-		l = *limit
-	}
-	o := 0
-	if offset != nil {
-		o = *offset
+// EditedAt is the resolver for the editedAt field.
+func (r *postResolver) EditedAt(ctx context.Context, obj *entities.Post) (*string, error) {
+	if obj.EditedAt == nil {
+		return nil, nil
 	}
+	editedAt := obj.EditedAt.Format("2006-01-02T15:04:05Z07:00")
+	return &editedAt, nil
+}
 
-	pagination := &entities.PaginationRequest{
-		Limit:  l,
-		Offset: o,
+// EditedBy is the resolver for the editedBy field.
+func (r *postResolver) EditedBy(ctx context.Context, obj *entities.Post) (*string, error) {
+	if obj.EditedBy == nil {
+		return nil, nil
 	}
+	editedByStr := obj.EditedBy.String()
+	return &editedByStr, nil
+}
+
+// Author is the resolver for the author field.
+func (r *postResolver) Author(ctx context.Context, obj *entities.Post) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.AuthorID, obj.Author)
+}
+
+// Revisions is the resolver for the revisions field.
+func (r *postResolver) Revisions(ctx context.Context, obj *entities.Post) ([]*entities.PostRevision, error) {
+	return r.Resolver.PostRevisionsQuery(ctx, obj.ID)
+}
+
+// Reactions is the resolver for the reactions field.
+func (r *postResolver) Reactions(ctx context.Context, obj *entities.Post) ([]*entities.ReactionSummary, error) {
+	return r.Resolver.ReactionsField(ctx, entities.ReactionTargetPost, obj.ID)
+}
+
+// Comments is the resolver for the comments field.
+func (r *postResolver) Comments(ctx context.Context, obj *entities.Post, limit *int, offset *int, sort *entities.CommentSort) (*CommentConnection, error) {
+	return r.Resolver.PostCommentsField(ctx, obj.ID, limit, offset, sort)
+}
+
+// CommentsConnection is the resolver for the commentsConnection field.
+func (r *postResolver) CommentsConnection(ctx context.Context, obj *entities.Post, first *int, after *string, last *int, before *string) (*CommentCursorConnection, error) {
+	return r.Resolver.PostCommentsConnectionQuery(ctx, obj.ID, first, after, last, before)
+}
+
+// ID is the resolver for the id field.
+func (r *postRevisionResolver) ID(ctx context.Context, obj *entities.PostRevision) (string, error) {
+	return obj.ID.String(), nil
+}
+
+// PostID is the resolver for the postId field.
+func (r *postRevisionResolver) PostID(ctx context.Context, obj *entities.PostRevision) (string, error) {
+	return obj.PostID.String(), nil
+}
 
-	comments, paginationResponse, err := r.commentService.GetPostComments(ctx, obj.ID, pagination)
+// EditorID is the resolver for the editorId field.
+func (r *postRevisionResolver) EditorID(ctx context.Context, obj *entities.PostRevision) (string, error) {
+	return obj.EditorID.String(), nil
+}
+
+// Editor is the resolver for the editor field.
+func (r *postRevisionResolver) Editor(ctx context.Context, obj *entities.PostRevision) (*entities.User, error) {
+	return r.Resolver.UserField(ctx, obj.EditorID, obj.Editor)
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *postRevisionResolver) CreatedAt(ctx context.Context, obj *entities.PostRevision) (string, error) {
+	return obj.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*entities.User, error) {
+	user, err := r.userService.GetCurrentUser(ctx)
 	if err != nil {
-		r.logger.WithError(err).WithField("post_id", obj.ID).Error("Ошибка получения комментариев поста")
-		return nil, fmt.Errorf("ошибка получения комментариев поста: %v", err)
+		r.logger.WithError(err).Debug("Ошибка получения текущего пользователя")
+		return nil, fmt.Errorf("ошибка получения текущего пользователя: %w", err)
 	}
 
-	return &CommentConnection{
-		Comments: comments,
-		Pagination: &PaginationInfo{
-			Total:   int(paginationResponse.Total),
-			Limit:   paginationResponse.Limit,
-			Offset:  paginationResponse.Offset,
-			HasMore: paginationResponse.HasMore,
-		},
-	}, nil
+	return user, nil
 }
 
 // User is the resolver for the user field.
@@ -248,13 +524,13 @@ func (r *queryResolver) User(ctx context.Context, id string) (*entities.User, er
 	userID, err := uuid.Parse(id)
 	if err != nil {
 		r.logger.WithError(err).WithField("id", id).Error("Ошибка парсинга UUID пользователя")
-		return nil, fmt.Errorf("некорректный формат ID пользователя: %v", err)
+		return nil, errors.NewInvalidRequestError("некорректный формат ID пользователя")
 	}
 
 	user, err := r.userService.GetUserByID(ctx, userID)
 	if err != nil {
 		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка получения пользователя")
-		return nil, fmt.Errorf("ошибка получения пользователя: %v", err)
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
 	}
 
 	return user, nil
@@ -265,7 +541,7 @@ func (r *queryResolver) UserByUsername(ctx context.Context, username string) (*e
 	user, err := r.userService.GetUserByUsername(ctx, username)
 	if err != nil {
 		r.logger.WithError(err).WithField("username", username).Error("Ошибка получения пользователя по имени")
-		return nil, fmt.Errorf("ошибка получения пользователя: %v", err)
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
 	}
 
 	return user, nil
@@ -276,13 +552,13 @@ func (r *queryResolver) Post(ctx context.Context, id string) (*entities.Post, er
 	postID, err := uuid.Parse(id)
 	if err != nil {
 		r.logger.WithError(err).WithField("id", id).Error("Ошибка парсинга UUID поста")
-		return nil, fmt.Errorf("некорректный формат ID поста: %v", err)
+		return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
 	}
 
 	post, err := r.postService.GetPostByID(ctx, postID)
 	if err != nil {
 		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения поста")
-		return nil, fmt.Errorf("ошибка получения поста: %v", err)
+		return nil, fmt.Errorf("ошибка получения поста: %w", err)
 	}
 
 	return post, nil
@@ -307,7 +583,7 @@ func (r *queryResolver) Posts(ctx context.Context, limit *int, offset *int) (*Po
 	posts, paginationResponse, err := r.postService.GetAllPosts(ctx, pagination)
 	if err != nil {
 		r.logger.WithError(err).Error("Ошибка получения списка постов")
-		return nil, fmt.Errorf("ошибка получения постов: %v", err)
+		return nil, fmt.Errorf("ошибка получения постов: %w", err)
 	}
 
 	return &PostConnection{
@@ -326,29 +602,44 @@ func (r *queryResolver) PostsByAuthor(ctx context.Context, authorID string, limi
 	return r.Resolver.GetPostsByAuthorQuery(ctx, authorID, limit, offset)
 }
 
+// PostsConnection is the resolver for the postsConnection field.
+func (r *queryResolver) PostsConnection(ctx context.Context, first *int, after *string, last *int, before *string) (*PostCursorConnection, error) {
+	return r.Resolver.PostsConnectionQuery(ctx, first, after, last, before)
+}
+
+// PostsByAuthorConnection is the resolver for the postsByAuthorConnection field.
+func (r *queryResolver) PostsByAuthorConnection(ctx context.Context, authorID string, first *int, after *string, last *int, before *string) (*PostCursorConnection, error) {
+	return r.Resolver.PostsByAuthorConnectionQuery(ctx, authorID, first, after, last, before)
+}
+
+// Feed is the resolver for the feed field.
+func (r *queryResolver) Feed(ctx context.Context, first *int, after *string) (*PostCursorConnection, error) {
+	return r.Resolver.FeedConnectionQuery(ctx, first, after)
+}
+
 // Comment is the resolver for the comment field.
 func (r *queryResolver) Comment(ctx context.Context, id string) (*entities.Comment, error) {
 	commentID, err := uuid.Parse(id)
 	if err != nil {
 		r.logger.WithError(err).WithField("id", id).Error("Ошибка парсинга UUID комментария")
-		return nil, fmt.Errorf("некорректный формат ID комментария: %v", err)
+		return nil, errors.NewInvalidRequestError("некорректный формат ID комментария")
 	}
 
 	comment, err := r.commentService.GetCommentByID(ctx, commentID)
 	if err != nil {
 		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка получения комментария")
-		return nil, fmt.Errorf("ошибка получения комментария: %v", err)
+		return nil, fmt.Errorf("ошибка получения комментария: %w", err)
 	}
 
 	return comment, nil
 }
 
 // PostComments is the resolver for the postComments field.
-func (r *queryResolver) PostComments(ctx context.Context, postID string, limit *int, offset *int) (*CommentConnection, error) {
+func (r *queryResolver) PostComments(ctx context.Context, postID string, limit *int, offset *int, sort *entities.CommentSort) (*CommentConnection, error) {
 	pid, err := uuid.Parse(postID)
 	if err != nil {
 		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка парсинга UUID поста")
-		return nil, fmt.Errorf("некорректный формат ID поста: %v", err)
+		return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
 	}
 
 	l := 20
@@ -365,10 +656,10 @@ func (r *queryResolver) PostComments(ctx context.Context, postID string, limit *
 		Offset: o,
 	}
 
-	comments, paginationResponse, err := r.commentService.GetPostComments(ctx, pid, pagination)
+	comments, paginationResponse, err := r.commentService.GetPostComments(ctx, pid, commentSortOrDefault(sort), pagination)
 	if err != nil {
 		r.logger.WithError(err).WithField("post_id", pid).Error("Ошибка получения комментариев поста")
-		return nil, fmt.Errorf("ошибка получения комментариев поста: %v", err)
+		return nil, fmt.Errorf("ошибка получения комментариев поста: %w", err)
 	}
 
 	return &CommentConnection{
@@ -383,11 +674,11 @@ func (r *queryResolver) PostComments(ctx context.Context, postID string, limit *
 }
 
 // CommentReplies is the resolver for the commentReplies field.
-func (r *queryResolver) CommentReplies(ctx context.Context, parentID string, limit *int, offset *int) (*CommentConnection, error) {
+func (r *queryResolver) CommentReplies(ctx context.Context, parentID string, limit *int, offset *int, sort *entities.CommentSort) (*CommentConnection, error) {
 	pid, err := uuid.Parse(parentID)
 	if err != nil {
 		r.logger.WithError(err).WithField("parent_id", parentID).Error("Ошибка парсинга UUID родительского комментария")
-		return nil, fmt.Errorf("некорректный формат ID родительского комментария: %v", err)
+		return nil, errors.NewInvalidRequestError("некорректный формат ID родительского комментария")
 	}
 
 	l := 20
@@ -404,10 +695,10 @@ func (r *queryResolver) CommentReplies(ctx context.Context, parentID string, lim
 		Offset: o,
 	}
 
-	comments, paginationResponse, err := r.commentService.GetCommentReplies(ctx, pid, pagination)
+	comments, paginationResponse, err := r.commentService.GetCommentReplies(ctx, pid, commentSortOrDefault(sort), pagination)
 	if err != nil {
 		r.logger.WithError(err).WithField("parent_id", pid).Error("Ошибка получения ответов на комментарий")
-		return nil, fmt.Errorf("ошибка получения ответов на комментарий: %v", err)
+		return nil, fmt.Errorf("ошибка получения ответов на комментарий: %w", err)
 	}
 
 	return &CommentConnection{
@@ -421,12 +712,34 @@ func (r *queryResolver) CommentReplies(ctx context.Context, parentID string, lim
 	}, nil
 }
 
+// PostCommentsConnection is the resolver for the postCommentsConnection field.
+func (r *queryResolver) PostCommentsConnection(ctx context.Context, postID string, first *int, after *string, last *int, before *string) (*CommentCursorConnection, error) {
+	pid, err := uuid.Parse(postID)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка парсинга UUID поста")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID поста")
+	}
+
+	return r.Resolver.PostCommentsConnectionQuery(ctx, pid, first, after, last, before)
+}
+
+// CommentRepliesConnection is the resolver for the commentRepliesConnection field.
+func (r *queryResolver) CommentRepliesConnection(ctx context.Context, parentID string, first *int, after *string, last *int, before *string) (*CommentCursorConnection, error) {
+	cid, err := uuid.Parse(parentID)
+	if err != nil {
+		r.logger.WithError(err).WithField("parent_id", parentID).Error("Ошибка парсинга UUID комментария")
+		return nil, errors.NewInvalidRequestError("некорректный формат ID комментария")
+	}
+
+	return r.Resolver.CommentRepliesConnectionQuery(ctx, cid, first, after, last, before)
+}
+
 // CommentThread is the resolver for the commentThread field.
 func (r *queryResolver) CommentThread(ctx context.Context, commentID string, maxDepth *int) ([]*entities.Comment, error) {
 	cid, err := uuid.Parse(commentID)
 	if err != nil {
 		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка парсинга UUID комментария")
-		return nil, fmt.Errorf("некорректный формат ID комментария: %v", err)
+		return nil, errors.NewInvalidRequestError("некорректный формат ID комментария")
 	}
 
 	depth := 10
@@ -437,15 +750,60 @@ func (r *queryResolver) CommentThread(ctx context.Context, commentID string, max
 	comments, err := r.commentService.GetCommentThread(ctx, cid, depth)
 	if err != nil {
 		r.logger.WithError(err).WithField("comment_id", cid).Error("Ошибка получения цепочки комментариев")
-		return nil, fmt.Errorf("ошибка получения цепочки комментариев: %v", err)
+		return nil, fmt.Errorf("ошибка получения цепочки комментариев: %w", err)
 	}
 
 	return comments, nil
 }
 
+// CommentTree is the resolver for the commentTree field.
+func (r *queryResolver) CommentTree(ctx context.Context, commentID string, maxDepth *int, childLimits []int) (*entities.CommentTreeNode, error) {
+	return r.Resolver.CommentTreeQuery(ctx, commentID, maxDepth, childLimits)
+}
+
+// SearchPosts is the resolver for the searchPosts field.
+func (r *queryResolver) SearchPosts(ctx context.Context, query string, limit *int, offset *int) (*PostSearchConnection, error) {
+	return r.Resolver.SearchPostsQuery(ctx, query, limit, offset)
+}
+
+// SearchComments is the resolver for the searchComments field.
+func (r *queryResolver) SearchComments(ctx context.Context, query string, postID *string, limit *int, offset *int) (*CommentSearchConnection, error) {
+	return r.Resolver.SearchCommentsQuery(ctx, query, postID, limit, offset)
+}
+
+// Notifications is the resolver for the notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, unreadOnly *bool, first *int, after *string) (*NotificationCursorConnection, error) {
+	return r.Resolver.NotificationsConnectionQuery(ctx, unreadOnly, first, after)
+}
+
+// ModerationQueue is the resolver for the moderationQueue field.
+func (r *queryResolver) ModerationQueue(ctx context.Context, first *int, after *string) (*ModerationQueueConnection, error) {
+	return r.Resolver.ModerationQueueQuery(ctx, first, after)
+}
+
+// ModerationLog is the resolver for the moderationLog field.
+func (r *queryResolver) ModerationLog(ctx context.Context, first *int, after *string) (*ModerationAuditConnection, error) {
+	return r.Resolver.ModerationLogQuery(ctx, first, after)
+}
+
 // CommentAdded is the resolver for the commentAdded field.
-func (r *subscriptionResolver) CommentAdded(ctx context.Context, postID string) (<-chan *CommentEvent, error) {
-	return r.Resolver.CommentAddedSubscription(ctx, postID)
+func (r *subscriptionResolver) CommentAdded(ctx context.Context, postID string, afterSequence *int) (<-chan *CommentEvent, error) {
+	return r.Resolver.CommentAddedSubscription(ctx, postID, afterSequence)
+}
+
+// CommentEvents is the resolver for the commentEvents field.
+func (r *subscriptionResolver) CommentEvents(ctx context.Context, postID string, types []CommentEventType, afterSequence *int) (<-chan *CommentEvent, error) {
+	return r.Resolver.CommentEventsSubscription(ctx, postID, types, afterSequence)
+}
+
+// MentionedIn is the resolver for the mentionedIn field.
+func (r *subscriptionResolver) MentionedIn(ctx context.Context) (<-chan *entities.Comment, error) {
+	return r.MentionedInSubscription(ctx)
+}
+
+// NotificationReceived is the resolver for the notificationReceived field.
+func (r *subscriptionResolver) NotificationReceived(ctx context.Context) (<-chan *entities.Notification, error) {
+	return r.Resolver.NotificationReceivedSubscription(ctx)
 }
 
 // ID is the resolver for the id field.
@@ -463,15 +821,44 @@ func (r *userResolver) UpdatedAt(ctx context.Context, obj *entities.User) (strin
 	return obj.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"), nil
 }
 
+// Followers is the resolver for the followers field.
+func (r *userResolver) Followers(ctx context.Context, obj *entities.User, first *int, after *string) (*UserCursorConnection, error) {
+	return r.Resolver.UserFollowersField(ctx, obj.ID, first, after)
+}
+
+// Following is the resolver for the following field.
+func (r *userResolver) Following(ctx context.Context, obj *entities.User, first *int, after *string) (*UserCursorConnection, error) {
+	return r.Resolver.UserFollowingField(ctx, obj.ID, first, after)
+}
+
 // Comment returns CommentResolver implementation.
 func (r *Resolver) Comment() CommentResolver { return &commentResolver{r} }
 
+// CommentRevision returns CommentRevisionResolver implementation.
+func (r *Resolver) CommentRevision() CommentRevisionResolver { return &commentRevisionResolver{r} }
+
+// ModerationAuditEntry returns ModerationAuditEntryResolver implementation.
+func (r *Resolver) ModerationAuditEntry() ModerationAuditEntryResolver {
+	return &moderationAuditEntryResolver{r}
+}
+
+// ModerationQueueItem returns ModerationQueueItemResolver implementation.
+func (r *Resolver) ModerationQueueItem() ModerationQueueItemResolver {
+	return &moderationQueueItemResolver{r}
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Notification returns NotificationResolver implementation.
+func (r *Resolver) Notification() NotificationResolver { return &notificationResolver{r} }
+
 // Post returns PostResolver implementation.
 func (r *Resolver) Post() PostResolver { return &postResolver{r} }
 
+// PostRevision returns PostRevisionResolver implementation.
+func (r *Resolver) PostRevision() PostRevisionResolver { return &postRevisionResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
@@ -482,8 +869,13 @@ func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionRes
 func (r *Resolver) User() UserResolver { return &userResolver{r} }
 
 type commentResolver struct{ *Resolver }
+type commentRevisionResolver struct{ *Resolver }
+type moderationAuditEntryResolver struct{ *Resolver }
+type moderationQueueItemResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type notificationResolver struct{ *Resolver }
 type postResolver struct{ *Resolver }
+type postRevisionResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
 type userResolver struct{ *Resolver }
@@ -1,36 +1,90 @@
 package graphql
 
 import (
+	"context"
 	"time"
 
 	"ozon-posts/internal/services"
+	"ozon-posts/pkg/metrics"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 func InitGraphQLServer(
 	userService *services.UserService,
 	postService *services.PostService,
 	commentService *services.CommentService,
+	reactionService *services.ReactionService,
+	notificationService *services.NotificationService,
+	followService *services.FollowService,
+	moderationService *services.ModerationService,
+	limits QueryLimits,
+	rateLimiter *services.RateLimiter,
+	registry *metrics.Registry,
 	logger *logrus.Logger,
 ) *handler.Server {
-	resolver := NewResolver(userService, postService, commentService, logger)
+	resolver := NewResolver(userService, postService, commentService, reactionService, notificationService, followService, moderationService, logger)
 
-	srv := handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: resolver}))
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  resolver,
+		Complexity: newComplexityRoot(),
+	}))
+
+	srv.SetErrorPresenter(errorPresenter)
+	srv.SetRecoverFunc(recoverFunc(logger))
 
 	srv.AddTransport(transport.Websocket{
 		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              websocketAuthInit(userService, logger),
 	})
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
 	srv.AddTransport(transport.MultipartForm{})
 
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	// Загрузчики создаются на каждый ответ: для подписки это каждое событие,
+	// поэтому кеш не переживает ответ и не отдает устаревшие данные
+	srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		return next(WithLoaders(ctx, NewLoaders(userService, postService, commentService, reactionService)))
+	})
+
+	if limits.Timeout > 0 {
+		srv.AroundOperations(withTimeout(limits.Timeout))
+	}
+
 	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
+
+	if registry != nil {
+		srv.Use(newOperationMetrics(registry))
+	}
+
+	if limits.MaxDepth > 0 {
+		srv.Use(depthLimit{maxDepth: limits.MaxDepth})
+	}
+	if limits.MaxComplexity > 0 {
+		srv.Use(&complexityLimit{maxComplexity: limits.MaxComplexity})
+	}
+
+	// Лимиты запросов списываются только с операций, прошедших проверку глубины и сложности
+	if rateLimiter != nil {
+		srv.Use(rateLimit{limiter: rateLimiter})
+	}
 
-	logger.Info("GraphQL сервер инициализирован")
+	logger.WithFields(logrus.Fields{
+		"max_depth":      limits.MaxDepth,
+		"max_complexity": limits.MaxComplexity,
+		"timeout":        limits.Timeout.String(),
+	}).Info("GraphQL сервер инициализирован")
 	return srv
 }
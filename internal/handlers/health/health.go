@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CheckTimeout - сколько ждать каждую проверку готовности
+const CheckTimeout = 2 * time.Second
+
+// Check проверяет зависимость, без которой экземпляр не может обслуживать запросы
+type Check func(ctx context.Context) error
+
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Handler отдает /healthz (процесс жив) и /readyz (экземпляр готов принимать запросы).
+// Во время остановки /readyz отвечает 503, чтобы балансировщик перестал направлять запросы.
+type Handler struct {
+	checks       map[string]Check
+	mu           sync.RWMutex
+	shuttingDown atomic.Bool
+	logger       *logrus.Logger
+}
+
+func NewHandler(logger *logrus.Logger) *Handler {
+	return &Handler{
+		checks: make(map[string]Check),
+		logger: logger,
+	}
+}
+
+// AddCheck добавляет проверку готовности под именем, которое попадет в ответ /readyz
+func (h *Handler) AddCheck(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// SetShuttingDown переводит экземпляр в состояние «не готов» до остановки HTTP сервера
+func (h *Handler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Register монтирует обработчики на /healthz и /readyz
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", h.Healthz)
+	mux.HandleFunc("/readyz", h.Readyz)
+}
+
+func (h *Handler) Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, response{Status: "ok"})
+}
+
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, response{Status: "shutting_down"})
+		return
+	}
+
+	h.mu.RLock()
+	checks := make(map[string]Check, len(h.checks))
+	names := make([]string, 0, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+		names = append(names, name)
+	}
+	h.mu.RUnlock()
+	sort.Strings(names)
+
+	resp := response{Status: "ok", Checks: make(map[string]string, len(names))}
+	status := http.StatusOK
+
+	for _, name := range names {
+		check := checks[name]
+
+		ctx, cancel := context.WithTimeout(r.Context(), CheckTimeout)
+		err := check(ctx)
+		cancel()
+
+		if err != nil {
+			h.logger.WithError(err).WithField("check", name).Warn("Проверка готовности не пройдена")
+			resp.Checks[name] = err.Error()
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+
+		resp.Checks[name] = "ok"
+	}
+
+	writeJSON(w, status, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
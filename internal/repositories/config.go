@@ -4,11 +4,25 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 type Config struct {
 	Type     string
 	Postgres PostgresConfig `json:"postgres"`
+	// AutoMigrate применяет встроенные миграции при запуске; если выключен,
+	// приложение отказывается стартовать на неактуальной схеме
+	AutoMigrate bool         `json:"auto_migrate"`
+	Memory      MemoryConfig `json:"memory"`
+}
+
+// MemoryConfig - файловое хранение режима memory. Пустой DataDir - данные живут только в памяти.
+// Fsync: always - сброс на диск после каждого изменения, interval - раз в FsyncInterval, never - на усмотрение ОС.
+type MemoryConfig struct {
+	DataDir          string        `json:"data_dir"`
+	Fsync            string        `json:"fsync"`
+	FsyncInterval    time.Duration `json:"fsync_interval"`
+	SnapshotInterval time.Duration `json:"snapshot_interval"`
 }
 
 type PostgresConfig struct {
@@ -31,6 +45,13 @@ func LoadConfig() *Config {
 			DBName:   getEnv("POSTGRES_DB", "ozon_posts"),
 			SSLMode:  getEnv("POSTGRES_SSL_MODE", "disable"),
 		},
+		AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", true),
+		Memory: MemoryConfig{
+			DataDir:          getEnv("MEMORY_DATA_DIR", ""),
+			Fsync:            getEnv("MEMORY_FSYNC", "interval"),
+			FsyncInterval:    getEnvAsDuration("MEMORY_FSYNC_INTERVAL", time.Second),
+			SnapshotInterval: getEnvAsDuration("MEMORY_SNAPSHOT_INTERVAL", 5*time.Minute),
+		},
 	}
 }
 
@@ -53,6 +74,10 @@ func (c *Config) IsMemoryMode() bool {
 	return c.Type == "memory"
 }
 
+func (c *Config) IsDurableMemory() bool {
+	return c.IsMemoryMode() && c.Memory.DataDir != ""
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -67,3 +92,19 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if value, err := time.ParseDuration(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -14,15 +14,34 @@ import (
 )
 
 type CommentRepository struct {
-	comments map[uuid.UUID]*entities.Comment
-	mutex    sync.RWMutex
-	logger   *logrus.Logger
+	comments  map[uuid.UUID]*entities.Comment
+	revisions map[uuid.UUID][]*entities.CommentRevision
+	mentions  map[uuid.UUID][]*entities.CommentMention
+	topLevel  map[uuid.UUID]*keysetIndex
+	replies   map[uuid.UUID]*keysetIndex
+	search    *searchIndex
+	posts     services.PostRepository
+	mutex     sync.RWMutex
+	journal   *journal
+	logger    *logrus.Logger
 }
 
-func NewCommentRepository(logger *logrus.Logger) services.CommentRepository {
+// NewCommentRepository создает репозиторий комментариев; posts нужен поиску, чтобы не выдавать
+// комментарии постов, скрытых модератором
+func NewCommentRepository(posts services.PostRepository, logger *logrus.Logger) services.CommentRepository {
+	return newCommentRepository(posts, logger)
+}
+
+func newCommentRepository(posts services.PostRepository, logger *logrus.Logger) *CommentRepository {
 	return &CommentRepository{
-		comments: make(map[uuid.UUID]*entities.Comment),
-		logger:   logger,
+		comments:  make(map[uuid.UUID]*entities.Comment),
+		revisions: make(map[uuid.UUID][]*entities.CommentRevision),
+		mentions:  make(map[uuid.UUID][]*entities.CommentMention),
+		topLevel:  make(map[uuid.UUID]*keysetIndex),
+		replies:   make(map[uuid.UUID]*keysetIndex),
+		search:    newSearchIndex(),
+		posts:     posts,
+		logger:    logger,
 	}
 }
 
@@ -32,7 +51,12 @@ func (r *CommentRepository) Create(ctx context.Context, comment *entities.Commen
 
 	comment.CreatedAt = time.Now()
 	comment.UpdatedAt = time.Now()
-	r.comments[comment.ID] = comment
+
+	if err := r.journal.append(journalRecord{Op: opCommentPut, Comment: storedComment(comment)}); err != nil {
+		return err
+	}
+
+	r.putComment(comment)
 	r.logger.WithField("comment_id", comment.ID).Debug("Комментарий создан в in-memory хранилище")
 	return nil
 }
@@ -50,128 +74,188 @@ func (r *CommentRepository) GetByID(ctx context.Context, id uuid.UUID) (*entitie
 	return &commentCopy, nil
 }
 
-func (r *CommentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+// GetByIDForShare не блокирует запись: взаимное исключение обеспечивает UnitOfWork
+func (r *CommentRepository) GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
+	return r.GetByID(ctx, id)
+}
+
+// GetByIDForUpdate не блокирует запись: взаимное исключение обеспечивает UnitOfWork
+func (r *CommentRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *CommentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	postComments := make([]*entities.Comment, 0)
-	for _, comment := range r.comments {
-		if comment.PostID == postID && comment.ParentID == nil {
-			commentCopy := *comment
-			postComments = append(postComments, &commentCopy)
-		}
-	}
+	return r.sortedPage(r.topLevel[postID], sort, pagination)
+}
 
-	sort.Slice(postComments, func(i, j int) bool {
-		return postComments[i].CreatedAt.Before(postComments[j].CreatedAt)
-	})
+func (r *CommentRepository) CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 
-	total := int64(len(postComments))
+	return indexLen(r.topLevel, postID), nil
+}
 
-	start := pagination.Offset
-	end := start + pagination.Limit
+func (r *CommentRepository) GetByParentID(ctx context.Context, parentID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 
-	if start >= len(postComments) {
-		return []*entities.Comment{}, &entities.PaginationResponse{
-			Total:   total,
-			Limit:   pagination.Limit,
-			Offset:  pagination.Offset,
-			HasMore: false,
-		}, nil
-	}
+	return r.sortedPage(r.replies[parentID], sort, pagination)
+}
 
-	if end > len(postComments) {
-		end = len(postComments)
+// sortedPage возвращает страницу pagination комментариев индекса в порядке sort. Индекс уже
+// упорядочен по времени создания, поэтому для OLDEST и NEWEST страница берется из него срезом;
+// по счетчикам сортируются указатели, а копируются только комментарии страницы.
+func (r *CommentRepository) sortedPage(index *keysetIndex, order entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+	if index == nil {
+		return []*entities.Comment{}, entities.NewPaginationResponse(0, pagination.Limit, pagination.Offset), nil
 	}
 
-	result := postComments[start:end]
+	total := int64(index.len())
 
-	return result, &entities.PaginationResponse{
-		Total:   total,
-		Limit:   pagination.Limit,
-		Offset:  pagination.Offset,
-		HasMore: end < len(postComments),
-	}, nil
-}
+	var ids []uuid.UUID
+	switch order {
+	case entities.CommentSortOldest, entities.CommentSortNewest:
+		ids = index.slice(pagination.Offset, pagination.Limit, order == entities.CommentSortNewest)
+	default:
+		all := make([]*entities.Comment, 0, index.len())
+		for _, id := range index.head(index.len()) {
+			all = append(all, r.comments[id])
+		}
 
-func (r *CommentRepository) CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+		sort.Slice(all, func(i, j int) bool {
+			return order.Less(all[i], all[j])
+		})
 
-	var count int64
-	for _, comment := range r.comments {
-		if comment.PostID == postID && comment.ParentID == nil {
-			count++
+		for _, comment := range all[min(pagination.Offset, len(all)):min(pagination.Offset+pagination.Limit, len(all))] {
+			ids = append(ids, comment.ID)
 		}
 	}
 
-	return count, nil
+	comments := make([]*entities.Comment, 0, len(ids))
+	for _, id := range ids {
+		commentCopy := *r.comments[id]
+		comments = append(comments, &commentCopy)
+	}
+
+	return comments, entities.NewPaginationResponse(total, pagination.Limit, pagination.Offset), nil
 }
 
-func (r *CommentRepository) GetByParentID(ctx context.Context, parentID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+func (r *CommentRepository) CountByParentID(ctx context.Context, parentID uuid.UUID) (int64, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	replies := make([]*entities.Comment, 0)
-	for _, comment := range r.comments {
-		if comment.ParentID != nil && *comment.ParentID == parentID {
-			commentCopy := *comment
-			replies = append(replies, &commentCopy)
-		}
-	}
+	return indexLen(r.replies, parentID), nil
+}
 
-	sort.Slice(replies, func(i, j int) bool {
-		return replies[i].CreatedAt.Before(replies[j].CreatedAt)
-	})
+func (r *CommentRepository) AddReplyCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	return r.addCounters(id, func(comment *entities.Comment) { comment.ReplyCount += delta })
+}
 
-	total := int64(len(replies))
+func (r *CommentRepository) AddReactionCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	return r.addCounters(id, func(comment *entities.Comment) { comment.ReactionCount += delta })
+}
 
-	start := pagination.Offset
-	end := start + pagination.Limit
+// addCounters сохраняет копию комментария с измененными счетчиками; в журнал, как и для
+// других изменений, пишется итоговое состояние комментария
+func (r *CommentRepository) addCounters(id uuid.UUID, apply func(comment *entities.Comment)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
-	if start >= len(replies) {
-		return []*entities.Comment{}, &entities.PaginationResponse{
-			Total:   total,
-			Limit:   pagination.Limit,
-			Offset:  pagination.Offset,
-			HasMore: false,
-		}, nil
+	existing, exists := r.comments[id]
+	if !exists {
+		return nil
 	}
 
-	if end > len(replies) {
-		end = len(replies)
+	updated := *existing
+	apply(&updated)
+	return r.saveComment(&updated)
+}
+
+// saveComment записывает итоговое состояние комментария в журнал и сохраняет его; вызывается под блокировкой
+func (r *CommentRepository) saveComment(comment *entities.Comment) error {
+	if err := r.journal.append(journalRecord{Op: opCommentPut, Comment: storedComment(comment)}); err != nil {
+		return err
 	}
 
-	result := replies[start:end]
+	r.putComment(comment)
+	return nil
+}
 
-	return result, &entities.PaginationResponse{
-		Total:   total,
-		Limit:   pagination.Limit,
-		Offset:  pagination.Offset,
-		HasMore: end < len(replies),
-	}, nil
+func (r *CommentRepository) GetByPostIDKeyset(ctx context.Context, postID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.keysetPage(r.topLevel[postID], req)
 }
 
-func (r *CommentRepository) CountByParentID(ctx context.Context, parentID uuid.UUID) (int64, error) {
+func (r *CommentRepository) GetByParentIDKeyset(ctx context.Context, parentID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	var count int64
-	for _, comment := range r.comments {
-		if comment.ParentID != nil && *comment.ParentID == parentID {
-			count++
-		}
+	return r.keysetPage(r.replies[parentID], req)
+}
+
+func (r *CommentRepository) SoftDelete(ctx context.Context, comment *entities.Comment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.comments[comment.ID]
+	if !exists || existing.IsDeleted() {
+		return nil
 	}
 
-	return count, nil
+	deleted := *existing
+	deleted.Content = comment.Content
+	deleted.DeletedAt = comment.DeletedAt
+	deleted.DeletedBy = comment.DeletedBy
+	deleted.UpdatedAt = comment.UpdatedAt
+
+	if err := r.journal.append(journalRecord{Op: opCommentPut, Comment: storedComment(&deleted)}); err != nil {
+		return err
+	}
+
+	r.putComment(&deleted)
+	return nil
 }
 
-func (r *CommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *CommentRepository) Purge(ctx context.Context, id uuid.UUID) (int64, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	delete(r.comments, id)
-	return nil
+	if _, exists := r.comments[id]; !exists {
+		return 0, nil
+	}
+
+	if err := r.journal.append(journalRecord{Op: opCommentPurge, ID: id}); err != nil {
+		return 0, err
+	}
+
+	return r.purgeComment(id), nil
+}
+
+// purgeComment удаляет комментарий вместе со всей веткой ответов и возвращает число удаленных
+func (r *CommentRepository) purgeComment(id uuid.UUID) int64 {
+	target, exists := r.comments[id]
+	if !exists {
+		return 0
+	}
+
+	pathPrefix := target.Path + "/"
+	var purged int64
+	for commentID, comment := range r.comments {
+		if comment.Path == target.Path || strings.HasPrefix(comment.Path, pathPrefix) {
+			r.unindexComment(comment)
+			delete(r.comments, commentID)
+			delete(r.revisions, commentID)
+			delete(r.mentions, commentID)
+			purged++
+		}
+	}
+
+	return purged
 }
 
 func (r *CommentRepository) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
@@ -236,19 +320,103 @@ func (r *CommentRepository) GetThread(ctx context.Context, commentID uuid.UUID,
 	return threadComments, nil
 }
 
+func (r *CommentRepository) GetChildrenByParentIDs(ctx context.Context, parentIDs []uuid.UUID, perParentLimit int) ([]*entities.Comment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.headsOf(r.replies, parentIDs, perParentLimit), nil
+}
+
+func (r *CommentRepository) CountByParentIDs(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.countsOf(r.replies, parentIDs), nil
+}
+
+func (r *CommentRepository) GetTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID, perPostLimit int) ([]*entities.Comment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.headsOf(r.topLevel, postIDs, perPostLimit), nil
+}
+
+func (r *CommentRepository) CountTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.countsOf(r.topLevel, postIDs), nil
+}
+
+// headsOf возвращает первые limit комментариев каждого индекса из keys
+func (r *CommentRepository) headsOf(indexes map[uuid.UUID]*keysetIndex, keys []uuid.UUID, limit int) []*entities.Comment {
+	result := make([]*entities.Comment, 0)
+	for _, key := range keys {
+		index, exists := indexes[key]
+		if !exists {
+			continue
+		}
+
+		for _, id := range index.head(limit) {
+			commentCopy := *r.comments[id]
+			result = append(result, &commentCopy)
+		}
+	}
+	return result
+}
+
+// indexLen - число комментариев в индексе поста или родителя без обхода всех комментариев
+func indexLen(indexes map[uuid.UUID]*keysetIndex, key uuid.UUID) int64 {
+	if index, exists := indexes[key]; exists {
+		return int64(index.len())
+	}
+	return 0
+}
+
+func (r *CommentRepository) countsOf(indexes map[uuid.UUID]*keysetIndex, keys []uuid.UUID) map[uuid.UUID]int64 {
+	counts := make(map[uuid.UUID]int64, len(keys))
+	for _, key := range keys {
+		if index, exists := indexes[key]; exists {
+			counts[key] = int64(index.len())
+		}
+	}
+	return counts
+}
+
 func (r *CommentRepository) Update(ctx context.Context, comment *entities.Comment) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.comments[comment.ID]; !exists {
+	existing, exists := r.comments[comment.ID]
+	if !exists {
 		return nil
 	}
 
+	// created_at неизменяем, как и в PostgreSQL: на нем держатся курсоры.
+	// Счетчики меняются только через AddReplyCount и AddReactionCount.
+	comment.CreatedAt = existing.CreatedAt
+	comment.ReplyCount = existing.ReplyCount
+	comment.ReactionCount = existing.ReactionCount
 	comment.UpdatedAt = time.Now()
-	r.comments[comment.ID] = comment
+
+	if err := r.journal.append(journalRecord{Op: opCommentPut, Comment: storedComment(comment)}); err != nil {
+		return err
+	}
+
+	r.putComment(comment)
 	return nil
 }
 
+// putComment сохраняет комментарий и перестраивает его индексы. Используется и при восстановлении из журнала.
+func (r *CommentRepository) putComment(comment *entities.Comment) {
+	if existing, exists := r.comments[comment.ID]; exists {
+		r.unindexComment(existing)
+	}
+
+	r.comments[comment.ID] = comment
+	r.indexComment(comment)
+}
+
 func (r *CommentRepository) GetByPath(ctx context.Context, pathPrefix string, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
@@ -292,3 +460,204 @@ func (r *CommentRepository) GetByPath(ctx context.Context, pathPrefix string, pa
 		HasMore: end < len(pathComments),
 	}, nil
 }
+
+func (r *CommentRepository) keysetPage(index *keysetIndex, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	if index == nil {
+		return []*entities.Comment{}, &entities.PageInfo{}, nil
+	}
+
+	ids := index.page(req)
+	result := make([]*entities.Comment, 0, len(ids))
+	for _, id := range ids {
+		commentCopy := *r.comments[id]
+		result = append(result, &commentCopy)
+	}
+
+	comments, pageInfo := entities.NewCursorPage(result, req)
+	return comments, pageInfo, nil
+}
+
+// commentIndex возвращает индекс, в котором комментарий упорядочен среди соседей:
+// корневые комментарии - в пределах поста, ответы - в пределах родителя
+func (r *CommentRepository) commentIndex(comment *entities.Comment, create bool) (map[uuid.UUID]*keysetIndex, uuid.UUID, *keysetIndex) {
+	indexes, key := r.topLevel, comment.PostID
+	if comment.ParentID != nil {
+		indexes, key = r.replies, *comment.ParentID
+	}
+
+	index, exists := indexes[key]
+	if !exists && create {
+		index = newKeysetIndex(false)
+		indexes[key] = index
+	}
+	return indexes, key, index
+}
+
+func (r *CommentRepository) indexComment(comment *entities.Comment) {
+	_, _, index := r.commentIndex(comment, true)
+	index.insert(comment.Cursor())
+
+	if !comment.IsDeleted() {
+		r.search.put(comment.ID, searchField{text: comment.Content, weight: 1})
+	}
+}
+
+func (r *CommentRepository) unindexComment(comment *entities.Comment) {
+	r.search.remove(comment.ID)
+
+	indexes, key, index := r.commentIndex(comment, false)
+	if index == nil {
+		return
+	}
+
+	index.remove(comment.Cursor())
+	if index.len() == 0 {
+		delete(indexes, key)
+	}
+}
+
+func (r *CommentRepository) CreateRevision(ctx context.Context, revision *entities.CommentRevision) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.journal.append(journalRecord{Op: opCommentRevision, CommentRevision: storedCommentRevision(revision)}); err != nil {
+		return err
+	}
+
+	r.appendRevision(revision)
+	return nil
+}
+
+func (r *CommentRepository) appendRevision(revision *entities.CommentRevision) {
+	revisionCopy := *revision
+	r.revisions[revision.CommentID] = append(r.revisions[revision.CommentID], &revisionCopy)
+}
+
+func (r *CommentRepository) GetRevisions(ctx context.Context, commentID uuid.UUID) ([]*entities.CommentRevision, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make([]*entities.CommentRevision, 0, len(r.revisions[commentID]))
+	for _, revision := range r.revisions[commentID] {
+		revisionCopy := *revision
+		result = append(result, &revisionCopy)
+	}
+
+	return result, nil
+}
+
+func (r *CommentRepository) ReplaceMentions(ctx context.Context, commentID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		wanted[userID] = true
+	}
+
+	// Сохраненные упоминания остаются на своих местах, новые добавляются в конец
+	current := r.mentions[commentID]
+	kept := make(map[uuid.UUID]bool, len(current))
+	mentions := make([]*entities.CommentMention, 0, len(userIDs))
+	for _, mention := range current {
+		if wanted[mention.UserID] {
+			mentions = append(mentions, mention)
+			kept[mention.UserID] = true
+		}
+	}
+
+	now := time.Now()
+	var added []uuid.UUID
+	for _, userID := range userIDs {
+		if kept[userID] {
+			continue
+		}
+		kept[userID] = true
+		mentions = append(mentions, &entities.CommentMention{CommentID: commentID, UserID: userID, CreatedAt: now})
+		added = append(added, userID)
+	}
+
+	if len(added) == 0 && len(mentions) == len(current) {
+		return nil, nil
+	}
+
+	if err := r.journal.append(journalRecord{Op: opCommentMentions, ID: commentID, Mentions: mentions}); err != nil {
+		return nil, err
+	}
+
+	r.setMentions(commentID, mentions)
+	return added, nil
+}
+
+func (r *CommentRepository) setMentions(commentID uuid.UUID, mentions []*entities.CommentMention) {
+	if len(mentions) == 0 {
+		delete(r.mentions, commentID)
+		return
+	}
+	r.mentions[commentID] = mentions
+}
+
+func (r *CommentRepository) GetMentionsByCommentIDs(ctx context.Context, commentIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make(map[uuid.UUID][]uuid.UUID, len(commentIDs))
+	for _, commentID := range commentIDs {
+		for _, mention := range r.mentions[commentID] {
+			result[commentID] = append(result[commentID], mention.UserID)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *CommentRepository) Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.CommentSearchResult, *entities.PaginationResponse, error) {
+	r.mutex.RLock()
+	scores := r.search.match(query.Terms)
+	matches := make([]*entities.CommentSearchResult, 0, len(scores))
+	for id, score := range scores {
+		comment := r.comments[id]
+		if query.PostID != nil && comment.PostID != *query.PostID {
+			continue
+		}
+		commentCopy := *comment
+		matches = append(matches, &entities.CommentSearchResult{Comment: &commentCopy, Rank: score})
+	}
+	r.mutex.RUnlock()
+
+	// Видимость постов проверяется после снятия блокировки: пересчет счетчиков берет
+	// блокировки постов и комментариев в обратном порядке
+	visible := make(map[uuid.UUID]bool)
+	results := make([]*entities.CommentSearchResult, 0, len(matches))
+	for _, match := range matches {
+		postID := match.Comment.PostID
+		shown, checked := visible[postID]
+		if !checked {
+			exists, err := r.posts.Exists(ctx, postID)
+			if err != nil {
+				return nil, nil, err
+			}
+			shown = exists
+			visible[postID] = shown
+		}
+		if shown {
+			results = append(results, match)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[j].Comment.Cursor().Less(results[i].Comment.Cursor())
+	})
+
+	total := int64(len(results))
+	results = results[min(pagination.Offset, len(results)):min(pagination.Offset+pagination.Limit, len(results))]
+
+	for _, result := range results {
+		result.Snippet = entities.Highlight(result.Comment.Content, query.Terms, entities.SearchSnippetWords)
+	}
+
+	return results, entities.NewPaginationResponse(total, pagination.Limit, pagination.Offset), nil
+}
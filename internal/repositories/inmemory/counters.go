@@ -0,0 +1,102 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+)
+
+// CounterReconciler пересчитывает денормализованные счетчики по индексам in-memory репозиториев.
+// Пересчет идет внутри UnitOfWork, поэтому не пересекается с созданием и удалением комментариев
+// и реакций; исправления пишутся в журнал как обычные изменения.
+type CounterReconciler struct {
+	posts     *PostRepository
+	comments  *CommentRepository
+	reactions *ReactionRepository
+	uow       services.UnitOfWork
+}
+
+// NewCounterReconciler принимает репозитории этого пакета: пересчет читает их индексы напрямую
+func NewCounterReconciler(
+	postRepo services.PostRepository,
+	commentRepo services.CommentRepository,
+	reactionRepo services.ReactionRepository,
+	uow services.UnitOfWork,
+) (services.CounterReconciler, error) {
+	posts, postsOK := postRepo.(*PostRepository)
+	comments, commentsOK := commentRepo.(*CommentRepository)
+	reactions, reactionsOK := reactionRepo.(*ReactionRepository)
+	if !postsOK || !commentsOK || !reactionsOK {
+		return nil, fmt.Errorf("пересчет счетчиков поддерживает только in-memory репозитории")
+	}
+
+	return &CounterReconciler{
+		posts:     posts,
+		comments:  comments,
+		reactions: reactions,
+		uow:       uow,
+	}, nil
+}
+
+func (r *CounterReconciler) ReconcileCounters(ctx context.Context) (*services.CounterReport, error) {
+	report := &services.CounterReport{}
+
+	err := r.uow.Do(ctx, func(ctx context.Context, _ services.Repositories) error {
+		// Порядок блокировок тот же, что и при записи снимка
+		r.posts.mutex.Lock()
+		defer r.posts.mutex.Unlock()
+		r.comments.mutex.Lock()
+		defer r.comments.mutex.Unlock()
+		r.reactions.mutex.RLock()
+		defer r.reactions.mutex.RUnlock()
+
+		for id, post := range r.posts.posts {
+			actual := indexLen(r.comments.topLevel, id)
+			if post.RootCommentCount == actual {
+				continue
+			}
+
+			updated := *post
+			updated.RootCommentCount = actual
+			if err := r.posts.savePost(&updated); err != nil {
+				return err
+			}
+			report.PostComments++
+		}
+
+		for id, comment := range r.comments.comments {
+			replies := indexLen(r.comments.replies, id)
+
+			var reactions int64
+			for _, count := range r.reactions.counts[reactionTarget{targetType: entities.ReactionTargetComment, targetID: id}] {
+				reactions += count
+			}
+
+			if comment.ReplyCount == replies && comment.ReactionCount == reactions {
+				continue
+			}
+
+			if comment.ReplyCount != replies {
+				report.CommentReplies++
+			}
+			if comment.ReactionCount != reactions {
+				report.CommentReactions++
+			}
+
+			updated := *comment
+			updated.ReplyCount = replies
+			updated.ReactionCount = reactions
+			if err := r.comments.saveComment(&updated); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,180 @@
+package inmemory
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type followKey struct {
+	followerID uuid.UUID
+	followeeID uuid.UUID
+}
+
+// FollowRepository хранит подписки и для каждого пользователя индексы подписчиков и подписок
+// в порядке выдачи (недавние сначала); в индексе хранится id второго участника подписки
+type FollowRepository struct {
+	follows   map[followKey]*entities.Follow
+	followers map[uuid.UUID]*keysetIndex
+	following map[uuid.UUID]*keysetIndex
+	mutex     sync.RWMutex
+	journal   *journal
+	logger    *logrus.Logger
+}
+
+func NewFollowRepository(logger *logrus.Logger) services.FollowRepository {
+	return newFollowRepository(logger)
+}
+
+func newFollowRepository(logger *logrus.Logger) *FollowRepository {
+	return &FollowRepository{
+		follows:   make(map[followKey]*entities.Follow),
+		followers: make(map[uuid.UUID]*keysetIndex),
+		following: make(map[uuid.UUID]*keysetIndex),
+		logger:    logger,
+	}
+}
+
+func (r *FollowRepository) Create(ctx context.Context, follow *entities.Follow) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.follows[followKey{followerID: follow.FollowerID, followeeID: follow.FolloweeID}]; exists {
+		return false, nil
+	}
+
+	followCopy := *follow
+	followCopy.Follower, followCopy.Followee = nil, nil
+	if err := r.journal.append(journalRecord{Op: opFollowPut, Follow: &followCopy}); err != nil {
+		return false, err
+	}
+
+	r.putFollow(&followCopy)
+	r.logger.WithFields(logrus.Fields{
+		"follower_id": follow.FollowerID,
+		"followee_id": follow.FolloweeID,
+	}).Debug("Подписка сохранена в in-memory хранилище")
+	return true, nil
+}
+
+func (r *FollowRepository) Delete(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	follow, exists := r.follows[followKey{followerID: followerID, followeeID: followeeID}]
+	if !exists {
+		return false, nil
+	}
+
+	if err := r.journal.append(journalRecord{Op: opFollowDelete, Follow: follow}); err != nil {
+		return false, err
+	}
+
+	r.deleteFollow(follow)
+	r.logger.WithFields(logrus.Fields{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+	}).Debug("Подписка удалена из in-memory хранилища")
+	return true, nil
+}
+
+func (r *FollowRepository) GetFollowersKeyset(ctx context.Context, followeeID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	index, exists := r.followers[followeeID]
+	if !exists {
+		return []*entities.Follow{}, &entities.PageInfo{}, nil
+	}
+
+	ids := index.page(req)
+	follows := make([]*entities.Follow, 0, len(ids))
+	for _, followerID := range ids {
+		followCopy := *r.follows[followKey{followerID: followerID, followeeID: followeeID}]
+		follows = append(follows, &followCopy)
+	}
+
+	follows, pageInfo := entities.NewCursorPage(follows, req)
+	return follows, pageInfo, nil
+}
+
+func (r *FollowRepository) GetFollowingKeyset(ctx context.Context, followerID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	index, exists := r.following[followerID]
+	if !exists {
+		return []*entities.Follow{}, &entities.PageInfo{}, nil
+	}
+
+	ids := index.page(req)
+	follows := make([]*entities.Follow, 0, len(ids))
+	for _, followeeID := range ids {
+		followCopy := *r.follows[followKey{followerID: followerID, followeeID: followeeID}]
+		follows = append(follows, &followCopy)
+	}
+
+	follows, pageInfo := entities.NewCursorPage(follows, req)
+	return follows, pageInfo, nil
+}
+
+func (r *FollowRepository) GetFolloweeIDs(ctx context.Context, followerID uuid.UUID) ([]uuid.UUID, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	index, exists := r.following[followerID]
+	if !exists {
+		return []uuid.UUID{}, nil
+	}
+
+	return index.head(index.len()), nil
+}
+
+// putFollow и deleteFollow меняют карты и индексы без журнала: их же применяет восстановление
+func (r *FollowRepository) putFollow(follow *entities.Follow) {
+	key := followKey{followerID: follow.FollowerID, followeeID: follow.FolloweeID}
+	if _, exists := r.follows[key]; exists {
+		return
+	}
+
+	r.follows[key] = follow
+	followIndex(r.followers, follow.FolloweeID).insert(follow.FollowerCursor())
+	followIndex(r.following, follow.FollowerID).insert(follow.FolloweeCursor())
+}
+
+func (r *FollowRepository) deleteFollow(follow *entities.Follow) {
+	key := followKey{followerID: follow.FollowerID, followeeID: follow.FolloweeID}
+	existing, exists := r.follows[key]
+	if !exists {
+		return
+	}
+
+	delete(r.follows, key)
+	removeFromFollowIndex(r.followers, existing.FolloweeID, existing.FollowerCursor())
+	removeFromFollowIndex(r.following, existing.FollowerID, existing.FolloweeCursor())
+}
+
+func followIndex(indexes map[uuid.UUID]*keysetIndex, userID uuid.UUID) *keysetIndex {
+	index, exists := indexes[userID]
+	if !exists {
+		index = newKeysetIndex(true)
+		indexes[userID] = index
+	}
+	return index
+}
+
+func removeFromFollowIndex(indexes map[uuid.UUID]*keysetIndex, userID uuid.UUID, cursor entities.Cursor) {
+	index, exists := indexes[userID]
+	if !exists {
+		return
+	}
+
+	index.remove(cursor)
+	if index.len() == 0 {
+		delete(indexes, userID)
+	}
+}
@@ -0,0 +1,402 @@
+package inmemory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"ozon-posts/internal/entities"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// FsyncPolicy определяет, когда записи журнала сбрасываются на диск
+type FsyncPolicy string
+
+const (
+	// FsyncAlways - после каждой записи: изменение не теряется, но каждая запись ждет диск
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval - периодически в фоне: при сбое ОС теряются изменения последнего интервала
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever - на усмотрение ОС
+	FsyncNever FsyncPolicy = "never"
+)
+
+func (p FsyncPolicy) IsValid() bool {
+	switch p {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+		return true
+	}
+	return false
+}
+
+const (
+	segmentPrefix = "wal-"
+	segmentSuffix = ".log"
+)
+
+type journalOp string
+
+const (
+	opUserPut         journalOp = "user.put"
+	opUserDelete      journalOp = "user.delete"
+	opPostPut         journalOp = "post.put"
+	opPostDelete      journalOp = "post.delete"
+	opPostRevision    journalOp = "post.revision"
+	opCommentPut      journalOp = "comment.put"
+	opCommentPurge    journalOp = "comment.purge"
+	opCommentRevision journalOp = "comment.revision"
+	opCommentMentions journalOp = "comment.mentions"
+	opReactionPut     journalOp = "reaction.put"
+	opReactionDelete  journalOp = "reaction.delete"
+	opNotificationPut journalOp = "notification.put"
+	opFollowPut       journalOp = "follow.put"
+	opFollowDelete    journalOp = "follow.delete"
+	opReportPut       journalOp = "report.put"
+	opAuditPut        journalOp = "audit.put"
+)
+
+// storedUser сохраняет хеш пароля, который entities.User не отдает в JSON
+type storedUser struct {
+	entities.User
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+func newStoredUser(user *entities.User) *storedUser {
+	return &storedUser{User: *user, PasswordHash: user.PasswordHash}
+}
+
+func (u *storedUser) entity() *entities.User {
+	user := u.User
+	user.PasswordHash = u.PasswordHash
+	return &user
+}
+
+// journalRecord - одно изменение хранилища. Записывается итоговое состояние сущности, а не вызов
+// репозитория, поэтому повтор записи при восстановлении не зависит от текущего времени.
+// У comment.mentions это полный список упоминаний комментария ID, пустой при удалении всех,
+// у notification.put и report.put - итоговое состояние всех оповещений или жалоб, созданных или измененных одним вызовом.
+type journalRecord struct {
+	LSN             uint64                         `json:"lsn"`
+	Op              journalOp                      `json:"op"`
+	ID              uuid.UUID                      `json:"id,omitzero"`
+	User            *storedUser                    `json:"user,omitempty"`
+	Post            *entities.Post                 `json:"post,omitempty"`
+	PostRevision    *entities.PostRevision         `json:"post_revision,omitempty"`
+	Comment         *entities.Comment              `json:"comment,omitempty"`
+	CommentRevision *entities.CommentRevision      `json:"comment_revision,omitempty"`
+	Reaction        *entities.Reaction             `json:"reaction,omitempty"`
+	Mentions        []*entities.CommentMention     `json:"mentions,omitempty"`
+	Notifications   []*entities.Notification       `json:"notifications,omitempty"`
+	Follow          *entities.Follow               `json:"follow,omitempty"`
+	Reports         []*entities.Report             `json:"reports,omitempty"`
+	ModerationAudit *entities.ModerationAuditEntry `json:"moderation_audit,omitempty"`
+}
+
+// journal - журнал упреждающей записи, общий для всех in-memory репозиториев. Репозиторий пишет
+// в журнал под своей блокировкой до изменения карт, поэтому порядок записей совпадает с порядком
+// изменений. Журнал разбит на сегменты wal-<первый LSN>.log: при снимке начинается новый сегмент,
+// а старые удаляются после того, как снимок записан. Nil-журнал ничего не пишет.
+type journal struct {
+	dir    string
+	policy FsyncPolicy
+	mutex  sync.Mutex
+	file   *os.File
+	lsn    uint64
+	dirty  bool
+	closed bool
+	logger *logrus.Logger
+}
+
+func openJournal(dir string, lastLSN uint64, policy FsyncPolicy, logger *logrus.Logger) (*journal, error) {
+	j := &journal{
+		dir:    dir,
+		policy: policy,
+		lsn:    lastLSN,
+		logger: logger,
+	}
+
+	if err := j.openSegment(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// append присваивает записи LSN и дописывает ее в текущий сегмент
+func (j *journal) append(record journalRecord) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return fmt.Errorf("журнал закрыт")
+	}
+
+	record.LSN = j.lsn + 1
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации записи журнала: %w", err)
+	}
+
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("ошибка записи в журнал: %w", err)
+	}
+
+	if j.policy == FsyncAlways {
+		if err := j.file.Sync(); err != nil {
+			return fmt.Errorf("ошибка сброса журнала на диск: %w", err)
+		}
+	} else {
+		j.dirty = true
+	}
+
+	j.lsn = record.LSN
+	return nil
+}
+
+func (j *journal) currentLSN() uint64 {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.lsn
+}
+
+// sync сбрасывает на диск записи, накопленные с прошлого вызова
+func (j *journal) sync() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed || !j.dirty {
+		return nil
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("ошибка сброса журнала на диск: %w", err)
+	}
+
+	j.dirty = false
+	return nil
+}
+
+// rotate закрывает текущий сегмент и начинает новый. Возвращает LSN последней записи
+// закрытого сегмента: все записи до него включительно должны войти в снимок.
+// Вызывается, пока репозитории заблокированы, чтобы LSN соответствовал их состоянию.
+func (j *journal) rotate() (uint64, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if err := j.file.Sync(); err != nil {
+		return 0, fmt.Errorf("ошибка сброса журнала на диск: %w", err)
+	}
+
+	if err := j.file.Close(); err != nil {
+		return 0, fmt.Errorf("ошибка закрытия сегмента журнала: %w", err)
+	}
+
+	if err := j.openSegment(); err != nil {
+		j.closed = true
+		return 0, err
+	}
+
+	j.dirty = false
+	return j.lsn, nil
+}
+
+// removeSegmentsBefore удаляет сегменты, все записи которых не новее lsn
+func (j *journal) removeSegmentsBefore(lsn uint64) error {
+	j.mutex.Lock()
+	current := j.file.Name()
+	j.mutex.Unlock()
+
+	segments, err := listSegments(j.dir)
+	if err != nil {
+		return err
+	}
+
+	for i, segment := range segments {
+		if segment.path == current {
+			break
+		}
+
+		// Сегмент заканчивается перед первой записью следующего
+		if i+1 < len(segments) && segments[i+1].firstLSN <= lsn+1 {
+			if err := os.Remove(segment.path); err != nil {
+				return fmt.Errorf("ошибка удаления сегмента журнала: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (j *journal) close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+
+	if err := j.file.Sync(); err != nil {
+		j.file.Close()
+		return fmt.Errorf("ошибка сброса журнала на диск: %w", err)
+	}
+
+	return j.file.Close()
+}
+
+func (j *journal) openSegment() error {
+	path := filepath.Join(j.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, j.lsn+1, segmentSuffix))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия сегмента журнала: %w", err)
+	}
+
+	if err := syncDir(j.dir); err != nil {
+		file.Close()
+		return err
+	}
+
+	j.file = file
+	return nil
+}
+
+type segmentFile struct {
+	path     string
+	firstLSN uint64
+}
+
+func listSegments(dir string) ([]segmentFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога данных: %w", err)
+	}
+
+	var segments []segmentFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		firstLSN, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректное имя сегмента журнала: %s", name)
+		}
+
+		segments = append(segments, segmentFile{path: filepath.Join(dir, name), firstLSN: firstLSN})
+	}
+
+	sort.Slice(segments, func(i, k int) bool {
+		return segments[i].firstLSN < segments[k].firstLSN
+	})
+
+	return segments, nil
+}
+
+// readSegment передает в apply записи сегмента по порядку. Недописанная последняя строка
+// последнего сегмента (сбой посреди записи) отрезается, повреждение в середине журнала - ошибка.
+func readSegment(segment segmentFile, last bool, apply func(record *journalRecord) error, logger *logrus.Logger) error {
+	file, err := os.OpenFile(segment.path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия сегмента журнала: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("ошибка чтения сегмента журнала: %w", readErr)
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var record journalRecord
+			complete := readErr == nil
+			if err := json.Unmarshal(line, &record); err != nil || !complete {
+				if !last || !isTail(reader) {
+					return fmt.Errorf("поврежденная запись журнала в %s на смещении %d", segment.path, offset)
+				}
+
+				logger.WithFields(logrus.Fields{
+					"segment": segment.path,
+					"offset":  offset,
+				}).Warn("Отброшена недописанная запись в конце журнала")
+				return file.Truncate(offset)
+			}
+
+			if err := apply(&record); err != nil {
+				return err
+			}
+		}
+
+		offset += int64(len(line))
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+func isTail(reader *bufio.Reader) bool {
+	_, err := reader.Peek(1)
+	return err == io.EOF
+}
+
+// syncDir фиксирует на диске создание и переименование файлов в каталоге
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия каталога данных: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("ошибка сброса каталога данных на диск: %w", err)
+	}
+	return nil
+}
+
+// Сущности пишутся без связанных объектов: их подгружают резолверы, а не хранилище
+
+func storedPost(post *entities.Post) *entities.Post {
+	postCopy := *post
+	postCopy.Author = nil
+	return &postCopy
+}
+
+func storedComment(comment *entities.Comment) *entities.Comment {
+	commentCopy := *comment
+	commentCopy.Author = nil
+	commentCopy.Post = nil
+	commentCopy.Parent = nil
+	commentCopy.Children = nil
+	return &commentCopy
+}
+
+func storedPostRevision(revision *entities.PostRevision) *entities.PostRevision {
+	revisionCopy := *revision
+	revisionCopy.Editor = nil
+	return &revisionCopy
+}
+
+func storedCommentRevision(revision *entities.CommentRevision) *entities.CommentRevision {
+	revisionCopy := *revision
+	revisionCopy.Editor = nil
+	return &revisionCopy
+}
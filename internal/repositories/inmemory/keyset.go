@@ -0,0 +1,108 @@
+package inmemory
+
+import (
+	"ozon-posts/internal/entities"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// keysetIndex хранит ключи (created_at, id) в порядке выдачи списка, чтобы страницы
+// по курсору находились бинарным поиском без сортировки всей коллекции на каждый запрос
+type keysetIndex struct {
+	keys       []entities.Cursor
+	descending bool
+}
+
+func newKeysetIndex(descending bool) *keysetIndex {
+	return &keysetIndex{descending: descending}
+}
+
+func (idx *keysetIndex) precedes(a, b entities.Cursor) bool {
+	if idx.descending {
+		return b.Less(a)
+	}
+	return a.Less(b)
+}
+
+func (idx *keysetIndex) search(cursor entities.Cursor) int {
+	return sort.Search(len(idx.keys), func(i int) bool {
+		return !idx.precedes(idx.keys[i], cursor)
+	})
+}
+
+func (idx *keysetIndex) insert(cursor entities.Cursor) {
+	i := idx.search(cursor)
+	idx.keys = append(idx.keys, entities.Cursor{})
+	copy(idx.keys[i+1:], idx.keys[i:])
+	idx.keys[i] = cursor
+}
+
+func (idx *keysetIndex) remove(cursor entities.Cursor) {
+	i := idx.search(cursor)
+	if i < len(idx.keys) && idx.keys[i].ID == cursor.ID {
+		idx.keys = append(idx.keys[:i], idx.keys[i+1:]...)
+	}
+}
+
+func (idx *keysetIndex) len() int {
+	return len(idx.keys)
+}
+
+// head возвращает идентификаторы первых n элементов в порядке выдачи
+func (idx *keysetIndex) head(n int) []uuid.UUID {
+	count := min(len(idx.keys), n)
+	ids := make([]uuid.UUID, 0, count)
+	for i := 0; i < count; i++ {
+		ids = append(ids, idx.keys[i].ID)
+	}
+	return ids
+}
+
+// slice возвращает идентификаторы элементов с offset по offset+limit; при reverse позиции
+// отсчитываются с конца индекса, и элементы идут в обратном порядке
+func (idx *keysetIndex) slice(offset, limit int, reverse bool) []uuid.UUID {
+	start := min(offset, len(idx.keys))
+	end := min(offset+limit, len(idx.keys))
+	ids := make([]uuid.UUID, 0, end-start)
+	for i := start; i < end; i++ {
+		if reverse {
+			ids = append(ids, idx.keys[len(idx.keys)-1-i].ID)
+		} else {
+			ids = append(ids, idx.keys[i].ID)
+		}
+	}
+	return ids
+}
+
+// page возвращает до Limit+1 идентификаторов в том порядке, который ожидает entities.NewCursorPage
+func (idx *keysetIndex) page(req *entities.CursorRequest) []uuid.UUID {
+	start, end := 0, len(idx.keys)
+	if req.After != nil {
+		start = sort.Search(len(idx.keys), func(i int) bool {
+			return idx.precedes(*req.After, idx.keys[i])
+		})
+	}
+	if req.Before != nil {
+		end = idx.search(*req.Before)
+	}
+	if start >= end {
+		return []uuid.UUID{}
+	}
+
+	window := idx.keys[start:end]
+	count := min(len(window), req.Limit+1)
+	ids := make([]uuid.UUID, 0, count)
+
+	if req.FromEnd {
+		for i := len(window) - 1; i >= len(window)-count; i-- {
+			ids = append(ids, window[i].ID)
+		}
+		return ids
+	}
+
+	for i := 0; i < count; i++ {
+		ids = append(ids, window[i].ID)
+	}
+	return ids
+}
@@ -0,0 +1,158 @@
+package inmemory
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// NotificationRepository хранит оповещения и для каждого пользователя два индекса в порядке
+// выдачи (новые сначала): всех оповещений и непрочитанных
+type NotificationRepository struct {
+	notifications map[uuid.UUID]*entities.Notification
+	byUser        map[uuid.UUID]*keysetIndex
+	unreadByUser  map[uuid.UUID]*keysetIndex
+	mutex         sync.RWMutex
+	journal       *journal
+	logger        *logrus.Logger
+}
+
+func NewNotificationRepository(logger *logrus.Logger) services.NotificationRepository {
+	return newNotificationRepository(logger)
+}
+
+func newNotificationRepository(logger *logrus.Logger) *NotificationRepository {
+	return &NotificationRepository{
+		notifications: make(map[uuid.UUID]*entities.Notification),
+		byUser:        make(map[uuid.UUID]*keysetIndex),
+		unreadByUser:  make(map[uuid.UUID]*keysetIndex),
+		logger:        logger,
+	}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, notifications []*entities.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	copies := make([]*entities.Notification, 0, len(notifications))
+	for _, notification := range notifications {
+		notificationCopy := *notification
+		copies = append(copies, &notificationCopy)
+	}
+
+	if err := r.journal.append(journalRecord{Op: opNotificationPut, Notifications: copies}); err != nil {
+		return err
+	}
+
+	for _, notification := range copies {
+		r.putNotification(notification)
+	}
+
+	r.logger.WithField("count", len(copies)).Debug("Оповещения сохранены в in-memory хранилище")
+	return nil
+}
+
+func (r *NotificationRepository) GetByUserIDKeyset(ctx context.Context, userID uuid.UUID, unreadOnly bool, req *entities.CursorRequest) ([]*entities.Notification, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	index := r.byUser[userID]
+	if unreadOnly {
+		index = r.unreadByUser[userID]
+	}
+	if index == nil {
+		return []*entities.Notification{}, &entities.PageInfo{}, nil
+	}
+
+	ids := index.page(req)
+	notifications := make([]*entities.Notification, 0, len(ids))
+	for _, id := range ids {
+		notificationCopy := *r.notifications[id]
+		notifications = append(notifications, &notificationCopy)
+	}
+
+	notifications, pageInfo := entities.NewCursorPage(notifications, req)
+	return notifications, pageInfo, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, readAt time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	unread := r.unreadByUser[userID]
+	if unread == nil {
+		return 0, nil
+	}
+
+	if len(ids) == 0 {
+		ids = unread.head(unread.len())
+	}
+
+	var marked []*entities.Notification
+	for _, id := range ids {
+		notification, exists := r.notifications[id]
+		if !exists || notification.UserID != userID || notification.IsRead() {
+			continue
+		}
+
+		notificationCopy := *notification
+		notificationCopy.ReadAt = &readAt
+		marked = append(marked, &notificationCopy)
+	}
+
+	if len(marked) == 0 {
+		return 0, nil
+	}
+
+	if err := r.journal.append(journalRecord{Op: opNotificationPut, Notifications: marked}); err != nil {
+		return 0, err
+	}
+
+	for _, notification := range marked {
+		r.putNotification(notification)
+	}
+
+	return int64(len(marked)), nil
+}
+
+// putNotification сохраняет итоговое состояние оповещения без журнала: его же применяет восстановление
+func (r *NotificationRepository) putNotification(notification *entities.Notification) {
+	cursor := notification.Cursor()
+
+	previous, exists := r.notifications[notification.ID]
+	if !exists {
+		notificationIndex(r.byUser, notification.UserID).insert(cursor)
+	}
+	wasUnread := exists && !previous.IsRead()
+
+	r.notifications[notification.ID] = notification
+
+	switch {
+	case !wasUnread && !notification.IsRead():
+		notificationIndex(r.unreadByUser, notification.UserID).insert(cursor)
+	case wasUnread && notification.IsRead():
+		unread := r.unreadByUser[notification.UserID]
+		unread.remove(cursor)
+		if unread.len() == 0 {
+			delete(r.unreadByUser, notification.UserID)
+		}
+	}
+}
+
+func notificationIndex(indexes map[uuid.UUID]*keysetIndex, userID uuid.UUID) *keysetIndex {
+	index, exists := indexes[userID]
+	if !exists {
+		index = newKeysetIndex(true)
+		indexes[userID] = index
+	}
+	return index
+}
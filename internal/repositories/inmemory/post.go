@@ -12,16 +12,31 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// PostRepository хранит посты. Скрытые модератором посты остаются в posts, но не попадают
+// в индексы выдачи и поиска, поэтому чтения по индексам пропускают их без проверок.
 type PostRepository struct {
-	posts  map[uuid.UUID]*entities.Post
-	mutex  sync.RWMutex
-	logger *logrus.Logger
+	posts     map[uuid.UUID]*entities.Post
+	revisions map[uuid.UUID][]*entities.PostRevision
+	ordered   *keysetIndex
+	byAuthor  map[uuid.UUID]*keysetIndex
+	search    *searchIndex
+	mutex     sync.RWMutex
+	journal   *journal
+	logger    *logrus.Logger
 }
 
 func NewPostRepository(logger *logrus.Logger) services.PostRepository {
+	return newPostRepository(logger)
+}
+
+func newPostRepository(logger *logrus.Logger) *PostRepository {
 	return &PostRepository{
-		posts:  make(map[uuid.UUID]*entities.Post),
-		logger: logger,
+		posts:     make(map[uuid.UUID]*entities.Post),
+		revisions: make(map[uuid.UUID][]*entities.PostRevision),
+		ordered:   newKeysetIndex(true),
+		byAuthor:  make(map[uuid.UUID]*keysetIndex),
+		search:    newSearchIndex(),
+		logger:    logger,
 	}
 }
 
@@ -31,17 +46,36 @@ func (r *PostRepository) Create(ctx context.Context, post *entities.Post) error
 
 	post.CreatedAt = time.Now()
 	post.UpdatedAt = time.Now()
-	r.posts[post.ID] = post
+
+	if err := r.journal.append(journalRecord{Op: opPostPut, Post: storedPost(post)}); err != nil {
+		return err
+	}
+
+	r.putPost(post)
 	r.logger.WithField("post_id", post.ID).Debug("Пост создан в in-memory хранилище")
 	return nil
 }
 
 func (r *PostRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	return r.getByID(id, false)
+}
+
+// GetByIDForShare не блокирует запись: взаимное исключение обеспечивает UnitOfWork
+func (r *PostRepository) GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	return r.getByID(id, false)
+}
+
+// GetByIDForUpdate не блокирует запись: взаимное исключение обеспечивает UnitOfWork
+func (r *PostRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	return r.getByID(id, true)
+}
+
+func (r *PostRepository) getByID(id uuid.UUID, withHidden bool) (*entities.Post, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	post, exists := r.posts[id]
-	if !exists {
+	if !exists || (post.IsHidden() && !withHidden) {
 		return nil, nil
 	}
 
@@ -55,6 +89,9 @@ func (r *PostRepository) GetAll(ctx context.Context, pagination *entities.Pagina
 
 	allPosts := make([]*entities.Post, 0, len(r.posts))
 	for _, post := range r.posts {
+		if post.IsHidden() {
+			continue
+		}
 		postCopy := *post
 		allPosts = append(allPosts, &postCopy)
 	}
@@ -97,7 +134,7 @@ func (r *PostRepository) GetByAuthorID(ctx context.Context, authorID uuid.UUID,
 
 	authorPosts := make([]*entities.Post, 0)
 	for _, post := range r.posts {
-		if post.AuthorID == authorID {
+		if post.AuthorID == authorID && !post.IsHidden() {
 			postCopy := *post
 			authorPosts = append(authorPosts, &postCopy)
 		}
@@ -135,33 +172,167 @@ func (r *PostRepository) GetByAuthorID(ctx context.Context, authorID uuid.UUID,
 	}, nil
 }
 
+func (r *PostRepository) GetAllKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	posts, pageInfo := entities.NewCursorPage(r.copyPosts(r.ordered.page(req)), req)
+	return posts, pageInfo, nil
+}
+
+func (r *PostRepository) GetByAuthorIDKeyset(ctx context.Context, authorID uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	index, exists := r.byAuthor[authorID]
+	if !exists {
+		return []*entities.Post{}, &entities.PageInfo{}, nil
+	}
+
+	posts, pageInfo := entities.NewCursorPage(r.copyPosts(index.page(req)), req)
+	return posts, pageInfo, nil
+}
+
+// GetByAuthorIDsKeyset берет у каждого автора не больше Limit+1 постов после курсора
+// и сливает их в общий порядок выдачи, не обходя остальные посты авторов
+func (r *PostRepository) GetByAuthorIDsKeyset(ctx context.Context, authorIDs []uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var candidates []entities.Cursor
+	for _, authorID := range authorIDs {
+		index, exists := r.byAuthor[authorID]
+		if !exists {
+			continue
+		}
+		for _, id := range index.page(req) {
+			candidates = append(candidates, r.posts[id].Cursor())
+		}
+	}
+
+	// index.page отдает посты с конца в обратном порядке, NewCursorPage ожидает того же от слияния
+	sort.Slice(candidates, func(i, j int) bool {
+		if req.FromEnd {
+			return candidates[i].Less(candidates[j])
+		}
+		return candidates[j].Less(candidates[i])
+	})
+
+	count := min(len(candidates), req.Limit+1)
+	ids := make([]uuid.UUID, 0, count)
+	for _, cursor := range candidates[:count] {
+		ids = append(ids, cursor.ID)
+	}
+
+	posts, pageInfo := entities.NewCursorPage(r.copyPosts(ids), req)
+	return posts, pageInfo, nil
+}
+
 func (r *PostRepository) Update(ctx context.Context, post *entities.Post) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.posts[post.ID]; !exists {
+	existing, exists := r.posts[post.ID]
+	if !exists {
 		return nil
 	}
 
+	// created_at неизменяем, как и в PostgreSQL: на нем держатся курсоры.
+	// Счетчик комментариев меняется только через AddCommentCount, отметка скрытия - через SetHidden.
+	post.CreatedAt = existing.CreatedAt
+	post.RootCommentCount = existing.RootCommentCount
+	post.HiddenAt = existing.HiddenAt
+	post.HiddenBy = existing.HiddenBy
 	post.UpdatedAt = time.Now()
-	r.posts[post.ID] = post
+
+	if err := r.journal.append(journalRecord{Op: opPostPut, Post: storedPost(post)}); err != nil {
+		return err
+	}
+
+	r.putPost(post)
 	return nil
 }
 
+func (r *PostRepository) SetHidden(ctx context.Context, post *entities.Post) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.posts[post.ID]
+	if !exists {
+		return nil
+	}
+
+	updated := *existing
+	updated.HiddenAt = post.HiddenAt
+	updated.HiddenBy = post.HiddenBy
+	updated.UpdatedAt = post.UpdatedAt
+	return r.savePost(&updated)
+}
+
 func (r *PostRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	delete(r.posts, id)
+	if _, exists := r.posts[id]; !exists {
+		return nil
+	}
+
+	if err := r.journal.append(journalRecord{Op: opPostDelete, ID: id}); err != nil {
+		return err
+	}
+
+	r.deletePost(id)
 	return nil
 }
 
+func (r *PostRepository) AddCommentCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.posts[id]
+	if !exists {
+		return nil
+	}
+
+	updated := *existing
+	updated.RootCommentCount += delta
+	return r.savePost(&updated)
+}
+
+// savePost записывает итоговое состояние поста в журнал и сохраняет его; вызывается под блокировкой
+func (r *PostRepository) savePost(post *entities.Post) error {
+	if err := r.journal.append(journalRecord{Op: opPostPut, Post: storedPost(post)}); err != nil {
+		return err
+	}
+
+	r.putPost(post)
+	return nil
+}
+
+// putPost сохраняет пост и перестраивает его индексы. Используется и при восстановлении из журнала.
+func (r *PostRepository) putPost(post *entities.Post) {
+	if existing, exists := r.posts[post.ID]; exists {
+		r.unindexPost(existing)
+	}
+
+	r.posts[post.ID] = post
+	r.indexPost(post)
+}
+
+func (r *PostRepository) deletePost(id uuid.UUID) {
+	if post, exists := r.posts[id]; exists {
+		r.unindexPost(post)
+		delete(r.posts, id)
+		delete(r.revisions, id)
+	}
+}
+
 func (r *PostRepository) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	_, exists := r.posts[id]
-	return exists, nil
+	post, exists := r.posts[id]
+	return exists && !post.IsHidden(), nil
 }
 
 func (r *PostRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Post, error) {
@@ -174,7 +345,7 @@ func (r *PostRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*enti
 
 	result := make([]*entities.Post, 0, len(ids))
 	for _, id := range ids {
-		if post, exists := r.posts[id]; exists {
+		if post, exists := r.posts[id]; exists && !post.IsHidden() {
 			postCopy := *post
 			result = append(result, &postCopy)
 		}
@@ -188,9 +359,114 @@ func (r *PostRepository) IsCommentsEnabled(ctx context.Context, postID uuid.UUID
 	defer r.mutex.RUnlock()
 
 	post, exists := r.posts[postID]
-	if !exists {
+	if !exists || post.IsHidden() {
 		return false, nil
 	}
 
 	return !post.CommentsDisabled, nil
 }
+
+func (r *PostRepository) indexPost(post *entities.Post) {
+	if post.IsHidden() {
+		return
+	}
+
+	r.ordered.insert(post.Cursor())
+
+	index, exists := r.byAuthor[post.AuthorID]
+	if !exists {
+		index = newKeysetIndex(true)
+		r.byAuthor[post.AuthorID] = index
+	}
+	index.insert(post.Cursor())
+
+	r.indexPostText(post)
+}
+
+// indexPostText индексирует текст поста для поиска; совпадение в заголовке весит вдвое больше
+func (r *PostRepository) indexPostText(post *entities.Post) {
+	r.search.put(post.ID,
+		searchField{text: post.Title, weight: 2},
+		searchField{text: post.Content, weight: 1},
+	)
+}
+
+func (r *PostRepository) unindexPost(post *entities.Post) {
+	r.ordered.remove(post.Cursor())
+	r.search.remove(post.ID)
+
+	if index, exists := r.byAuthor[post.AuthorID]; exists {
+		index.remove(post.Cursor())
+		if index.len() == 0 {
+			delete(r.byAuthor, post.AuthorID)
+		}
+	}
+}
+
+func (r *PostRepository) copyPosts(ids []uuid.UUID) []*entities.Post {
+	result := make([]*entities.Post, 0, len(ids))
+	for _, id := range ids {
+		postCopy := *r.posts[id]
+		result = append(result, &postCopy)
+	}
+	return result
+}
+
+func (r *PostRepository) CreateRevision(ctx context.Context, revision *entities.PostRevision) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.journal.append(journalRecord{Op: opPostRevision, PostRevision: storedPostRevision(revision)}); err != nil {
+		return err
+	}
+
+	r.appendRevision(revision)
+	return nil
+}
+
+func (r *PostRepository) appendRevision(revision *entities.PostRevision) {
+	revisionCopy := *revision
+	r.revisions[revision.PostID] = append(r.revisions[revision.PostID], &revisionCopy)
+}
+
+func (r *PostRepository) GetRevisions(ctx context.Context, postID uuid.UUID) ([]*entities.PostRevision, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make([]*entities.PostRevision, 0, len(r.revisions[postID]))
+	for _, revision := range r.revisions[postID] {
+		revisionCopy := *revision
+		result = append(result, &revisionCopy)
+	}
+
+	return result, nil
+}
+
+func (r *PostRepository) Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.PostSearchResult, *entities.PaginationResponse, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	scores := r.search.match(query.Terms)
+	results := make([]*entities.PostSearchResult, 0, len(scores))
+	for id, score := range scores {
+		postCopy := *r.posts[id]
+		results = append(results, &entities.PostSearchResult{Post: &postCopy, Rank: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[j].Post.Cursor().Less(results[i].Post.Cursor())
+	})
+
+	total := int64(len(results))
+	results = results[min(pagination.Offset, len(results)):min(pagination.Offset+pagination.Limit, len(results))]
+
+	for _, result := range results {
+		result.TitleHighlight = entities.Highlight(result.Post.Title, query.Terms, 0)
+		result.Snippet = entities.Highlight(result.Post.Content, query.Terms, entities.SearchSnippetWords)
+	}
+
+	return results, entities.NewPaginationResponse(total, pagination.Limit, pagination.Offset), nil
+}
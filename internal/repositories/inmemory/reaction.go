@@ -0,0 +1,171 @@
+package inmemory
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type reactionTarget struct {
+	targetType entities.ReactionTargetType
+	targetID   uuid.UUID
+}
+
+type reactionKey struct {
+	reactionTarget
+	userID uuid.UUID
+	kind   entities.ReactionKind
+}
+
+func keyOf(reaction *entities.Reaction) reactionKey {
+	return reactionKey{
+		reactionTarget: reactionTarget{targetType: reaction.TargetType, targetID: reaction.TargetID},
+		userID:         reaction.UserID,
+		kind:           reaction.Kind,
+	}
+}
+
+// ReactionRepository хранит реакции по ключу (объект, пользователь, вид) и ведет счетчики
+// по объектам, чтобы сводка не требовала обхода всех реакций
+type ReactionRepository struct {
+	reactions map[reactionKey]*entities.Reaction
+	counts    map[reactionTarget]map[entities.ReactionKind]int64
+	mutex     sync.RWMutex
+	journal   *journal
+	logger    *logrus.Logger
+}
+
+func NewReactionRepository(logger *logrus.Logger) services.ReactionRepository {
+	return newReactionRepository(logger)
+}
+
+func newReactionRepository(logger *logrus.Logger) *ReactionRepository {
+	return &ReactionRepository{
+		reactions: make(map[reactionKey]*entities.Reaction),
+		counts:    make(map[reactionTarget]map[entities.ReactionKind]int64),
+		logger:    logger,
+	}
+}
+
+func (r *ReactionRepository) Add(ctx context.Context, reaction *entities.Reaction) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.reactions[keyOf(reaction)]; exists {
+		return false, nil
+	}
+
+	reactionCopy := *reaction
+	if err := r.journal.append(journalRecord{Op: opReactionPut, Reaction: &reactionCopy}); err != nil {
+		return false, err
+	}
+
+	r.putReaction(&reactionCopy)
+	r.logger.WithField("reaction_id", reaction.ID).Debug("Реакция сохранена в in-memory хранилище")
+	return true, nil
+}
+
+func (r *ReactionRepository) Remove(ctx context.Context, targetType entities.ReactionTargetType, targetID, userID uuid.UUID, kind entities.ReactionKind) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := reactionKey{
+		reactionTarget: reactionTarget{targetType: targetType, targetID: targetID},
+		userID:         userID,
+		kind:           kind,
+	}
+
+	reaction, exists := r.reactions[key]
+	if !exists {
+		return false, nil
+	}
+
+	if err := r.journal.append(journalRecord{Op: opReactionDelete, Reaction: reaction}); err != nil {
+		return false, err
+	}
+
+	r.deleteReaction(reaction)
+	r.logger.WithField("reaction_id", reaction.ID).Debug("Реакция удалена из in-memory хранилища")
+	return true, nil
+}
+
+func (r *ReactionRepository) CountByTargets(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReactionKind]int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make(map[uuid.UUID]map[entities.ReactionKind]int64, len(targetIDs))
+	for _, targetID := range targetIDs {
+		counts, exists := r.counts[reactionTarget{targetType: targetType, targetID: targetID}]
+		if !exists {
+			continue
+		}
+
+		countsCopy := make(map[entities.ReactionKind]int64, len(counts))
+		for kind, count := range counts {
+			countsCopy[kind] = count
+		}
+		result[targetID] = countsCopy
+	}
+
+	return result, nil
+}
+
+func (r *ReactionRepository) GetUserKinds(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID][]entities.ReactionKind, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make(map[uuid.UUID][]entities.ReactionKind)
+	for _, targetID := range targetIDs {
+		target := reactionTarget{targetType: targetType, targetID: targetID}
+		if _, exists := r.counts[target]; !exists {
+			continue
+		}
+
+		for _, kind := range entities.ReactionKinds {
+			if _, reacted := r.reactions[reactionKey{reactionTarget: target, userID: userID, kind: kind}]; reacted {
+				result[targetID] = append(result[targetID], kind)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// putReaction и deleteReaction меняют карты без журнала: их же применяет восстановление
+func (r *ReactionRepository) putReaction(reaction *entities.Reaction) {
+	key := keyOf(reaction)
+	if _, exists := r.reactions[key]; exists {
+		return
+	}
+
+	r.reactions[key] = reaction
+
+	counts, exists := r.counts[key.reactionTarget]
+	if !exists {
+		counts = make(map[entities.ReactionKind]int64)
+		r.counts[key.reactionTarget] = counts
+	}
+	counts[reaction.Kind]++
+}
+
+func (r *ReactionRepository) deleteReaction(reaction *entities.Reaction) {
+	key := keyOf(reaction)
+	if _, exists := r.reactions[key]; !exists {
+		return
+	}
+
+	delete(r.reactions, key)
+
+	counts := r.counts[key.reactionTarget]
+	counts[reaction.Kind]--
+	if counts[reaction.Kind] <= 0 {
+		delete(counts, reaction.Kind)
+	}
+	if len(counts) == 0 {
+		delete(r.counts, key.reactionTarget)
+	}
+}
@@ -0,0 +1,240 @@
+package inmemory
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ReportRepository хранит жалобы и журнал модерации. Открытые жалобы сгруппированы по объекту
+// в порядке подачи, а очередь модерации - индекс объектов по времени первой открытой жалобы.
+type ReportRepository struct {
+	reports    map[uuid.UUID]*entities.Report
+	open       map[uuid.UUID][]*entities.Report
+	queue      *keysetIndex
+	audit      map[uuid.UUID]*entities.ModerationAuditEntry
+	auditIndex *keysetIndex
+	mutex      sync.RWMutex
+	journal    *journal
+	logger     *logrus.Logger
+}
+
+func NewReportRepository(logger *logrus.Logger) services.ReportRepository {
+	return newReportRepository(logger)
+}
+
+func newReportRepository(logger *logrus.Logger) *ReportRepository {
+	return &ReportRepository{
+		reports:    make(map[uuid.UUID]*entities.Report),
+		open:       make(map[uuid.UUID][]*entities.Report),
+		queue:      newKeysetIndex(false),
+		audit:      make(map[uuid.UUID]*entities.ModerationAuditEntry),
+		auditIndex: newKeysetIndex(true),
+		logger:     logger,
+	}
+}
+
+func (r *ReportRepository) Create(ctx context.Context, report *entities.Report) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.open[report.TargetID] {
+		if existing.ReporterID == report.ReporterID {
+			return false, nil
+		}
+	}
+
+	reportCopy := *report
+	if err := r.journal.append(journalRecord{Op: opReportPut, Reports: []*entities.Report{&reportCopy}}); err != nil {
+		return false, err
+	}
+
+	r.putReport(&reportCopy)
+
+	r.logger.WithFields(logrus.Fields{
+		"report_id": report.ID,
+		"target_id": report.TargetID,
+	}).Debug("Жалоба сохранена в in-memory хранилище")
+	return true, nil
+}
+
+func (r *ReportRepository) GetQueueKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationQueueItem, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	targetIDs := r.queue.page(req)
+	items := make([]*entities.ModerationQueueItem, 0, len(targetIDs))
+	for _, targetID := range targetIDs {
+		items = append(items, queueItem(r.open[targetID]))
+	}
+
+	items, pageInfo := entities.NewCursorPage(items, req)
+	return items, pageInfo, nil
+}
+
+func (r *ReportRepository) GetQueueItem(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID) (*entities.ModerationQueueItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	reports := r.open[targetID]
+	if len(reports) == 0 || reports[0].TargetType != targetType {
+		return nil, nil
+	}
+
+	return queueItem(reports), nil
+}
+
+func (r *ReportRepository) CountOpenByReason(ctx context.Context, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReportReason]int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	counts := make(map[uuid.UUID]map[entities.ReportReason]int64, len(targetIDs))
+	for _, targetID := range targetIDs {
+		for _, report := range r.open[targetID] {
+			if counts[targetID] == nil {
+				counts[targetID] = make(map[entities.ReportReason]int64)
+			}
+			counts[targetID][report.Reason]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (r *ReportRepository) ResolveOpen(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID, action entities.ModerationAction, moderatorID uuid.UUID, resolvedAt time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var resolved []*entities.Report
+	for _, report := range r.open[targetID] {
+		if report.TargetType != targetType {
+			continue
+		}
+
+		reportCopy := *report
+		reportCopy.Resolve(action, moderatorID, resolvedAt)
+		resolved = append(resolved, &reportCopy)
+	}
+
+	if len(resolved) == 0 {
+		return 0, nil
+	}
+
+	if err := r.journal.append(journalRecord{Op: opReportPut, Reports: resolved}); err != nil {
+		return 0, err
+	}
+
+	for _, report := range resolved {
+		r.putReport(report)
+	}
+
+	return int64(len(resolved)), nil
+}
+
+func (r *ReportRepository) CreateAuditEntry(ctx context.Context, entry *entities.ModerationAuditEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entryCopy := *entry
+	if err := r.journal.append(journalRecord{Op: opAuditPut, ModerationAudit: &entryCopy}); err != nil {
+		return err
+	}
+
+	r.putAuditEntry(&entryCopy)
+	return nil
+}
+
+func (r *ReportRepository) GetAuditLogKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationAuditEntry, *entities.PageInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ids := r.auditIndex.page(req)
+	entries := make([]*entities.ModerationAuditEntry, 0, len(ids))
+	for _, id := range ids {
+		entryCopy := *r.audit[id]
+		entries = append(entries, &entryCopy)
+	}
+
+	entries, pageInfo := entities.NewCursorPage(entries, req)
+	return entries, pageInfo, nil
+}
+
+// putReport сохраняет итоговое состояние жалобы без журнала: его же применяет восстановление
+func (r *ReportRepository) putReport(report *entities.Report) {
+	previous, exists := r.reports[report.ID]
+	r.reports[report.ID] = report
+
+	reports := r.open[report.TargetID]
+	if exists && previous.IsOpen() {
+		reports = withoutReport(reports, report.ID)
+	}
+	if report.IsOpen() {
+		reports = withReport(reports, report)
+	}
+
+	r.setOpen(report.TargetID, reports)
+}
+
+// setOpen заменяет открытые жалобы на объект и переставляет объект в очереди по первой из них
+func (r *ReportRepository) setOpen(targetID uuid.UUID, reports []*entities.Report) {
+	if previous := r.open[targetID]; len(previous) > 0 {
+		r.queue.remove(entities.NewCursor(previous[0].CreatedAt, targetID))
+	}
+
+	if len(reports) == 0 {
+		delete(r.open, targetID)
+		return
+	}
+
+	r.open[targetID] = reports
+	r.queue.insert(entities.NewCursor(reports[0].CreatedAt, targetID))
+}
+
+func (r *ReportRepository) putAuditEntry(entry *entities.ModerationAuditEntry) {
+	if _, exists := r.audit[entry.ID]; !exists {
+		r.auditIndex.insert(entry.Cursor())
+	}
+	r.audit[entry.ID] = entry
+}
+
+// withReport возвращает копию списка с жалобой, вставленной по порядку (created_at, id)
+func withReport(reports []*entities.Report, report *entities.Report) []*entities.Report {
+	cursor := entities.NewCursor(report.CreatedAt, report.ID)
+	i := sort.Search(len(reports), func(i int) bool {
+		return cursor.Less(entities.NewCursor(reports[i].CreatedAt, reports[i].ID))
+	})
+
+	result := make([]*entities.Report, 0, len(reports)+1)
+	result = append(result, reports[:i]...)
+	result = append(result, report)
+	return append(result, reports[i:]...)
+}
+
+func withoutReport(reports []*entities.Report, id uuid.UUID) []*entities.Report {
+	result := make([]*entities.Report, 0, len(reports))
+	for _, report := range reports {
+		if report.ID != id {
+			result = append(result, report)
+		}
+	}
+	return result
+}
+
+// queueItem сводит открытые жалобы на объект, упорядоченные по времени подачи, в элемент очереди
+func queueItem(reports []*entities.Report) *entities.ModerationQueueItem {
+	first, last := reports[0], reports[len(reports)-1]
+	return &entities.ModerationQueueItem{
+		TargetType:      first.TargetType,
+		TargetID:        first.TargetID,
+		AuthorID:        first.AuthorID,
+		ReportCount:     int64(len(reports)),
+		FirstReportedAt: first.CreatedAt,
+		LastReportedAt:  last.CreatedAt,
+	}
+}
@@ -0,0 +1,109 @@
+package inmemory
+
+import (
+	"math"
+	"ozon-posts/internal/entities"
+
+	"github.com/google/uuid"
+)
+
+// searchField - индексируемый текст документа с весом, например заголовок поста весомее текста
+type searchField struct {
+	text   string
+	weight float64
+}
+
+type searchDocument struct {
+	frequencies map[string]float64
+	length      int
+}
+
+// searchIndex - инвертированный индекс термов для полнотекстового поиска.
+// Не потокобезопасен: вызывается под мьютексом репозитория.
+type searchIndex struct {
+	postings  map[string]map[uuid.UUID]struct{}
+	documents map[uuid.UUID]*searchDocument
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings:  make(map[string]map[uuid.UUID]struct{}),
+		documents: make(map[uuid.UUID]*searchDocument),
+	}
+}
+
+// put индексирует документ, заменяя его прежнюю версию
+func (idx *searchIndex) put(id uuid.UUID, fields ...searchField) {
+	idx.remove(id)
+
+	doc := &searchDocument{frequencies: make(map[string]float64)}
+	for _, field := range fields {
+		for _, term := range entities.Tokenize(field.text) {
+			doc.frequencies[term] += field.weight
+			doc.length++
+		}
+	}
+
+	for term := range doc.frequencies {
+		ids, ok := idx.postings[term]
+		if !ok {
+			ids = make(map[uuid.UUID]struct{})
+			idx.postings[term] = ids
+		}
+		ids[id] = struct{}{}
+	}
+
+	idx.documents[id] = doc
+}
+
+func (idx *searchIndex) remove(id uuid.UUID) {
+	doc, ok := idx.documents[id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.frequencies {
+		ids := idx.postings[term]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+
+	delete(idx.documents, id)
+}
+
+// match возвращает документы, содержащие все термы, с оценкой TF-IDF
+func (idx *searchIndex) match(terms []string) map[uuid.UUID]float64 {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	// Начинаем с самого редкого терма, чтобы пересечение было минимальным
+	rarest := terms[0]
+	for _, term := range terms[1:] {
+		if len(idx.postings[term]) < len(idx.postings[rarest]) {
+			rarest = term
+		}
+	}
+
+	total := float64(len(idx.documents))
+	scores := make(map[uuid.UUID]float64)
+
+candidates:
+	for id := range idx.postings[rarest] {
+		doc := idx.documents[id]
+		var score float64
+		for _, term := range terms {
+			frequency, ok := doc.frequencies[term]
+			if !ok {
+				continue candidates
+			}
+			idf := math.Log(1 + total/float64(len(idx.postings[term])))
+			score += frequency / float64(doc.length) * idf
+		}
+		scores[id] = score
+	}
+
+	return scores
+}
@@ -0,0 +1,499 @@
+package inmemory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const snapshotFile = "snapshot.json"
+
+// StorageOptions - параметры файлового хранения in-memory репозиториев
+type StorageOptions struct {
+	Dir              string
+	Fsync            FsyncPolicy
+	FsyncInterval    time.Duration
+	SnapshotInterval time.Duration
+}
+
+// snapshot - полное состояние репозиториев на момент записи журнала с номером LSN
+type snapshot struct {
+	LSN              uint64                           `json:"lsn"`
+	CreatedAt        time.Time                        `json:"created_at"`
+	Users            []*storedUser                    `json:"users"`
+	Posts            []*entities.Post                 `json:"posts"`
+	PostRevisions    []*entities.PostRevision         `json:"post_revisions"`
+	Comments         []*entities.Comment              `json:"comments"`
+	CommentRevisions []*entities.CommentRevision      `json:"comment_revisions"`
+	Mentions         []*entities.CommentMention       `json:"mentions"`
+	Reactions        []*entities.Reaction             `json:"reactions"`
+	Notifications    []*entities.Notification         `json:"notifications"`
+	Follows          []*entities.Follow               `json:"follows"`
+	Reports          []*entities.Report               `json:"reports"`
+	ModerationAudit  []*entities.ModerationAuditEntry `json:"moderation_audit"`
+}
+
+// Storage - in-memory репозитории, переживающие перезапуск. Каждое изменение сначала пишется
+// в журнал, а при запуске репозитории восстанавливаются из последнего снимка и журнала после него.
+// Снимок делается периодически и при закрытии, после чего покрытые им сегменты журнала удаляются.
+type Storage struct {
+	users         *UserRepository
+	posts         *PostRepository
+	comments      *CommentRepository
+	reactions     *ReactionRepository
+	notifications *NotificationRepository
+	follows       *FollowRepository
+	reports       *ReportRepository
+	journal       *journal
+	opts          StorageOptions
+	logger        *logrus.Logger
+
+	// snapshotMutex не дает двум снимкам писать файл одновременно
+	snapshotMutex sync.Mutex
+	snapshotLSN   uint64
+	done          chan struct{}
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+}
+
+func OpenStorage(opts StorageOptions, logger *logrus.Logger) (*Storage, error) {
+	if !opts.Fsync.IsValid() {
+		return nil, fmt.Errorf("неизвестная политика fsync: %q", opts.Fsync)
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога данных: %w", err)
+	}
+
+	posts := newPostRepository(logger)
+	s := &Storage{
+		users:         newUserRepository(logger),
+		posts:         posts,
+		comments:      newCommentRepository(posts, logger),
+		reactions:     newReactionRepository(logger),
+		notifications: newNotificationRepository(logger),
+		follows:       newFollowRepository(logger),
+		reports:       newReportRepository(logger),
+		opts:          opts,
+		logger:        logger,
+		done:          make(chan struct{}),
+	}
+
+	lastLSN, err := s.restore()
+	if err != nil {
+		return nil, err
+	}
+
+	s.journal, err = openJournal(opts.Dir, lastLSN, opts.Fsync, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	s.users.journal = s.journal
+	s.posts.journal = s.journal
+	s.comments.journal = s.journal
+	s.reactions.journal = s.journal
+	s.notifications.journal = s.journal
+	s.follows.journal = s.journal
+	s.reports.journal = s.journal
+
+	s.startBackground()
+
+	logger.WithFields(logrus.Fields{
+		"dir":           opts.Dir,
+		"lsn":           lastLSN,
+		"users":         len(s.users.users),
+		"posts":         len(s.posts.posts),
+		"comments":      len(s.comments.comments),
+		"reactions":     len(s.reactions.reactions),
+		"notifications": len(s.notifications.notifications),
+		"follows":       len(s.follows.follows),
+		"reports":       len(s.reports.reports),
+	}).Info("In-memory хранилище восстановлено с диска")
+
+	return s, nil
+}
+
+func (s *Storage) Users() services.UserRepository {
+	return s.users
+}
+
+func (s *Storage) Posts() services.PostRepository {
+	return s.posts
+}
+
+func (s *Storage) Comments() services.CommentRepository {
+	return s.comments
+}
+
+func (s *Storage) Reactions() services.ReactionRepository {
+	return s.reactions
+}
+
+func (s *Storage) Notifications() services.NotificationRepository {
+	return s.notifications
+}
+
+func (s *Storage) Follows() services.FollowRepository {
+	return s.follows
+}
+
+func (s *Storage) Reports() services.ReportRepository {
+	return s.reports
+}
+
+// Snapshot записывает полное состояние на диск и удаляет покрытые снимком сегменты журнала
+func (s *Storage) Snapshot() error {
+	s.snapshotMutex.Lock()
+	defer s.snapshotMutex.Unlock()
+
+	state, err := s.capture()
+	if err != nil || state == nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации снимка: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(s.opts.Dir, snapshotFile), data); err != nil {
+		return err
+	}
+
+	s.snapshotLSN = state.LSN
+
+	if err := s.journal.removeSegmentsBefore(state.LSN); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"lsn":  state.LSN,
+		"size": len(data),
+	}).Info("Снимок in-memory хранилища записан")
+
+	return nil
+}
+
+// Close останавливает фоновые задачи, делает последний снимок и закрывает журнал
+func (s *Storage) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+
+		if snapshotErr := s.Snapshot(); snapshotErr != nil {
+			s.logger.WithError(snapshotErr).Error("Ошибка записи снимка при закрытии хранилища")
+			err = snapshotErr
+		}
+
+		if closeErr := s.journal.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
+
+// capture копирует состояние всех репозиториев и начинает новый сегмент журнала. Репозитории
+// заблокированы на чтение, поэтому запись в журнал в это время невозможна и LSN снимка точен.
+// Если с прошлого снимка ничего не изменилось, возвращает nil.
+func (s *Storage) capture() (*snapshot, error) {
+	s.users.mu.RLock()
+	defer s.users.mu.RUnlock()
+	s.posts.mutex.RLock()
+	defer s.posts.mutex.RUnlock()
+	s.comments.mutex.RLock()
+	defer s.comments.mutex.RUnlock()
+	s.reactions.mutex.RLock()
+	defer s.reactions.mutex.RUnlock()
+	s.notifications.mutex.RLock()
+	defer s.notifications.mutex.RUnlock()
+	s.follows.mutex.RLock()
+	defer s.follows.mutex.RUnlock()
+	s.reports.mutex.RLock()
+	defer s.reports.mutex.RUnlock()
+
+	if s.journal.currentLSN() == s.snapshotLSN {
+		return nil, nil
+	}
+
+	lsn, err := s.journal.rotate()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &snapshot{
+		LSN:              lsn,
+		CreatedAt:        time.Now(),
+		Users:            make([]*storedUser, 0, len(s.users.users)),
+		Posts:            make([]*entities.Post, 0, len(s.posts.posts)),
+		PostRevisions:    []*entities.PostRevision{},
+		Comments:         make([]*entities.Comment, 0, len(s.comments.comments)),
+		CommentRevisions: []*entities.CommentRevision{},
+		Mentions:         []*entities.CommentMention{},
+		Reactions:        make([]*entities.Reaction, 0, len(s.reactions.reactions)),
+		Notifications:    make([]*entities.Notification, 0, len(s.notifications.notifications)),
+		Follows:          make([]*entities.Follow, 0, len(s.follows.follows)),
+		Reports:          make([]*entities.Report, 0, len(s.reports.reports)),
+		ModerationAudit:  make([]*entities.ModerationAuditEntry, 0, len(s.reports.audit)),
+	}
+
+	for _, user := range s.users.users {
+		state.Users = append(state.Users, newStoredUser(user))
+	}
+	for _, post := range s.posts.posts {
+		state.Posts = append(state.Posts, storedPost(post))
+	}
+	for _, revisions := range s.posts.revisions {
+		for _, revision := range revisions {
+			state.PostRevisions = append(state.PostRevisions, storedPostRevision(revision))
+		}
+	}
+	for _, comment := range s.comments.comments {
+		state.Comments = append(state.Comments, storedComment(comment))
+	}
+	for _, revisions := range s.comments.revisions {
+		for _, revision := range revisions {
+			state.CommentRevisions = append(state.CommentRevisions, storedCommentRevision(revision))
+		}
+	}
+	for _, mentions := range s.comments.mentions {
+		state.Mentions = append(state.Mentions, mentions...)
+	}
+	for _, reaction := range s.reactions.reactions {
+		state.Reactions = append(state.Reactions, reaction)
+	}
+	for _, notification := range s.notifications.notifications {
+		state.Notifications = append(state.Notifications, notification)
+	}
+	for _, follow := range s.follows.follows {
+		state.Follows = append(state.Follows, follow)
+	}
+	for _, report := range s.reports.reports {
+		state.Reports = append(state.Reports, report)
+	}
+	for _, entry := range s.reports.audit {
+		state.ModerationAudit = append(state.ModerationAudit, entry)
+	}
+
+	return state, nil
+}
+
+// restore загружает снимок и применяет записи журнала новее него. Возвращает LSN последней записи.
+func (s *Storage) restore() (uint64, error) {
+	var lastLSN uint64
+
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, snapshotFile))
+	switch {
+	case err == nil:
+		var state snapshot
+		if err := json.Unmarshal(data, &state); err != nil {
+			return 0, fmt.Errorf("ошибка чтения снимка: %w", err)
+		}
+		s.load(&state)
+		lastLSN = state.LSN
+		s.snapshotLSN = state.LSN
+	case !os.IsNotExist(err):
+		return 0, fmt.Errorf("ошибка чтения снимка: %w", err)
+	}
+
+	segments, err := listSegments(s.opts.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for i, segment := range segments {
+		err := readSegment(segment, i == len(segments)-1, func(record *journalRecord) error {
+			if record.LSN <= lastLSN {
+				return nil
+			}
+			if record.LSN != lastLSN+1 {
+				return fmt.Errorf("пропуск в журнале: после записи %d идет %d", lastLSN, record.LSN)
+			}
+
+			if err := s.apply(record); err != nil {
+				return err
+			}
+
+			lastLSN = record.LSN
+			replayed++
+			return nil
+		}, s.logger)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if replayed > 0 {
+		s.logger.WithField("records", replayed).Info("Применены записи журнала после снимка")
+	}
+
+	return lastLSN, nil
+}
+
+func (s *Storage) load(state *snapshot) {
+	for _, user := range state.Users {
+		s.users.putUser(user.entity())
+	}
+	for _, post := range state.Posts {
+		s.posts.putPost(post)
+	}
+	for _, revision := range state.PostRevisions {
+		s.posts.appendRevision(revision)
+	}
+	for _, comment := range state.Comments {
+		s.comments.putComment(comment)
+	}
+	for _, revision := range state.CommentRevisions {
+		s.comments.appendRevision(revision)
+	}
+	for _, mention := range state.Mentions {
+		s.comments.mentions[mention.CommentID] = append(s.comments.mentions[mention.CommentID], mention)
+	}
+	for _, reaction := range state.Reactions {
+		s.reactions.putReaction(reaction)
+	}
+	for _, notification := range state.Notifications {
+		s.notifications.putNotification(notification)
+	}
+	for _, follow := range state.Follows {
+		s.follows.putFollow(follow)
+	}
+	for _, report := range state.Reports {
+		s.reports.putReport(report)
+	}
+	for _, entry := range state.ModerationAudit {
+		s.reports.putAuditEntry(entry)
+	}
+}
+
+func (s *Storage) apply(record *journalRecord) error {
+	switch {
+	case record.Op == opUserPut && record.User != nil:
+		s.users.putUser(record.User.entity())
+	case record.Op == opUserDelete:
+		delete(s.users.users, record.ID)
+	case record.Op == opPostPut && record.Post != nil:
+		s.posts.putPost(record.Post)
+	case record.Op == opPostDelete:
+		s.posts.deletePost(record.ID)
+	case record.Op == opPostRevision && record.PostRevision != nil:
+		s.posts.appendRevision(record.PostRevision)
+	case record.Op == opCommentPut && record.Comment != nil:
+		s.comments.putComment(record.Comment)
+	case record.Op == opCommentPurge:
+		s.comments.purgeComment(record.ID)
+	case record.Op == opCommentRevision && record.CommentRevision != nil:
+		s.comments.appendRevision(record.CommentRevision)
+	case record.Op == opCommentMentions:
+		s.comments.setMentions(record.ID, record.Mentions)
+	case record.Op == opReactionPut && record.Reaction != nil:
+		s.reactions.putReaction(record.Reaction)
+	case record.Op == opReactionDelete && record.Reaction != nil:
+		s.reactions.deleteReaction(record.Reaction)
+	case record.Op == opNotificationPut:
+		for _, notification := range record.Notifications {
+			s.notifications.putNotification(notification)
+		}
+	case record.Op == opFollowPut && record.Follow != nil:
+		s.follows.putFollow(record.Follow)
+	case record.Op == opFollowDelete && record.Follow != nil:
+		s.follows.deleteFollow(record.Follow)
+	case record.Op == opReportPut:
+		for _, report := range record.Reports {
+			s.reports.putReport(report)
+		}
+	case record.Op == opAuditPut && record.ModerationAudit != nil:
+		s.reports.putAuditEntry(record.ModerationAudit)
+	default:
+		return fmt.Errorf("некорректная запись журнала %d: %s", record.LSN, record.Op)
+	}
+	return nil
+}
+
+func (s *Storage) startBackground() {
+	var (
+		tickers      []*time.Ticker
+		fsyncTick    <-chan time.Time
+		snapshotTick <-chan time.Time
+	)
+
+	if s.opts.Fsync == FsyncInterval && s.opts.FsyncInterval > 0 {
+		ticker := time.NewTicker(s.opts.FsyncInterval)
+		tickers = append(tickers, ticker)
+		fsyncTick = ticker.C
+	}
+
+	if s.opts.SnapshotInterval > 0 {
+		ticker := time.NewTicker(s.opts.SnapshotInterval)
+		tickers = append(tickers, ticker)
+		snapshotTick = ticker.C
+	}
+
+	if len(tickers) == 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			for _, ticker := range tickers {
+				ticker.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-fsyncTick:
+				if err := s.journal.sync(); err != nil {
+					s.logger.WithError(err).Error("Ошибка периодического сброса журнала")
+				}
+			case <-snapshotTick:
+				if err := s.Snapshot(); err != nil {
+					s.logger.WithError(err).Error("Ошибка записи периодического снимка")
+				}
+			}
+		}
+	}()
+}
+
+// writeFileAtomic пишет файл через временный и rename, чтобы при сбое остался прежний снимок
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла снимка: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка записи снимка: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка сброса снимка на диск: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("ошибка закрытия файла снимка: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("ошибка замены снимка: %w", err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
@@ -0,0 +1,43 @@
+package inmemory
+
+import (
+	"context"
+	"ozon-posts/internal/services"
+	"sync"
+)
+
+// UnitOfWork выполняет составные операции над in-memory хранилищем под одной блокировкой,
+// поэтому проверка состояния и последующая запись не перемежаются с другими такими операциями.
+// Изменения при ошибке не откатываются: сервисы выполняют все чтения и проверки до первой записи,
+// а дальше только пишут, и записи могут завершиться ошибкой лишь при сбое журнала на диске.
+type UnitOfWork struct {
+	repos services.Repositories
+	mutex sync.Mutex
+}
+
+func NewUnitOfWork(
+	userRepo services.UserRepository,
+	postRepo services.PostRepository,
+	commentRepo services.CommentRepository,
+	reactionRepo services.ReactionRepository,
+	notificationRepo services.NotificationRepository,
+	reportRepo services.ReportRepository,
+) services.UnitOfWork {
+	return &UnitOfWork{
+		repos: services.Repositories{
+			Users:         userRepo,
+			Posts:         postRepo,
+			Comments:      commentRepo,
+			Reactions:     reactionRepo,
+			Notifications: notificationRepo,
+			Reports:       reportRepo,
+		},
+	}
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos services.Repositories) error) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	return fn(ctx, u.repos)
+}
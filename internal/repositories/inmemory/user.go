@@ -11,12 +11,17 @@ import (
 )
 
 type UserRepository struct {
-	users  map[uuid.UUID]*entities.User
-	mu     sync.RWMutex
-	logger *logrus.Logger
+	users   map[uuid.UUID]*entities.User
+	mu      sync.RWMutex
+	journal *journal
+	logger  *logrus.Logger
 }
 
 func NewUserRepository(logger *logrus.Logger) services.UserRepository {
+	return newUserRepository(logger)
+}
+
+func newUserRepository(logger *logrus.Logger) *UserRepository {
 	return &UserRepository{
 		users:  make(map[uuid.UUID]*entities.User),
 		logger: logger,
@@ -27,7 +32,11 @@ func (r *UserRepository) Create(ctx context.Context, user *entities.User) error
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.users[user.ID] = user
+	if err := r.journal.append(journalRecord{Op: opUserPut, User: newStoredUser(user)}); err != nil {
+		return err
+	}
+
+	r.putUser(user)
 	r.logger.WithField("user_id", user.ID).Debug("Пользователь создан в in-memory хранилище")
 	return nil
 }
@@ -78,7 +87,58 @@ func (r *UserRepository) Update(ctx context.Context, user *entities.User) error
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.users[user.ID] = user
+	existing, exists := r.users[user.ID]
+	if !exists {
+		return nil
+	}
+
+	// Роль и блокировка меняются только через SetRole и SetBanned, как и в PostgreSQL
+	user.Role = existing.Role
+	user.BannedAt = existing.BannedAt
+
+	if err := r.journal.append(journalRecord{Op: opUserPut, User: newStoredUser(user)}); err != nil {
+		return err
+	}
+
+	r.putUser(user)
+	return nil
+}
+
+func (r *UserRepository) SetRole(ctx context.Context, user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.users[user.ID]
+	if !exists {
+		return nil
+	}
+
+	updated := *existing
+	updated.Role = user.Role
+	updated.UpdatedAt = user.UpdatedAt
+	return r.saveUser(&updated)
+}
+
+func (r *UserRepository) SetBanned(ctx context.Context, user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.users[user.ID]
+	if !exists || existing.IsBanned() || user.BannedAt == nil {
+		return nil
+	}
+
+	updated := *existing
+	updated.Ban(*user.BannedAt)
+	return r.saveUser(&updated)
+}
+
+func (r *UserRepository) saveUser(user *entities.User) error {
+	if err := r.journal.append(journalRecord{Op: opUserPut, User: newStoredUser(user)}); err != nil {
+		return err
+	}
+
+	r.putUser(user)
 	return nil
 }
 
@@ -86,10 +146,18 @@ func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.journal.append(journalRecord{Op: opUserDelete, ID: id}); err != nil {
+		return err
+	}
+
 	delete(r.users, id)
 	return nil
 }
 
+func (r *UserRepository) putUser(user *entities.User) {
+	r.users[user.ID] = user
+}
+
 func (r *UserRepository) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"ozon-posts/internal/entities"
 	"ozon-posts/internal/services"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -12,8 +14,26 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// commentOrderBy - ORDER BY для каждого порядка выдачи; совпадает с entities.CommentSort.Less
+// и опирается на индексы из миграций 000008 и 000014
+var commentOrderBy = map[entities.CommentSort]string{
+	entities.CommentSortOldest:      "created_at ASC, id ASC",
+	entities.CommentSortNewest:      "created_at DESC, id DESC",
+	entities.CommentSortTop:         "reaction_count DESC, created_at ASC, id ASC",
+	entities.CommentSortMostReplied: "reply_count DESC, created_at ASC, id ASC",
+}
+
+// commentSortQuery подставляет порядок выдачи в запрос; неизвестный порядок заменяется OLDEST
+func commentSortQuery(query string, sort entities.CommentSort) string {
+	orderBy, ok := commentOrderBy[sort]
+	if !ok {
+		orderBy = commentOrderBy[entities.CommentSortOldest]
+	}
+	return fmt.Sprintf(query, orderBy)
+}
+
 type CommentRepository struct {
-	db     *sqlx.DB
+	db     queryer
 	logger *logrus.Logger
 }
 
@@ -46,8 +66,20 @@ func (r *CommentRepository) Create(ctx context.Context, comment *entities.Commen
 }
 
 func (r *CommentRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
+	return r.getByID(ctx, CommentSelectByIDQuery, id)
+}
+
+func (r *CommentRepository) GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
+	return r.getByID(ctx, CommentSelectByIDForShareQuery, id)
+}
+
+func (r *CommentRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
+	return r.getByID(ctx, CommentSelectByIDForUpdateQuery, id)
+}
+
+func (r *CommentRepository) getByID(ctx context.Context, query string, id uuid.UUID) (*entities.Comment, error) {
 	var comment entities.Comment
-	err := r.db.GetContext(ctx, &comment, CommentSelectByIDQuery, id)
+	err := r.db.GetContext(ctx, &comment, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -64,6 +96,9 @@ func (r *CommentRepository) Update(ctx context.Context, comment *entities.Commen
 		comment.ID,
 		comment.Content,
 		comment.UpdatedAt,
+		comment.EditCount,
+		comment.EditedAt,
+		comment.EditedBy,
 	)
 
 	if err != nil {
@@ -84,10 +119,16 @@ func (r *CommentRepository) Update(ctx context.Context, comment *entities.Commen
 	return nil
 }
 
-func (r *CommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	result, err := r.db.ExecContext(ctx, CommentDeleteQuery, id)
+func (r *CommentRepository) SoftDelete(ctx context.Context, comment *entities.Comment) error {
+	result, err := r.db.ExecContext(ctx, CommentSoftDeleteQuery,
+		comment.ID,
+		comment.Content,
+		comment.DeletedAt,
+		comment.DeletedBy,
+		comment.UpdatedAt,
+	)
 	if err != nil {
-		r.logger.WithError(err).WithField("comment_id", id).Error("Ошибка удаления комментария")
+		r.logger.WithError(err).WithField("comment_id", comment.ID).Error("Ошибка удаления комментария")
 		return err
 	}
 
@@ -98,13 +139,33 @@ func (r *CommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		r.logger.WithField("comment_id", id).Warn("Комментарий для удаления не найден")
+		r.logger.WithField("comment_id", comment.ID).Warn("Комментарий для удаления не найден или уже удален")
 	}
 
 	return nil
 }
 
-func (r *CommentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+func (r *CommentRepository) Purge(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, CommentPurgeQuery, id)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", id).Error("Ошибка полной очистки ветки комментария")
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения количества удаленных строк")
+		return 0, err
+	}
+
+	if rowsAffected == 0 {
+		r.logger.WithField("comment_id", id).Warn("Комментарий для очистки не найден")
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *CommentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
 	var total int64
 	err := r.db.GetContext(ctx, &total, CommentCountByPostQuery, postID)
 	if err != nil {
@@ -113,7 +174,7 @@ func (r *CommentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, p
 	}
 
 	var comments []*entities.Comment
-	err = r.db.SelectContext(ctx, &comments, CommentSelectByPostQuery, postID, pagination.Limit, pagination.Offset)
+	err = r.db.SelectContext(ctx, &comments, commentSortQuery(CommentSelectByPostQuery, sort), postID, pagination.Limit, pagination.Offset)
 	if err != nil {
 		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения комментариев поста")
 		return nil, nil, err
@@ -135,7 +196,7 @@ func (r *CommentRepository) CountByPostID(ctx context.Context, postID uuid.UUID)
 	return total, nil
 }
 
-func (r *CommentRepository) GetByParentID(ctx context.Context, parentID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+func (r *CommentRepository) GetByParentID(ctx context.Context, parentID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
 	var total int64
 	err := r.db.GetContext(ctx, &total, CommentCountByParentQuery, parentID)
 	if err != nil {
@@ -144,7 +205,7 @@ func (r *CommentRepository) GetByParentID(ctx context.Context, parentID uuid.UUI
 	}
 
 	var comments []*entities.Comment
-	err = r.db.SelectContext(ctx, &comments, CommentSelectByParentQuery, parentID, pagination.Limit, pagination.Offset)
+	err = r.db.SelectContext(ctx, &comments, commentSortQuery(CommentSelectByParentQuery, sort), parentID, pagination.Limit, pagination.Offset)
 	if err != nil {
 		r.logger.WithError(err).WithField("parent_id", parentID).Error("Ошибка получения дочерних комментариев")
 		return nil, nil, err
@@ -166,6 +227,48 @@ func (r *CommentRepository) CountByParentID(ctx context.Context, parentID uuid.U
 	return total, nil
 }
 
+func (r *CommentRepository) AddReplyCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	if _, err := r.db.ExecContext(ctx, CommentAddReplyCountQuery, id, delta); err != nil {
+		r.logger.WithError(err).WithField("comment_id", id).Error("Ошибка обновления счетчика ответов")
+		return err
+	}
+	return nil
+}
+
+func (r *CommentRepository) AddReactionCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	if _, err := r.db.ExecContext(ctx, CommentAddReactionCountQuery, id, delta); err != nil {
+		r.logger.WithError(err).WithField("comment_id", id).Error("Ошибка обновления счетчика реакций")
+		return err
+	}
+	return nil
+}
+
+func (r *CommentRepository) GetByPostIDKeyset(ctx context.Context, postID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	var comments []*entities.Comment
+	query := keysetQuery(req, CommentSelectByPostKeysetQuery, CommentSelectByPostKeysetBackwardQuery)
+	err := r.db.SelectContext(ctx, &comments, query, keysetArgs(req, postID)...)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения страницы комментариев поста")
+		return nil, nil, err
+	}
+
+	comments, pageInfo := entities.NewCursorPage(comments, req)
+	return comments, pageInfo, nil
+}
+
+func (r *CommentRepository) GetByParentIDKeyset(ctx context.Context, parentID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	var comments []*entities.Comment
+	query := keysetQuery(req, CommentSelectByParentKeysetQuery, CommentSelectByParentKeysetBackwardQuery)
+	err := r.db.SelectContext(ctx, &comments, query, keysetArgs(req, parentID)...)
+	if err != nil {
+		r.logger.WithError(err).WithField("parent_id", parentID).Error("Ошибка получения страницы дочерних комментариев")
+		return nil, nil, err
+	}
+
+	comments, pageInfo := entities.NewCursorPage(comments, req)
+	return comments, pageInfo, nil
+}
+
 func (r *CommentRepository) GetThread(ctx context.Context, commentID uuid.UUID, maxDepth int) ([]*entities.Comment, error) {
 	startComment, err := r.GetByID(ctx, commentID)
 	if err != nil {
@@ -188,6 +291,82 @@ func (r *CommentRepository) GetThread(ctx context.Context, commentID uuid.UUID,
 	return comments, nil
 }
 
+func (r *CommentRepository) GetChildrenByParentIDs(ctx context.Context, parentIDs []uuid.UUID, perParentLimit int) ([]*entities.Comment, error) {
+	if len(parentIDs) == 0 {
+		return []*entities.Comment{}, nil
+	}
+
+	var comments []*entities.Comment
+	err := r.db.SelectContext(ctx, &comments, CommentSelectChildrenByParentsQuery, pq.Array(parentIDs), perParentLimit)
+	if err != nil {
+		r.logger.WithError(err).WithField("parents_count", len(parentIDs)).Error("Ошибка получения ответов для списка комментариев")
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepository) GetTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID, perPostLimit int) ([]*entities.Comment, error) {
+	if len(postIDs) == 0 {
+		return []*entities.Comment{}, nil
+	}
+
+	var comments []*entities.Comment
+	err := r.db.SelectContext(ctx, &comments, CommentSelectTopLevelByPostsQuery, pq.Array(postIDs), perPostLimit)
+	if err != nil {
+		r.logger.WithError(err).WithField("posts_count", len(postIDs)).Error("Ошибка получения комментариев для списка постов")
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepository) CountTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID uuid.UUID `db:"post_id"`
+		Count  int64     `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, CommentCountTopLevelByPostsQuery, pq.Array(postIDs))
+	if err != nil {
+		r.logger.WithError(err).WithField("posts_count", len(postIDs)).Error("Ошибка подсчета комментариев для списка постов")
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *CommentRepository) CountByParentIDs(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID uuid.UUID `db:"parent_id"`
+		Count    int64     `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, CommentCountByParentsQuery, pq.Array(parentIDs))
+	if err != nil {
+		r.logger.WithError(err).WithField("parents_count", len(parentIDs)).Error("Ошибка подсчета ответов для списка комментариев")
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+
+	return counts, nil
+}
+
 func (r *CommentRepository) GetByPath(ctx context.Context, pathPrefix string, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
 	var total int64
 	err := r.db.GetContext(ctx, &total, CommentCountByPathQuery, pathPrefix+"%")
@@ -233,3 +412,107 @@ func (r *CommentRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*e
 
 	return comments, nil
 }
+
+func (r *CommentRepository) CreateRevision(ctx context.Context, revision *entities.CommentRevision) error {
+	_, err := r.db.ExecContext(ctx, CommentRevisionInsertQuery,
+		revision.ID,
+		revision.CommentID,
+		revision.Version,
+		revision.Content,
+		revision.EditorID,
+		revision.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", revision.CommentID).Error("Ошибка сохранения версии комментария")
+		return err
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) GetRevisions(ctx context.Context, commentID uuid.UUID) ([]*entities.CommentRevision, error) {
+	revisions := []*entities.CommentRevision{}
+	err := r.db.SelectContext(ctx, &revisions, CommentRevisionSelectByCommentQuery, commentID)
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка получения истории правок комментария")
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (r *CommentRepository) ReplaceMentions(ctx context.Context, commentID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if _, err := r.db.ExecContext(ctx, CommentMentionDeleteExceptQuery, commentID, pq.Array(userIDs)); err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка удаления упоминаний комментария")
+		return nil, err
+	}
+
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var added []uuid.UUID
+	err := r.db.SelectContext(ctx, &added, CommentMentionInsertQuery, commentID, pq.Array(userIDs), time.Now())
+	if err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Ошибка сохранения упоминаний комментария")
+		return nil, err
+	}
+
+	return added, nil
+}
+
+func (r *CommentRepository) GetMentionsByCommentIDs(ctx context.Context, commentIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	result := make(map[uuid.UUID][]uuid.UUID, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return result, nil
+	}
+
+	var mentions []*entities.CommentMention
+	err := r.db.SelectContext(ctx, &mentions, CommentMentionSelectByCommentsQuery, pq.Array(commentIDs))
+	if err != nil {
+		r.logger.WithError(err).WithField("comments_count", len(commentIDs)).Error("Ошибка получения упоминаний комментариев")
+		return nil, err
+	}
+
+	for _, mention := range mentions {
+		result[mention.CommentID] = append(result[mention.CommentID], mention.UserID)
+	}
+
+	return result, nil
+}
+
+// commentSearchRow - строка результата поиска: колонки комментария плюс релевантность и фрагмент
+type commentSearchRow struct {
+	entities.Comment
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
+}
+
+func (r *CommentRepository) Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.CommentSearchResult, *entities.PaginationResponse, error) {
+	var total int64
+	err := r.db.GetContext(ctx, &total, CommentSearchCountQuery, query.Text, query.PostID)
+	if err != nil {
+		r.logger.WithError(err).WithField("query", query.Text).Error("Ошибка подсчета результатов поиска комментариев")
+		return nil, nil, err
+	}
+
+	var rows []*commentSearchRow
+	err = r.db.SelectContext(ctx, &rows, CommentSearchQuery, query.Text, query.PostID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		r.logger.WithError(err).WithField("query", query.Text).Error("Ошибка поиска комментариев")
+		return nil, nil, err
+	}
+
+	results := make([]*entities.CommentSearchResult, 0, len(rows))
+	for _, row := range rows {
+		comment := row.Comment
+		results = append(results, &entities.CommentSearchResult{
+			Comment: &comment,
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+
+	return results, entities.NewPaginationResponse(total, pagination.Limit, pagination.Offset), nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ozon-posts/internal/services"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// CounterReconciler пересчитывает денормализованные счетчики одной транзакцией REPEATABLE READ:
+// все счетчики сверяются с одним снимком данных. Если параллельная транзакция изменила ту же строку,
+// пересчет завершается ошибкой сериализации и повторяется при следующем запуске.
+type CounterReconciler struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+func NewCounterReconciler(db *sqlx.DB, logger *logrus.Logger) services.CounterReconciler {
+	return &CounterReconciler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *CounterReconciler) ReconcileCounters(ctx context.Context) (*services.CounterReport, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			r.logger.WithError(rollbackErr).Error("Ошибка отката транзакции")
+		}
+	}()
+
+	report := &services.CounterReport{}
+	steps := []struct {
+		query  string
+		target *int64
+	}{
+		{PostReconcileCommentCountQuery, &report.PostComments},
+		{CommentReconcileReplyCountQuery, &report.CommentReplies},
+		{CommentReconcileReactionCountQuery, &report.CommentReactions},
+	}
+
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.query)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка пересчета счетчиков: %w", err)
+		}
+
+		if *step.target, err = result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("ошибка получения количества исправленных счетчиков: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	committed = true
+
+	return report, nil
+}
@@ -0,0 +1,363 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"ozon-posts/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CommentEventsChannel = "comment_events"
+	UserEventsChannel    = "user_events"
+
+	// Postgres ограничивает payload NOTIFY 8000 байтами
+	maxNotifyPayload = 7900
+
+	listenerPingInterval = 90 * time.Second
+	notifyTimeout        = 5 * time.Second
+)
+
+type commentEventPayload struct {
+	Sequence int64                     `json:"sequence"`
+	Type     services.CommentEventType `json:"type"`
+	PostID   uuid.UUID                 `json:"post_id"`
+	Comment  *entities.Comment         `json:"comment,omitempty"`
+	Reaction *services.ReactionEvent   `json:"reaction,omitempty"`
+	// Truncated означает, что текст комментария не поместился в payload и должен быть перечитан из БД
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type userEventPayload struct {
+	Origin       uuid.UUID              `json:"origin"`
+	Type         services.UserEventType `json:"type"`
+	UserID       uuid.UUID              `json:"user_id"`
+	Comment      *entities.Comment      `json:"comment,omitempty"`
+	Notification *entities.Notification `json:"notification,omitempty"`
+	Truncated    bool                   `json:"truncated,omitempty"`
+}
+
+// EventBus доставляет события комментариев между экземплярами приложения через LISTEN/NOTIFY.
+// Номера событий выдаются таблицей comment_event_sequences, поэтому они общие для всех экземпляров
+// и клиент может возобновить подписку на любом из них. Все события поста, включая собственные,
+// экземпляр получает из канала comment_events: номер выдается и NOTIFY отправляется в одной
+// транзакции, блокировка строки номера упорядочивает фиксации, а PostgreSQL доставляет
+// уведомления в порядке фиксации, поэтому подписчики видят события в порядке номеров.
+// События пользователей идут через канал user_events без номеров и журнала.
+type EventBus struct {
+	db          *sqlx.DB
+	listener    *pq.Listener
+	local       *services.InProcessEventBus
+	commentRepo services.CommentRepository
+	instanceID  uuid.UUID
+	logger      *logrus.Logger
+	done        chan struct{}
+}
+
+func NewEventBus(
+	db *sqlx.DB,
+	dsn string,
+	commentRepo services.CommentRepository,
+	opts services.EventBusOptions,
+	logger *logrus.Logger,
+) (*EventBus, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.WithError(err).Warn("Ошибка соединения LISTEN с PostgreSQL")
+		}
+	})
+
+	for _, channel := range []string{CommentEventsChannel, UserEventsChannel} {
+		if err := listener.Listen(channel); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("ошибка подписки на канал %s: %w", channel, err)
+		}
+	}
+
+	bus := &EventBus{
+		db:          db,
+		listener:    listener,
+		local:       services.NewInProcessEventBus(opts, logger),
+		commentRepo: commentRepo,
+		instanceID:  uuid.New(),
+		logger:      logger,
+		done:        make(chan struct{}),
+	}
+
+	go bus.listen()
+
+	logger.WithField("instance_id", bus.instanceID).Info("Шина событий PostgreSQL запущена")
+	return bus, nil
+}
+
+func (b *EventBus) Subscribe(postID uuid.UUID, afterSequence *int64) (*services.Subscription, error) {
+	if afterSequence != nil {
+		// Экземпляр мог не видеть последние события поста (например, только что запущен),
+		// поэтому актуальный номер берется из БД: без этого пропуск событий не обнаружить
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+
+		var current int64
+		if err := b.db.GetContext(ctx, &current, CommentEventSequenceCurrentQuery, postID); err != nil {
+			b.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения номера события поста")
+			return nil, errors.NewDatabaseError(err)
+		}
+		b.local.Advance(postID, current)
+	}
+
+	return b.local.Subscribe(postID, afterSequence)
+}
+
+func (b *EventBus) Unsubscribe(sub *services.Subscription) {
+	b.local.Unsubscribe(sub)
+}
+
+// Stats возвращает состояние локальных подписок этого экземпляра
+func (b *EventBus) Stats() services.EventBusStats {
+	return b.local.Stats()
+}
+
+// Publish выдает событию номер и отправляет его в канал comment_events. Локальные подписчики
+// получают событие из канала вместе с остальными: прямая доставка могла бы обогнать событие
+// с меньшим номером, еще не дошедшее от другого экземпляра или параллельного Publish.
+func (b *EventBus) Publish(postID uuid.UUID, event *services.CommentEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	logger := b.logger.WithFields(logrus.Fields{
+		"post_id":    postID,
+		"event_type": event.Type,
+	})
+
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("Ошибка начала транзакции события, событие отброшено")
+		return
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			b.logger.WithError(rollbackErr).Error("Ошибка отката транзакции события")
+		}
+	}()
+
+	// Без номера из БД событие отбрасывается: локальный номер разошелся бы с другими экземплярами,
+	// а клиент, пропустивший событие, восстановит состояние запросом после EVENT_REPLAY_UNAVAILABLE.
+	// Строка номера остается заблокированной до фиксации, поэтому следующее событие поста
+	// будет зафиксировано и доставлено только после этого.
+	if err := tx.GetContext(ctx, &event.Sequence, CommentEventSequenceNextQuery, postID); err != nil {
+		logger.WithError(err).Error("Ошибка получения номера события, событие отброшено")
+		return
+	}
+
+	payload, err := b.encode(event)
+	if err != nil {
+		logger.WithError(err).Error("Ошибка сериализации события комментария")
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, EventNotifyQuery, CommentEventsChannel, payload); err != nil {
+		logger.WithError(err).Error("Ошибка отправки NOTIFY события комментария")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.WithError(err).Error("Ошибка фиксации события комментария")
+		return
+	}
+	committed = true
+}
+
+func (b *EventBus) SubscribeUser(userID uuid.UUID) *services.UserSubscription {
+	return b.local.SubscribeUser(userID)
+}
+
+func (b *EventBus) UnsubscribeUser(sub *services.UserSubscription) {
+	b.local.UnsubscribeUser(sub)
+}
+
+// PublishUserEvent доставляет событие локальным подписчикам и отправляет его остальным экземплярам
+func (b *EventBus) PublishUserEvent(event *services.UserEvent) {
+	b.local.PublishUserEvent(event)
+
+	payload := userEventPayload{
+		Origin:       b.instanceID,
+		Type:         event.Type,
+		UserID:       event.UserID,
+		Comment:      notifiedComment(event.Comment),
+		Notification: event.Notification,
+	}
+
+	data, err := json.Marshal(payload)
+	if err == nil && len(data) > maxNotifyPayload && payload.Comment != nil {
+		payload.Comment.Content = ""
+		payload.Truncated = true
+		data, err = json.Marshal(payload)
+	}
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", event.UserID).Error("Ошибка сериализации события пользователя")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if _, err := b.db.ExecContext(ctx, EventNotifyQuery, UserEventsChannel, string(data)); err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":    event.UserID,
+			"event_type": event.Type,
+		}).Error("Ошибка отправки NOTIFY события пользователя")
+	}
+}
+
+func (b *EventBus) Close() error {
+	close(b.done)
+	return b.listener.Close()
+}
+
+func (b *EventBus) listen() {
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+
+		case n, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			// nil приходит после переподключения: события, отправленные во время разрыва, потеряны
+			if n == nil {
+				b.logger.Warn("Соединение LISTEN восстановлено, часть событий могла быть пропущена")
+				continue
+			}
+			if n.Channel == UserEventsChannel {
+				b.handleUserNotification(n.Extra)
+			} else {
+				b.handleNotification(n.Extra)
+			}
+
+		case <-ticker.C:
+			go func() {
+				if err := b.listener.Ping(); err != nil {
+					b.logger.WithError(err).Warn("Ошибка проверки соединения LISTEN")
+				}
+			}()
+		}
+	}
+}
+
+func (b *EventBus) handleNotification(raw string) {
+	var payload commentEventPayload
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		b.logger.WithError(err).Error("Ошибка разбора NOTIFY события комментария")
+		return
+	}
+
+	if payload.Truncated && payload.Comment != nil {
+		payload.Comment = b.reloadComment(payload.Comment)
+	}
+
+	b.local.Deliver(payload.PostID, &services.CommentEvent{
+		Sequence: payload.Sequence,
+		Type:     payload.Type,
+		PostID:   payload.PostID,
+		Comment:  payload.Comment,
+		Reaction: payload.Reaction,
+	})
+}
+
+func (b *EventBus) handleUserNotification(raw string) {
+	var payload userEventPayload
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		b.logger.WithError(err).Error("Ошибка разбора NOTIFY события пользователя")
+		return
+	}
+
+	if payload.Origin == b.instanceID {
+		return
+	}
+
+	if payload.Truncated && payload.Comment != nil {
+		payload.Comment = b.reloadComment(payload.Comment)
+	}
+
+	b.local.PublishUserEvent(&services.UserEvent{
+		Type:         payload.Type,
+		UserID:       payload.UserID,
+		Comment:      payload.Comment,
+		Notification: payload.Notification,
+	})
+}
+
+// reloadComment перечитывает комментарий, текст которого не поместился в payload.
+// При ошибке возвращается комментарий из payload без текста.
+func (b *EventBus) reloadComment(truncated *entities.Comment) *entities.Comment {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	comment, err := b.commentRepo.GetByID(ctx, truncated.ID)
+	if err != nil {
+		b.logger.WithError(err).WithField("comment_id", truncated.ID).Error("Ошибка перечитывания комментария для события")
+		return truncated
+	}
+	if comment == nil {
+		return truncated
+	}
+	return comment
+}
+
+func (b *EventBus) encode(event *services.CommentEvent) (string, error) {
+	payload := commentEventPayload{
+		Sequence: event.Sequence,
+		Type:     event.Type,
+		PostID:   event.PostID,
+		Reaction: event.Reaction,
+	}
+
+	payload.Comment = notifiedComment(event.Comment)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > maxNotifyPayload && payload.Comment != nil {
+		payload.Comment.Content = ""
+		payload.Truncated = true
+		if data, err = json.Marshal(payload); err != nil {
+			return "", err
+		}
+	}
+
+	return string(data), nil
+}
+
+// notifiedComment копирует комментарий для payload без связанных сущностей: они раздувают payload
+// и загружаются получателем при необходимости
+func notifiedComment(comment *entities.Comment) *entities.Comment {
+	if comment == nil {
+		return nil
+	}
+
+	copied := *comment
+	copied.Author = nil
+	copied.Post = nil
+	copied.Parent = nil
+	copied.Children = nil
+	return &copied
+}
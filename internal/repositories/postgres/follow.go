@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type FollowRepository struct {
+	db     queryer
+	logger *logrus.Logger
+}
+
+func NewFollowRepository(db *sqlx.DB, logger *logrus.Logger) services.FollowRepository {
+	return &FollowRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *FollowRepository) Create(ctx context.Context, follow *entities.Follow) (bool, error) {
+	result, err := r.db.ExecContext(ctx, FollowInsertQuery, follow.FollowerID, follow.FolloweeID, follow.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"follower_id": follow.FollowerID,
+			"followee_id": follow.FolloweeID,
+		}).Error("Ошибка сохранения подписки")
+		return false, err
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return created > 0, nil
+}
+
+func (r *FollowRepository) Delete(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, FollowDeleteQuery, followerID, followeeID)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"follower_id": followerID,
+			"followee_id": followeeID,
+		}).Error("Ошибка удаления подписки")
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func (r *FollowRepository) GetFollowersKeyset(ctx context.Context, followeeID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	var follows []*entities.Follow
+	query := keysetQuery(req, FollowSelectFollowersKeysetQuery, FollowSelectFollowersKeysetBackwardQuery)
+	err := r.db.SelectContext(ctx, &follows, query, keysetArgs(req, followeeID)...)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", followeeID).Error("Ошибка получения страницы подписчиков")
+		return nil, nil, err
+	}
+
+	follows, pageInfo := entities.NewCursorPage(follows, req)
+	return follows, pageInfo, nil
+}
+
+func (r *FollowRepository) GetFollowingKeyset(ctx context.Context, followerID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	var follows []*entities.Follow
+	query := keysetQuery(req, FollowSelectFollowingKeysetQuery, FollowSelectFollowingKeysetBackwardQuery)
+	err := r.db.SelectContext(ctx, &follows, query, keysetArgs(req, followerID)...)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", followerID).Error("Ошибка получения страницы подписок")
+		return nil, nil, err
+	}
+
+	follows, pageInfo := entities.NewCursorPage(follows, req)
+	return follows, pageInfo, nil
+}
+
+func (r *FollowRepository) GetFolloweeIDs(ctx context.Context, followerID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := r.db.SelectContext(ctx, &ids, FollowSelectFolloweeIDsQuery, followerID); err != nil {
+		r.logger.WithError(err).WithField("user_id", followerID).Error("Ошибка получения подписок пользователя")
+		return nil, err
+	}
+
+	return ids, nil
+}
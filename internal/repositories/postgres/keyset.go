@@ -0,0 +1,26 @@
+package postgres
+
+import "ozon-posts/internal/entities"
+
+// keysetQuery выбирает вариант запроса в зависимости от направления чтения страницы
+func keysetQuery(req *entities.CursorRequest, forward, backward string) string {
+	if req.FromEnd {
+		return backward
+	}
+	return forward
+}
+
+// keysetArgs формирует параметры $1..$5 keyset-запросов; дополнительные параметры идут следом
+func keysetArgs(req *entities.CursorRequest, extra ...interface{}) []interface{} {
+	args := make([]interface{}, 4, 5+len(extra))
+	if req.After != nil {
+		args[0], args[1] = req.After.CreatedAt, req.After.ID
+	}
+	if req.Before != nil {
+		args[2], args[3] = req.Before.CreatedAt, req.Before.ID
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать о наличии следующей страницы
+	args = append(args, req.Limit+1)
+	return append(args, extra...)
+}
@@ -0,0 +1,382 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// migrationLockKey - ключ advisory-блокировки, чтобы реплики не применяли миграции одновременно
+	migrationLockKey = 7301204115
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration - версия схемы с SQL для применения и отката
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%06d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus - состояние схемы: текущая версия (0 - миграции не применялись) и ожидающие миграции.
+// Dirty означает, что внешняя утилита прервалась посреди миграции и схему нужно проверить вручную.
+type MigrationStatus struct {
+	Version int64
+	Dirty   bool
+	Pending []Migration
+}
+
+// MigrateOptions - параметры up/down. Steps ограничивает число миграций (0 - все),
+// DryRun только перечисляет миграции, не изменяя схему.
+type MigrateOptions struct {
+	Steps  int
+	DryRun bool
+}
+
+// LoadMigrations читает пары файлов NNNNNN_name.up.sql / NNNNNN_name.down.sql и сортирует их по версии
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения списка миграций: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		match := migrationFileName.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("некорректное имя файла миграции: %s", file)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("некорректная версия миграции: %s", file)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения миграции %s: %w", file, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("у версии %d несколько миграций: %s и %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("у миграции %s нет пары up/down", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator применяет и откатывает встроенные миграции. Каждая миграция выполняется в своей
+// транзакции вместе с записью новой версии, поэтому ошибка не оставляет схему наполовину измененной.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+	logger     *logrus.Logger
+}
+
+func NewMigrator(db *sqlx.DB, fsys fs.FS, logger *logrus.Logger) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
+
+// Status возвращает текущую версию схемы и список ожидающих миграций
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	return m.status(ctx, m.db)
+}
+
+// EnsureUpToDate возвращает ошибку, если схема не соответствует встроенным миграциям
+func (m *Migrator) EnsureUpToDate(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty {
+		return dirtyError(status.Version)
+	}
+
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("схема базы данных устарела: версия %d, не применено миграций: %d", status.Version, len(status.Pending))
+	}
+
+	return nil
+}
+
+// Up применяет ожидающие миграции по возрастанию версии и возвращает примененные
+func (m *Migrator) Up(ctx context.Context, opts MigrateOptions) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if status.Dirty {
+			return dirtyError(status.Version)
+		}
+
+		pending := status.Pending
+		if opts.Steps > 0 && opts.Steps < len(pending) {
+			pending = pending[:opts.Steps]
+		}
+
+		if len(pending) == 0 {
+			m.logger.WithField("version", status.Version).Info("Схема базы данных актуальна")
+			return nil
+		}
+
+		for _, migration := range pending {
+			if opts.DryRun {
+				m.logger.WithField("migration", migration.String()).Info("Будет применена миграция")
+				applied = append(applied, migration)
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("ошибка применения миграции %s: %w", migration, err)
+			}
+
+			m.logger.WithField("migration", migration.String()).Info("Миграция применена")
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down откатывает примененные миграции по убыванию версии и возвращает откаченные
+func (m *Migrator) Down(ctx context.Context, opts MigrateOptions) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if status.Dirty {
+			return dirtyError(status.Version)
+		}
+
+		appliedCount := len(m.migrations) - len(status.Pending)
+		if status.Version > 0 && (appliedCount == 0 || m.migrations[appliedCount-1].Version != status.Version) {
+			return fmt.Errorf("версия схемы %d не найдена среди встроенных миграций", status.Version)
+		}
+
+		steps := appliedCount
+		if opts.Steps > 0 && opts.Steps < steps {
+			steps = opts.Steps
+		}
+
+		if steps == 0 {
+			m.logger.Info("Нет миграций для отката")
+			return nil
+		}
+
+		for i := appliedCount - 1; i >= appliedCount-steps; i-- {
+			migration := m.migrations[i]
+
+			if opts.DryRun {
+				m.logger.WithField("migration", migration.String()).Info("Будет откачена миграция")
+				reverted = append(reverted, migration)
+				continue
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err := m.apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("ошибка отката миграции %s: %w", migration, err)
+			}
+
+			m.logger.WithField("migration", migration.String()).Info("Миграция откачена")
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force записывает версию схемы без выполнения миграций и снимает признак dirty.
+// Используется после ручного исправления схемы.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("ошибка начала транзакции: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := ensureMigrationsTable(ctx, tx); err != nil {
+			return err
+		}
+
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+		}
+
+		m.logger.WithField("version", version).Warn("Версия схемы установлена принудительно")
+		return nil
+	})
+}
+
+// apply выполняет SQL миграции и записывает новую версию в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, query string, version int64) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ensureMigrationsTable(ctx, tx); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
+
+// withLock выполняет fn на отдельном соединении под advisory-блокировкой
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения соединения для миграций: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, MigrationLockQuery, migrationLockKey); err != nil {
+		return fmt.Errorf("ошибка получения блокировки миграций: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), MigrationUnlockQuery, migrationLockKey); err != nil {
+			m.logger.WithError(err).Error("Ошибка снятия блокировки миграций")
+		}
+	}()
+
+	return fn(conn)
+}
+
+func (m *Migrator) status(ctx context.Context, q queryer) (*MigrationStatus, error) {
+	status := &MigrationStatus{}
+
+	var exists bool
+	if err := q.GetContext(ctx, &exists, MigrationTableExistsQuery); err != nil {
+		return nil, fmt.Errorf("ошибка проверки таблицы версий: %w", err)
+	}
+
+	if exists {
+		var row struct {
+			Version int64 `db:"version"`
+			Dirty   bool  `db:"dirty"`
+		}
+		err := q.GetContext(ctx, &row, MigrationSelectVersionQuery)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+		}
+		status.Version = row.Version
+		status.Dirty = row.Dirty
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > status.Version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+
+	return status, nil
+}
+
+func ensureMigrationsTable(ctx context.Context, q queryer) error {
+	_, err := q.ExecContext(ctx, MigrationCreateTableQuery)
+	if err != nil {
+		return fmt.Errorf("ошибка создания таблицы версий: %w", err)
+	}
+	return nil
+}
+
+// setVersion хранит одну строку с текущей версией; версия 0 означает пустую схему
+func setVersion(ctx context.Context, q queryer, version int64) error {
+	if _, err := q.ExecContext(ctx, MigrationDeleteVersionQuery); err != nil {
+		return fmt.Errorf("ошибка записи версии схемы: %w", err)
+	}
+
+	if version == 0 {
+		return nil
+	}
+
+	if _, err := q.ExecContext(ctx, MigrationInsertVersionQuery, version); err != nil {
+		return fmt.Errorf("ошибка записи версии схемы: %w", err)
+	}
+
+	return nil
+}
+
+func dirtyError(version int64) error {
+	return fmt.Errorf("схема базы данных в состоянии dirty на версии %d: проверьте ее вручную и выполните migrate force", version)
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationRepository struct {
+	db     queryer
+	logger *logrus.Logger
+}
+
+func NewNotificationRepository(db *sqlx.DB, logger *logrus.Logger) services.NotificationRepository {
+	return &NotificationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, notifications []*entities.Notification) error {
+	for _, notification := range notifications {
+		_, err := r.db.ExecContext(ctx, NotificationInsertQuery,
+			notification.ID,
+			notification.UserID,
+			notification.Type,
+			notification.ActorID,
+			notification.PostID,
+			notification.CommentID,
+			notification.ReadAt,
+			notification.CreatedAt,
+		)
+		if err != nil {
+			r.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":    notification.UserID,
+				"comment_id": notification.CommentID,
+			}).Error("Ошибка сохранения оповещения")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) GetByUserIDKeyset(ctx context.Context, userID uuid.UUID, unreadOnly bool, req *entities.CursorRequest) ([]*entities.Notification, *entities.PageInfo, error) {
+	query := keysetQuery(req, NotificationSelectByUserKeysetQuery, NotificationSelectByUserKeysetBackwardQuery)
+	if unreadOnly {
+		query = keysetQuery(req, NotificationSelectUnreadByUserKeysetQuery, NotificationSelectUnreadByUserKeysetBackwardQuery)
+	}
+
+	var notifications []*entities.Notification
+	err := r.db.SelectContext(ctx, &notifications, query, keysetArgs(req, userID)...)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка получения страницы оповещений")
+		return nil, nil, err
+	}
+
+	notifications, pageInfo := entities.NewCursorPage(notifications, req)
+	return notifications, pageInfo, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, readAt time.Time) (int64, error) {
+	var (
+		result sql.Result
+		err    error
+	)
+	if len(ids) == 0 {
+		result, err = r.db.ExecContext(ctx, NotificationMarkAllReadQuery, userID, readAt)
+	} else {
+		result, err = r.db.ExecContext(ctx, NotificationMarkReadQuery, userID, pq.Array(ids), readAt)
+	}
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Ошибка отметки оповещений прочитанными")
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
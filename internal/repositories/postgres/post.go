@@ -13,7 +13,7 @@ import (
 )
 
 type PostRepository struct {
-	db     *sqlx.DB
+	db     queryer
 	logger *logrus.Logger
 }
 
@@ -44,8 +44,20 @@ func (r *PostRepository) Create(ctx context.Context, post *entities.Post) error
 }
 
 func (r *PostRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	return r.getByID(ctx, PostSelectByIDQuery, id)
+}
+
+func (r *PostRepository) GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	return r.getByID(ctx, PostSelectByIDForShareQuery, id)
+}
+
+func (r *PostRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	return r.getByID(ctx, PostSelectByIDForUpdateQuery, id)
+}
+
+func (r *PostRepository) getByID(ctx context.Context, query string, id uuid.UUID) (*entities.Post, error) {
 	var post entities.Post
-	err := r.db.GetContext(ctx, &post, PostSelectByIDQuery, id)
+	err := r.db.GetContext(ctx, &post, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -64,6 +76,9 @@ func (r *PostRepository) Update(ctx context.Context, post *entities.Post) error
 		post.Content,
 		post.CommentsDisabled,
 		post.UpdatedAt,
+		post.EditCount,
+		post.EditedAt,
+		post.EditedBy,
 	)
 
 	if err != nil {
@@ -84,6 +99,14 @@ func (r *PostRepository) Update(ctx context.Context, post *entities.Post) error
 	return nil
 }
 
+func (r *PostRepository) SetHidden(ctx context.Context, post *entities.Post) error {
+	if _, err := r.db.ExecContext(ctx, PostSetHiddenQuery, post.ID, post.HiddenAt, post.HiddenBy, post.UpdatedAt); err != nil {
+		r.logger.WithError(err).WithField("post_id", post.ID).Error("Ошибка изменения отметки скрытия поста")
+		return err
+	}
+	return nil
+}
+
 func (r *PostRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	result, err := r.db.ExecContext(ctx, PostDeleteQuery, id)
 	if err != nil {
@@ -104,6 +127,14 @@ func (r *PostRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+func (r *PostRepository) AddCommentCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	if _, err := r.db.ExecContext(ctx, PostAddCommentCountQuery, id, delta); err != nil {
+		r.logger.WithError(err).WithField("post_id", id).Error("Ошибка обновления счетчика комментариев поста")
+		return err
+	}
+	return nil
+}
+
 func (r *PostRepository) GetAll(ctx context.Context, pagination *entities.PaginationRequest) ([]*entities.Post, *entities.PaginationResponse, error) {
 	var total int64
 	err := r.db.GetContext(ctx, &total, PostCountAllQuery)
@@ -144,6 +175,49 @@ func (r *PostRepository) GetByAuthorID(ctx context.Context, authorID uuid.UUID,
 	return posts, paginationResponse, nil
 }
 
+func (r *PostRepository) GetAllKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	var posts []*entities.Post
+	query := keysetQuery(req, PostSelectAllKeysetQuery, PostSelectAllKeysetBackwardQuery)
+	err := r.db.SelectContext(ctx, &posts, query, keysetArgs(req)...)
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы постов")
+		return nil, nil, err
+	}
+
+	posts, pageInfo := entities.NewCursorPage(posts, req)
+	return posts, pageInfo, nil
+}
+
+func (r *PostRepository) GetByAuthorIDKeyset(ctx context.Context, authorID uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	var posts []*entities.Post
+	query := keysetQuery(req, PostSelectByAuthorKeysetQuery, PostSelectByAuthorKeysetBackwardQuery)
+	err := r.db.SelectContext(ctx, &posts, query, keysetArgs(req, authorID)...)
+	if err != nil {
+		r.logger.WithError(err).WithField("author_id", authorID).Error("Ошибка получения страницы постов автора")
+		return nil, nil, err
+	}
+
+	posts, pageInfo := entities.NewCursorPage(posts, req)
+	return posts, pageInfo, nil
+}
+
+func (r *PostRepository) GetByAuthorIDsKeyset(ctx context.Context, authorIDs []uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	if len(authorIDs) == 0 {
+		return []*entities.Post{}, &entities.PageInfo{}, nil
+	}
+
+	var posts []*entities.Post
+	query := keysetQuery(req, PostSelectByAuthorsKeysetQuery, PostSelectByAuthorsKeysetBackwardQuery)
+	err := r.db.SelectContext(ctx, &posts, query, keysetArgs(req, pq.Array(authorIDs))...)
+	if err != nil {
+		r.logger.WithError(err).WithField("authors_count", len(authorIDs)).Error("Ошибка получения страницы постов авторов")
+		return nil, nil, err
+	}
+
+	posts, pageInfo := entities.NewCursorPage(posts, req)
+	return posts, pageInfo, nil
+}
+
 func (r *PostRepository) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
 	var exists bool
 	err := r.db.GetContext(ctx, &exists, PostExistsQuery, id)
@@ -183,3 +257,70 @@ func (r *PostRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*enti
 
 	return posts, nil
 }
+
+func (r *PostRepository) CreateRevision(ctx context.Context, revision *entities.PostRevision) error {
+	_, err := r.db.ExecContext(ctx, PostRevisionInsertQuery,
+		revision.ID,
+		revision.PostID,
+		revision.Version,
+		revision.Title,
+		revision.Content,
+		revision.EditorID,
+		revision.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", revision.PostID).Error("Ошибка сохранения версии поста")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostRepository) GetRevisions(ctx context.Context, postID uuid.UUID) ([]*entities.PostRevision, error) {
+	revisions := []*entities.PostRevision{}
+	err := r.db.SelectContext(ctx, &revisions, PostRevisionSelectByPostQuery, postID)
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Ошибка получения истории правок поста")
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// postSearchRow - строка результата поиска: колонки поста плюс релевантность и фрагменты
+type postSearchRow struct {
+	entities.Post
+	Rank           float64 `db:"rank"`
+	TitleHighlight string  `db:"title_highlight"`
+	Snippet        string  `db:"snippet"`
+}
+
+func (r *PostRepository) Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.PostSearchResult, *entities.PaginationResponse, error) {
+	var total int64
+	err := r.db.GetContext(ctx, &total, PostSearchCountQuery, query.Text)
+	if err != nil {
+		r.logger.WithError(err).WithField("query", query.Text).Error("Ошибка подсчета результатов поиска постов")
+		return nil, nil, err
+	}
+
+	var rows []*postSearchRow
+	err = r.db.SelectContext(ctx, &rows, PostSearchQuery, query.Text, pagination.Limit, pagination.Offset)
+	if err != nil {
+		r.logger.WithError(err).WithField("query", query.Text).Error("Ошибка поиска постов")
+		return nil, nil, err
+	}
+
+	results := make([]*entities.PostSearchResult, 0, len(rows))
+	for _, row := range rows {
+		post := row.Post
+		results = append(results, &entities.PostSearchResult{
+			Post:           &post,
+			Rank:           row.Rank,
+			TitleHighlight: row.TitleHighlight,
+			Snippet:        row.Snippet,
+		})
+	}
+
+	return results, entities.NewPaginationResponse(total, pagination.Limit, pagination.Offset), nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"ozon-posts/internal/services"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rateLimitCleanupInterval = time.Minute
+	rateLimitCleanupTimeout  = 10 * time.Second
+)
+
+// RateLimitStore хранит корзины лимитов в таблице rate_limit_buckets, поэтому лимиты общие
+// для всех экземпляров приложения. Корзины, не использованные дольше периода лимита, уже полные
+// и удаляются фоновой очисткой не чаще раза в минуту.
+type RateLimitStore struct {
+	db          *sqlx.DB
+	lastCleanup atomic.Int64
+	logger      *logrus.Logger
+}
+
+func NewRateLimitStore(db *sqlx.DB, logger *logrus.Logger) *RateLimitStore {
+	store := &RateLimitStore{
+		db:     db,
+		logger: logger,
+	}
+	store.lastCleanup.Store(time.Now().UnixNano())
+	return store
+}
+
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit services.RateLimit) (bool, time.Duration, error) {
+	var bucket struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+
+	err := s.db.GetContext(ctx, &bucket, RateLimitTakeQuery,
+		key,
+		float64(limit.Burst),
+		limit.Rate(),
+		limit.Period.Seconds(),
+	)
+	if err != nil {
+		return false, 0, fmt.Errorf("ошибка списания токена лимита: %w", err)
+	}
+
+	s.cleanupExpired()
+
+	if !bucket.Allowed {
+		return false, services.RetryAfter(bucket.Tokens, limit), nil
+	}
+
+	return true, 0, nil
+}
+
+// cleanupExpired запускает удаление полных корзин, если с прошлой очистки прошла минута.
+// Очистку запускает только один запрос, остальные продолжают без ожидания.
+func (s *RateLimitStore) cleanupExpired() {
+	last := s.lastCleanup.Load()
+	now := time.Now().UnixNano()
+	if time.Duration(now-last) < rateLimitCleanupInterval || !s.lastCleanup.CompareAndSwap(last, now) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), rateLimitCleanupTimeout)
+		defer cancel()
+
+		result, err := s.db.ExecContext(ctx, RateLimitDeleteExpiredQuery)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка очистки корзин лимитов")
+			return
+		}
+
+		if deleted, _ := result.RowsAffected(); deleted > 0 {
+			s.logger.WithField("deleted", deleted).Debug("Удалены неиспользуемые корзины лимитов")
+		}
+	}()
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type ReactionRepository struct {
+	db     queryer
+	logger *logrus.Logger
+}
+
+func NewReactionRepository(db *sqlx.DB, logger *logrus.Logger) services.ReactionRepository {
+	return &ReactionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *ReactionRepository) Add(ctx context.Context, reaction *entities.Reaction) (bool, error) {
+	result, err := r.db.ExecContext(ctx, ReactionInsertQuery,
+		reaction.ID,
+		reaction.TargetType,
+		reaction.TargetID,
+		reaction.UserID,
+		reaction.Kind,
+		reaction.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"target_type": reaction.TargetType,
+			"target_id":   reaction.TargetID,
+		}).Error("Ошибка сохранения реакции")
+		return false, err
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return added > 0, nil
+}
+
+func (r *ReactionRepository) Remove(ctx context.Context, targetType entities.ReactionTargetType, targetID, userID uuid.UUID, kind entities.ReactionKind) (bool, error) {
+	result, err := r.db.ExecContext(ctx, ReactionDeleteQuery, targetType, targetID, userID, kind)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"target_type": targetType,
+			"target_id":   targetID,
+		}).Error("Ошибка удаления реакции")
+		return false, err
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return removed > 0, nil
+}
+
+func (r *ReactionRepository) CountByTargets(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReactionKind]int64, error) {
+	counts := make(map[uuid.UUID]map[entities.ReactionKind]int64, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		TargetID uuid.UUID             `db:"target_id"`
+		Kind     entities.ReactionKind `db:"kind"`
+		Count    int64                 `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, ReactionCountByTargetsQuery, targetType, pq.Array(targetIDs))
+	if err != nil {
+		r.logger.WithError(err).WithField("targets_count", len(targetIDs)).Error("Ошибка подсчета реакций")
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.TargetID] == nil {
+			counts[row.TargetID] = make(map[entities.ReactionKind]int64)
+		}
+		counts[row.TargetID][row.Kind] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *ReactionRepository) GetUserKinds(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID][]entities.ReactionKind, error) {
+	kinds := make(map[uuid.UUID][]entities.ReactionKind)
+	if len(targetIDs) == 0 {
+		return kinds, nil
+	}
+
+	var rows []struct {
+		TargetID uuid.UUID             `db:"target_id"`
+		Kind     entities.ReactionKind `db:"kind"`
+	}
+	err := r.db.SelectContext(ctx, &rows, ReactionSelectUserKindsQuery, targetType, pq.Array(targetIDs), userID)
+	if err != nil {
+		r.logger.WithError(err).WithField("targets_count", len(targetIDs)).Error("Ошибка получения реакций пользователя")
+		return nil, err
+	}
+
+	for _, row := range rows {
+		kinds[row.TargetID] = append(kinds[row.TargetID], row.Kind)
+	}
+
+	return kinds, nil
+}
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/services"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type ReportRepository struct {
+	db     queryer
+	logger *logrus.Logger
+}
+
+func NewReportRepository(db *sqlx.DB, logger *logrus.Logger) services.ReportRepository {
+	return &ReportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *ReportRepository) Create(ctx context.Context, report *entities.Report) (bool, error) {
+	result, err := r.db.ExecContext(ctx, ReportInsertQuery,
+		report.ID,
+		report.TargetType,
+		report.TargetID,
+		report.AuthorID,
+		report.ReporterID,
+		report.Reason,
+		report.Status,
+		report.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"target_id":   report.TargetID,
+			"reporter_id": report.ReporterID,
+		}).Error("Ошибка сохранения жалобы")
+		return false, err
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return created > 0, nil
+}
+
+func (r *ReportRepository) GetQueueKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationQueueItem, *entities.PageInfo, error) {
+	var items []*entities.ModerationQueueItem
+	query := keysetQuery(req, ReportSelectQueueKeysetQuery, ReportSelectQueueKeysetBackwardQuery)
+	if err := r.db.SelectContext(ctx, &items, query, keysetArgs(req)...); err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы очереди модерации")
+		return nil, nil, err
+	}
+
+	items, pageInfo := entities.NewCursorPage(items, req)
+	return items, pageInfo, nil
+}
+
+func (r *ReportRepository) GetQueueItem(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID) (*entities.ModerationQueueItem, error) {
+	var item entities.ModerationQueueItem
+	err := r.db.GetContext(ctx, &item, ReportSelectQueueItemQuery, targetType, targetID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.WithError(err).WithField("target_id", targetID).Error("Ошибка получения открытых жалоб на объект")
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (r *ReportRepository) CountOpenByReason(ctx context.Context, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReportReason]int64, error) {
+	counts := make(map[uuid.UUID]map[entities.ReportReason]int64, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		TargetID uuid.UUID             `db:"target_id"`
+		Reason   entities.ReportReason `db:"reason"`
+		Count    int64                 `db:"count"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, ReportCountOpenByReasonQuery, pq.Array(targetIDs)); err != nil {
+		r.logger.WithError(err).WithField("targets_count", len(targetIDs)).Error("Ошибка подсчета причин жалоб")
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.TargetID] == nil {
+			counts[row.TargetID] = make(map[entities.ReportReason]int64)
+		}
+		counts[row.TargetID][row.Reason] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *ReportRepository) ResolveOpen(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID, action entities.ModerationAction, moderatorID uuid.UUID, resolvedAt time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, ReportResolveOpenQuery, targetType, targetID, action, moderatorID, resolvedAt)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", targetID).Error("Ошибка закрытия жалоб")
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *ReportRepository) CreateAuditEntry(ctx context.Context, entry *entities.ModerationAuditEntry) error {
+	_, err := r.db.ExecContext(ctx, ModerationAuditInsertQuery,
+		entry.ID,
+		entry.ModeratorID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		entry.AuthorID,
+		entry.ReportsResolved,
+		entry.Note,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).WithField("target_id", entry.TargetID).Error("Ошибка записи в журнал модерации")
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReportRepository) GetAuditLogKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationAuditEntry, *entities.PageInfo, error) {
+	var entries []*entities.ModerationAuditEntry
+	query := keysetQuery(req, ModerationAuditSelectKeysetQuery, ModerationAuditSelectKeysetBackwardQuery)
+	if err := r.db.SelectContext(ctx, &entries, query, keysetArgs(req)...); err != nil {
+		r.logger.WithError(err).Error("Ошибка получения страницы журнала модерации")
+		return nil, nil, err
+	}
+
+	entries, pageInfo := entities.NewCursorPage(entries, req)
+	return entries, pageInfo, nil
+}
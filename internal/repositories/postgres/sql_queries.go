@@ -2,31 +2,43 @@ package postgres
 
 const (
 	UserInsertQuery = `
-		INSERT INTO users (id, username, email, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (id, username, email, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	UserSelectByIDQuery = `
-		SELECT id, username, email, created_at, updated_at
+		SELECT id, username, email, password_hash, role, created_at, updated_at, banned_at
 		FROM users
 		WHERE id = $1
 	`
 
 	UserSelectByUsernameQuery = `
-		SELECT id, username, email, created_at, updated_at
+		SELECT id, username, email, password_hash, role, created_at, updated_at, banned_at
 		FROM users
 		WHERE username = $1
 	`
 
 	UserSelectByEmailQuery = `
-		SELECT id, username, email, created_at, updated_at
+		SELECT id, username, email, password_hash, role, created_at, updated_at, banned_at
 		FROM users
 		WHERE email = $1
 	`
 
 	UserUpdateQuery = `
 		UPDATE users 
-		SET username = $2, email = $3, updated_at = $4 
+		SET username = $2, email = $3, updated_at = $4
+		WHERE id = $1
+	`
+
+	UserSetRoleQuery = `
+		UPDATE users
+		SET role = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	UserSetBannedQuery = `
+		UPDATE users
+		SET banned_at = COALESCE(banned_at, $2)
 		WHERE id = $1
 	`
 
@@ -35,7 +47,7 @@ const (
 	UserExistsQuery = `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`
 
 	UserSelectByIDsQuery = `
-		SELECT id, username, email, created_at, updated_at 
+		SELECT id, username, email, password_hash, role, created_at, updated_at, banned_at 
 		FROM users 
 		WHERE id = ANY($1)
 		ORDER BY created_at DESC
@@ -48,47 +60,139 @@ const (
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
+	// Скрытые модератором посты (hidden_at IS NOT NULL) исключаются из всех чтений, кроме
+	// PostSelectByIDForUpdateQuery, через который модерация скрывает и восстанавливает пост
 	PostSelectByIDQuery = `
-		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
+		FROM posts
+		WHERE id = $1 AND hidden_at IS NULL
+	`
+
+	PostSelectByIDForShareQuery = PostSelectByIDQuery + ` FOR SHARE`
+
+	PostSelectByIDForUpdateQuery = `
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count, hidden_at, hidden_by
 		FROM posts
 		WHERE id = $1
+		FOR NO KEY UPDATE
 	`
 
 	PostUpdateQuery = `
 		UPDATE posts
-		SET title = $2, content = $3, comments_disabled = $4, updated_at = $5
+		SET title = $2, content = $3, comments_disabled = $4, updated_at = $5,
+			edit_count = $6, edited_at = $7, edited_by = $8
 		WHERE id = $1
 	`
 
+	PostSetHiddenQuery = `UPDATE posts SET hidden_at = $2, hidden_by = $3, updated_at = $4 WHERE id = $1`
+
 	PostDeleteQuery = `DELETE FROM posts WHERE id = $1`
 
-	PostCountAllQuery = `SELECT COUNT(*) FROM posts`
+	PostAddCommentCountQuery = `UPDATE posts SET comment_count = comment_count + $2 WHERE id = $1`
+
+	PostCountAllQuery = `SELECT COUNT(*) FROM posts WHERE hidden_at IS NULL`
 
 	PostSelectAllQuery = `
-		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
 		FROM posts
+		WHERE hidden_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	PostCountByAuthorQuery = `SELECT COUNT(*) FROM posts WHERE author_id = $1`
+	PostCountByAuthorQuery = `SELECT COUNT(*) FROM posts WHERE author_id = $1 AND hidden_at IS NULL`
 
 	PostSelectByAuthorQuery = `
-		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
 		FROM posts
-		WHERE author_id = $1
+		WHERE author_id = $1 AND hidden_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	PostExistsQuery = `SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1)`
+	// Keyset-запросы: $1/$2 - курсор after, $3/$4 - курсор before (NULL, если не задан).
+	// Backward-варианты читают страницу с конца в обратном порядке.
+	PostSelectAllKeysetQuery = `
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
+		FROM posts
+		WHERE hidden_at IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
+	PostSelectAllKeysetBackwardQuery = `
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
+		FROM posts
+		WHERE hidden_at IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5
+	`
+
+	PostSelectByAuthorKeysetQuery = `
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
+		FROM posts
+		WHERE author_id = $6 AND hidden_at IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
+	PostSelectByAuthorKeysetBackwardQuery = `
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
+		FROM posts
+		WHERE author_id = $6 AND hidden_at IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5
+	`
+
+	// Лента из постов нескольких авторов, $6 - массив авторов. Для каждого автора индекс
+	// idx_posts_author_created_id отдает не больше $5 постов после курсора, затем они сливаются,
+	// поэтому стоимость зависит от числа авторов и размера страницы, а не от числа их постов
+	PostSelectByAuthorsKeysetQuery = `
+		SELECT p.id, p.author_id, p.title, p.content, p.comments_disabled, p.created_at, p.updated_at, p.edit_count, p.edited_at, p.edited_by, p.comment_count
+		FROM unnest($6::uuid[]) AS a(author_id)
+		CROSS JOIN LATERAL (
+			SELECT * FROM posts
+			WHERE posts.author_id = a.author_id AND posts.hidden_at IS NULL
+			  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+			  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+			ORDER BY created_at DESC, id DESC
+			LIMIT $5
+		) p
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $5
+	`
+
+	PostSelectByAuthorsKeysetBackwardQuery = `
+		SELECT p.id, p.author_id, p.title, p.content, p.comments_disabled, p.created_at, p.updated_at, p.edit_count, p.edited_at, p.edited_by, p.comment_count
+		FROM unnest($6::uuid[]) AS a(author_id)
+		CROSS JOIN LATERAL (
+			SELECT * FROM posts
+			WHERE posts.author_id = a.author_id AND posts.hidden_at IS NULL
+			  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+			  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+			ORDER BY created_at ASC, id ASC
+			LIMIT $5
+		) p
+		ORDER BY p.created_at ASC, p.id ASC
+		LIMIT $5
+	`
+
+	PostExistsQuery = `SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1 AND hidden_at IS NULL)`
 
-	PostCommentsEnabledQuery = `SELECT NOT comments_disabled FROM posts WHERE id = $1`
+	PostCommentsEnabledQuery = `SELECT NOT comments_disabled FROM posts WHERE id = $1 AND hidden_at IS NULL`
 
 	PostSelectByIDsQuery = `
-		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count
 		FROM posts
-		WHERE id = ANY($1)
+		WHERE id = ANY($1) AND hidden_at IS NULL
 		ORDER BY created_at DESC
 	`
 )
@@ -100,50 +204,149 @@ const (
 	`
 
 	CommentSelectByIDQuery = `
-		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
 		FROM comments
 		WHERE id = $1
 	`
 
+	CommentSelectByIDForShareQuery = CommentSelectByIDQuery + ` FOR SHARE`
+
+	CommentSelectByIDForUpdateQuery = CommentSelectByIDQuery + ` FOR NO KEY UPDATE`
+
 	CommentUpdateQuery = `
 		UPDATE comments
-		SET content = $2, updated_at = $3
+		SET content = $2, updated_at = $3, edit_count = $4, edited_at = $5, edited_by = $6
 		WHERE id = $1
 	`
 
-	CommentDeleteQuery = `DELETE FROM comments WHERE id = $1`
+	CommentSoftDeleteQuery = `
+		UPDATE comments
+		SET content = $2, deleted_at = $3, deleted_by = $4, updated_at = $5
+		WHERE id = $1 AND deleted_at IS NULL
+	`
 
-	CommentCountByPostQuery = `SELECT COUNT(*) FROM comments WHERE post_id = $1 AND parent_id IS NULL`
+	// Полная очистка удаляет комментарий вместе со всей веткой ответов
+	CommentPurgeQuery = `
+		WITH target AS (SELECT path FROM comments WHERE id = $1)
+		DELETE FROM comments
+		USING target
+		WHERE comments.path = target.path OR comments.path LIKE target.path || '/%'
+	`
 
+	// Число корневых комментариев и ответов берется из счетчиков posts.comment_count и comments.reply_count
+	CommentCountByPostQuery = `SELECT COALESCE((SELECT comment_count FROM posts WHERE id = $1), 0)`
+
+	// Порядок выдачи подставляется вместо %s из commentOrderBy
 	CommentSelectByPostQuery = `
-		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
 		FROM comments
 		WHERE post_id = $1 AND parent_id IS NULL
-		ORDER BY created_at ASC
+		ORDER BY %s
 		LIMIT $2 OFFSET $3
 	`
 
-	CommentCountByParentQuery = `SELECT COUNT(*) FROM comments WHERE parent_id = $1`
+	CommentCountByParentQuery = `SELECT COALESCE((SELECT reply_count FROM comments WHERE id = $1), 0)`
+
+	CommentAddReplyCountQuery = `UPDATE comments SET reply_count = reply_count + $2 WHERE id = $1`
+
+	CommentAddReactionCountQuery = `UPDATE comments SET reaction_count = reaction_count + $2 WHERE id = $1`
 
 	CommentSelectByParentQuery = `
-		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
 		FROM comments
 		WHERE parent_id = $1
-		ORDER BY created_at ASC
+		ORDER BY %s
 		LIMIT $2 OFFSET $3
 	`
 
+	CommentSelectByPostKeysetQuery = `
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
+		FROM comments
+		WHERE post_id = $6 AND parent_id IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) > ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) < ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5
+	`
+
+	CommentSelectByPostKeysetBackwardQuery = `
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
+		FROM comments
+		WHERE post_id = $6 AND parent_id IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) > ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) < ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
+	CommentSelectByParentKeysetQuery = `
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
+		FROM comments
+		WHERE parent_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, id) > ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) < ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5
+	`
+
+	CommentSelectByParentKeysetBackwardQuery = `
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
+		FROM comments
+		WHERE parent_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, id) > ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) < ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
 	CommentSelectThreadQuery = `
-		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
 		FROM comments
 		WHERE (path = $1 OR path LIKE $2) AND level <= $3
 		ORDER BY path, created_at ASC
 	`
 
+	// Первые $2 ответов каждого из родителей за один запрос
+	CommentSelectChildrenByParentsQuery = `
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
+		FROM (
+			SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count,
+				ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY created_at ASC, id ASC) AS position
+			FROM comments
+			WHERE parent_id = ANY($1)
+		) ranked
+		WHERE position <= $2
+		ORDER BY parent_id, created_at ASC, id ASC
+	`
+
+	CommentCountByParentsQuery = `
+		SELECT id AS parent_id, reply_count AS count
+		FROM comments
+		WHERE id = ANY($1) AND reply_count > 0
+	`
+
+	CommentSelectTopLevelByPostsQuery = `
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
+		FROM (
+			SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count,
+				ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY created_at ASC, id ASC) AS position
+			FROM comments
+			WHERE post_id = ANY($1) AND parent_id IS NULL
+		) ranked
+		WHERE position <= $2
+		ORDER BY post_id, created_at ASC, id ASC
+	`
+
+	CommentCountTopLevelByPostsQuery = `
+		SELECT id AS post_id, comment_count AS count
+		FROM posts
+		WHERE id = ANY($1) AND comment_count > 0
+	`
+
 	CommentCountByPathQuery = `SELECT COUNT(*) FROM comments WHERE path LIKE $1`
 
 	CommentSelectByPathQuery = `
-		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
 		FROM comments
 		WHERE path LIKE $1
 		ORDER BY path, created_at ASC
@@ -153,9 +356,414 @@ const (
 	CommentExistsQuery = `SELECT EXISTS(SELECT 1 FROM comments WHERE id = $1)`
 
 	CommentSelectByIDsQuery = `
-		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count
 		FROM comments
 		WHERE id = ANY($1)
 		ORDER BY created_at ASC
 	`
 )
+
+const (
+	PostRevisionInsertQuery = `
+		INSERT INTO post_revisions (id, post_id, version, title, content, editor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	PostRevisionSelectByPostQuery = `
+		SELECT id, post_id, version, title, content, editor_id, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY version ASC
+	`
+
+	CommentRevisionInsertQuery = `
+		INSERT INTO comment_revisions (id, comment_id, version, content, editor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	CommentRevisionSelectByCommentQuery = `
+		SELECT id, comment_id, version, content, editor_id, created_at
+		FROM comment_revisions
+		WHERE comment_id = $1
+		ORDER BY version ASC
+	`
+)
+
+const (
+	CommentMentionDeleteExceptQuery = `
+		DELETE FROM comment_mentions
+		WHERE comment_id = $1 AND user_id <> ALL($2::uuid[])
+	`
+
+	CommentMentionInsertQuery = `
+		INSERT INTO comment_mentions (comment_id, user_id, position, created_at)
+		SELECT $1, mentioned.user_id, mentioned.position, $3
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS mentioned(user_id, position)
+		ORDER BY mentioned.position
+		ON CONFLICT (comment_id, user_id) DO NOTHING
+		RETURNING user_id
+	`
+
+	CommentMentionSelectByCommentsQuery = `
+		SELECT comment_id, user_id
+		FROM comment_mentions
+		WHERE comment_id = ANY($1)
+		ORDER BY comment_id, created_at, position
+	`
+)
+
+const (
+	NotificationInsertQuery = `
+		INSERT INTO notifications (id, user_id, type, actor_id, post_id, comment_id, read_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	// Keyset-запросы оповещений: новые сначала, $6 - получатель
+	NotificationSelectByUserKeysetQuery = `
+		SELECT id, user_id, type, actor_id, post_id, comment_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
+	NotificationSelectByUserKeysetBackwardQuery = `
+		SELECT id, user_id, type, actor_id, post_id, comment_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5
+	`
+
+	NotificationSelectUnreadByUserKeysetQuery = `
+		SELECT id, user_id, type, actor_id, post_id, comment_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = $6 AND read_at IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
+	NotificationSelectUnreadByUserKeysetBackwardQuery = `
+		SELECT id, user_id, type, actor_id, post_id, comment_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = $6 AND read_at IS NULL
+		  AND ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5
+	`
+
+	NotificationMarkReadQuery = `
+		UPDATE notifications SET read_at = $3
+		WHERE user_id = $1 AND read_at IS NULL AND id = ANY($2)
+	`
+
+	NotificationMarkAllReadQuery = `
+		UPDATE notifications SET read_at = $2
+		WHERE user_id = $1 AND read_at IS NULL
+	`
+)
+
+const (
+	FollowInsertQuery = `
+		INSERT INTO follows (follower_id, followee_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING
+	`
+
+	FollowDeleteQuery = `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`
+
+	// Keyset-запросы подписок: недавние сначала, id курсора - второй участник подписки, $6 - пользователь
+	FollowSelectFollowersKeysetQuery = `
+		SELECT follower_id, followee_id, created_at
+		FROM follows
+		WHERE followee_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, follower_id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, follower_id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, follower_id DESC
+		LIMIT $5
+	`
+
+	FollowSelectFollowersKeysetBackwardQuery = `
+		SELECT follower_id, followee_id, created_at
+		FROM follows
+		WHERE followee_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, follower_id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, follower_id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, follower_id ASC
+		LIMIT $5
+	`
+
+	FollowSelectFollowingKeysetQuery = `
+		SELECT follower_id, followee_id, created_at
+		FROM follows
+		WHERE follower_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, followee_id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, followee_id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, followee_id DESC
+		LIMIT $5
+	`
+
+	FollowSelectFollowingKeysetBackwardQuery = `
+		SELECT follower_id, followee_id, created_at
+		FROM follows
+		WHERE follower_id = $6
+		  AND ($1::timestamptz IS NULL OR (created_at, followee_id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, followee_id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, followee_id ASC
+		LIMIT $5
+	`
+
+	FollowSelectFolloweeIDsQuery = `SELECT followee_id FROM follows WHERE follower_id = $1`
+)
+
+const (
+	EventNotifyQuery = `SELECT pg_notify($1, $2)`
+
+	CommentEventSequenceNextQuery = `
+		INSERT INTO comment_event_sequences (post_id, sequence)
+		VALUES ($1, 1)
+		ON CONFLICT (post_id) DO UPDATE SET sequence = comment_event_sequences.sequence + 1
+		RETURNING sequence
+	`
+
+	CommentEventSequenceCurrentQuery = `
+		SELECT COALESCE((SELECT sequence FROM comment_event_sequences WHERE post_id = $1), 0)
+	`
+)
+
+// Полнотекстовый поиск. Запрос разбирается websearch_to_tsquery: слова объединяются через AND,
+// поддерживаются "фразы", OR и -исключения
+const (
+	PostSearchCountQuery = `
+		SELECT COUNT(*)
+		FROM posts, websearch_to_tsquery('russian', $1) query
+		WHERE search_vector @@ query AND hidden_at IS NULL
+	`
+
+	PostSearchQuery = `
+		SELECT id, author_id, title, content, comments_disabled, created_at, updated_at, edit_count, edited_at, edited_by, comment_count,
+			ts_rank(search_vector, query) AS rank,
+			ts_headline('russian', title, query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS title_highlight,
+			ts_headline('russian', content, query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=15') AS snippet
+		FROM posts, websearch_to_tsquery('russian', $1) query
+		WHERE search_vector @@ query AND hidden_at IS NULL
+		ORDER BY rank DESC, created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	CommentSearchCountQuery = `
+		SELECT COUNT(*)
+		FROM comments, websearch_to_tsquery('russian', $1) query
+		WHERE search_vector @@ query AND deleted_at IS NULL AND ($2::uuid IS NULL OR post_id = $2)
+			AND EXISTS (SELECT 1 FROM posts p WHERE p.id = comments.post_id AND p.hidden_at IS NULL)
+	`
+
+	CommentSearchQuery = `
+		SELECT id, post_id, author_id, parent_id, content, path, level, created_at, updated_at, deleted_at, deleted_by, edit_count, edited_at, edited_by, reply_count, reaction_count,
+			ts_rank(search_vector, query) AS rank,
+			ts_headline('russian', content, query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=15') AS snippet
+		FROM comments, websearch_to_tsquery('russian', $1) query
+		WHERE search_vector @@ query AND deleted_at IS NULL AND ($2::uuid IS NULL OR post_id = $2)
+			AND EXISTS (SELECT 1 FROM posts p WHERE p.id = comments.post_id AND p.hidden_at IS NULL)
+		ORDER BY rank DESC, created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+)
+
+// Таблица версий совместима с golang-migrate: базы, размеченные внешней утилитой, продолжают работать
+const (
+	MigrationLockQuery   = `SELECT pg_advisory_lock($1)`
+	MigrationUnlockQuery = `SELECT pg_advisory_unlock($1)`
+
+	MigrationTableExistsQuery = `SELECT to_regclass('schema_migrations') IS NOT NULL`
+
+	MigrationCreateTableQuery = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`
+
+	MigrationSelectVersionQuery = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+	MigrationDeleteVersionQuery = `DELETE FROM schema_migrations`
+
+	MigrationInsertVersionQuery = `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`
+)
+
+// Пополнение и списание выполняются одним upsert под блокировкой строки. Время берется из now(),
+// чтобы пополнение и решение считались от одного момента; allowed хранит результат последнего списания.
+const (
+	RateLimitTakeQuery = `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at, expires_at)
+		VALUES ($1, $2::float8 - 1, TRUE, now(), now() + make_interval(secs => $4::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - b.updated_at)) * $3::float8)
+				- CASE WHEN LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - b.updated_at)) * $3::float8) >= 1 THEN 1 ELSE 0 END,
+			allowed = LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - b.updated_at)) * $3::float8) >= 1,
+			updated_at = GREATEST(b.updated_at, now()),
+			expires_at = now() + make_interval(secs => $4::float8)
+		RETURNING tokens, allowed
+	`
+
+	RateLimitDeleteExpiredQuery = `DELETE FROM rate_limit_buckets WHERE expires_at < now()`
+)
+
+// Объект реакции хранится в post_id или comment_id в зависимости от target_type,
+// а выборки идут по вычисляемой колонке target_id
+const (
+	ReactionInsertQuery = `
+		INSERT INTO reactions (id, target_type, post_id, comment_id, user_id, kind, created_at)
+		VALUES (
+			$1, $2::varchar,
+			CASE WHEN $2::varchar = 'post' THEN $3::uuid END,
+			CASE WHEN $2::varchar = 'comment' THEN $3::uuid END,
+			$4, $5, $6
+		)
+		ON CONFLICT (target_type, target_id, user_id, kind) DO NOTHING
+	`
+
+	ReactionDeleteQuery = `
+		DELETE FROM reactions
+		WHERE target_type = $1 AND target_id = $2 AND user_id = $3 AND kind = $4
+	`
+
+	ReactionCountByTargetsQuery = `
+		SELECT target_id, kind, COUNT(*) AS count
+		FROM reactions
+		WHERE target_type = $1 AND target_id = ANY($2)
+		GROUP BY target_id, kind
+	`
+
+	ReactionSelectUserKindsQuery = `
+		SELECT target_id, kind
+		FROM reactions
+		WHERE target_type = $1 AND target_id = ANY($2) AND user_id = $3
+	`
+)
+
+// Пересчет денормализованных счетчиков с нуля: обновляются только разошедшиеся строки
+const (
+	PostReconcileCommentCountQuery = `
+		UPDATE posts
+		SET comment_count = actual.count
+		FROM (
+			SELECT p.id, COUNT(c.id) AS count
+			FROM posts p
+			LEFT JOIN comments c ON c.post_id = p.id AND c.parent_id IS NULL
+			GROUP BY p.id
+		) actual
+		WHERE posts.id = actual.id AND posts.comment_count <> actual.count
+	`
+
+	CommentReconcileReplyCountQuery = `
+		UPDATE comments
+		SET reply_count = actual.count
+		FROM (
+			SELECT c.id, COUNT(r.id) AS count
+			FROM comments c
+			LEFT JOIN comments r ON r.parent_id = c.id
+			GROUP BY c.id
+		) actual
+		WHERE comments.id = actual.id AND comments.reply_count <> actual.count
+	`
+
+	CommentReconcileReactionCountQuery = `
+		UPDATE comments
+		SET reaction_count = actual.count
+		FROM (
+			SELECT c.id, COUNT(r.id) AS count
+			FROM comments c
+			LEFT JOIN reactions r ON r.comment_id = c.id
+			GROUP BY c.id
+		) actual
+		WHERE comments.id = actual.id AND comments.reaction_count <> actual.count
+	`
+)
+
+const (
+	// Повторная жалоба пользователя на объект, пока прежняя открыта, не сохраняется
+	ReportInsertQuery = `
+		INSERT INTO reports (id, target_type, target_id, author_id, reporter_id, reason, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (target_id, reporter_id) WHERE status = 'open' DO NOTHING
+	`
+
+	// Keyset-запросы очереди модерации: открытые жалобы сгруппированы по объекту,
+	// курсор - (время первой жалобы, объект), дольше всех ждущие решения сначала
+	ReportSelectQueueKeysetQuery = `
+		SELECT target_type, target_id, author_id, COUNT(*) AS report_count,
+		       MIN(created_at) AS first_reported_at, MAX(created_at) AS last_reported_at
+		FROM reports
+		WHERE status = 'open'
+		GROUP BY target_type, target_id, author_id
+		HAVING ($1::timestamptz IS NULL OR (MIN(created_at), target_id) > ($1::timestamptz, $2::uuid))
+		   AND ($3::timestamptz IS NULL OR (MIN(created_at), target_id) < ($3::timestamptz, $4::uuid))
+		ORDER BY first_reported_at ASC, target_id ASC
+		LIMIT $5
+	`
+
+	ReportSelectQueueKeysetBackwardQuery = `
+		SELECT target_type, target_id, author_id, COUNT(*) AS report_count,
+		       MIN(created_at) AS first_reported_at, MAX(created_at) AS last_reported_at
+		FROM reports
+		WHERE status = 'open'
+		GROUP BY target_type, target_id, author_id
+		HAVING ($1::timestamptz IS NULL OR (MIN(created_at), target_id) > ($1::timestamptz, $2::uuid))
+		   AND ($3::timestamptz IS NULL OR (MIN(created_at), target_id) < ($3::timestamptz, $4::uuid))
+		ORDER BY first_reported_at DESC, target_id DESC
+		LIMIT $5
+	`
+
+	ReportSelectQueueItemQuery = `
+		SELECT target_type, target_id, author_id, COUNT(*) AS report_count,
+		       MIN(created_at) AS first_reported_at, MAX(created_at) AS last_reported_at
+		FROM reports
+		WHERE status = 'open' AND target_type = $1 AND target_id = $2
+		GROUP BY target_type, target_id, author_id
+	`
+
+	ReportCountOpenByReasonQuery = `
+		SELECT target_id, reason, COUNT(*) AS count
+		FROM reports
+		WHERE status = 'open' AND target_id = ANY($1)
+		GROUP BY target_id, reason
+	`
+
+	ReportResolveOpenQuery = `
+		UPDATE reports
+		SET status = 'resolved', resolution = $3, resolved_by = $4, resolved_at = $5
+		WHERE target_type = $1 AND target_id = $2 AND status = 'open'
+	`
+
+	ModerationAuditInsertQuery = `
+		INSERT INTO moderation_audit_log (id, moderator_id, action, target_type, target_id, author_id, reports_resolved, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	// Keyset-запросы журнала модерации: новые записи сначала
+	ModerationAuditSelectKeysetQuery = `
+		SELECT id, moderator_id, action, target_type, target_id, author_id, reports_resolved, note, created_at
+		FROM moderation_audit_log
+		WHERE ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
+	ModerationAuditSelectKeysetBackwardQuery = `
+		SELECT id, moderator_id, action, target_type, target_id, author_id, reports_resolved, note, created_at
+		FROM moderation_audit_log
+		WHERE ($1::timestamptz IS NULL OR (created_at, id) < ($1::timestamptz, $2::uuid))
+		  AND ($3::timestamptz IS NULL OR (created_at, id) > ($3::timestamptz, $4::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5
+	`
+)
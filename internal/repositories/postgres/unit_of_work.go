@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ozon-posts/internal/services"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// queryer - общие методы *sqlx.DB и *sqlx.Tx: репозитории одинаково работают
+// с подключением и внутри транзакции UnitOfWork
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// UnitOfWork выполняет составные операции в одной транзакции READ COMMITTED.
+// Согласованность проверок обеспечивают блокирующие чтения GetByIDForShare/GetByIDForUpdate.
+type UnitOfWork struct {
+	db     *sqlx.DB
+	logger *logrus.Logger
+}
+
+func NewUnitOfWork(db *sqlx.DB, logger *logrus.Logger) services.UnitOfWork {
+	return &UnitOfWork{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos services.Repositories) error) error {
+	tx, err := u.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			u.logger.WithError(rollbackErr).Error("Ошибка отката транзакции")
+		}
+	}()
+
+	repos := services.Repositories{
+		Users:         &UserRepository{db: tx, logger: u.logger},
+		Posts:         &PostRepository{db: tx, logger: u.logger},
+		Comments:      &CommentRepository{db: tx, logger: u.logger},
+		Reactions:     &ReactionRepository{db: tx, logger: u.logger},
+		Notifications: &NotificationRepository{db: tx, logger: u.logger},
+		Reports:       &ReportRepository{db: tx, logger: u.logger},
+	}
+
+	if err := fn(ctx, repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	committed = true
+
+	return nil
+}
@@ -13,7 +13,7 @@ import (
 )
 
 type UserRepository struct {
-	db     *sqlx.DB
+	db     queryer
 	logger *logrus.Logger
 }
 
@@ -29,6 +29,8 @@ func (r *UserRepository) Create(ctx context.Context, user *entities.User) error
 		user.ID,
 		user.Username,
 		user.Email,
+		user.PasswordHash,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -109,6 +111,36 @@ func (r *UserRepository) Update(ctx context.Context, user *entities.User) error
 	return nil
 }
 
+func (r *UserRepository) SetRole(ctx context.Context, user *entities.User) error {
+	result, err := r.db.ExecContext(ctx, UserSetRoleQuery, user.ID, user.Role, user.UpdatedAt)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", user.ID).Error("Ошибка изменения роли пользователя")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithError(err).Error("Ошибка получения количества обновленных строк")
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.logger.WithField("user_id", user.ID).Warn("Пользователь для изменения роли не найден")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) SetBanned(ctx context.Context, user *entities.User) error {
+	_, err := r.db.ExecContext(ctx, UserSetBannedQuery, user.ID, user.BannedAt)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", user.ID).Error("Ошибка блокировки пользователя")
+		return err
+	}
+
+	return nil
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	result, err := r.db.ExecContext(ctx, UserDeleteQuery, id)
 	if err != nil {
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/pkg/auth"
+	"ozon-posts/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// actorFromContext возвращает ID аутентифицированного пользователя, выполняющего запрос
+func actorFromContext(ctx context.Context) (uuid.UUID, error) {
+	actorID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return uuid.Nil, errors.NewUnauthorizedError()
+	}
+	return actorID, nil
+}
@@ -5,119 +5,197 @@ import (
 	"ozon-posts/internal/entities"
 	"ozon-posts/pkg/errors"
 	"strings"
-	"sync"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+type CommentEventType string
+
+const (
+	CommentEventCreated          CommentEventType = "comment_created"
+	CommentEventUpdated          CommentEventType = "comment_updated"
+	CommentEventDeleted          CommentEventType = "comment_deleted"
+	CommentEventCommentsDisabled CommentEventType = "comments_disabled"
+	CommentEventCommentsEnabled  CommentEventType = "comments_enabled"
+	CommentEventPurged           CommentEventType = "comment_purged"
+	CommentEventReactionAdded    CommentEventType = "reaction_added"
+	CommentEventReactionRemoved  CommentEventType = "reaction_removed"
+	CommentEventPostHidden       CommentEventType = "post_hidden"
+	CommentEventPostRestored     CommentEventType = "post_restored"
+)
+
+// CommentEvent - событие для подписчиков поста. Comment равен nil для событий переключения комментариев,
+// скрытия поста и реакций, Reaction заполнен только у событий реакций.
+// Sequence монотонно возрастает в пределах поста и присваивается шиной событий при публикации.
 type CommentEvent struct {
-	Type    string            `json:"type"`
-	PostID  uuid.UUID         `json:"post_id"`
-	Comment *entities.Comment `json:"comment"`
+	Sequence int64             `json:"sequence"`
+	Type     CommentEventType  `json:"type"`
+	PostID   uuid.UUID         `json:"post_id"`
+	Comment  *entities.Comment `json:"comment"`
+	Reaction *ReactionEvent    `json:"reaction,omitempty"`
 }
 
 type CommentService struct {
 	commentRepo CommentRepository
 	postRepo    PostRepository
 	userRepo    UserRepository
+	uow         UnitOfWork
+	policy      *Policy
+	events      EventBus
 	logger      *logrus.Logger
-
-	subscribers map[uuid.UUID][]chan *CommentEvent
-	mu          sync.RWMutex
 }
 
 func NewCommentService(
 	commentRepo CommentRepository,
 	postRepo PostRepository,
 	userRepo UserRepository,
+	uow UnitOfWork,
+	events EventBus,
 	logger *logrus.Logger,
 ) *CommentService {
 	return &CommentService{
 		commentRepo: commentRepo,
 		postRepo:    postRepo,
 		userRepo:    userRepo,
+		uow:         uow,
+		policy:      NewPolicy(userRepo),
+		events:      events,
 		logger:      logger,
-		subscribers: make(map[uuid.UUID][]chan *CommentEvent),
 	}
 }
 
-func (s *CommentService) CreateComment(ctx context.Context, postID, authorID uuid.UUID, content string, parentID *uuid.UUID) (*entities.Comment, error) {
+func (s *CommentService) CreateComment(ctx context.Context, postID uuid.UUID, content string, parentID *uuid.UUID) (*entities.Comment, error) {
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"post_id":   postID,
 		"author_id": authorID,
 		"parent_id": parentID,
 	}).Info("Создание нового комментария")
 
-	post, err := s.postRepo.GetByID(ctx, postID)
-	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения поста")
-		return nil, errors.NewDatabaseError(err)
-	}
+	// Пост и родительский комментарий блокируются на запись: их счетчики меняются в этой же
+	// транзакции, а разделяемая блокировка, повышаемая до записи, приводит к взаимоблокировке
+	// параллельных комментаторов. Отключение комментариев дождется завершения транзакции,
+	// и комментарий не будет сохранен после того, как их отключили. Пост всегда блокируется
+	// раньше комментариев, как и в PurgeComment.
+	var (
+		post          *entities.Post
+		author        *entities.User
+		parentComment *entities.Comment
+		comment       *entities.Comment
+		mentioned     []uuid.UUID
+		notifications []*entities.Notification
+	)
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		var err error
+		post, err = repos.Posts.GetByIDForUpdate(ctx, postID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения поста")
+			return errors.NewDatabaseError(err)
+		}
 
-	if post == nil {
-		s.logger.WithField("post_id", postID).Warn("Пост не найден")
-		return nil, errors.NewPostNotFoundError(postID.String())
-	}
+		if post == nil || post.IsHidden() {
+			s.logger.WithField("post_id", postID).Warn("Пост не найден")
+			return errors.NewPostNotFoundError(postID.String())
+		}
 
-	if post.CommentsDisabled {
-		s.logger.WithField("post_id", postID).Warn("Комментарии к посту отключены")
-		return nil, errors.NewCommentsDisabledError()
-	}
+		if post.CommentsDisabled {
+			s.logger.WithField("post_id", postID).Warn("Комментарии к посту отключены")
+			return errors.NewCommentsDisabledError()
+		}
 
-	author, err := s.userRepo.GetByID(ctx, authorID)
-	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения автора комментария")
-		return nil, errors.NewDatabaseError(err)
-	}
+		author, err = repos.Users.GetByID(ctx, authorID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения автора комментария")
+			return errors.NewDatabaseError(err)
+		}
 
-	if author == nil {
-		s.logger.WithField("author_id", authorID).Warn("Автор комментария не найден")
-		return nil, errors.NewUserNotFoundError(authorID.String())
-	}
+		if author == nil {
+			s.logger.WithField("author_id", authorID).Warn("Автор комментария не найден")
+			return errors.NewUserNotFoundError(authorID.String())
+		}
+
+		if parentID != nil {
+			parentComment, err = repos.Comments.GetByIDForUpdate(ctx, *parentID)
+			if err != nil {
+				s.logger.WithError(err).Error("Ошибка получения родительского комментария")
+				return errors.NewDatabaseError(err)
+			}
+
+			if parentComment == nil {
+				s.logger.WithField("parent_id", *parentID).Warn("Родительский комментарий не найден")
+				return errors.NewCommentNotFoundError(parentID.String())
+			}
+
+			if parentComment.IsDeleted() {
+				s.logger.WithField("parent_id", *parentID).Warn("Попытка ответа на удаленный комментарий")
+				return errors.NewCommentDeletedError(parentID.String())
+			}
+
+			if parentComment.PostID != postID {
+				s.logger.WithFields(logrus.Fields{
+					"parent_post_id": parentComment.PostID,
+					"target_post_id": postID,
+				}).Warn("Родительский комментарий принадлежит другому посту")
+				return errors.NewInvalidCommentDataError("Родительский комментарий принадлежит другому посту")
+			}
+		}
 
-	var parentComment *entities.Comment
-	if parentID != nil {
-		parentComment, err = s.commentRepo.GetByID(ctx, *parentID)
+		comment, err = entities.NewComment(postID, authorID, content, parentComment)
 		if err != nil {
-			s.logger.WithError(err).Error("Ошибка получения родительского комментария")
-			return nil, errors.NewDatabaseError(err)
+			s.logger.WithError(err).Error("Ошибка создания комментария")
+			return err
 		}
 
-		if parentComment == nil {
-			s.logger.WithField("parent_id", *parentID).Warn("Родительский комментарий не найден")
-			return nil, errors.NewCommentNotFoundError(parentID.String())
+		// Все чтения выполняются до первой записи: in-memory UnitOfWork не откатывает изменения
+		mentionIDs, err := s.resolveMentions(ctx, repos, comment.Content)
+		if err != nil {
+			return err
 		}
 
-		if parentComment.PostID != postID {
-			s.logger.WithFields(logrus.Fields{
-				"parent_post_id": parentComment.PostID,
-				"target_post_id": postID,
-			}).Warn("Родительский комментарий принадлежит другому посту")
-			return nil, errors.NewInvalidCommentDataError("Родительский комментарий принадлежит другому посту")
+		if err := repos.Comments.Create(ctx, comment); err != nil {
+			s.logger.WithError(err).Error("Ошибка сохранения комментария")
+			return errors.NewDatabaseError(err)
 		}
-	}
 
-	comment, err := entities.NewComment(postID, authorID, content, parentComment)
+		if err := s.addToCounters(ctx, repos, comment, 1); err != nil {
+			return err
+		}
+
+		mentioned, err = s.saveMentions(ctx, repos, comment, mentionIDs)
+		if err != nil {
+			return err
+		}
+
+		// Оповещения сохраняются вместе с комментарием, чтобы не появиться во входящих без него
+		notifications = entities.NotificationsForComment(comment, post, parentComment)
+		if len(notifications) > 0 {
+			if err := repos.Notifications.Create(ctx, notifications); err != nil {
+				s.logger.WithError(err).Error("Ошибка сохранения оповещений о комментарии")
+				return errors.NewDatabaseError(err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		s.logger.WithError(err).Error("Ошибка создания комментария")
 		return nil, err
 	}
 
-	if err := s.commentRepo.Create(ctx, comment); err != nil {
-		s.logger.WithError(err).Error("Ошибка сохранения комментария")
-		return nil, errors.NewDatabaseError(err)
-	}
-
 	comment.Author = author
 	comment.Post = post
 	comment.Parent = parentComment
 
 	s.notifySubscribers(postID, &CommentEvent{
-		Type:    "comment_created",
+		Type:    CommentEventCreated,
 		PostID:  postID,
 		Comment: comment,
 	})
+	s.notifyMentioned(comment, mentioned)
+	publishNotifications(s.events, notifications)
 
 	s.logger.WithField("comment_id", comment.ID).Info("Комментарий успешно создан")
 	return comment, nil
@@ -126,6 +204,12 @@ func (s *CommentService) CreateComment(ctx context.Context, postID, authorID uui
 func (s *CommentService) GetCommentByID(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
 	s.logger.WithField("comment_id", id).Debug("Получение комментария по ID")
 
+	return s.getVisibleComment(ctx, id)
+}
+
+// getVisibleComment возвращает комментарий, если его пост не скрыт модератором: комментарии
+// скрытого поста недоступны так же, как и сам пост
+func (s *CommentService) getVisibleComment(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
 	comment, err := s.commentRepo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.WithError(err).Error("Ошибка получения комментария")
@@ -137,20 +221,99 @@ func (s *CommentService) GetCommentByID(ctx context.Context, id uuid.UUID) (*ent
 		return nil, errors.NewCommentNotFoundError(id.String())
 	}
 
-	if err := s.loadCommentRelations(ctx, comment); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки связанных данных комментария")
+	visible, err := s.postRepo.Exists(ctx, comment.PostID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки существования поста")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	if !visible {
+		s.logger.WithField("comment_id", id).Warn("Комментарий принадлежит скрытому посту")
+		return nil, errors.NewCommentNotFoundError(id.String())
 	}
 
 	return comment, nil
 }
 
-func (s *CommentService) GetPostComments(ctx context.Context, postID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+func (s *CommentService) GetCommentsByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Comment, error) {
+	s.logger.WithField("ids_count", len(ids)).Debug("Получение комментариев по списку ID")
+
+	comments, err := s.commentRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения комментариев по ID")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	return comments, nil
+}
+
+// GetFirstCommentsByPostIDs возвращает первую страницу корневых комментариев и их общее число
+// сразу для нескольких постов: один запрос выборки и один подсчет на весь список
+func (s *CommentService) GetFirstCommentsByPostIDs(ctx context.Context, postIDs []uuid.UUID, limit int) (map[uuid.UUID][]*entities.Comment, map[uuid.UUID]int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"posts_count": len(postIDs),
+		"limit":       limit,
+	}).Debug("Получение комментариев для списка постов")
+
+	comments, err := s.commentRepo.GetTopLevelByPostIDs(ctx, postIDs, limit)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения комментариев для списка постов")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	counts, err := s.commentRepo.CountTopLevelByPostIDs(ctx, postIDs)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка подсчета комментариев для списка постов")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	byPost := make(map[uuid.UUID][]*entities.Comment, len(postIDs))
+	for _, comment := range comments {
+		byPost[comment.PostID] = append(byPost[comment.PostID], comment)
+	}
+
+	return byPost, counts, nil
+}
+
+// GetFirstRepliesByParentIDs - то же, что GetFirstCommentsByPostIDs, для ответов на комментарии
+func (s *CommentService) GetFirstRepliesByParentIDs(ctx context.Context, parentIDs []uuid.UUID, limit int) (map[uuid.UUID][]*entities.Comment, map[uuid.UUID]int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"parents_count": len(parentIDs),
+		"limit":         limit,
+	}).Debug("Получение ответов для списка комментариев")
+
+	replies, err := s.commentRepo.GetChildrenByParentIDs(ctx, parentIDs, limit)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения ответов для списка комментариев")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	counts, err := s.commentRepo.CountByParentIDs(ctx, parentIDs)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка подсчета ответов для списка комментариев")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	byParent := make(map[uuid.UUID][]*entities.Comment, len(parentIDs))
+	for _, reply := range replies {
+		byParent[*reply.ParentID] = append(byParent[*reply.ParentID], reply)
+	}
+
+	return byParent, counts, nil
+}
+
+func (s *CommentService) GetPostComments(ctx context.Context, postID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
 	s.logger.WithFields(logrus.Fields{
 		"post_id": postID,
+		"sort":    sort,
 		"limit":   pagination.Limit,
 		"offset":  pagination.Offset,
 	}).Debug("Получение комментариев поста")
 
+	if !sort.IsValid() {
+		return nil, nil, errors.NewValidationError("Неизвестный порядок комментариев")
+	}
+
 	exists, err := s.postRepo.Exists(ctx, postID)
 	if err != nil {
 		s.logger.WithError(err).Error("Ошибка проверки существования поста")
@@ -161,47 +324,120 @@ func (s *CommentService) GetPostComments(ctx context.Context, postID uuid.UUID,
 		return nil, nil, errors.NewPostNotFoundError(postID.String())
 	}
 
-	comments, paginationResponse, err := s.commentRepo.GetByPostID(ctx, postID, pagination)
+	comments, paginationResponse, err := s.commentRepo.GetByPostID(ctx, postID, sort, pagination)
 	if err != nil {
 		s.logger.WithError(err).Error("Ошибка получения комментариев поста")
 		return nil, nil, errors.NewDatabaseError(err)
 	}
 
-	if err := s.loadCommentsRelations(ctx, comments); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки связанных данных комментариев")
+	return comments, paginationResponse, nil
+}
+
+// SearchComments ищет комментарии по тексту, при заданном postID - только в этом посте.
+// Удаленные комментарии в поиск не попадают.
+func (s *CommentService) SearchComments(ctx context.Context, text string, postID *uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.CommentSearchResult, *entities.PaginationResponse, error) {
+	query, err := entities.NewSearchQuery(text, postID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return comments, paginationResponse, nil
+	s.logger.WithFields(logrus.Fields{
+		"query":   query.Text,
+		"post_id": postID,
+		"limit":   pagination.Limit,
+		"offset":  pagination.Offset,
+	}).Debug("Поиск комментариев")
+
+	if postID != nil {
+		exists, err := s.postRepo.Exists(ctx, *postID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка проверки существования поста")
+			return nil, nil, errors.NewDatabaseError(err)
+		}
+
+		if !exists {
+			return nil, nil, errors.NewPostNotFoundError(postID.String())
+		}
+	}
+
+	results, paginationResponse, err := s.commentRepo.Search(ctx, query, pagination)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка поиска комментариев")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return results, paginationResponse, nil
 }
 
-func (s *CommentService) GetCommentReplies(ctx context.Context, parentID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+func (s *CommentService) GetCommentReplies(ctx context.Context, parentID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
 	s.logger.WithFields(logrus.Fields{
 		"parent_id": parentID,
+		"sort":      sort,
 		"limit":     pagination.Limit,
 		"offset":    pagination.Offset,
 	}).Debug("Получение ответов на комментарий")
 
-	exists, err := s.commentRepo.Exists(ctx, parentID)
+	if !sort.IsValid() {
+		return nil, nil, errors.NewValidationError("Неизвестный порядок ответов")
+	}
+
+	if _, err := s.getVisibleComment(ctx, parentID); err != nil {
+		return nil, nil, err
+	}
+
+	replies, paginationResponse, err := s.commentRepo.GetByParentID(ctx, parentID, sort, pagination)
 	if err != nil {
-		s.logger.WithError(err).Error("Ошибка проверки существования комментария")
+		s.logger.WithError(err).Error("Ошибка получения ответов на комментарий")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return replies, paginationResponse, nil
+}
+
+func (s *CommentService) GetPostCommentsPage(ctx context.Context, postID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	s.logger.WithFields(logrus.Fields{
+		"post_id":  postID,
+		"limit":    req.Limit,
+		"from_end": req.FromEnd,
+	}).Debug("Получение страницы комментариев поста по курсору")
+
+	exists, err := s.postRepo.Exists(ctx, postID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки существования поста")
 		return nil, nil, errors.NewDatabaseError(err)
 	}
 
 	if !exists {
-		return nil, nil, errors.NewCommentNotFoundError(parentID.String())
+		return nil, nil, errors.NewPostNotFoundError(postID.String())
 	}
 
-	replies, paginationResponse, err := s.commentRepo.GetByParentID(ctx, parentID, pagination)
+	comments, pageInfo, err := s.commentRepo.GetByPostIDKeyset(ctx, postID, req)
 	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения ответов на комментарий")
+		s.logger.WithError(err).Error("Ошибка получения страницы комментариев поста")
 		return nil, nil, errors.NewDatabaseError(err)
 	}
 
-	if err := s.loadCommentsRelations(ctx, replies); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки связанных данных ответов")
+	return comments, pageInfo, nil
+}
+
+func (s *CommentService) GetCommentRepliesPage(ctx context.Context, parentID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	s.logger.WithFields(logrus.Fields{
+		"parent_id": parentID,
+		"limit":     req.Limit,
+		"from_end":  req.FromEnd,
+	}).Debug("Получение страницы ответов на комментарий по курсору")
+
+	if _, err := s.getVisibleComment(ctx, parentID); err != nil {
+		return nil, nil, err
 	}
 
-	return replies, paginationResponse, nil
+	replies, pageInfo, err := s.commentRepo.GetByParentIDKeyset(ctx, parentID, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы ответов на комментарий")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return replies, pageInfo, nil
 }
 
 func (s *CommentService) GetCommentThread(ctx context.Context, commentID uuid.UUID, maxDepth int) ([]*entities.Comment, error) {
@@ -210,14 +446,8 @@ func (s *CommentService) GetCommentThread(ctx context.Context, commentID uuid.UU
 		"max_depth":  maxDepth,
 	}).Debug("Получение ветки комментариев")
 
-	exists, err := s.commentRepo.Exists(ctx, commentID)
-	if err != nil {
-		s.logger.WithError(err).Error("Ошибка проверки существования комментария")
-		return nil, errors.NewDatabaseError(err)
-	}
-
-	if !exists {
-		return nil, errors.NewCommentNotFoundError(commentID.String())
+	if _, err := s.getVisibleComment(ctx, commentID); err != nil {
+		return nil, err
 	}
 
 	comments, err := s.commentRepo.GetThread(ctx, commentID, maxDepth)
@@ -226,238 +456,524 @@ func (s *CommentService) GetCommentThread(ctx context.Context, commentID uuid.UU
 		return nil, errors.NewDatabaseError(err)
 	}
 
-	if err := s.loadCommentsRelations(ctx, comments); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки связанных данных ветки")
+	return comments, nil
+}
+
+// GetCommentTree собирает ветку комментария в дерево уровень за уровнем: на каждый уровень
+// уходит один запрос детей и один подсчет, а размер ответа ограничен параметрами запроса
+func (s *CommentService) GetCommentTree(ctx context.Context, commentID uuid.UUID, req *entities.CommentTreeRequest) (*entities.CommentTreeNode, error) {
+	s.logger.WithFields(logrus.Fields{
+		"comment_id":   commentID,
+		"max_depth":    req.MaxDepth,
+		"child_limits": req.ChildLimits,
+	}).Debug("Получение дерева комментариев")
+
+	rootComment, err := s.getVisibleComment(ctx, commentID)
+	if err != nil {
+		return nil, err
 	}
 
-	return comments, nil
+	root := &entities.CommentTreeNode{Comment: rootComment, Children: []*entities.CommentTreeNode{}}
+	frontier := []*entities.CommentTreeNode{root}
+	budget := req.MaxNodes - 1
+
+	for depth := 0; len(frontier) > 0; depth++ {
+		parentIDs := make([]uuid.UUID, 0, len(frontier))
+		for _, node := range frontier {
+			parentIDs = append(parentIDs, node.Comment.ID)
+		}
+
+		counts, err := s.commentRepo.CountByParentIDs(ctx, parentIDs)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка подсчета ответов в дереве комментариев")
+			return nil, errors.NewDatabaseError(err)
+		}
+
+		for _, node := range frontier {
+			node.ChildCount = int(counts[node.Comment.ID])
+			node.HasMoreChildren = node.ChildCount > 0
+		}
+
+		if depth >= req.MaxDepth || budget <= 0 {
+			break
+		}
+
+		children, err := s.commentRepo.GetChildrenByParentIDs(ctx, parentIDs, req.ChildLimit(depth))
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения ответов в дереве комментариев")
+			return nil, errors.NewDatabaseError(err)
+		}
+
+		byParent := make(map[uuid.UUID][]*entities.Comment, len(frontier))
+		for _, child := range children {
+			byParent[*child.ParentID] = append(byParent[*child.ParentID], child)
+		}
+
+		// Бюджет узлов распределяется в порядке обхода, чтобы ранние ветки не обрывались из-за поздних
+		next := make([]*entities.CommentTreeNode, 0, len(children))
+		for _, node := range frontier {
+			for _, child := range byParent[node.Comment.ID] {
+				if budget <= 0 {
+					break
+				}
+
+				childNode := &entities.CommentTreeNode{Comment: child, Children: []*entities.CommentTreeNode{}}
+				node.Children = append(node.Children, childNode)
+				next = append(next, childNode)
+				budget--
+			}
+			node.HasMoreChildren = node.ChildCount > len(node.Children)
+		}
+
+		frontier = next
+	}
+
+	return root, nil
 }
 
-func (s *CommentService) DeleteComment(ctx context.Context, commentID, authorID uuid.UUID) error {
+func (s *CommentService) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"comment_id": commentID,
 		"author_id":  authorID,
 	}).Info("Удаление комментария")
 
-	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	var comment *entities.Comment
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		var err error
+		comment, err = repos.Comments.GetByIDForUpdate(ctx, commentID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения комментария для удаления")
+			return errors.NewDatabaseError(err)
+		}
+
+		if comment == nil {
+			return errors.NewCommentNotFoundError(commentID.String())
+		}
+
+		if comment.IsDeleted() {
+			return errors.NewCommentDeletedError(commentID.String())
+		}
+
+		allowed, err := s.policy.Allowed(ctx, authorID, ActionDeleteComment, comment.AuthorID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка проверки прав доступа к комментарию")
+			return err
+		}
+
+		if !allowed {
+			s.logger.WithFields(logrus.Fields{
+				"comment_author_id": comment.AuthorID,
+				"requester_id":      authorID,
+			}).Warn("Попытка удаления чужого комментария")
+			return errors.NewCommentAccessDeniedError(commentID.String())
+		}
+
+		comment.MarkDeleted(authorID)
+
+		if err := repos.Comments.SoftDelete(ctx, comment); err != nil {
+			s.logger.WithError(err).Error("Ошибка удаления комментария")
+			return errors.NewDatabaseError(err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения комментария для удаления")
-		return errors.NewDatabaseError(err)
+		return err
 	}
 
-	if comment == nil {
-		return errors.NewCommentNotFoundError(commentID.String())
+	s.notifySubscribers(comment.PostID, &CommentEvent{
+		Type:    CommentEventDeleted,
+		PostID:  comment.PostID,
+		Comment: comment,
+	})
+
+	s.logger.WithField("comment_id", commentID).Info("Комментарий успешно удален")
+	return nil
+}
+
+// PurgeComment безвозвратно удаляет комментарий вместе со всей веткой ответов (только для администраторов)
+func (s *CommentService) PurgeComment(ctx context.Context, commentID uuid.UUID) (int64, error) {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return 0, err
 	}
 
-	if comment.AuthorID != authorID {
-		s.logger.WithFields(logrus.Fields{
-			"comment_author_id": comment.AuthorID,
-			"requester_id":      authorID,
-		}).Warn("Попытка удаления чужого комментария")
-		return errors.NewCommentAccessDeniedError(commentID.String())
+	s.logger.WithFields(logrus.Fields{
+		"comment_id": commentID,
+		"actor_id":   actorID,
+	}).Info("Полная очистка ветки комментария")
+
+	var (
+		comment *entities.Comment
+		purged  int64
+	)
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		// Пост блокируется раньше комментария, как в CreateComment: иначе очистка и ответ
+		// на тот же комментарий ждали бы друг друга, обновляя счетчики
+		target, err := repos.Comments.GetByID(ctx, commentID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения комментария для очистки")
+			return errors.NewDatabaseError(err)
+		}
+
+		if target == nil {
+			return errors.NewCommentNotFoundError(commentID.String())
+		}
+
+		if _, err := repos.Posts.GetByIDForUpdate(ctx, target.PostID); err != nil {
+			s.logger.WithError(err).Error("Ошибка блокировки поста для очистки комментария")
+			return errors.NewDatabaseError(err)
+		}
+
+		comment, err = repos.Comments.GetByIDForUpdate(ctx, commentID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения комментария для очистки")
+			return errors.NewDatabaseError(err)
+		}
+
+		if comment == nil {
+			return errors.NewCommentNotFoundError(commentID.String())
+		}
+
+		allowed, err := s.policy.Allowed(ctx, actorID, ActionPurgeComment, comment.AuthorID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка проверки прав доступа к комментарию")
+			return err
+		}
+
+		if !allowed {
+			s.logger.WithField("requester_id", actorID).Warn("Попытка полной очистки комментария без прав администратора")
+			return errors.NewCommentAccessDeniedError(commentID.String())
+		}
+
+		purged, err = repos.Comments.Purge(ctx, commentID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка полной очистки ветки комментария")
+			return errors.NewDatabaseError(err)
+		}
+
+		// Счетчики комментариев внутри ветки уходят вместе с ними, меняется только счетчик родителя
+		if err := s.addToCounters(ctx, repos, comment, -1); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	if err := s.commentRepo.Delete(ctx, commentID); err != nil {
-		s.logger.WithError(err).Error("Ошибка удаления комментария")
-		return errors.NewDatabaseError(err)
+	// Подписчики получают только идентификацию ветки, текст очищенного комментария не рассылается
+	if !comment.IsDeleted() {
+		comment.MarkDeleted(actorID)
 	}
 
-	s.logger.WithField("comment_id", commentID).Info("Комментарий успешно удален")
-	return nil
-}
+	s.notifySubscribers(comment.PostID, &CommentEvent{
+		Type:    CommentEventPurged,
+		PostID:  comment.PostID,
+		Comment: comment,
+	})
 
-func (s *CommentService) UpdateComment(ctx context.Context, commentID, authorID uuid.UUID, content string) (*entities.Comment, error) {
 	s.logger.WithFields(logrus.Fields{
 		"comment_id": commentID,
-		"author_id":  authorID,
-	}).Info("Обновление комментария")
+		"purged":     purged,
+	}).Info("Ветка комментария полностью удалена")
+	return purged, nil
+}
 
-	if strings.TrimSpace(content) == "" {
-		return nil, errors.NewCommentEmptyError()
+// addToCounters учитывает появление (delta = 1) или исчезновение (delta = -1) комментария
+// в счетчике родителя: у ответа это число ответов комментария, у корневого - число комментариев поста
+func (s *CommentService) addToCounters(ctx context.Context, repos Repositories, comment *entities.Comment, delta int64) error {
+	if comment.ParentID != nil {
+		if err := repos.Comments.AddReplyCount(ctx, *comment.ParentID, delta); err != nil {
+			s.logger.WithError(err).Error("Ошибка обновления счетчика ответов")
+			return errors.NewDatabaseError(err)
+		}
+		return nil
 	}
 
-	if len(content) > entities.MaxCommentLength {
-		return nil, errors.NewCommentTooLongError(entities.MaxCommentLength)
+	if err := repos.Posts.AddCommentCount(ctx, comment.PostID, delta); err != nil {
+		s.logger.WithError(err).Error("Ошибка обновления счетчика комментариев поста")
+		return errors.NewDatabaseError(err)
 	}
+	return nil
+}
 
-	comment, err := s.commentRepo.GetByID(ctx, commentID)
+// resolveMentions находит пользователей, упомянутых в тексте комментария; имена, которых нет
+// среди пользователей, остаются обычным текстом
+func (s *CommentService) resolveMentions(ctx context.Context, repos Repositories, content string) ([]uuid.UUID, error) {
+	usernames := entities.ParseMentions(content)
+	userIDs := make([]uuid.UUID, 0, len(usernames))
+	for _, username := range usernames {
+		user, err := repos.Users.GetByUsername(ctx, username)
+		if err != nil {
+			s.logger.WithError(err).WithField("username", username).Error("Ошибка получения упомянутого пользователя")
+			return nil, errors.NewDatabaseError(err)
+		}
+
+		if user != nil {
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+
+	return userIDs, nil
+}
+
+// saveMentions сохраняет упоминания, найденные resolveMentions, и возвращает упомянутых впервые,
+// кроме автора. Для нового комментария без упоминаний репозиторий не вызывается.
+func (s *CommentService) saveMentions(ctx context.Context, repos Repositories, comment *entities.Comment, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if len(userIDs) == 0 && comment.EditCount == 0 {
+		return nil, nil
+	}
+
+	added, err := repos.Comments.ReplaceMentions(ctx, comment.ID, userIDs)
 	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения комментария для обновления")
+		s.logger.WithError(err).Error("Ошибка сохранения упоминаний комментария")
 		return nil, errors.NewDatabaseError(err)
 	}
 
-	if comment == nil {
-		return nil, errors.NewCommentNotFoundError(commentID.String())
+	recipients := make([]uuid.UUID, 0, len(added))
+	for _, userID := range added {
+		if userID != comment.AuthorID {
+			recipients = append(recipients, userID)
+		}
 	}
 
-	if comment.AuthorID != authorID {
+	return recipients, nil
+}
+
+// notifyMentioned оповещает подписчиков упомянутых пользователей после фиксации транзакции
+func (s *CommentService) notifyMentioned(comment *entities.Comment, userIDs []uuid.UUID) {
+	for _, userID := range userIDs {
+		s.events.PublishUserEvent(&UserEvent{
+			Type:    UserEventMentioned,
+			UserID:  userID,
+			Comment: comment,
+		})
+	}
+
+	if len(userIDs) > 0 {
 		s.logger.WithFields(logrus.Fields{
-			"comment_author_id": comment.AuthorID,
-			"requester_id":      authorID,
-		}).Warn("Попытка обновления чужого комментария")
-		return nil, errors.NewCommentAccessDeniedError(commentID.String())
+			"comment_id": comment.ID,
+			"mentioned":  len(userIDs),
+		}).Debug("Отправлены оповещения об упоминаниях")
+	}
+}
+
+func (s *CommentService) UpdateComment(ctx context.Context, commentID uuid.UUID, content string) (*entities.Comment, error) {
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	comment.Content = content
+	s.logger.WithFields(logrus.Fields{
+		"comment_id": commentID,
+		"author_id":  authorID,
+	}).Info("Обновление комментария")
 
-	if err := s.commentRepo.Update(ctx, comment); err != nil {
-		s.logger.WithError(err).Error("Ошибка обновления комментария")
-		return nil, errors.NewDatabaseError(err)
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.NewCommentEmptyError()
 	}
 
-	if err := s.loadCommentRelations(ctx, comment); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки связанных данных комментария")
+	if len(content) > entities.MaxCommentLength {
+		return nil, errors.NewCommentTooLongError(entities.MaxCommentLength)
 	}
 
-	s.logger.WithField("comment_id", commentID).Info("Комментарий успешно обновлен")
-	return comment, nil
-}
+	var (
+		comment   *entities.Comment
+		unchanged bool
+		mentioned []uuid.UUID
+	)
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		var err error
+		comment, err = repos.Comments.GetByIDForUpdate(ctx, commentID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения комментария для обновления")
+			return errors.NewDatabaseError(err)
+		}
 
-func (s *CommentService) SubscribeToPost(postID uuid.UUID) <-chan *CommentEvent {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+		if comment == nil {
+			return errors.NewCommentNotFoundError(commentID.String())
+		}
 
-	ch := make(chan *CommentEvent, 10)
+		if comment.IsDeleted() {
+			return errors.NewCommentDeletedError(commentID.String())
+		}
 
-	if s.subscribers[postID] == nil {
-		s.subscribers[postID] = make([]chan *CommentEvent, 0)
-	}
+		allowed, err := s.policy.Allowed(ctx, authorID, ActionUpdateComment, comment.AuthorID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка проверки прав доступа к комментарию")
+			return err
+		}
 
-	s.subscribers[postID] = append(s.subscribers[postID], ch)
+		if !allowed {
+			s.logger.WithFields(logrus.Fields{
+				"comment_author_id": comment.AuthorID,
+				"requester_id":      authorID,
+			}).Warn("Попытка обновления чужого комментария")
+			return errors.NewCommentAccessDeniedError(commentID.String())
+		}
 
-	s.logger.WithField("post_id", postID).Debug("Добавлена подписка на комментарии поста")
+		if comment.Content == content {
+			unchanged = true
+			return nil
+		}
 
-	return ch
-}
+		// Все чтения выполняются до первой записи: in-memory UnitOfWork не откатывает изменения
+		mentionIDs, err := s.resolveMentions(ctx, repos, content)
+		if err != nil {
+			return err
+		}
 
-func (s *CommentService) UnsubscribeFromPost(postID uuid.UUID, ch <-chan *CommentEvent) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+		if err := repos.Comments.CreateRevision(ctx, entities.NewCommentRevision(comment)); err != nil {
+			s.logger.WithError(err).Error("Ошибка сохранения прежней версии комментария")
+			return errors.NewDatabaseError(err)
+		}
 
-	subscribers := s.subscribers[postID]
-	for i, subscriber := range subscribers {
-		if subscriber == ch {
-			s.subscribers[postID] = append(subscribers[:i], subscribers[i+1:]...)
-			close(subscriber)
-			break
+		comment.ApplyEdit(content, authorID)
+
+		if err := repos.Comments.Update(ctx, comment); err != nil {
+			s.logger.WithError(err).Error("Ошибка обновления комментария")
+			return errors.NewDatabaseError(err)
 		}
+
+		// Оповещаются только пользователи, упомянутые правкой впервые
+		mentioned, err = s.saveMentions(ctx, repos, comment, mentionIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if len(s.subscribers[postID]) == 0 {
-		delete(s.subscribers, postID)
+	if unchanged {
+		return comment, nil
 	}
 
-	s.logger.WithField("post_id", postID).Debug("Удалена подписка на комментарии поста")
+	s.notifySubscribers(comment.PostID, &CommentEvent{
+		Type:    CommentEventUpdated,
+		PostID:  comment.PostID,
+		Comment: comment,
+	})
+	s.notifyMentioned(comment, mentioned)
+
+	s.logger.WithField("comment_id", commentID).Info("Комментарий успешно обновлен")
+	return comment, nil
 }
 
-func (s *CommentService) notifySubscribers(postID uuid.UUID, event *CommentEvent) {
-	s.mu.RLock()
-	subscribers := s.subscribers[postID]
-	s.mu.RUnlock()
+// SubscribeToPost подписывает на события поста; afterSequence позволяет возобновить поток после переподключения
+func (s *CommentService) SubscribeToPost(postID uuid.UUID, afterSequence *int64) (*Subscription, error) {
+	return s.events.Subscribe(postID, afterSequence)
+}
 
-	if len(subscribers) == 0 {
-		return
+func (s *CommentService) UnsubscribeFromPost(sub *Subscription) {
+	s.events.Unsubscribe(sub)
+}
+
+// SubscribeToUserEvents подписывает пользователя из контекста на адресованные ему события
+func (s *CommentService) SubscribeToUserEvents(ctx context.Context) (*UserSubscription, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	s.logger.WithFields(logrus.Fields{
-		"post_id":           postID,
-		"subscribers_count": len(subscribers),
-		"event_type":        event.Type,
-	}).Debug("Отправка уведомления подписчикам")
+	return s.events.SubscribeUser(userID), nil
+}
 
-	for _, ch := range subscribers {
-		select {
-		case ch <- event:
-		default:
-			s.logger.Warn("Канал подписчика заблокирован, пропускаем уведомление")
-		}
-	}
+func (s *CommentService) UnsubscribeFromUserEvents(sub *UserSubscription) {
+	s.events.UnsubscribeUser(sub)
 }
 
-func (s *CommentService) loadCommentRelations(ctx context.Context, comment *entities.Comment) error {
-	if author, err := s.userRepo.GetByID(ctx, comment.AuthorID); err == nil && author != nil {
-		comment.Author = author
-	}
+// GetMentionsByCommentIDs возвращает упомянутых в комментариях пользователей одним запросом
+// упоминаний и одним запросом пользователей на весь список
+func (s *CommentService) GetMentionsByCommentIDs(ctx context.Context, commentIDs []uuid.UUID) (map[uuid.UUID][]*entities.User, error) {
+	s.logger.WithField("comments_count", len(commentIDs)).Debug("Получение упоминаний для списка комментариев")
 
-	if post, err := s.postRepo.GetByID(ctx, comment.PostID); err == nil && post != nil {
-		comment.Post = post
+	mentions, err := s.commentRepo.GetMentionsByCommentIDs(ctx, commentIDs)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения упоминаний комментариев")
+		return nil, errors.NewDatabaseError(err)
 	}
 
-	if comment.ParentID != nil {
-		if parent, err := s.commentRepo.GetByID(ctx, *comment.ParentID); err == nil && parent != nil {
-			comment.Parent = parent
+	var userIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, mentioned := range mentions {
+		for _, userID := range mentioned {
+			if !seen[userID] {
+				seen[userID] = true
+				userIDs = append(userIDs, userID)
+			}
 		}
 	}
 
-	return nil
-}
-
-func (s *CommentService) loadCommentsRelations(ctx context.Context, comments []*entities.Comment) error {
-	if len(comments) == 0 {
-		return nil
+	result := make(map[uuid.UUID][]*entities.User, len(mentions))
+	if len(userIDs) == 0 {
+		return result, nil
 	}
 
-	userIDs := make([]uuid.UUID, 0)
-	postIDs := make([]uuid.UUID, 0)
-	parentIDs := make([]uuid.UUID, 0)
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения упомянутых пользователей")
+		return nil, errors.NewDatabaseError(err)
+	}
 
-	userIDSet := make(map[uuid.UUID]bool)
-	postIDSet := make(map[uuid.UUID]bool)
-	parentIDSet := make(map[uuid.UUID]bool)
+	byID := make(map[uuid.UUID]*entities.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
 
-	for _, comment := range comments {
-		if !userIDSet[comment.AuthorID] {
-			userIDs = append(userIDs, comment.AuthorID)
-			userIDSet[comment.AuthorID] = true
+	for commentID, mentioned := range mentions {
+		for _, userID := range mentioned {
+			if user, ok := byID[userID]; ok {
+				result[commentID] = append(result[commentID], user)
+			}
 		}
+	}
 
-		if !postIDSet[comment.PostID] {
-			postIDs = append(postIDs, comment.PostID)
-			postIDSet[comment.PostID] = true
-		}
+	return result, nil
+}
 
-		if comment.ParentID != nil && !parentIDSet[*comment.ParentID] {
-			parentIDs = append(parentIDs, *comment.ParentID)
-			parentIDSet[*comment.ParentID] = true
-		}
-	}
+func (s *CommentService) notifySubscribers(postID uuid.UUID, event *CommentEvent) {
+	s.events.Publish(postID, event)
+}
 
-	users, _ := s.userRepo.GetByIDs(ctx, userIDs)
-	userMap := make(map[uuid.UUID]*entities.User)
-	for _, user := range users {
-		userMap[user.ID] = user
+// GetCommentRevisions возвращает прежние версии комментария от исходной к последней.
+// История доступна автору комментария и модераторам.
+func (s *CommentService) GetCommentRevisions(ctx context.Context, commentID uuid.UUID) ([]*entities.CommentRevision, error) {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	posts, _ := s.postRepo.GetByIDs(ctx, postIDs)
-	postMap := make(map[uuid.UUID]*entities.Post)
-	for _, post := range posts {
-		postMap[post.ID] = post
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения комментария для просмотра истории")
+		return nil, errors.NewDatabaseError(err)
 	}
 
-	var parentMap map[uuid.UUID]*entities.Comment
-	if len(parentIDs) > 0 {
-		parents, _ := s.commentRepo.GetByIDs(ctx, parentIDs)
-		parentMap = make(map[uuid.UUID]*entities.Comment)
-		for _, parent := range parents {
-			parentMap[parent.ID] = parent
-		}
+	if comment == nil {
+		return nil, errors.NewCommentNotFoundError(commentID.String())
 	}
 
-	for _, comment := range comments {
-		if user, exists := userMap[comment.AuthorID]; exists {
-			comment.Author = user
-		}
+	allowed, err := s.policy.Allowed(ctx, actorID, ActionViewCommentHistory, comment.AuthorID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки прав доступа к истории комментария")
+		return nil, err
+	}
 
-		if post, exists := postMap[comment.PostID]; exists {
-			comment.Post = post
-		}
+	if !allowed {
+		return nil, errors.NewCommentAccessDeniedError(commentID.String())
+	}
 
-		if comment.ParentID != nil {
-			if parent, exists := parentMap[*comment.ParentID]; exists {
-				comment.Parent = parent
-			}
-		}
+	revisions, err := s.commentRepo.GetRevisions(ctx, commentID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения истории правок комментария")
+		return nil, errors.NewDatabaseError(err)
 	}
 
-	return nil
+	return revisions, nil
 }
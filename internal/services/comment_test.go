@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCommentService_CreateComment_Success(t *testing.T) {
@@ -20,7 +21,7 @@ func TestCommentService_CreateComment_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
@@ -33,13 +34,14 @@ func TestCommentService_CreateComment_Success(t *testing.T) {
 	author := testutils2.CreateTestUser("testuser", "test@example.com")
 	author.ID = authorID
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(post, nil)
 	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
 	mockCommentRepo.On("Create", mock.Anything, mock.MatchedBy(func(comment *entities.Comment) bool {
 		return comment.PostID == postID && comment.AuthorID == authorID && comment.Content == content
 	})).Return(nil)
+	mockPostRepo.On("AddCommentCount", mock.Anything, postID, int64(1)).Return(nil)
 
-	comment, err := service.CreateComment(context.Background(), postID, authorID, content, nil)
+	comment, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, content, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, comment)
@@ -60,7 +62,7 @@ func TestCommentService_CreateComment_WithParent(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
@@ -77,14 +79,15 @@ func TestCommentService_CreateComment_WithParent(t *testing.T) {
 	parentComment := testutils2.CreateTestComment(postID, uuid.New(), "Parent comment", nil)
 	parentComment.ID = parentID
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(post, nil)
 	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
-	mockCommentRepo.On("GetByID", mock.Anything, parentID).Return(parentComment, nil)
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, parentID).Return(parentComment, nil)
 	mockCommentRepo.On("Create", mock.Anything, mock.MatchedBy(func(comment *entities.Comment) bool {
 		return comment.PostID == postID && comment.ParentID != nil && *comment.ParentID == parentID
 	})).Return(nil)
+	mockCommentRepo.On("AddReplyCount", mock.Anything, parentID, int64(1)).Return(nil)
 
-	comment, err := service.CreateComment(context.Background(), postID, authorID, content, &parentID)
+	comment, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, content, &parentID)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, comment)
@@ -96,20 +99,147 @@ func TestCommentService_CreateComment_WithParent(t *testing.T) {
 	mockUserRepo.AssertExpectations(t)
 }
 
+func TestCommentService_CreateComment_Notifications(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	events := NewInProcessEventBus(DefaultEventBusOptions(), logger)
+	uow := newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, uow, events, logger)
+
+	postAuthorID := uuid.New()
+	post := testutils2.CreateTestPost(postAuthorID, "Test Post", "Content")
+	author := testutils2.CreateTestUser("replier", "replier@example.com")
+	parent := testutils2.CreateTestComment(post.ID, uuid.New(), "Parent comment", nil)
+
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, post.ID).Return(post, nil)
+	mockUserRepo.On("GetByID", mock.Anything, author.ID).Return(author, nil)
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, parent.ID).Return(parent, nil)
+	mockCommentRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockCommentRepo.On("AddReplyCount", mock.Anything, parent.ID, int64(1)).Return(nil)
+
+	parentSub := events.SubscribeUser(parent.AuthorID)
+	defer events.UnsubscribeUser(parentSub)
+	postAuthorSub := events.SubscribeUser(postAuthorID)
+	defer events.UnsubscribeUser(postAuthorSub)
+
+	comment, err := service.CreateComment(testutils2.CreateAuthContext(author.ID), post.ID, "Ответ", &parent.ID)
+	require.NoError(t, err)
+
+	created := uow.repos.Notifications.(*recordingNotifications).created
+	require.Len(t, created, 2)
+
+	for sub, expected := range map[*UserSubscription]entities.NotificationType{
+		parentSub:     entities.NotificationCommentReply,
+		postAuthorSub: entities.NotificationPostComment,
+	} {
+		select {
+		case event := <-sub.Events():
+			assert.Equal(t, UserEventNotification, event.Type)
+			assert.Equal(t, sub.UserID, event.Notification.UserID)
+			assert.Equal(t, expected, event.Notification.Type)
+			assert.Equal(t, comment.ID, event.Notification.CommentID)
+			assert.Equal(t, author.ID, event.Notification.ActorID)
+		default:
+			t.Fatalf("получатель %s не получил оповещение", sub.UserID)
+		}
+	}
+}
+
+func TestCommentService_CreateComment_Mentions(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	events := NewInProcessEventBus(DefaultEventBusOptions(), logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), events, logger)
+
+	post := testutils2.CreateTestPost(uuid.New(), "Test Post", "Content")
+	author := testutils2.CreateTestUser("author", "author@example.com")
+	alice := testutils2.CreateTestUser("alice", "alice@example.com")
+	bob := testutils2.CreateTestUser("bob", "bob@example.com")
+	content := "@alice и @bob, посмотрите; @ghost, @author"
+
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, post.ID).Return(post, nil)
+	mockUserRepo.On("GetByID", mock.Anything, author.ID).Return(author, nil)
+	mockCommentRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockPostRepo.On("AddCommentCount", mock.Anything, post.ID, int64(1)).Return(nil)
+	mockUserRepo.On("GetByUsername", mock.Anything, "alice").Return(alice, nil)
+	mockUserRepo.On("GetByUsername", mock.Anything, "bob").Return(bob, nil)
+	mockUserRepo.On("GetByUsername", mock.Anything, "ghost").Return(nil, nil)
+	mockUserRepo.On("GetByUsername", mock.Anything, "author").Return(author, nil)
+	// bob уже упомянут: повторное сохранение не возвращает его среди добавленных
+	mockCommentRepo.On("ReplaceMentions", mock.Anything, mock.Anything, []uuid.UUID{alice.ID, bob.ID, author.ID}).
+		Return([]uuid.UUID{alice.ID, author.ID}, nil)
+
+	aliceSub := events.SubscribeUser(alice.ID)
+	defer events.UnsubscribeUser(aliceSub)
+	bobSub := events.SubscribeUser(bob.ID)
+	defer events.UnsubscribeUser(bobSub)
+	authorSub := events.SubscribeUser(author.ID)
+	defer events.UnsubscribeUser(authorSub)
+
+	comment, err := service.CreateComment(testutils2.CreateAuthContext(author.ID), post.ID, content, nil)
+	require.NoError(t, err)
+
+	select {
+	case event := <-aliceSub.Events():
+		assert.Equal(t, UserEventMentioned, event.Type)
+		assert.Equal(t, alice.ID, event.UserID)
+		assert.Equal(t, comment.ID, event.Comment.ID)
+	default:
+		t.Fatal("упомянутый пользователь не получил событие")
+	}
+
+	assert.Empty(t, bobSub.Events(), "повторное упоминание не оповещается")
+	assert.Empty(t, authorSub.Events(), "автор не оповещается о собственном упоминании")
+	mockCommentRepo.AssertExpectations(t)
+}
+
+func TestCommentService_GetMentionsByCommentIDs(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	alice := testutils2.CreateTestUser("alice", "alice@example.com")
+	bob := testutils2.CreateTestUser("bob", "bob@example.com")
+	deletedUserID := uuid.New()
+	first, second, silent := uuid.New(), uuid.New(), uuid.New()
+
+	mockCommentRepo.On("GetMentionsByCommentIDs", mock.Anything, []uuid.UUID{first, second, silent}).Return(map[uuid.UUID][]uuid.UUID{
+		first:  {bob.ID, alice.ID},
+		second: {alice.ID, deletedUserID},
+	}, nil)
+	mockUserRepo.On("GetByIDs", mock.Anything, mock.MatchedBy(func(ids []uuid.UUID) bool {
+		return len(ids) == 3
+	})).Return([]*entities.User{alice, bob}, nil)
+
+	mentions, err := service.GetMentionsByCommentIDs(context.Background(), []uuid.UUID{first, second, silent})
+	require.NoError(t, err)
+
+	assert.Equal(t, []*entities.User{bob, alice}, mentions[first])
+	assert.Equal(t, []*entities.User{alice}, mentions[second], "удаленный пользователь пропускается")
+	assert.Empty(t, mentions[silent])
+	mockUserRepo.AssertNumberOfCalls(t, "GetByIDs", 1)
+}
+
 func TestCommentService_CreateComment_PostNotFound(t *testing.T) {
 	mockCommentRepo := &testutils2.MockCommentRepository{}
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
 	content := testutils2.CreateValidCommentData()
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(nil, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(nil, nil)
 
-	comment, err := service.CreateComment(context.Background(), postID, authorID, content, nil)
+	comment, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, content, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, comment)
@@ -125,7 +255,7 @@ func TestCommentService_CreateComment_CommentsDisabled(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
@@ -135,9 +265,9 @@ func TestCommentService_CreateComment_CommentsDisabled(t *testing.T) {
 	post.ID = postID
 	post.CommentsDisabled = true
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(post, nil)
 
-	comment, err := service.CreateComment(context.Background(), postID, authorID, content, nil)
+	comment, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, content, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, comment)
@@ -153,7 +283,7 @@ func TestCommentService_CreateComment_ParentFromDifferentPost(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	postID := uuid.New()
 	otherPostID := uuid.New()
@@ -171,11 +301,11 @@ func TestCommentService_CreateComment_ParentFromDifferentPost(t *testing.T) {
 	parentComment := testutils2.CreateTestComment(otherPostID, uuid.New(), "Parent comment", nil)
 	parentComment.ID = parentID
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(post, nil)
 	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
-	mockCommentRepo.On("GetByID", mock.Anything, parentID).Return(parentComment, nil)
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, parentID).Return(parentComment, nil)
 
-	comment, err := service.CreateComment(context.Background(), postID, authorID, content, &parentID)
+	comment, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, content, &parentID)
 
 	assert.Error(t, err)
 	assert.Nil(t, comment)
@@ -203,7 +333,7 @@ func TestCommentService_CreateComment_InvalidContent(t *testing.T) {
 			mockPostRepo := &testutils2.MockPostRepository{}
 			mockUserRepo := &testutils2.MockUserRepository{}
 			logger := testutils2.CreateTestLogger()
-			service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+			service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 			postID := uuid.New()
 			authorID := uuid.New()
@@ -215,10 +345,10 @@ func TestCommentService_CreateComment_InvalidContent(t *testing.T) {
 			author := testutils2.CreateTestUser("testuser", "test@example.com")
 			author.ID = authorID
 
-			mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+			mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(post, nil)
 			mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
 
-			comment, err := service.CreateComment(context.Background(), postID, authorID, tc.content, nil)
+			comment, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, tc.content, nil)
 
 			assert.Error(t, err)
 			assert.Nil(t, comment)
@@ -235,7 +365,7 @@ func TestCommentService_GetCommentByID_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	commentID := uuid.New()
 	authorID := uuid.New()
@@ -244,25 +374,45 @@ func TestCommentService_GetCommentByID_Success(t *testing.T) {
 	expectedComment := testutils2.CreateTestComment(postID, authorID, "Test comment", nil)
 	expectedComment.ID = commentID
 
-	author := testutils2.CreateTestUser("testuser", "test@example.com")
-	author.ID = authorID
-
-	post := testutils2.CreateTestPost(uuid.New(), "Test Post", "Content")
-	post.ID = postID
-
 	mockCommentRepo.On("GetByID", mock.Anything, commentID).Return(expectedComment, nil)
-	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+	mockPostRepo.On("Exists", mock.Anything, postID).Return(true, nil)
 
 	comment, err := service.GetCommentByID(context.Background(), commentID)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, comment)
 	assert.Equal(t, commentID, comment.ID)
-	assert.Equal(t, author, comment.Author)
-	assert.Equal(t, post, comment.Post)
+	// Автор и пост загружаются резолверами GraphQL только по запросу
+	assert.Nil(t, comment.Author)
+	assert.Nil(t, comment.Post)
+	mockCommentRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	mockPostRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_GetCommentByID_HiddenPost(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	postID := uuid.New()
+	comment := testutils2.CreateTestComment(postID, uuid.New(), "Test comment", nil)
+
+	mockCommentRepo.On("GetByID", mock.Anything, comment.ID).Return(comment, nil)
+	// Скрытый пост репозиторий считает несуществующим
+	mockPostRepo.On("Exists", mock.Anything, postID).Return(false, nil)
+
+	result, err := service.GetCommentByID(context.Background(), comment.ID)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrCommentNotFound, appErr.Code)
 	mockCommentRepo.AssertExpectations(t)
-	mockUserRepo.AssertExpectations(t)
 	mockPostRepo.AssertExpectations(t)
 }
 
@@ -271,7 +421,7 @@ func TestCommentService_GetPostComments_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	postID := uuid.New()
 	pagination := testutils2.CreateTestPagination(10, 0)
@@ -291,24 +441,10 @@ func TestCommentService_GetPostComments_Success(t *testing.T) {
 		HasMore: false,
 	}
 
-	authors := []*entities.User{
-		testutils2.CreateTestUser("user1", "user1@example.com"),
-		testutils2.CreateTestUser("user2", "user2@example.com"),
-	}
-	authors[0].ID = authorID1
-	authors[1].ID = authorID2
-
-	posts := []*entities.Post{
-		testutils2.CreateTestPost(uuid.New(), "Post", "Content"),
-	}
-	posts[0].ID = postID
-
 	mockPostRepo.On("Exists", mock.Anything, postID).Return(true, nil)
-	mockCommentRepo.On("GetByPostID", mock.Anything, postID, pagination).Return(expectedComments, expectedPagination, nil)
-	mockUserRepo.On("GetByIDs", mock.Anything, []uuid.UUID{authorID1, authorID2}).Return(authors, nil)
-	mockPostRepo.On("GetByIDs", mock.Anything, []uuid.UUID{postID}).Return(posts, nil)
+	mockCommentRepo.On("GetByPostID", mock.Anything, postID, entities.CommentSortTop, pagination).Return(expectedComments, expectedPagination, nil)
 
-	comments, paginationResp, err := service.GetPostComments(context.Background(), postID, pagination)
+	comments, paginationResp, err := service.GetPostComments(context.Background(), postID, entities.CommentSortTop, pagination)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, comments)
@@ -319,12 +455,33 @@ func TestCommentService_GetPostComments_Success(t *testing.T) {
 	mockUserRepo.AssertExpectations(t)
 }
 
+func TestCommentService_GetPostCommentsPage_PostNotFound(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	postID := uuid.New()
+	mockPostRepo.On("Exists", mock.Anything, postID).Return(false, nil)
+
+	comments, pageInfo, err := service.GetPostCommentsPage(context.Background(), postID, &entities.CursorRequest{Limit: 10})
+
+	assert.Error(t, err)
+	assert.Nil(t, comments)
+	assert.Nil(t, pageInfo)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrPostNotFound, appErr.Code)
+	mockCommentRepo.AssertNotCalled(t, "GetByPostIDKeyset", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestCommentService_UpdateComment_Success(t *testing.T) {
 	mockCommentRepo := &testutils2.MockCommentRepository{}
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	commentID := uuid.New()
 	authorID := uuid.New()
@@ -334,27 +491,23 @@ func TestCommentService_UpdateComment_Success(t *testing.T) {
 	existingComment := testutils2.CreateTestComment(postID, authorID, "Old content", nil)
 	existingComment.ID = commentID
 
-	author := testutils2.CreateTestUser("testuser", "test@example.com")
-	author.ID = authorID
-
-	post := testutils2.CreateTestPost(uuid.New(), "Test Post", "Content")
-	post.ID = postID
-
-	mockCommentRepo.On("GetByID", mock.Anything, commentID).Return(existingComment, nil)
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, commentID).Return(existingComment, nil)
+	mockCommentRepo.On("CreateRevision", mock.Anything, mock.MatchedBy(func(revision *entities.CommentRevision) bool {
+		return revision.CommentID == commentID && revision.Version == 0 && revision.Content == "Old content" && revision.EditorID == authorID
+	})).Return(nil)
 	mockCommentRepo.On("Update", mock.Anything, mock.MatchedBy(func(comment *entities.Comment) bool {
-		return comment.ID == commentID && comment.Content == newContent
+		return comment.ID == commentID && comment.Content == newContent && comment.EditCount == 1
 	})).Return(nil)
-	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+	mockCommentRepo.On("ReplaceMentions", mock.Anything, commentID, []uuid.UUID{}).Return(nil, nil)
 
-	comment, err := service.UpdateComment(context.Background(), commentID, authorID, newContent)
+	comment, err := service.UpdateComment(testutils2.CreateAuthContext(authorID), commentID, newContent)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, comment)
 	assert.Equal(t, newContent, comment.Content)
+	assert.True(t, comment.IsEdited())
+	assert.Equal(t, authorID, *comment.EditedBy)
 	mockCommentRepo.AssertExpectations(t)
-	mockUserRepo.AssertExpectations(t)
-	mockPostRepo.AssertExpectations(t)
 }
 
 func TestCommentService_UpdateComment_AccessDenied(t *testing.T) {
@@ -362,7 +515,7 @@ func TestCommentService_UpdateComment_AccessDenied(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	commentID := uuid.New()
 	realAuthorID := uuid.New()
@@ -372,9 +525,12 @@ func TestCommentService_UpdateComment_AccessDenied(t *testing.T) {
 	existingComment := testutils2.CreateTestComment(postID, realAuthorID, "Content", nil)
 	existingComment.ID = commentID
 
-	mockCommentRepo.On("GetByID", mock.Anything, commentID).Return(existingComment, nil)
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, commentID).Return(existingComment, nil)
+	fakeAuthor := testutils2.CreateTestUser("intruder", "intruder@example.com")
+	fakeAuthor.ID = fakeAuthorID
+	mockUserRepo.On("GetByID", mock.Anything, fakeAuthorID).Return(fakeAuthor, nil)
 
-	comment, err := service.UpdateComment(context.Background(), commentID, fakeAuthorID, "New content")
+	comment, err := service.UpdateComment(testutils2.CreateAuthContext(fakeAuthorID), commentID, "New content")
 
 	assert.Error(t, err)
 	assert.Nil(t, comment)
@@ -383,6 +539,7 @@ func TestCommentService_UpdateComment_AccessDenied(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, appErrors.ErrCommentAccessDenied, appErr.Code)
 	mockCommentRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
 }
 
 func TestCommentService_DeleteComment_Success(t *testing.T) {
@@ -390,7 +547,7 @@ func TestCommentService_DeleteComment_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	commentID := uuid.New()
 	authorID := uuid.New()
@@ -399,13 +556,115 @@ func TestCommentService_DeleteComment_Success(t *testing.T) {
 	existingComment := testutils2.CreateTestComment(postID, authorID, "Content", nil)
 	existingComment.ID = commentID
 
-	mockCommentRepo.On("GetByID", mock.Anything, commentID).Return(existingComment, nil)
-	mockCommentRepo.On("Delete", mock.Anything, commentID).Return(nil)
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, commentID).Return(existingComment, nil)
+	mockCommentRepo.On("SoftDelete", mock.Anything, mock.MatchedBy(func(c *entities.Comment) bool {
+		return c.ID == commentID && c.IsDeleted() && *c.DeletedBy == authorID && c.Content == entities.DeletedCommentPlaceholder
+	})).Return(nil)
 
-	err := service.DeleteComment(context.Background(), commentID, authorID)
+	err := service.DeleteComment(testutils2.CreateAuthContext(authorID), commentID)
 
 	assert.NoError(t, err)
 	mockCommentRepo.AssertExpectations(t)
+	mockCommentRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_DeleteComment_AlreadyDeleted(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	authorID := uuid.New()
+	existingComment := testutils2.CreateTestComment(uuid.New(), authorID, "Content", nil)
+	existingComment.MarkDeleted(authorID)
+
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, existingComment.ID).Return(existingComment, nil)
+
+	err := service.DeleteComment(testutils2.CreateAuthContext(authorID), existingComment.ID)
+
+	assert.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrCommentDeleted, appErr.Code)
+	mockCommentRepo.AssertNotCalled(t, "SoftDelete", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_UpdateComment_Deleted(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	authorID := uuid.New()
+	existingComment := testutils2.CreateTestComment(uuid.New(), authorID, "Content", nil)
+	existingComment.MarkDeleted(authorID)
+
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, existingComment.ID).Return(existingComment, nil)
+
+	comment, err := service.UpdateComment(testutils2.CreateAuthContext(authorID), existingComment.ID, "Restored")
+
+	assert.Error(t, err)
+	assert.Nil(t, comment)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrCommentDeleted, appErr.Code)
+	mockCommentRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_PurgeComment(t *testing.T) {
+	testCases := []struct {
+		name          string
+		role          entities.Role
+		isAuthor      bool
+		expectedError appErrors.ErrorCode
+	}{
+		{"admin_purges_thread", entities.RoleAdmin, false, ""},
+		{"moderator_denied", entities.RoleModerator, false, appErrors.ErrCommentAccessDenied},
+		{"author_denied", entities.RoleUser, true, appErrors.ErrCommentAccessDenied},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCommentRepo := &testutils2.MockCommentRepository{}
+			mockPostRepo := &testutils2.MockPostRepository{}
+			mockUserRepo := &testutils2.MockUserRepository{}
+			logger := testutils2.CreateTestLogger()
+			service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+			actor := testutils2.CreateTestUser("actor", "actor@example.com")
+			actor.Role = tc.role
+
+			authorID := uuid.New()
+			if tc.isAuthor {
+				authorID = actor.ID
+			}
+			existingComment := testutils2.CreateTestComment(uuid.New(), authorID, "Content", nil)
+
+			mockCommentRepo.On("GetByID", mock.Anything, existingComment.ID).Return(existingComment, nil)
+			mockPostRepo.On("GetByIDForUpdate", mock.Anything, existingComment.PostID).Return(testutils2.CreateTestPost(authorID, "Title", "Content"), nil)
+			mockCommentRepo.On("GetByIDForUpdate", mock.Anything, existingComment.ID).Return(existingComment, nil)
+			mockUserRepo.On("GetByID", mock.Anything, actor.ID).Return(actor, nil)
+			mockCommentRepo.On("Purge", mock.Anything, existingComment.ID).Return(int64(3), nil)
+			mockPostRepo.On("AddCommentCount", mock.Anything, existingComment.PostID, int64(-1)).Return(nil)
+
+			purged, err := service.PurgeComment(testutils2.CreateAuthContext(actor.ID), existingComment.ID)
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				appErr, ok := err.(*appErrors.AppError)
+				assert.True(t, ok)
+				assert.Equal(t, tc.expectedError, appErr.Code)
+				mockCommentRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), purged)
+			mockPostRepo.AssertCalled(t, "AddCommentCount", mock.Anything, existingComment.PostID, int64(-1))
+		})
+	}
 }
 
 func TestCommentService_GetCommentThread_Success(t *testing.T) {
@@ -413,20 +672,21 @@ func TestCommentService_GetCommentThread_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
-	commentID := uuid.New()
 	maxDepth := 5
+	postID := uuid.New()
+	root := testutils2.CreateTestComment(postID, uuid.New(), "Root comment", nil)
+	commentID := root.ID
 
 	expectedComments := []*entities.Comment{
-		testutils2.CreateTestComment(uuid.New(), uuid.New(), "Root comment", nil),
-		testutils2.CreateTestComment(uuid.New(), uuid.New(), "Child comment", nil),
+		root,
+		testutils2.CreateTestComment(postID, uuid.New(), "Child comment", root),
 	}
 
-	mockCommentRepo.On("Exists", mock.Anything, commentID).Return(true, nil)
+	mockCommentRepo.On("GetByID", mock.Anything, commentID).Return(root, nil)
+	mockPostRepo.On("Exists", mock.Anything, postID).Return(true, nil)
 	mockCommentRepo.On("GetThread", mock.Anything, commentID, maxDepth).Return(expectedComments, nil)
-	mockUserRepo.On("GetByIDs", mock.Anything, mock.Anything).Return([]*entities.User{}, nil)
-	mockPostRepo.On("GetByIDs", mock.Anything, mock.Anything).Return([]*entities.Post{}, nil)
 
 	comments, err := service.GetCommentThread(context.Background(), commentID, maxDepth)
 
@@ -436,18 +696,87 @@ func TestCommentService_GetCommentThread_Success(t *testing.T) {
 	mockCommentRepo.AssertExpectations(t)
 }
 
+func TestCommentService_GetCommentTree_Limits(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	postID := uuid.New()
+	authorID := uuid.New()
+	root := testutils2.CreateTestComment(postID, authorID, "Root", nil)
+	child1 := testutils2.CreateTestComment(postID, authorID, "Child 1", root)
+	child2 := testutils2.CreateTestComment(postID, authorID, "Child 2", root)
+	grandchild := testutils2.CreateTestComment(postID, authorID, "Grandchild", child1)
+
+	req := entities.NewCommentTreeRequest(2, []int{2, 1})
+
+	mockCommentRepo.On("GetByID", mock.Anything, root.ID).Return(root, nil)
+	mockPostRepo.On("Exists", mock.Anything, postID).Return(true, nil)
+	mockCommentRepo.On("CountByParentIDs", mock.Anything, []uuid.UUID{root.ID}).Return(map[uuid.UUID]int64{root.ID: 3}, nil)
+	mockCommentRepo.On("GetChildrenByParentIDs", mock.Anything, []uuid.UUID{root.ID}, 2).Return([]*entities.Comment{child1, child2}, nil)
+	mockCommentRepo.On("CountByParentIDs", mock.Anything, []uuid.UUID{child1.ID, child2.ID}).Return(map[uuid.UUID]int64{child1.ID: 2}, nil)
+	mockCommentRepo.On("GetChildrenByParentIDs", mock.Anything, []uuid.UUID{child1.ID, child2.ID}, 1).Return([]*entities.Comment{grandchild}, nil)
+	mockCommentRepo.On("CountByParentIDs", mock.Anything, []uuid.UUID{grandchild.ID}).Return(map[uuid.UUID]int64{grandchild.ID: 1}, nil)
+
+	tree, err := service.GetCommentTree(context.Background(), root.ID, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, root, tree.Comment)
+	assert.Equal(t, 3, tree.ChildCount)
+	assert.True(t, tree.HasMoreChildren)
+	assert.Len(t, tree.Children, 2)
+
+	assert.Equal(t, child1, tree.Children[0].Comment)
+	assert.Equal(t, 2, tree.Children[0].ChildCount)
+	assert.True(t, tree.Children[0].HasMoreChildren)
+	assert.Len(t, tree.Children[0].Children, 1)
+
+	assert.Equal(t, 0, tree.Children[1].ChildCount)
+	assert.False(t, tree.Children[1].HasMoreChildren)
+	assert.Empty(t, tree.Children[1].Children)
+
+	leaf := tree.Children[0].Children[0]
+	assert.Equal(t, grandchild, leaf.Comment)
+	assert.Equal(t, 1, leaf.ChildCount)
+	assert.True(t, leaf.HasMoreChildren)
+	assert.Empty(t, leaf.Children)
+	mockCommentRepo.AssertExpectations(t)
+}
+
+func TestCommentService_GetCommentTree_NotFound(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	commentID := uuid.New()
+	mockCommentRepo.On("GetByID", mock.Anything, commentID).Return(nil, nil)
+
+	tree, err := service.GetCommentTree(context.Background(), commentID, entities.NewCommentTreeRequest(0, nil))
+
+	assert.Error(t, err)
+	assert.Nil(t, tree)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrCommentNotFound, appErr.Code)
+}
+
 func TestCommentService_Subscriptions(t *testing.T) {
 	mockCommentRepo := &testutils2.MockCommentRepository{}
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	postID := uuid.New()
 
 	t.Run("subscribe_and_receive_event", func(t *testing.T) {
-		ch := service.SubscribeToPost(postID)
-		assert.NotNil(t, ch)
+		sub, err := service.SubscribeToPost(postID, nil)
+		assert.NoError(t, err)
+		assert.NotNil(t, sub)
 
 		authorID := uuid.New()
 		content := testutils2.CreateValidCommentData()
@@ -459,37 +788,41 @@ func TestCommentService_Subscriptions(t *testing.T) {
 		author := testutils2.CreateTestUser("testuser", "test@example.com")
 		author.ID = authorID
 
-		mockPostRepo.On("GetByID", mock.Anything, postID).Return(post, nil)
+		mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(post, nil)
 		mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
 		mockCommentRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		mockPostRepo.On("AddCommentCount", mock.Anything, postID, int64(1)).Return(nil)
 
 		go func() {
-			_, err := service.CreateComment(context.Background(), postID, authorID, content, nil)
+			_, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, content, nil)
 			assert.NoError(t, err)
 		}()
 
 		select {
-		case event := <-ch:
+		case event := <-sub.Events():
 			assert.NotNil(t, event)
-			assert.Equal(t, "comment_created", event.Type)
+			assert.Equal(t, CommentEventCreated, event.Type)
+			assert.Equal(t, int64(1), event.Sequence)
 			assert.Equal(t, postID, event.PostID)
 			assert.NotNil(t, event.Comment)
 		case <-time.After(time.Second):
 			t.Fatal("Did not receive event within timeout")
 		}
 
-		service.UnsubscribeFromPost(postID, ch)
+		service.UnsubscribeFromPost(sub)
 	})
 
 	t.Run("multiple_subscribers", func(t *testing.T) {
-		ch1 := service.SubscribeToPost(postID)
-		ch2 := service.SubscribeToPost(postID)
+		sub1, err := service.SubscribeToPost(postID, nil)
+		assert.NoError(t, err)
+		sub2, err := service.SubscribeToPost(postID, nil)
+		assert.NoError(t, err)
 
-		assert.NotNil(t, ch1)
-		assert.NotNil(t, ch2)
+		assert.NotNil(t, sub1)
+		assert.NotNil(t, sub2)
 
-		service.UnsubscribeFromPost(postID, ch1)
-		service.UnsubscribeFromPost(postID, ch2)
+		service.UnsubscribeFromPost(sub1)
+		service.UnsubscribeFromPost(sub2)
 	})
 }
 
@@ -499,14 +832,14 @@ func TestCommentService_DatabaseErrors(t *testing.T) {
 		mockPostRepo := &testutils2.MockPostRepository{}
 		mockUserRepo := &testutils2.MockUserRepository{}
 		logger := testutils2.CreateTestLogger()
-		service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+		service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 		postID := uuid.New()
 		authorID := uuid.New()
 
-		mockPostRepo.On("GetByID", mock.Anything, postID).Return(nil, errors.New("db error"))
+		mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(nil, errors.New("db error"))
 
-		_, err := service.CreateComment(context.Background(), postID, authorID, "content", nil)
+		_, err := service.CreateComment(testutils2.CreateAuthContext(authorID), postID, "content", nil)
 
 		assert.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
@@ -520,7 +853,7 @@ func TestCommentService_DatabaseErrors(t *testing.T) {
 		mockPostRepo := &testutils2.MockPostRepository{}
 		mockUserRepo := &testutils2.MockUserRepository{}
 		logger := testutils2.CreateTestLogger()
-		service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+		service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 		commentID := uuid.New()
 		mockCommentRepo.On("GetByID", mock.Anything, commentID).Return(nil, errors.New("db error"))
@@ -540,10 +873,11 @@ func TestCommentService_GetCommentReplies_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
-	parentID := uuid.New()
 	postID := uuid.New()
+	parent := testutils2.CreateTestComment(postID, uuid.New(), "Parent", nil)
+	parentID := parent.ID
 	pagination := testutils2.CreateTestPagination(10, 0)
 
 	authorID1 := uuid.New()
@@ -561,32 +895,17 @@ func TestCommentService_GetCommentReplies_Success(t *testing.T) {
 		HasMore: false,
 	}
 
-	authors := []*entities.User{
-		testutils2.CreateTestUser("user1", "user1@example.com"),
-		testutils2.CreateTestUser("user2", "user2@example.com"),
-	}
-	authors[0].ID = authorID1
-	authors[1].ID = authorID2
-
-	posts := []*entities.Post{
-		testutils2.CreateTestPost(uuid.New(), "Post", "Content"),
-	}
-	posts[0].ID = postID
-
-	mockCommentRepo.On("Exists", mock.Anything, parentID).Return(true, nil)
-	mockCommentRepo.On("GetByParentID", mock.Anything, parentID, pagination).Return(expectedReplies, expectedPagination, nil)
-	mockUserRepo.On("GetByIDs", mock.Anything, []uuid.UUID{authorID1, authorID2}).Return(authors, nil)
-	mockPostRepo.On("GetByIDs", mock.Anything, []uuid.UUID{postID}).Return(posts, nil)
+	mockCommentRepo.On("GetByID", mock.Anything, parentID).Return(parent, nil)
+	mockPostRepo.On("Exists", mock.Anything, postID).Return(true, nil)
+	mockCommentRepo.On("GetByParentID", mock.Anything, parentID, entities.CommentSortMostReplied, pagination).Return(expectedReplies, expectedPagination, nil)
 
-	replies, paginationResp, err := service.GetCommentReplies(context.Background(), parentID, pagination)
+	replies, paginationResp, err := service.GetCommentReplies(context.Background(), parentID, entities.CommentSortMostReplied, pagination)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, replies)
 	assert.Len(t, replies, 2)
 	assert.Equal(t, expectedPagination, paginationResp)
 	mockCommentRepo.AssertExpectations(t)
-	mockPostRepo.AssertExpectations(t)
-	mockUserRepo.AssertExpectations(t)
 }
 
 func TestCommentService_GetCommentReplies_ParentNotFound(t *testing.T) {
@@ -594,14 +913,14 @@ func TestCommentService_GetCommentReplies_ParentNotFound(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	parentID := uuid.New()
 	pagination := testutils2.CreateTestPagination(10, 0)
 
-	mockCommentRepo.On("Exists", mock.Anything, parentID).Return(false, nil)
+	mockCommentRepo.On("GetByID", mock.Anything, parentID).Return(nil, nil)
 
-	replies, paginationResp, err := service.GetCommentReplies(context.Background(), parentID, pagination)
+	replies, paginationResp, err := service.GetCommentReplies(context.Background(), parentID, entities.CommentSortOldest, pagination)
 
 	assert.Error(t, err)
 	assert.Nil(t, replies)
@@ -618,14 +937,14 @@ func TestCommentService_GetCommentReplies_DatabaseError(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
 	parentID := uuid.New()
 	pagination := testutils2.CreateTestPagination(10, 0)
 
-	mockCommentRepo.On("Exists", mock.Anything, parentID).Return(false, errors.New("db error"))
+	mockCommentRepo.On("GetByID", mock.Anything, parentID).Return(nil, errors.New("db error"))
 
-	replies, paginationResp, err := service.GetCommentReplies(context.Background(), parentID, pagination)
+	replies, paginationResp, err := service.GetCommentReplies(context.Background(), parentID, entities.CommentSortOldest, pagination)
 
 	assert.Error(t, err)
 	assert.Nil(t, replies)
@@ -637,44 +956,162 @@ func TestCommentService_GetCommentReplies_DatabaseError(t *testing.T) {
 	mockCommentRepo.AssertExpectations(t)
 }
 
-func TestCommentService_GetCommentReplies_LoadRelationsError(t *testing.T) {
+func TestCommentService_GetFirstRepliesByParentIDs(t *testing.T) {
 	mockCommentRepo := &testutils2.MockCommentRepository{}
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, logger)
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
-	parentID := uuid.New()
 	postID := uuid.New()
-	pagination := testutils2.CreateTestPagination(10, 0)
-
 	authorID := uuid.New()
+	parent1 := testutils2.CreateTestComment(postID, authorID, "Parent 1", nil)
+	parent2 := testutils2.CreateTestComment(postID, authorID, "Parent 2", nil)
+	parent3 := testutils2.CreateTestComment(postID, authorID, "Parent 3", nil)
+	reply1 := testutils2.CreateTestComment(postID, authorID, "Reply 1", parent1)
+	reply2 := testutils2.CreateTestComment(postID, authorID, "Reply 2", parent1)
+	reply3 := testutils2.CreateTestComment(postID, authorID, "Reply 3", parent2)
 
-	expectedReplies := []*entities.Comment{
-		testutils2.CreateTestComment(postID, authorID, "Reply 1", nil),
-	}
+	parentIDs := []uuid.UUID{parent1.ID, parent2.ID, parent3.ID}
+	mockCommentRepo.On("GetChildrenByParentIDs", mock.Anything, parentIDs, 2).Return([]*entities.Comment{reply1, reply2, reply3}, nil)
+	mockCommentRepo.On("CountByParentIDs", mock.Anything, parentIDs).Return(map[uuid.UUID]int64{parent1.ID: 5, parent2.ID: 1}, nil)
 
-	expectedPagination := &entities.PaginationResponse{
-		Total:   1,
-		Limit:   10,
-		Offset:  0,
-		HasMore: false,
-	}
+	replies, counts, err := service.GetFirstRepliesByParentIDs(context.Background(), parentIDs, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*entities.Comment{reply1, reply2}, replies[parent1.ID])
+	assert.Equal(t, []*entities.Comment{reply3}, replies[parent2.ID])
+	assert.Empty(t, replies[parent3.ID])
+	assert.Equal(t, int64(5), counts[parent1.ID])
+	assert.Equal(t, int64(0), counts[parent3.ID])
+	mockCommentRepo.AssertExpectations(t)
+}
+
+func TestCommentService_GetFirstCommentsByPostIDs_DatabaseError(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
 
-	mockCommentRepo.On("Exists", mock.Anything, parentID).Return(true, nil)
-	mockCommentRepo.On("GetByParentID", mock.Anything, parentID, pagination).Return(expectedReplies, expectedPagination, nil)
-	// Имитируем ошибку при загрузке связанных данных
-	mockUserRepo.On("GetByIDs", mock.Anything, []uuid.UUID{authorID}).Return(nil, errors.New("user load error"))
-	mockPostRepo.On("GetByIDs", mock.Anything, []uuid.UUID{postID}).Return(nil, errors.New("post load error"))
+	postIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	mockCommentRepo.On("GetTopLevelByPostIDs", mock.Anything, postIDs, 10).Return(nil, errors.New("db error"))
 
-	replies, paginationResp, err := service.GetCommentReplies(context.Background(), parentID, pagination)
+	comments, counts, err := service.GetFirstCommentsByPostIDs(context.Background(), postIDs, 10)
+
+	assert.Nil(t, comments)
+	assert.Nil(t, counts)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrDatabase, appErr.Code)
+	mockCommentRepo.AssertNotCalled(t, "CountTopLevelByPostIDs", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_DeleteComment_ByModerator(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	commentID := uuid.New()
+	postID := uuid.New()
+
+	moderator := testutils2.CreateTestUser("moderator", "moderator@example.com")
+	moderator.Role = entities.RoleModerator
+
+	existingComment := testutils2.CreateTestComment(postID, uuid.New(), "Content", nil)
+	existingComment.ID = commentID
+
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, commentID).Return(existingComment, nil)
+	mockUserRepo.On("GetByID", mock.Anything, moderator.ID).Return(moderator, nil)
+	mockCommentRepo.On("SoftDelete", mock.Anything, mock.MatchedBy(func(c *entities.Comment) bool {
+		return c.ID == commentID && *c.DeletedBy == moderator.ID
+	})).Return(nil)
+
+	err := service.DeleteComment(testutils2.CreateAuthContext(moderator.ID), commentID)
 
-	// Функция должна завершиться успешно даже если связанные данные не загрузились
 	assert.NoError(t, err)
-	assert.NotNil(t, replies)
-	assert.Len(t, replies, 1)
-	assert.Equal(t, expectedPagination, paginationResp)
 	mockCommentRepo.AssertExpectations(t)
-	mockPostRepo.AssertExpectations(t)
 	mockUserRepo.AssertExpectations(t)
 }
+
+func TestCommentService_UpdateComment_UnchangedContentSkipsRevision(t *testing.T) {
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	authorID := uuid.New()
+	existingComment := testutils2.CreateTestComment(uuid.New(), authorID, "Same content", nil)
+
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, existingComment.ID).Return(existingComment, nil)
+	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(nil, nil)
+	mockPostRepo.On("GetByID", mock.Anything, existingComment.PostID).Return(nil, nil)
+
+	comment, err := service.UpdateComment(testutils2.CreateAuthContext(authorID), existingComment.ID, "Same content")
+
+	assert.NoError(t, err)
+	assert.False(t, comment.IsEdited())
+	mockCommentRepo.AssertNotCalled(t, "CreateRevision", mock.Anything, mock.Anything)
+	mockCommentRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestCommentService_GetCommentRevisions(t *testing.T) {
+	authorID := uuid.New()
+	moderatorID := uuid.New()
+	strangerID := uuid.New()
+
+	tests := []struct {
+		name         string
+		actorID      uuid.UUID
+		actorRole    entities.Role
+		expectedCode appErrors.ErrorCode
+	}{
+		{name: "author_sees_history", actorID: authorID, actorRole: entities.RoleUser},
+		{name: "moderator_sees_history", actorID: moderatorID, actorRole: entities.RoleModerator},
+		{name: "stranger_denied", actorID: strangerID, actorRole: entities.RoleUser, expectedCode: appErrors.ErrCommentAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCommentRepo := &testutils2.MockCommentRepository{}
+			mockPostRepo := &testutils2.MockPostRepository{}
+			mockUserRepo := &testutils2.MockUserRepository{}
+			logger := testutils2.CreateTestLogger()
+			service := NewCommentService(mockCommentRepo, mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, mockCommentRepo), NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+			comment := testutils2.CreateTestComment(uuid.New(), authorID, "Current", nil)
+			actor := testutils2.CreateTestUser("actor", "actor@example.com")
+			actor.ID = tt.actorID
+			actor.Role = tt.actorRole
+
+			revisions := []*entities.CommentRevision{
+				{ID: uuid.New(), CommentID: comment.ID, Version: 0, Content: "Original", EditorID: authorID},
+				{ID: uuid.New(), CommentID: comment.ID, Version: 1, Content: "Second", EditorID: authorID},
+			}
+
+			mockCommentRepo.On("GetByID", mock.Anything, comment.ID).Return(comment, nil)
+			mockUserRepo.On("GetByID", mock.Anything, tt.actorID).Return(actor, nil)
+			mockCommentRepo.On("GetRevisions", mock.Anything, comment.ID).Return(revisions, nil)
+
+			result, err := service.GetCommentRevisions(testutils2.CreateAuthContext(tt.actorID), comment.ID)
+
+			if tt.expectedCode != "" {
+				assert.Nil(t, result)
+				appErr, ok := err.(*appErrors.AppError)
+				assert.True(t, ok)
+				assert.Equal(t, tt.expectedCode, appErr.Code)
+				mockCommentRepo.AssertNotCalled(t, "GetRevisions", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, result, 2)
+			assert.Equal(t, "Original", result[0].Content)
+			assert.Equal(t, authorID, result[0].EditorID)
+			mockUserRepo.AssertNotCalled(t, "GetByIDs", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"ozon-posts/internal/entities"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -10,13 +11,42 @@ import (
 type CommentRepository interface {
 	Create(ctx context.Context, comment *entities.Comment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.Comment, error)
+	// GetByIDForShare и GetByIDForUpdate внутри UnitOfWork блокируют комментарий до конца транзакции:
+	// первый - от изменения, второй - от любых блокирующих чтений. Строку, которая будет изменена
+	// в той же транзакции (например, счетчик), читают через GetByIDForUpdate: две разделяемые
+	// блокировки, повышаемые до записи, взаимоблокируются. Вне транзакции равносильны GetByID.
+	GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Comment, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Comment, error)
 	Update(ctx context.Context, comment *entities.Comment) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	GetByPostID(ctx context.Context, postID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error)
+	// SoftDelete сохраняет отметку об удалении; строка и ответы на комментарий остаются
+	SoftDelete(ctx context.Context, comment *entities.Comment) error
+	// Purge безвозвратно удаляет комментарий вместе со всей веткой ответов и возвращает число удаленных записей
+	Purge(ctx context.Context, id uuid.UUID) (int64, error)
+	// GetByPostID и GetByParentID возвращают страницу корневых комментариев поста и ответов на комментарий в порядке sort
+	GetByPostID(ctx context.Context, postID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error)
 	CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error)
-	GetByParentID(ctx context.Context, parentID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error)
+	GetByParentID(ctx context.Context, parentID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error)
 	CountByParentID(ctx context.Context, parentID uuid.UUID) (int64, error)
+	// AddReplyCount и AddReactionCount изменяют счетчики комментария на delta; вызываются в той же
+	// транзакции UnitOfWork, что и создание или удаление ответа или реакции
+	AddReplyCount(ctx context.Context, id uuid.UUID, delta int64) error
+	AddReactionCount(ctx context.Context, id uuid.UUID, delta int64) error
+	GetByPostIDKeyset(ctx context.Context, postID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error)
+	GetByParentIDKeyset(ctx context.Context, parentID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error)
 	GetThread(ctx context.Context, commentID uuid.UUID, maxDepth int) ([]*entities.Comment, error)
+	GetChildrenByParentIDs(ctx context.Context, parentIDs []uuid.UUID, perParentLimit int) ([]*entities.Comment, error)
+	CountByParentIDs(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	GetTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID, perPostLimit int) ([]*entities.Comment, error)
+	CountTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	CreateRevision(ctx context.Context, revision *entities.CommentRevision) error
+	GetRevisions(ctx context.Context, commentID uuid.UUID) ([]*entities.CommentRevision, error)
+	// ReplaceMentions заменяет упоминания комментария на userIDs и возвращает пользователей,
+	// которых раньше среди упомянутых не было. Пустой userIDs удаляет все упоминания.
+	ReplaceMentions(ctx context.Context, commentID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error)
+	// GetMentionsByCommentIDs возвращает упомянутых пользователей в порядке добавления упоминаний
+	GetMentionsByCommentIDs(ctx context.Context, commentIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+	// Search не возвращает комментарии постов, скрытых модератором
+	Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.CommentSearchResult, *entities.PaginationResponse, error)
 	GetByPath(ctx context.Context, pathPrefix string, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error)
 	Exists(ctx context.Context, id uuid.UUID) (bool, error)
 	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Comment, error)
@@ -25,13 +55,29 @@ type CommentRepository interface {
 type PostRepository interface {
 	Create(ctx context.Context, post *entities.Post) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.Post, error)
+	// GetByIDForShare и GetByIDForUpdate - блокирующие чтения поста, как у CommentRepository.
+	// Скрытые модератором посты не возвращает ни одно чтение, кроме GetByIDForUpdate:
+	// через него модерация скрывает и восстанавливает пост.
+	GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Post, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Post, error)
 	Update(ctx context.Context, post *entities.Post) error
+	// SetHidden сохраняет только отметку скрытия поста (HiddenAt, HiddenBy)
+	SetHidden(ctx context.Context, post *entities.Post) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// AddCommentCount изменяет счетчик корневых комментариев поста на delta в транзакции UnitOfWork
+	AddCommentCount(ctx context.Context, id uuid.UUID, delta int64) error
 	GetAll(ctx context.Context, pagination *entities.PaginationRequest) ([]*entities.Post, *entities.PaginationResponse, error)
 	GetByAuthorID(ctx context.Context, authorID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Post, *entities.PaginationResponse, error)
+	GetAllKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error)
+	GetByAuthorIDKeyset(ctx context.Context, authorID uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error)
+	// GetByAuthorIDsKeyset возвращает страницу постов любого из авторов, новые сначала
+	GetByAuthorIDsKeyset(ctx context.Context, authorIDs []uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error)
 	Exists(ctx context.Context, id uuid.UUID) (bool, error)
 	IsCommentsEnabled(ctx context.Context, postID uuid.UUID) (bool, error)
 	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Post, error)
+	CreateRevision(ctx context.Context, revision *entities.PostRevision) error
+	GetRevisions(ctx context.Context, postID uuid.UUID) ([]*entities.PostRevision, error)
+	Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.PostSearchResult, *entities.PaginationResponse, error)
 }
 
 type UserRepository interface {
@@ -39,8 +85,121 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
 	GetByUsername(ctx context.Context, username string) (*entities.User, error)
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
+	// Update сохраняет профиль пользователя (имя и email); роль и блокировка меняются только
+	// через SetRole и SetBanned, чтобы устаревшая копия не затерла решение администратора или модератора
 	Update(ctx context.Context, user *entities.User) error
+	// SetRole сохраняет роль пользователя
+	SetRole(ctx context.Context, user *entities.User) error
+	// SetBanned сохраняет блокировку пользователя; у уже заблокированного остается первоначальное время
+	SetBanned(ctx context.Context, user *entities.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	Exists(ctx context.Context, id uuid.UUID) (bool, error)
 	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.User, error)
 }
+
+type ReactionRepository interface {
+	// Add сохраняет реакцию и возвращает false, если пользователь уже поставил реакцию этого вида на объект
+	Add(ctx context.Context, reaction *entities.Reaction) (bool, error)
+	// Remove удаляет реакцию пользователя и возвращает false, если ее не было
+	Remove(ctx context.Context, targetType entities.ReactionTargetType, targetID, userID uuid.UUID, kind entities.ReactionKind) (bool, error)
+	// CountByTargets возвращает число реакций каждого вида на объекты; объекты без реакций в результат не попадают
+	CountByTargets(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReactionKind]int64, error)
+	// GetUserKinds возвращает виды реакций, поставленных пользователем на объекты
+	GetUserKinds(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID][]entities.ReactionKind, error)
+}
+
+type NotificationRepository interface {
+	// Create сохраняет оповещения; вызывается в транзакции UnitOfWork вместе с действием, о котором они
+	Create(ctx context.Context, notifications []*entities.Notification) error
+	// GetByUserIDKeyset возвращает страницу оповещений пользователя, новые сначала; unreadOnly оставляет только непрочитанные
+	GetByUserIDKeyset(ctx context.Context, userID uuid.UUID, unreadOnly bool, req *entities.CursorRequest) ([]*entities.Notification, *entities.PageInfo, error)
+	// MarkRead отмечает прочитанными непрочитанные оповещения пользователя из ids, а при пустом ids - все его
+	// оповещения; чужие и уже прочитанные пропускаются. Возвращает число отмеченных.
+	MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, readAt time.Time) (int64, error)
+}
+
+type FollowRepository interface {
+	// Create сохраняет подписку и возвращает false, если пользователь уже подписан
+	Create(ctx context.Context, follow *entities.Follow) (bool, error)
+	// Delete удаляет подписку и возвращает false, если ее не было
+	Delete(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error)
+	// GetFollowersKeyset возвращает страницу подписчиков пользователя по курсору Follow.FollowerCursor,
+	// недавние подписки сначала
+	GetFollowersKeyset(ctx context.Context, followeeID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error)
+	// GetFollowingKeyset возвращает страницу подписок пользователя по курсору Follow.FolloweeCursor,
+	// недавние подписки сначала
+	GetFollowingKeyset(ctx context.Context, followerID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error)
+	// GetFolloweeIDs возвращает всех пользователей, на которых подписан followerID
+	GetFolloweeIDs(ctx context.Context, followerID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type ReportRepository interface {
+	// Create сохраняет жалобу и возвращает false, если у пользователя уже есть открытая жалоба на этот объект
+	Create(ctx context.Context, report *entities.Report) (bool, error)
+	// GetQueueKeyset возвращает страницу объектов с открытыми жалобами по курсору ModerationQueueItem.Cursor,
+	// дольше всех ждущие решения сначала. Reasons не заполняется.
+	GetQueueKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationQueueItem, *entities.PageInfo, error)
+	// GetQueueItem возвращает сводку открытых жалоб на объект или nil, если открытых жалоб нет
+	GetQueueItem(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID) (*entities.ModerationQueueItem, error)
+	// CountOpenByReason возвращает число открытых жалоб на объекты по причинам; объекты без жалоб в результат не попадают
+	CountOpenByReason(ctx context.Context, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReportReason]int64, error)
+	// ResolveOpen закрывает все открытые жалобы на объект решением модератора и возвращает их число;
+	// вызывается в транзакции UnitOfWork вместе с записью в журнал модерации
+	ResolveOpen(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID, action entities.ModerationAction, moderatorID uuid.UUID, resolvedAt time.Time) (int64, error)
+	CreateAuditEntry(ctx context.Context, entry *entities.ModerationAuditEntry) error
+	// GetAuditLogKeyset возвращает страницу журнала модерации, новые записи сначала
+	GetAuditLogKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationAuditEntry, *entities.PageInfo, error)
+}
+
+// Repositories - репозитории, привязанные к одной транзакции UnitOfWork
+type Repositories struct {
+	Users         UserRepository
+	Posts         PostRepository
+	Comments      CommentRepository
+	Reactions     ReactionRepository
+	Notifications NotificationRepository
+	Reports       ReportRepository
+}
+
+// UnitOfWork выполняет fn атомарно: изменения, сделанные через переданные репозитории,
+// сохраняются вместе, только если fn вернула nil. Вложенные вызовы Do не поддерживаются.
+// In-memory реализация изменения не откатывает, поэтому fn выполняет все чтения и проверки
+// до первой записи: после нее ошибку может вернуть только сбой записи в журнал хранилища.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
+
+// CounterReconciler пересчитывает денормализованные счетчики постов и комментариев по исходным
+// данным и исправляет разошедшиеся. Пересчет не должен терять изменения, сделанные параллельно с ним.
+type CounterReconciler interface {
+	ReconcileCounters(ctx context.Context) (*CounterReport, error)
+}
+
+// CommentEventPublisher доставляет события жизненного цикла комментариев подписчикам поста
+type CommentEventPublisher interface {
+	Publish(postID uuid.UUID, event *CommentEvent)
+}
+
+// UserEventPublisher доставляет адресованные пользователю события его подписчикам
+type UserEventPublisher interface {
+	PublishUserEvent(event *UserEvent)
+}
+
+// EventBus - шина событий комментариев и событий пользователей. Реализация определяет, видят ли
+// подписчики события, опубликованные другими экземплярами приложения.
+type EventBus interface {
+	CommentEventPublisher
+	UserEventPublisher
+	Subscribe(postID uuid.UUID, afterSequence *int64) (*Subscription, error)
+	Unsubscribe(sub *Subscription)
+	SubscribeUser(userID uuid.UUID) *UserSubscription
+	UnsubscribeUser(sub *UserSubscription)
+	Stats() EventBusStats
+}
+
+// RateLimitStore хранит корзины лимитов. Take атомарно пополняет корзину key и списывает из нее токен;
+// если токена нет, возвращает false и время до его появления. Реализация определяет,
+// общие ли лимиты для нескольких экземпляров приложения.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error)
+}
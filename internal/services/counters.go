@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CounterReport - число записей, счетчики которых разошлись с данными и были исправлены
+type CounterReport struct {
+	PostComments     int64
+	CommentReplies   int64
+	CommentReactions int64
+}
+
+func (r *CounterReport) Total() int64 {
+	return r.PostComments + r.CommentReplies + r.CommentReactions
+}
+
+// CounterReconciliationJob периодически пересчитывает счетчики с нуля. Счетчики обновляются
+// в транзакциях вместе с данными, поэтому расхождения возникают только при сбоях, ручных правках
+// базы и каскадных удалениях мимо сервисов; пересчет их исправляет.
+type CounterReconciliationJob struct {
+	reconciler CounterReconciler
+	interval   time.Duration
+	logger     *logrus.Logger
+}
+
+func NewCounterReconciliationJob(reconciler CounterReconciler, interval time.Duration, logger *logrus.Logger) *CounterReconciliationJob {
+	return &CounterReconciliationJob{
+		reconciler: reconciler,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// RunOnce выполняет один пересчет и логирует исправленные счетчики
+func (j *CounterReconciliationJob) RunOnce(ctx context.Context) (*CounterReport, error) {
+	started := time.Now()
+
+	report, err := j.reconciler.ReconcileCounters(ctx)
+	if err != nil {
+		j.logger.WithError(err).Error("Ошибка пересчета счетчиков")
+		return nil, err
+	}
+
+	fields := logrus.Fields{
+		"post_comments":     report.PostComments,
+		"comment_replies":   report.CommentReplies,
+		"comment_reactions": report.CommentReactions,
+		"duration":          time.Since(started).String(),
+	}
+	if report.Total() > 0 {
+		j.logger.WithFields(fields).Warn("Пересчет исправил разошедшиеся счетчики")
+	} else {
+		j.logger.WithFields(fields).Debug("Счетчики совпадают с данными")
+	}
+
+	return report, nil
+}
+
+// Run выполняет пересчет сразу и затем раз в interval, пока не отменен ctx.
+// Ошибка пересчета не останавливает задачу: следующая попытка будет через interval.
+func (j *CounterReconciliationJob) Run(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = j.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	testutils2 "ozon-posts/pkg/testutils"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCounterReconciler struct {
+	report *CounterReport
+	err    error
+	calls  atomic.Int32
+}
+
+func (r *fakeCounterReconciler) ReconcileCounters(context.Context) (*CounterReport, error) {
+	r.calls.Add(1)
+	return r.report, r.err
+}
+
+func TestCounterReconciliationJob_RunOnce(t *testing.T) {
+	reconciler := &fakeCounterReconciler{report: &CounterReport{PostComments: 1, CommentReactions: 2}}
+	job := NewCounterReconciliationJob(reconciler, time.Hour, testutils2.CreateTestLogger())
+
+	report, err := job.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), report.Total())
+
+	reconciler.err = fmt.Errorf("could not serialize access")
+	_, err = job.RunOnce(context.Background())
+	assert.Error(t, err)
+}
+
+func TestCounterReconciliationJob_Run(t *testing.T) {
+	t.Run("runs_until_cancelled", func(t *testing.T) {
+		reconciler := &fakeCounterReconciler{err: fmt.Errorf("connection refused")}
+		job := NewCounterReconciliationJob(reconciler, time.Millisecond, testutils2.CreateTestLogger())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			job.Run(ctx)
+			close(done)
+		}()
+
+		// Ошибка пересчета не останавливает задачу
+		assert.Eventually(t, func() bool { return reconciler.calls.Load() >= 3 }, time.Second, time.Millisecond)
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("задача не остановилась после отмены контекста")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		reconciler := &fakeCounterReconciler{report: &CounterReport{}}
+		NewCounterReconciliationJob(reconciler, 0, testutils2.CreateTestLogger()).Run(context.Background())
+		assert.Zero(t, reconciler.calls.Load())
+	})
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultEventReplaySize       = 100
+	DefaultEventSubscriberBuffer = 64
+	DefaultEventStreamRetention  = time.Hour
+)
+
+const eventStreamSweepInterval = time.Minute
+
+type EventBusOptions struct {
+	// ReplaySize - сколько последних событий каждого поста хранится для возобновления подписок
+	ReplaySize int
+	// SubscriberBuffer - размер буфера подписчика, при переполнении подписка закрывается
+	SubscriberBuffer int
+	// StreamRetention - сколько журнал поста без подписчиков хранится после последнего события,
+	// 0 - журналы не вытесняются
+	StreamRetention time.Duration
+}
+
+func DefaultEventBusOptions() EventBusOptions {
+	return EventBusOptions{
+		ReplaySize:       DefaultEventReplaySize,
+		SubscriberBuffer: DefaultEventSubscriberBuffer,
+		StreamRetention:  DefaultEventStreamRetention,
+	}
+}
+
+// Subscription - подписка на события одного поста
+type Subscription struct {
+	PostID uuid.UUID
+
+	events chan *CommentEvent
+	err    error
+	closed bool
+}
+
+func (s *Subscription) Events() <-chan *CommentEvent {
+	return s.events
+}
+
+// Err возвращает причину принудительного закрытия подписки или nil, если она закрыта через Unsubscribe.
+// Значение актуально после закрытия канала Events.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// UserEventType - вид события, адресованного пользователю
+type UserEventType string
+
+const (
+	UserEventMentioned    UserEventType = "mentioned"
+	UserEventNotification UserEventType = "notification"
+)
+
+// UserEvent - событие для подписчиков одного пользователя. У UserEventMentioned Comment - комментарий,
+// в котором пользователя упомянули, у UserEventNotification Notification - новое оповещение во входящих.
+// События пользователей не нумеруются и не повторяются после переподключения: пропущенные
+// оповещения остаются во входящих.
+type UserEvent struct {
+	Type         UserEventType          `json:"type"`
+	UserID       uuid.UUID              `json:"user_id"`
+	Comment      *entities.Comment      `json:"comment,omitempty"`
+	Notification *entities.Notification `json:"notification,omitempty"`
+}
+
+// UserSubscription - подписка на события одного пользователя
+type UserSubscription struct {
+	UserID uuid.UUID
+
+	events chan *UserEvent
+	err    error
+	closed bool
+}
+
+func (s *UserSubscription) Events() <-chan *UserEvent {
+	return s.events
+}
+
+// Err возвращает причину принудительного закрытия подписки, как Subscription.Err
+func (s *UserSubscription) Err() error {
+	return s.err
+}
+
+// postStream хранит последний выданный номер события поста, журнал для повтора и подписчиков
+type postStream struct {
+	lastSequence int64
+	log          []*CommentEvent
+	subscribers  []*Subscription
+	// updatedAt - время последнего события или сдвига номера, от него отсчитывается StreamRetention
+	updatedAt time.Time
+}
+
+// EventBusStats - состояние шины для мониторинга
+type EventBusStats struct {
+	// Subscribers - число активных подписок по постам, посты без подписчиков не включаются
+	Subscribers map[uuid.UUID]int
+	// UserSubscribers - число активных подписок на события пользователей
+	UserSubscribers int
+	// DroppedEvents - сколько событий не доставлено подписчикам, отключенным из-за переполнения буфера
+	DroppedEvents uint64
+}
+
+// InProcessEventBus рассылает события подписчикам внутри одного процесса
+type InProcessEventBus struct {
+	opts   EventBusOptions
+	logger *logrus.Logger
+
+	streams map[uuid.UUID]*postStream
+	users   map[uuid.UUID][]*UserSubscription
+	dropped uint64
+	// evictedSequence - наибольший номер среди вытесненных журналов: Publish продолжает нумерацию
+	// с него, чтобы пост после вытеснения не выдал повторно уже известные клиентам номера
+	evictedSequence int64
+	lastSweep       time.Time
+	now             func() time.Time
+	mu              sync.Mutex
+}
+
+func NewInProcessEventBus(opts EventBusOptions, logger *logrus.Logger) *InProcessEventBus {
+	return &InProcessEventBus{
+		opts:    opts,
+		logger:  logger,
+		streams: make(map[uuid.UUID]*postStream),
+		users:   make(map[uuid.UUID][]*UserSubscription),
+		now:     time.Now,
+	}
+}
+
+// Subscribe создает подписку на события поста. Если afterSequence задан, подписчик сначала
+// получает все события после этого номера из журнала; если часть из них уже вытеснена,
+// возвращается ошибка EVENT_REPLAY_UNAVAILABLE.
+func (b *InProcessEventBus) Subscribe(postID uuid.UUID, afterSequence *int64) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream := b.stream(postID)
+
+	var replay []*CommentEvent
+	if afterSequence != nil {
+		after := *afterSequence
+		if after > stream.lastSequence || (after < stream.lastSequence && (len(stream.log) == 0 || stream.log[0].Sequence > after+1)) {
+			b.logger.WithFields(logrus.Fields{
+				"post_id":        postID,
+				"after_sequence": after,
+				"last_sequence":  stream.lastSequence,
+			}).Warn("Невозможно возобновить подписку: события вытеснены из журнала")
+			return nil, errors.NewEventReplayUnavailableError(after)
+		}
+
+		idx := sort.Search(len(stream.log), func(i int) bool {
+			return stream.log[i].Sequence > after
+		})
+		replay = stream.log[idx:]
+	}
+
+	sub := &Subscription{
+		PostID: postID,
+		events: make(chan *CommentEvent, b.opts.SubscriberBuffer+len(replay)),
+	}
+	for _, event := range replay {
+		sub.events <- event
+	}
+
+	stream.subscribers = append(stream.subscribers, sub)
+
+	b.logger.WithFields(logrus.Fields{
+		"post_id":       postID,
+		"replay_events": len(replay),
+	}).Debug("Добавлена подписка на комментарии поста")
+
+	return sub, nil
+}
+
+func (b *InProcessEventBus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if stream, ok := b.streams[sub.PostID]; ok {
+		b.remove(stream, sub)
+	}
+	b.close(sub, nil)
+
+	b.logger.WithField("post_id", sub.PostID).Debug("Удалена подписка на комментарии поста")
+}
+
+// Publish присваивает событию следующий номер в пределах поста и рассылает его подписчикам
+func (b *InProcessEventBus) Publish(postID uuid.UUID, event *CommentEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream := b.stream(postID)
+	if stream.lastSequence < b.evictedSequence {
+		stream.lastSequence = b.evictedSequence
+	}
+	stream.lastSequence++
+	event.Sequence = stream.lastSequence
+
+	b.deliver(postID, stream, event)
+}
+
+// Deliver рассылает событие с уже присвоенным номером, например полученное от другого экземпляра
+func (b *InProcessEventBus) Deliver(postID uuid.UUID, event *CommentEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream := b.stream(postID)
+	if event.Sequence > stream.lastSequence {
+		stream.lastSequence = event.Sequence
+	}
+
+	b.deliver(postID, stream, event)
+}
+
+// Advance сообщает шине, что события поста до sequence уже были выданы, даже если не проходили через нее
+func (b *InProcessEventBus) Advance(postID uuid.UUID, sequence int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream := b.stream(postID)
+	if sequence > stream.lastSequence {
+		stream.lastSequence = sequence
+		stream.updatedAt = b.now()
+	}
+}
+
+// Stats возвращает число подписок по постам и счетчик недоставленных событий
+func (b *InProcessEventBus) Stats() EventBusStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := EventBusStats{
+		Subscribers:   make(map[uuid.UUID]int),
+		DroppedEvents: b.dropped,
+	}
+	for postID, stream := range b.streams {
+		if len(stream.subscribers) > 0 {
+			stats.Subscribers[postID] = len(stream.subscribers)
+		}
+	}
+	for _, subscribers := range b.users {
+		stats.UserSubscribers += len(subscribers)
+	}
+	return stats
+}
+
+// SubscribeUser создает подписку на события пользователя
+func (b *InProcessEventBus) SubscribeUser(userID uuid.UUID) *UserSubscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &UserSubscription{
+		UserID: userID,
+		events: make(chan *UserEvent, b.opts.SubscriberBuffer),
+	}
+	b.users[userID] = append(b.users[userID], sub)
+
+	b.logger.WithField("user_id", userID).Debug("Добавлена подписка на события пользователя")
+	return sub
+}
+
+func (b *InProcessEventBus) UnsubscribeUser(sub *UserSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeUser(sub)
+	b.closeUser(sub, nil)
+
+	b.logger.WithField("user_id", sub.UserID).Debug("Удалена подписка на события пользователя")
+}
+
+// PublishUserEvent рассылает событие подписчикам пользователя event.UserID. Подписчик с заполненным
+// буфером отключается так же, как подписчик поста.
+func (b *InProcessEventBus) PublishUserEvent(event *UserEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscribers := b.users[event.UserID]
+	if len(subscribers) == 0 {
+		return
+	}
+
+	active := subscribers[:0]
+	for _, sub := range subscribers {
+		select {
+		case sub.events <- event:
+			active = append(active, sub)
+		default:
+			b.dropped++
+			b.logger.WithField("user_id", event.UserID).Warn("Подписчик не успевает получать события пользователя, подписка закрыта")
+			b.closeUser(sub, errors.NewSubscriptionOverflowError())
+		}
+	}
+	for i := len(active); i < len(subscribers); i++ {
+		subscribers[i] = nil
+	}
+
+	if len(active) == 0 {
+		delete(b.users, event.UserID)
+		return
+	}
+	b.users[event.UserID] = active
+}
+
+func (b *InProcessEventBus) stream(postID uuid.UUID) *postStream {
+	now := b.now()
+	b.sweep(now)
+
+	stream, ok := b.streams[postID]
+	if !ok {
+		stream = &postStream{updatedAt: now}
+		b.streams[postID] = stream
+	}
+	return stream
+}
+
+// sweep вытесняет журналы постов без подписчиков, в которых не было событий дольше StreamRetention.
+// Возобновить подписку на такой пост уже нельзя, как и после вытеснения событий из журнала.
+func (b *InProcessEventBus) sweep(now time.Time) {
+	if b.opts.StreamRetention <= 0 {
+		return
+	}
+	if b.lastSweep.IsZero() {
+		b.lastSweep = now
+	}
+	if now.Sub(b.lastSweep) < eventStreamSweepInterval {
+		return
+	}
+	b.lastSweep = now
+
+	for postID, stream := range b.streams {
+		if len(stream.subscribers) > 0 || now.Sub(stream.updatedAt) < b.opts.StreamRetention {
+			continue
+		}
+		if stream.lastSequence > b.evictedSequence {
+			b.evictedSequence = stream.lastSequence
+		}
+		delete(b.streams, postID)
+	}
+}
+
+func (b *InProcessEventBus) deliver(postID uuid.UUID, stream *postStream, event *CommentEvent) {
+	stream.updatedAt = b.now()
+	b.record(stream, event)
+
+	if len(stream.subscribers) == 0 {
+		return
+	}
+
+	b.logger.WithFields(logrus.Fields{
+		"post_id":           postID,
+		"subscribers_count": len(stream.subscribers),
+		"event_type":        event.Type,
+		"sequence":          event.Sequence,
+	}).Debug("Отправка уведомления подписчикам")
+
+	// Отправка неблокирующая: подписчик с заполненным буфером отключается явно,
+	// чтобы он мог переподключиться с afterSequence вместо тихой потери событий
+	active := stream.subscribers[:0]
+	for _, sub := range stream.subscribers {
+		select {
+		case sub.events <- event:
+			active = append(active, sub)
+		default:
+			b.dropped++
+			b.logger.WithFields(logrus.Fields{
+				"post_id":  postID,
+				"sequence": event.Sequence,
+			}).Warn("Подписчик не успевает получать события, подписка закрыта")
+			b.close(sub, errors.NewSubscriptionOverflowError())
+		}
+	}
+	for i := len(active); i < len(stream.subscribers); i++ {
+		stream.subscribers[i] = nil
+	}
+	stream.subscribers = active
+}
+
+// record добавляет событие в журнал поста с сохранением порядка номеров и ограничением размера
+func (b *InProcessEventBus) record(stream *postStream, event *CommentEvent) {
+	if b.opts.ReplaySize <= 0 {
+		return
+	}
+
+	idx := sort.Search(len(stream.log), func(i int) bool {
+		return stream.log[i].Sequence >= event.Sequence
+	})
+	if idx < len(stream.log) && stream.log[idx].Sequence == event.Sequence {
+		return
+	}
+
+	stream.log = append(stream.log, nil)
+	copy(stream.log[idx+1:], stream.log[idx:])
+	stream.log[idx] = event
+
+	if overflow := len(stream.log) - b.opts.ReplaySize; overflow > 0 {
+		stream.log = append(stream.log[:0:0], stream.log[overflow:]...)
+	}
+}
+
+func (b *InProcessEventBus) remove(stream *postStream, sub *Subscription) {
+	for i, subscriber := range stream.subscribers {
+		if subscriber == sub {
+			stream.subscribers = append(stream.subscribers[:i], stream.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *InProcessEventBus) removeUser(sub *UserSubscription) {
+	subscribers := b.users[sub.UserID]
+	for i, subscriber := range subscribers {
+		if subscriber == sub {
+			subscribers = append(subscribers[:i], subscribers[i+1:]...)
+			break
+		}
+	}
+
+	if len(subscribers) == 0 {
+		delete(b.users, sub.UserID)
+		return
+	}
+	b.users[sub.UserID] = subscribers
+}
+
+func (b *InProcessEventBus) closeUser(sub *UserSubscription, reason error) {
+	if sub.closed {
+		return
+	}
+	sub.err = reason
+	sub.closed = true
+	close(sub.events)
+}
+
+func (b *InProcessEventBus) close(sub *Subscription, reason error) {
+	if sub.closed {
+		return
+	}
+	sub.err = reason
+	sub.closed = true
+	close(sub.events)
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	appErrors "ozon-posts/pkg/errors"
+	testutils2 "ozon-posts/pkg/testutils"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishEvents(bus *InProcessEventBus, postID uuid.UUID, count int) {
+	for i := 0; i < count; i++ {
+		bus.Publish(postID, &CommentEvent{Type: CommentEventCreated, PostID: postID})
+	}
+}
+
+func TestInProcessEventBus_SequencePerPost(t *testing.T) {
+	bus := NewInProcessEventBus(DefaultEventBusOptions(), testutils2.CreateTestLogger())
+
+	postA := uuid.New()
+	postB := uuid.New()
+
+	subA, err := bus.Subscribe(postA, nil)
+	require.NoError(t, err)
+	subB, err := bus.Subscribe(postB, nil)
+	require.NoError(t, err)
+
+	publishEvents(bus, postA, 2)
+	publishEvents(bus, postB, 1)
+
+	assert.Equal(t, int64(1), (<-subA.Events()).Sequence)
+	assert.Equal(t, int64(2), (<-subA.Events()).Sequence)
+	assert.Equal(t, int64(1), (<-subB.Events()).Sequence)
+
+	bus.Unsubscribe(subA)
+	bus.Unsubscribe(subB)
+}
+
+func TestInProcessEventBus_Resume(t *testing.T) {
+	bus := NewInProcessEventBus(DefaultEventBusOptions(), testutils2.CreateTestLogger())
+	postID := uuid.New()
+
+	publishEvents(bus, postID, 5)
+
+	after := int64(2)
+	sub, err := bus.Subscribe(postID, &after)
+	require.NoError(t, err)
+	defer bus.Unsubscribe(sub)
+
+	for _, expected := range []int64{3, 4, 5} {
+		event := <-sub.Events()
+		assert.Equal(t, expected, event.Sequence)
+	}
+
+	publishEvents(bus, postID, 1)
+	assert.Equal(t, int64(6), (<-sub.Events()).Sequence)
+}
+
+func TestInProcessEventBus_ResumeUpToDate(t *testing.T) {
+	bus := NewInProcessEventBus(DefaultEventBusOptions(), testutils2.CreateTestLogger())
+	postID := uuid.New()
+
+	publishEvents(bus, postID, 3)
+
+	after := int64(3)
+	sub, err := bus.Subscribe(postID, &after)
+	require.NoError(t, err)
+	defer bus.Unsubscribe(sub)
+
+	assert.Len(t, sub.Events(), 0)
+}
+
+func TestInProcessEventBus_ResumeUnavailable(t *testing.T) {
+	opts := DefaultEventBusOptions()
+	opts.ReplaySize = 3
+	bus := NewInProcessEventBus(opts, testutils2.CreateTestLogger())
+	postID := uuid.New()
+
+	publishEvents(bus, postID, 10)
+
+	testCases := []struct {
+		name  string
+		after int64
+	}{
+		{"evicted_events", 2},
+		{"unknown_future_sequence", 42},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			after := tc.after
+			sub, err := bus.Subscribe(postID, &after)
+
+			assert.Nil(t, sub)
+			require.Error(t, err)
+
+			appErr, ok := err.(*appErrors.AppError)
+			assert.True(t, ok)
+			assert.Equal(t, appErrors.ErrEventReplayUnavailable, appErr.Code)
+		})
+	}
+
+	after := int64(7)
+	sub, err := bus.Subscribe(postID, &after)
+	require.NoError(t, err)
+	assert.Len(t, sub.Events(), 3)
+	bus.Unsubscribe(sub)
+}
+
+func TestInProcessEventBus_SlowConsumerDisconnected(t *testing.T) {
+	opts := DefaultEventBusOptions()
+	opts.SubscriberBuffer = 2
+	bus := NewInProcessEventBus(opts, testutils2.CreateTestLogger())
+	postID := uuid.New()
+
+	slow, err := bus.Subscribe(postID, nil)
+	require.NoError(t, err)
+	fast, err := bus.Subscribe(postID, nil)
+	require.NoError(t, err)
+	defer bus.Unsubscribe(fast)
+
+	for i := 1; i <= 3; i++ {
+		publishEvents(bus, postID, 1)
+		assert.Equal(t, int64(i), (<-fast.Events()).Sequence)
+	}
+
+	var received []int64
+	for event := range slow.Events() {
+		received = append(received, event.Sequence)
+	}
+	assert.Equal(t, []int64{1, 2}, received)
+
+	appErr, ok := slow.Err().(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrSubscriptionOverflow, appErr.Code)
+
+	// Отключенный подписчик может продолжить с последнего полученного события без потерь
+	after := received[len(received)-1]
+	resumed, err := bus.Subscribe(postID, &after)
+	require.NoError(t, err)
+	defer bus.Unsubscribe(resumed)
+	assert.Equal(t, int64(3), (<-resumed.Events()).Sequence)
+
+	stats := bus.Stats()
+	assert.Equal(t, uint64(1), stats.DroppedEvents)
+	assert.Equal(t, map[uuid.UUID]int{postID: 2}, stats.Subscribers)
+
+	// Повторная отписка закрытой подписки безопасна
+	bus.Unsubscribe(slow)
+}
+
+func TestInProcessEventBus_DeliverKeepsOrder(t *testing.T) {
+	bus := NewInProcessEventBus(DefaultEventBusOptions(), testutils2.CreateTestLogger())
+	postID := uuid.New()
+
+	for _, seq := range []int64{1, 3, 2} {
+		bus.Deliver(postID, &CommentEvent{Sequence: seq, Type: CommentEventCreated, PostID: postID})
+	}
+
+	after := int64(0)
+	sub, err := bus.Subscribe(postID, &after)
+	require.NoError(t, err)
+	defer bus.Unsubscribe(sub)
+
+	for _, expected := range []int64{1, 2, 3} {
+		assert.Equal(t, expected, (<-sub.Events()).Sequence)
+	}
+}
+
+func TestInProcessEventBus_EvictsIdleStreams(t *testing.T) {
+	now := time.Now()
+	bus := NewInProcessEventBus(DefaultEventBusOptions(), testutils2.CreateTestLogger())
+	bus.now = func() time.Time { return now }
+
+	idle := uuid.New()
+	watched := uuid.New()
+	publishEvents(bus, idle, 3)
+	publishEvents(bus, watched, 1)
+
+	sub, err := bus.Subscribe(watched, nil)
+	require.NoError(t, err)
+	defer bus.Unsubscribe(sub)
+
+	// До истечения срока хранения журнал поста без подписчиков остается
+	now = now.Add(DefaultEventStreamRetention - time.Second)
+	publishEvents(bus, uuid.New(), 1)
+	assert.Contains(t, bus.streams, idle)
+
+	now = now.Add(eventStreamSweepInterval)
+	publishEvents(bus, uuid.New(), 1)
+	assert.NotContains(t, bus.streams, idle)
+	assert.Contains(t, bus.streams, watched, "журнал поста с подписчиками не вытесняется")
+
+	after := int64(1)
+	_, err = bus.Subscribe(idle, &after)
+	assertAppErrorCode(t, err, appErrors.ErrEventReplayUnavailable)
+
+	// Нумерация вытесненного поста не начинается заново
+	resumed, err := bus.Subscribe(idle, nil)
+	require.NoError(t, err)
+	defer bus.Unsubscribe(resumed)
+
+	publishEvents(bus, idle, 1)
+	assert.Greater(t, (<-resumed.Events()).Sequence, int64(3))
+}
+
+func TestInProcessEventBus_UserEvents(t *testing.T) {
+	opts := DefaultEventBusOptions()
+	opts.SubscriberBuffer = 1
+	bus := NewInProcessEventBus(opts, testutils2.CreateTestLogger())
+
+	userA := uuid.New()
+	userB := uuid.New()
+
+	subA := bus.SubscribeUser(userA)
+	subB := bus.SubscribeUser(userB)
+	defer bus.UnsubscribeUser(subB)
+
+	assert.Equal(t, 2, bus.Stats().UserSubscribers)
+
+	bus.PublishUserEvent(&UserEvent{Type: UserEventMentioned, UserID: userA})
+	event := <-subA.Events()
+	assert.Equal(t, userA, event.UserID)
+	assert.Len(t, subB.Events(), 0, "событие доставляется только адресату")
+
+	// Переполненная подписка закрывается с ошибкой
+	bus.PublishUserEvent(&UserEvent{Type: UserEventMentioned, UserID: userA})
+	bus.PublishUserEvent(&UserEvent{Type: UserEventMentioned, UserID: userA})
+
+	var received int
+	for range subA.Events() {
+		received++
+	}
+	assert.Equal(t, 1, received)
+
+	appErr, ok := subA.Err().(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrSubscriptionOverflow, appErr.Code)
+
+	stats := bus.Stats()
+	assert.Equal(t, uint64(1), stats.DroppedEvents)
+	assert.Equal(t, 1, stats.UserSubscribers)
+
+	bus.UnsubscribeUser(subA)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// FollowService - подписки пользователей друг на друга и лента постов тех, на кого подписан пользователь
+type FollowService struct {
+	followRepo FollowRepository
+	userRepo   UserRepository
+	postRepo   PostRepository
+	logger     *logrus.Logger
+}
+
+func NewFollowService(followRepo FollowRepository, userRepo UserRepository, postRepo PostRepository, logger *logrus.Logger) *FollowService {
+	return &FollowService{
+		followRepo: followRepo,
+		userRepo:   userRepo,
+		postRepo:   postRepo,
+		logger:     logger,
+	}
+}
+
+// Follow подписывает пользователя из контекста на followeeID и возвращает followee.
+// Повторная подписка ничего не меняет.
+func (s *FollowService) Follow(ctx context.Context, followeeID uuid.UUID) (*entities.User, error) {
+	followerID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if followerID == followeeID {
+		return nil, errors.NewValidationError("Нельзя подписаться на самого себя")
+	}
+
+	followee, err := s.getUser(ctx, followeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.followRepo.Create(ctx, entities.NewFollow(followerID, followeeID))
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка сохранения подписки")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+		"created":     created,
+	}).Info("Подписка на пользователя")
+
+	return followee, nil
+}
+
+// Unfollow отменяет подписку пользователя из контекста на followeeID; отмена отсутствующей подписки не ошибка
+func (s *FollowService) Unfollow(ctx context.Context, followeeID uuid.UUID) (*entities.User, error) {
+	followerID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	followee, err := s.getUser(ctx, followeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.followRepo.Delete(ctx, followerID, followeeID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка удаления подписки")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+		"deleted":     deleted,
+	}).Info("Отмена подписки на пользователя")
+
+	return followee, nil
+}
+
+// GetFollowersPage возвращает страницу подписчиков пользователя с заполненным Follow.Follower
+func (s *FollowService) GetFollowersPage(ctx context.Context, userID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"limit":   req.Limit,
+	}).Debug("Получение страницы подписчиков")
+
+	follows, pageInfo, err := s.followRepo.GetFollowersKeyset(ctx, userID, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы подписчиков")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	follows, err = s.attachUsers(ctx, follows, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return follows, pageInfo, nil
+}
+
+// GetFollowingPage возвращает страницу подписок пользователя с заполненным Follow.Followee
+func (s *FollowService) GetFollowingPage(ctx context.Context, userID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"limit":   req.Limit,
+	}).Debug("Получение страницы подписок")
+
+	follows, pageInfo, err := s.followRepo.GetFollowingKeyset(ctx, userID, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы подписок")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	follows, err = s.attachUsers(ctx, follows, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return follows, pageInfo, nil
+}
+
+// GetFeedPage возвращает ленту пользователя из контекста: посты тех, на кого он подписан, новые сначала
+func (s *FollowService) GetFeedPage(ctx context.Context, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	followeeIDs, err := s.followRepo.GetFolloweeIDs(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения подписок для ленты")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"followees": len(followeeIDs),
+		"limit":     req.Limit,
+	}).Debug("Получение страницы ленты")
+
+	if len(followeeIDs) == 0 {
+		return []*entities.Post{}, &entities.PageInfo{}, nil
+	}
+
+	posts, pageInfo, err := s.postRepo.GetByAuthorIDsKeyset(ctx, followeeIDs, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы ленты")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return posts, pageInfo, nil
+}
+
+func (s *FollowService) getUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения пользователя")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	if user == nil {
+		return nil, errors.NewUserNotFoundError(userID.String())
+	}
+
+	return user, nil
+}
+
+// attachUsers загружает одним запросом подписчиков (followers) или тех, на кого подписаны.
+// Подписки на пользователей, которых уже нет, пропускаются: в памяти удаление пользователя
+// не удаляет его подписки.
+func (s *FollowService) attachUsers(ctx context.Context, follows []*entities.Follow, followers bool) ([]*entities.Follow, error) {
+	if len(follows) == 0 {
+		return follows, nil
+	}
+
+	otherID := func(follow *entities.Follow) uuid.UUID {
+		if followers {
+			return follow.FollowerID
+		}
+		return follow.FolloweeID
+	}
+
+	ids := make([]uuid.UUID, 0, len(follows))
+	for _, follow := range follows {
+		ids = append(ids, otherID(follow))
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения пользователей подписок")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	byID := make(map[uuid.UUID]*entities.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	result := make([]*entities.Follow, 0, len(follows))
+	for _, follow := range follows {
+		user, exists := byID[otherID(follow)]
+		if !exists {
+			continue
+		}
+
+		if followers {
+			follow.Follower = user
+		} else {
+			follow.Followee = user
+		}
+		result = append(result, follow)
+	}
+
+	return result, nil
+}
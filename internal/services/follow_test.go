@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	appErrors "ozon-posts/pkg/errors"
+	testutils2 "ozon-posts/pkg/testutils"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowService_Follow(t *testing.T) {
+	mockFollowRepo := &testutils2.MockFollowRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewFollowService(mockFollowRepo, mockUserRepo, mockPostRepo, logger)
+
+	followerID := uuid.New()
+	followee := &entities.User{ID: uuid.New(), Username: "followee"}
+	ctx := testutils2.CreateAuthContext(followerID)
+
+	mockUserRepo.On("GetByID", mock.Anything, followee.ID).Return(followee, nil)
+	missingID := uuid.New()
+	mockUserRepo.On("GetByID", mock.Anything, missingID).Return(nil, nil)
+	mockFollowRepo.On("Create", mock.Anything, mock.MatchedBy(func(follow *entities.Follow) bool {
+		return follow.FollowerID == followerID && follow.FolloweeID == followee.ID
+	})).Return(true, nil)
+
+	user, err := service.Follow(ctx, followee.ID)
+	require.NoError(t, err)
+	assert.Equal(t, followee, user)
+
+	_, err = service.Follow(ctx, followerID)
+	assertAppErrorCode(t, err, appErrors.ErrValidation)
+
+	_, err = service.Follow(ctx, missingID)
+	assertAppErrorCode(t, err, appErrors.ErrUserNotFound)
+
+	_, err = service.Follow(context.Background(), followee.ID)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	mockFollowRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestFollowService_GetFollowersPage(t *testing.T) {
+	mockFollowRepo := &testutils2.MockFollowRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewFollowService(mockFollowRepo, mockUserRepo, mockPostRepo, logger)
+
+	userID := uuid.New()
+	follower := &entities.User{ID: uuid.New(), Username: "follower"}
+	deletedID := uuid.New()
+	req := &entities.CursorRequest{Limit: 10}
+
+	follows := []*entities.Follow{
+		entities.NewFollow(follower.ID, userID),
+		entities.NewFollow(deletedID, userID),
+	}
+	mockFollowRepo.On("GetFollowersKeyset", mock.Anything, userID, req).Return(follows, &entities.PageInfo{}, nil)
+	mockUserRepo.On("GetByIDs", mock.Anything, []uuid.UUID{follower.ID, deletedID}).Return([]*entities.User{follower}, nil)
+
+	page, _, err := service.GetFollowersPage(context.Background(), userID, req)
+	require.NoError(t, err)
+	require.Len(t, page, 1, "подписки удаленных пользователей пропускаются")
+	assert.Equal(t, follower, page[0].Follower)
+	assert.Nil(t, page[0].Followee)
+}
+
+func TestFollowService_GetFeedPage(t *testing.T) {
+	mockFollowRepo := &testutils2.MockFollowRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewFollowService(mockFollowRepo, mockUserRepo, mockPostRepo, logger)
+
+	userID := uuid.New()
+	lonelyID := uuid.New()
+	followeeIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	req := &entities.CursorRequest{Limit: 10}
+	expected := []*entities.Post{{ID: uuid.New(), AuthorID: followeeIDs[0]}}
+
+	mockFollowRepo.On("GetFolloweeIDs", mock.Anything, userID).Return(followeeIDs, nil)
+	mockFollowRepo.On("GetFolloweeIDs", mock.Anything, lonelyID).Return([]uuid.UUID{}, nil)
+	mockPostRepo.On("GetByAuthorIDsKeyset", mock.Anything, followeeIDs, req).Return(expected, &entities.PageInfo{}, nil)
+
+	posts, _, err := service.GetFeedPage(testutils2.CreateAuthContext(userID), req)
+	require.NoError(t, err)
+	assert.Equal(t, expected, posts)
+
+	posts, _, err = service.GetFeedPage(testutils2.CreateAuthContext(lonelyID), req)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	_, _, err = service.GetFeedPage(context.Background(), req)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	mockPostRepo.AssertNumberOfCalls(t, "GetByAuthorIDsKeyset", 1)
+}
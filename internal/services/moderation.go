@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ModerationService - жалобы пользователей на посты и комментарии, очередь модерации
+// и решения модераторов по ней с записью в журнал модерации
+type ModerationService struct {
+	reportRepo  ReportRepository
+	postRepo    PostRepository
+	commentRepo CommentRepository
+	uow         UnitOfWork
+	policy      *Policy
+	events      CommentEventPublisher
+	logger      *logrus.Logger
+}
+
+func NewModerationService(
+	reportRepo ReportRepository,
+	postRepo PostRepository,
+	commentRepo CommentRepository,
+	userRepo UserRepository,
+	uow UnitOfWork,
+	events CommentEventPublisher,
+	logger *logrus.Logger,
+) *ModerationService {
+	return &ModerationService{
+		reportRepo:  reportRepo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		uow:         uow,
+		policy:      NewPolicy(userRepo),
+		events:      events,
+		logger:      logger,
+	}
+}
+
+// Report подает жалобу пользователя из контекста на пост или комментарий. Возвращает false, если
+// у пользователя уже есть открытая жалоба на этот объект: повторная жалоба ничего не меняет.
+func (s *ModerationService) Report(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID, reason entities.ReportReason) (bool, error) {
+	reporterID, err := actorFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if !targetType.IsValid() {
+		return false, errors.NewValidationError("Неизвестный тип объекта жалобы")
+	}
+
+	if !reason.IsValid() {
+		return false, errors.NewValidationError("Неизвестная причина жалобы")
+	}
+
+	authorID, err := s.getTargetAuthor(ctx, targetType, targetID)
+	if err != nil {
+		return false, err
+	}
+
+	if authorID == reporterID {
+		return false, errors.NewValidationError("Нельзя пожаловаться на собственную публикацию")
+	}
+
+	created, err := s.reportRepo.Create(ctx, entities.NewReport(targetType, targetID, authorID, reporterID, reason))
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка сохранения жалобы")
+		return false, errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"target_type": targetType,
+		"target_id":   targetID,
+		"reporter_id": reporterID,
+		"reason":      reason,
+		"created":     created,
+	}).Info("Жалоба на публикацию")
+
+	return created, nil
+}
+
+// GetModerationQueuePage возвращает страницу объектов с открытыми жалобами и сводкой их причин,
+// дольше всех ждущие решения сначала. Доступно модераторам.
+func (s *ModerationService) GetModerationQueuePage(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationQueueItem, *entities.PageInfo, error) {
+	if _, err := s.requireModerator(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	items, pageInfo, err := s.reportRepo.GetQueueKeyset(ctx, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы очереди модерации")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	targetIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		targetIDs = append(targetIDs, item.TargetID)
+	}
+
+	counts, err := s.reportRepo.CountOpenByReason(ctx, targetIDs)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка подсчета причин жалоб")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	for _, item := range items {
+		item.Reasons = entities.SummarizeReportReasons(counts[item.TargetID])
+	}
+
+	return items, pageInfo, nil
+}
+
+// ResolveReports закрывает все открытые жалобы на объект решением модератора из контекста.
+// Скрытие удаляет комментарий мягко, как его удаление модератором, а пост убирает из выдачи
+// вместе с комментариями до восстановления через RestorePost; блокировка автора дополнительно
+// скрывает объект. Жалобы, изменения и запись журнала сохраняются в одной транзакции.
+func (s *ModerationService) ResolveReports(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID, action entities.ModerationAction, note *string) (*entities.ModerationAuditEntry, error) {
+	moderatorID, err := s.requireModerator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !targetType.IsValid() {
+		return nil, errors.NewValidationError("Неизвестный тип объекта жалобы")
+	}
+
+	if !action.IsValid() {
+		return nil, errors.NewValidationError("Неизвестное решение по жалобам")
+	}
+
+	note, err = normalizeModerationNote(note)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"target_type":  targetType,
+		"target_id":    targetID,
+		"moderator_id": moderatorID,
+		"action":       action,
+	})
+	logger.Info("Решение по жалобам")
+
+	var (
+		entry         *entities.ModerationAuditEntry
+		hiddenComment *entities.Comment
+		hiddenPost    *entities.Post
+	)
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		item, err := repos.Reports.GetQueueItem(ctx, targetType, targetID)
+		if err != nil {
+			logger.WithError(err).Error("Ошибка получения открытых жалоб на объект")
+			return errors.NewDatabaseError(err)
+		}
+
+		if item == nil {
+			return errors.NewInvalidRequestError("Открытых жалоб на объект нет")
+		}
+
+		// Все проверки выполняются до первой записи: in-memory UnitOfWork не откатывает изменения
+		var (
+			comment *entities.Comment
+			post    *entities.Post
+			author  *entities.User
+		)
+		if action.HidesContent() {
+			comment, post, err = s.lockTarget(ctx, repos, targetType, targetID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if action == entities.ModerationBanAuthor {
+			author, err = repos.Users.GetByID(ctx, item.AuthorID)
+			if err != nil {
+				logger.WithError(err).Error("Ошибка получения автора публикации")
+				return errors.NewDatabaseError(err)
+			}
+
+			if author != nil && author.Role.IsModerator() {
+				return errors.NewForbiddenError("Нельзя заблокировать модератора")
+			}
+		}
+
+		now := time.Now()
+
+		resolved, err := repos.Reports.ResolveOpen(ctx, targetType, targetID, action, moderatorID, now)
+		if err != nil {
+			logger.WithError(err).Error("Ошибка закрытия жалоб")
+			return errors.NewDatabaseError(err)
+		}
+
+		// Параллельное решение другого модератора успело закрыть жалобы
+		if resolved == 0 {
+			return errors.NewInvalidRequestError("Открытых жалоб на объект нет")
+		}
+		item.ReportCount = resolved
+
+		if comment != nil && !comment.IsDeleted() {
+			comment.MarkDeleted(moderatorID)
+			if err := repos.Comments.SoftDelete(ctx, comment); err != nil {
+				logger.WithError(err).Error("Ошибка скрытия комментария")
+				return errors.NewDatabaseError(err)
+			}
+			hiddenComment = comment
+		}
+
+		if post != nil && !post.IsHidden() {
+			post.Hide(moderatorID, now)
+			if err := repos.Posts.SetHidden(ctx, post); err != nil {
+				logger.WithError(err).Error("Ошибка скрытия поста")
+				return errors.NewDatabaseError(err)
+			}
+			hiddenPost = post
+		}
+
+		if author != nil && !author.IsBanned() {
+			author.Ban(now)
+			if err := repos.Users.SetBanned(ctx, author); err != nil {
+				logger.WithError(err).Error("Ошибка блокировки автора")
+				return errors.NewDatabaseError(err)
+			}
+		}
+
+		entry = entities.NewModerationAuditEntry(moderatorID, action, item, note, now)
+		if err := repos.Reports.CreateAuditEntry(ctx, entry); err != nil {
+			logger.WithError(err).Error("Ошибка записи в журнал модерации")
+			return errors.NewDatabaseError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if hiddenComment != nil {
+		s.events.Publish(hiddenComment.PostID, &CommentEvent{
+			Type:    CommentEventDeleted,
+			PostID:  hiddenComment.PostID,
+			Comment: hiddenComment,
+		})
+	}
+
+	if hiddenPost != nil {
+		s.events.Publish(hiddenPost.ID, &CommentEvent{
+			Type:   CommentEventPostHidden,
+			PostID: hiddenPost.ID,
+		})
+	}
+
+	logger.WithField("reports_resolved", entry.ReportsResolved).Info("Жалобы закрыты")
+	return entry, nil
+}
+
+// RestorePost возвращает в выдачу пост, скрытый решением по жалобам, вместе с его комментариями.
+// Восстановление записывается в журнал модерации. Доступно модераторам.
+func (s *ModerationService) RestorePost(ctx context.Context, postID uuid.UUID, note *string) (*entities.ModerationAuditEntry, error) {
+	moderatorID, err := s.requireModerator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err = normalizeModerationNote(note)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"post_id":      postID,
+		"moderator_id": moderatorID,
+	})
+	logger.Info("Восстановление скрытого поста")
+
+	var entry *entities.ModerationAuditEntry
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		post, err := repos.Posts.GetByIDForUpdate(ctx, postID)
+		if err != nil {
+			logger.WithError(err).Error("Ошибка получения поста для восстановления")
+			return errors.NewDatabaseError(err)
+		}
+
+		if post == nil {
+			return errors.NewPostNotFoundError(postID.String())
+		}
+
+		if !post.IsHidden() {
+			return errors.NewInvalidRequestError("Пост не скрыт")
+		}
+
+		now := time.Now()
+		post.Restore(now)
+		if err := repos.Posts.SetHidden(ctx, post); err != nil {
+			logger.WithError(err).Error("Ошибка восстановления поста")
+			return errors.NewDatabaseError(err)
+		}
+
+		target := &entities.ModerationQueueItem{TargetType: entities.ReportTargetPost, TargetID: post.ID, AuthorID: post.AuthorID}
+		entry = entities.NewModerationAuditEntry(moderatorID, entities.ModerationRestoreContent, target, note, now)
+		if err := repos.Reports.CreateAuditEntry(ctx, entry); err != nil {
+			logger.WithError(err).Error("Ошибка записи в журнал модерации")
+			return errors.NewDatabaseError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(postID, &CommentEvent{
+		Type:   CommentEventPostRestored,
+		PostID: postID,
+	})
+
+	logger.Info("Пост восстановлен")
+	return entry, nil
+}
+
+// GetAuditLogPage возвращает страницу журнала модерации, новые записи сначала. Доступно модераторам.
+func (s *ModerationService) GetAuditLogPage(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationAuditEntry, *entities.PageInfo, error) {
+	if _, err := s.requireModerator(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	entries, pageInfo, err := s.reportRepo.GetAuditLogKeyset(ctx, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы журнала модерации")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return entries, pageInfo, nil
+}
+
+func (s *ModerationService) requireModerator(ctx context.Context) (uuid.UUID, error) {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	allowed, err := s.policy.Allowed(ctx, actorID, ActionModerateReports, uuid.Nil)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки прав модератора")
+		return uuid.Nil, err
+	}
+
+	if !allowed {
+		s.logger.WithField("actor_id", actorID).Warn("Попытка доступа к модерации без прав модератора")
+		return uuid.Nil, errors.NewForbiddenError("Модерация доступна только модераторам")
+	}
+
+	return actorID, nil
+}
+
+// getTargetAuthor возвращает автора существующего объекта жалобы; на удаленный комментарий жаловаться нельзя
+func (s *ModerationService) getTargetAuthor(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID) (uuid.UUID, error) {
+	if targetType == entities.ReportTargetPost {
+		post, err := s.postRepo.GetByID(ctx, targetID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения поста для жалобы")
+			return uuid.Nil, errors.NewDatabaseError(err)
+		}
+		if post == nil {
+			return uuid.Nil, errors.NewPostNotFoundError(targetID.String())
+		}
+		return post.AuthorID, nil
+	}
+
+	comment, err := s.commentRepo.GetByID(ctx, targetID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения комментария для жалобы")
+		return uuid.Nil, errors.NewDatabaseError(err)
+	}
+	if comment == nil {
+		return uuid.Nil, errors.NewCommentNotFoundError(targetID.String())
+	}
+	if comment.IsDeleted() {
+		return uuid.Nil, errors.NewCommentDeletedError(targetID.String())
+	}
+	return comment.AuthorID, nil
+}
+
+// lockTarget блокирует объект жалобы до конца транзакции. Объект, удаленный после подачи жалоб,
+// возвращается как nil: жалобы на него можно закрыть, но скрывать уже нечего.
+func (s *ModerationService) lockTarget(ctx context.Context, repos Repositories, targetType entities.ReportTargetType, targetID uuid.UUID) (*entities.Comment, *entities.Post, error) {
+	if targetType == entities.ReportTargetPost {
+		post, err := repos.Posts.GetByIDForUpdate(ctx, targetID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения поста для скрытия")
+			return nil, nil, errors.NewDatabaseError(err)
+		}
+		return nil, post, nil
+	}
+
+	comment, err := repos.Comments.GetByIDForUpdate(ctx, targetID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения комментария для скрытия")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+	return comment, nil, nil
+}
+
+func normalizeModerationNote(note *string) (*string, error) {
+	if note == nil {
+		return nil, nil
+	}
+
+	trimmed := strings.TrimSpace(*note)
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	if utf8.RuneCountInString(trimmed) > entities.MaxModerationNoteLength {
+		return nil, errors.NewValidationError("Комментарий модератора слишком длинный")
+	}
+
+	return &trimmed, nil
+}
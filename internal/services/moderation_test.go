@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	appErrors "ozon-posts/pkg/errors"
+	testutils2 "ozon-posts/pkg/testutils"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type moderationTestSuite struct {
+	reports   *testutils2.MockReportRepository
+	users     *testutils2.MockUserRepository
+	posts     *testutils2.MockPostRepository
+	comments  *testutils2.MockCommentRepository
+	publisher *recordingPublisher
+	service   *ModerationService
+	moderator *entities.User
+}
+
+func newModerationTestSuite() *moderationTestSuite {
+	s := &moderationTestSuite{
+		reports:   &testutils2.MockReportRepository{},
+		users:     &testutils2.MockUserRepository{},
+		posts:     &testutils2.MockPostRepository{},
+		comments:  &testutils2.MockCommentRepository{},
+		publisher: &recordingPublisher{},
+		moderator: testutils2.CreateTestUser("moderator", "moderator@example.com"),
+	}
+	s.moderator.Role = entities.RoleModerator
+	s.users.On("GetByID", mock.Anything, s.moderator.ID).Return(s.moderator, nil).Maybe()
+
+	uow := &mockUnitOfWork{repos: Repositories{Users: s.users, Posts: s.posts, Comments: s.comments, Reports: s.reports}}
+	s.service = NewModerationService(s.reports, s.posts, s.comments, s.users, uow, s.publisher, testutils2.CreateTestLogger())
+	return s
+}
+
+func TestModerationService_Report_Comment(t *testing.T) {
+	s := newModerationTestSuite()
+
+	authorID := uuid.New()
+	reporterID := uuid.New()
+	comment := testutils2.CreateTestComment(uuid.New(), authorID, "Комментарий", nil)
+
+	s.comments.On("GetByID", mock.Anything, comment.ID).Return(comment, nil)
+	s.reports.On("Create", mock.Anything, mock.MatchedBy(func(report *entities.Report) bool {
+		return report.TargetType == entities.ReportTargetComment &&
+			report.TargetID == comment.ID &&
+			report.AuthorID == authorID &&
+			report.ReporterID == reporterID &&
+			report.Reason == entities.ReportSpam &&
+			report.IsOpen()
+	})).Return(true, nil)
+
+	created, err := s.service.Report(testutils2.CreateAuthContext(reporterID), entities.ReportTargetComment, comment.ID, entities.ReportSpam)
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	s.reports.AssertExpectations(t)
+}
+
+func TestModerationService_Report_Rejected(t *testing.T) {
+	authorID := uuid.New()
+
+	t.Run("own_content", func(t *testing.T) {
+		s := newModerationTestSuite()
+		post := testutils2.CreateTestPost(authorID, "Пост", "Текст")
+		s.posts.On("GetByID", mock.Anything, post.ID).Return(post, nil)
+
+		_, err := s.service.Report(testutils2.CreateAuthContext(authorID), entities.ReportTargetPost, post.ID, entities.ReportOther)
+
+		assertAppErrorCode(t, err, appErrors.ErrValidation)
+		s.reports.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("deleted_comment", func(t *testing.T) {
+		s := newModerationTestSuite()
+		comment := testutils2.CreateTestComment(uuid.New(), authorID, "Комментарий", nil)
+		comment.MarkDeleted(authorID)
+		s.comments.On("GetByID", mock.Anything, comment.ID).Return(comment, nil)
+
+		_, err := s.service.Report(testutils2.CreateAuthContext(uuid.New()), entities.ReportTargetComment, comment.ID, entities.ReportSpam)
+
+		assertAppErrorCode(t, err, appErrors.ErrCommentDeleted)
+	})
+
+	t.Run("unknown_reason", func(t *testing.T) {
+		s := newModerationTestSuite()
+
+		_, err := s.service.Report(testutils2.CreateAuthContext(uuid.New()), entities.ReportTargetPost, uuid.New(), entities.ReportReason("boring"))
+
+		assertAppErrorCode(t, err, appErrors.ErrValidation)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		s := newModerationTestSuite()
+
+		_, err := s.service.Report(context.Background(), entities.ReportTargetPost, uuid.New(), entities.ReportSpam)
+
+		assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+	})
+}
+
+func TestModerationService_GetModerationQueuePage(t *testing.T) {
+	s := newModerationTestSuite()
+
+	item := &entities.ModerationQueueItem{TargetType: entities.ReportTargetPost, TargetID: uuid.New(), ReportCount: 3}
+	req := &entities.CursorRequest{Limit: 10}
+
+	s.reports.On("GetQueueKeyset", mock.Anything, req).Return([]*entities.ModerationQueueItem{item}, &entities.PageInfo{}, nil)
+	s.reports.On("CountOpenByReason", mock.Anything, []uuid.UUID{item.TargetID}).Return(map[uuid.UUID]map[entities.ReportReason]int64{
+		item.TargetID: {entities.ReportHarassment: 1, entities.ReportSpam: 2},
+	}, nil)
+
+	items, _, err := s.service.GetModerationQueuePage(testutils2.CreateAuthContext(s.moderator.ID), req)
+
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, []*entities.ReportReasonCount{
+		{Reason: entities.ReportSpam, Count: 2},
+		{Reason: entities.ReportHarassment, Count: 1},
+	}, items[0].Reasons)
+}
+
+func TestModerationService_GetModerationQueuePage_Forbidden(t *testing.T) {
+	s := newModerationTestSuite()
+
+	user := testutils2.CreateTestUser("reader", "reader@example.com")
+	s.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	_, _, err := s.service.GetModerationQueuePage(testutils2.CreateAuthContext(user.ID), &entities.CursorRequest{Limit: 10})
+
+	assertAppErrorCode(t, err, appErrors.ErrForbidden)
+	s.reports.AssertNotCalled(t, "GetQueueKeyset", mock.Anything, mock.Anything)
+}
+
+func TestModerationService_ResolveReports_BanAuthor(t *testing.T) {
+	s := newModerationTestSuite()
+
+	author := testutils2.CreateTestUser("author", "author@example.com")
+	comment := testutils2.CreateTestComment(uuid.New(), author.ID, "Спам", nil)
+	item := &entities.ModerationQueueItem{TargetType: entities.ReportTargetComment, TargetID: comment.ID, AuthorID: author.ID, ReportCount: 1}
+
+	s.reports.On("GetQueueItem", mock.Anything, entities.ReportTargetComment, comment.ID).Return(item, nil)
+	s.comments.On("GetByIDForUpdate", mock.Anything, comment.ID).Return(comment, nil)
+	s.users.On("GetByID", mock.Anything, author.ID).Return(author, nil)
+	s.reports.On("ResolveOpen", mock.Anything, entities.ReportTargetComment, comment.ID, entities.ModerationBanAuthor, s.moderator.ID, mock.Anything).Return(int64(2), nil)
+	s.comments.On("SoftDelete", mock.Anything, mock.MatchedBy(func(c *entities.Comment) bool {
+		return c.ID == comment.ID && c.IsDeleted()
+	})).Return(nil)
+	s.users.On("SetBanned", mock.Anything, mock.MatchedBy(func(u *entities.User) bool {
+		return u.ID == author.ID && u.IsBanned()
+	})).Return(nil)
+	s.reports.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(nil)
+
+	note := "  рассылка спама  "
+	entry, err := s.service.ResolveReports(testutils2.CreateAuthContext(s.moderator.ID), entities.ReportTargetComment, comment.ID, entities.ModerationBanAuthor, &note)
+
+	require.NoError(t, err)
+	assert.Equal(t, s.moderator.ID, entry.ModeratorID)
+	assert.Equal(t, author.ID, entry.AuthorID)
+	assert.Equal(t, int64(2), entry.ReportsResolved, "число закрытых жалоб берется из ResolveOpen")
+	require.NotNil(t, entry.Note)
+	assert.Equal(t, "рассылка спама", *entry.Note)
+
+	require.Len(t, s.publisher.events, 1)
+	assert.Equal(t, CommentEventDeleted, s.publisher.events[0].Type)
+
+	s.reports.AssertExpectations(t)
+	s.comments.AssertExpectations(t)
+	s.users.AssertExpectations(t)
+}
+
+func TestModerationService_ResolveReports_HidePost(t *testing.T) {
+	s := newModerationTestSuite()
+
+	post := testutils2.CreateTestPost(uuid.New(), "Пост", "Текст")
+	item := &entities.ModerationQueueItem{TargetType: entities.ReportTargetPost, TargetID: post.ID, AuthorID: post.AuthorID, ReportCount: 1}
+
+	s.reports.On("GetQueueItem", mock.Anything, entities.ReportTargetPost, post.ID).Return(item, nil)
+	s.posts.On("GetByIDForUpdate", mock.Anything, post.ID).Return(post, nil)
+	s.reports.On("ResolveOpen", mock.Anything, entities.ReportTargetPost, post.ID, entities.ModerationHideContent, s.moderator.ID, mock.Anything).Return(int64(1), nil)
+	s.posts.On("SetHidden", mock.Anything, mock.MatchedBy(func(p *entities.Post) bool {
+		return p.ID == post.ID && p.IsHidden() && *p.HiddenBy == s.moderator.ID
+	})).Return(nil)
+	s.reports.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(nil)
+
+	entry, err := s.service.ResolveReports(testutils2.CreateAuthContext(s.moderator.ID), entities.ReportTargetPost, post.ID, entities.ModerationHideContent, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, entities.ModerationHideContent, entry.Action)
+	assert.Nil(t, entry.Note)
+	require.Len(t, s.publisher.events, 1)
+	assert.Equal(t, CommentEventPostHidden, s.publisher.events[0].Type)
+	assert.Equal(t, post.ID, s.publisher.events[0].PostID)
+	s.posts.AssertExpectations(t)
+	s.posts.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	s.users.AssertNotCalled(t, "SetBanned", mock.Anything, mock.Anything)
+}
+
+func TestModerationService_RestorePost(t *testing.T) {
+	s := newModerationTestSuite()
+
+	post := testutils2.CreateTestPost(uuid.New(), "Пост", "Текст")
+	post.Hide(s.moderator.ID, post.CreatedAt)
+
+	s.posts.On("GetByIDForUpdate", mock.Anything, post.ID).Return(post, nil)
+	s.posts.On("SetHidden", mock.Anything, mock.MatchedBy(func(p *entities.Post) bool {
+		return p.ID == post.ID && !p.IsHidden()
+	})).Return(nil)
+	s.reports.On("CreateAuditEntry", mock.Anything, mock.MatchedBy(func(entry *entities.ModerationAuditEntry) bool {
+		return entry.Action == entities.ModerationRestoreContent && entry.TargetID == post.ID && entry.AuthorID == post.AuthorID
+	})).Return(nil)
+
+	entry, err := s.service.RestorePost(testutils2.CreateAuthContext(s.moderator.ID), post.ID, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), entry.ReportsResolved)
+	require.Len(t, s.publisher.events, 1)
+	assert.Equal(t, CommentEventPostRestored, s.publisher.events[0].Type)
+	s.posts.AssertExpectations(t)
+	s.reports.AssertExpectations(t)
+}
+
+func TestModerationService_RestorePost_NotHidden(t *testing.T) {
+	s := newModerationTestSuite()
+
+	post := testutils2.CreateTestPost(uuid.New(), "Пост", "Текст")
+	s.posts.On("GetByIDForUpdate", mock.Anything, post.ID).Return(post, nil)
+
+	_, err := s.service.RestorePost(testutils2.CreateAuthContext(s.moderator.ID), post.ID, nil)
+
+	assertAppErrorCode(t, err, appErrors.ErrInvalidRequest)
+	s.posts.AssertNotCalled(t, "SetHidden", mock.Anything, mock.Anything)
+	assert.Empty(t, s.publisher.events)
+}
+
+func TestModerationService_ResolveReports_Rejected(t *testing.T) {
+	t.Run("no_open_reports", func(t *testing.T) {
+		s := newModerationTestSuite()
+		targetID := uuid.New()
+		s.reports.On("GetQueueItem", mock.Anything, entities.ReportTargetPost, targetID).Return(nil, nil)
+
+		_, err := s.service.ResolveReports(testutils2.CreateAuthContext(s.moderator.ID), entities.ReportTargetPost, targetID, entities.ModerationDismiss, nil)
+
+		assertAppErrorCode(t, err, appErrors.ErrInvalidRequest)
+	})
+
+	t.Run("ban_moderator", func(t *testing.T) {
+		s := newModerationTestSuite()
+		other := testutils2.CreateTestUser("other", "other@example.com")
+		other.Role = entities.RoleModerator
+		post := testutils2.CreateTestPost(other.ID, "Пост", "Текст")
+		item := &entities.ModerationQueueItem{TargetType: entities.ReportTargetPost, TargetID: post.ID, AuthorID: other.ID, ReportCount: 1}
+
+		s.reports.On("GetQueueItem", mock.Anything, entities.ReportTargetPost, post.ID).Return(item, nil)
+		s.posts.On("GetByIDForUpdate", mock.Anything, post.ID).Return(post, nil)
+		s.users.On("GetByID", mock.Anything, other.ID).Return(other, nil)
+
+		_, err := s.service.ResolveReports(testutils2.CreateAuthContext(s.moderator.ID), entities.ReportTargetPost, post.ID, entities.ModerationBanAuthor, nil)
+
+		assertAppErrorCode(t, err, appErrors.ErrForbidden)
+		s.reports.AssertNotCalled(t, "ResolveOpen", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		s.posts.AssertNotCalled(t, "SetHidden", mock.Anything, mock.Anything)
+	})
+
+	t.Run("not_moderator", func(t *testing.T) {
+		s := newModerationTestSuite()
+		user := testutils2.CreateTestUser("reader", "reader@example.com")
+		s.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+		_, err := s.service.ResolveReports(testutils2.CreateAuthContext(user.ID), entities.ReportTargetPost, uuid.New(), entities.ModerationDismiss, nil)
+
+		assertAppErrorCode(t, err, appErrors.ErrForbidden)
+	})
+}
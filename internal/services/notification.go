@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxNotificationsMarkedAtOnce ограничивает число идентификаторов в одном markNotificationsRead
+const MaxNotificationsMarkedAtOnce = 100
+
+// NotificationService - входящие оповещения пользователя. Оповещения создают другие сервисы
+// в транзакциях своих операций, а после фиксации публикуют их через publishNotifications.
+type NotificationService struct {
+	notificationRepo NotificationRepository
+	events           EventBus
+	logger           *logrus.Logger
+}
+
+func NewNotificationService(notificationRepo NotificationRepository, events EventBus, logger *logrus.Logger) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		events:           events,
+		logger:           logger,
+	}
+}
+
+// GetNotificationsPage возвращает страницу оповещений пользователя из контекста, новые сначала
+func (s *NotificationService) GetNotificationsPage(ctx context.Context, unreadOnly bool, req *entities.CursorRequest) ([]*entities.Notification, *entities.PageInfo, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"unread_only": unreadOnly,
+		"limit":       req.Limit,
+	}).Debug("Получение страницы оповещений")
+
+	notifications, pageInfo, err := s.notificationRepo.GetByUserIDKeyset(ctx, userID, unreadOnly, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы оповещений")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return notifications, pageInfo, nil
+}
+
+// MarkNotificationsRead отмечает прочитанными оповещения пользователя из контекста: перечисленные в ids
+// или все, если ids пуст. Чужие, несуществующие и уже прочитанные оповещения пропускаются.
+func (s *NotificationService) MarkNotificationsRead(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) > MaxNotificationsMarkedAtOnce {
+		return 0, errors.NewInvalidRequestError("слишком много оповещений в одном запросе")
+	}
+
+	marked, err := s.notificationRepo.MarkRead(ctx, userID, ids, time.Now())
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка отметки оповещений прочитанными")
+		return 0, errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"marked":  marked,
+	}).Info("Оповещения отмечены прочитанными")
+
+	return marked, nil
+}
+
+// SubscribeToNotifications подписывает пользователя из контекста на новые оповещения
+func (s *NotificationService) SubscribeToNotifications(ctx context.Context) (*UserSubscription, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.events.SubscribeUser(userID), nil
+}
+
+func (s *NotificationService) Unsubscribe(sub *UserSubscription) {
+	s.events.UnsubscribeUser(sub)
+}
+
+// publishNotifications рассылает сохраненные оповещения подписчикам получателей; вызывается после фиксации транзакции
+func publishNotifications(events UserEventPublisher, notifications []*entities.Notification) {
+	for _, notification := range notifications {
+		events.PublishUserEvent(&UserEvent{
+			Type:         UserEventNotification,
+			UserID:       notification.UserID,
+			Notification: notification,
+		})
+	}
+}
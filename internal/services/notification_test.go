@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	appErrors "ozon-posts/pkg/errors"
+	testutils2 "ozon-posts/pkg/testutils"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationService_GetNotificationsPage(t *testing.T) {
+	mockRepo := &testutils2.MockNotificationRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewNotificationService(mockRepo, NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	userID := uuid.New()
+	req := &entities.CursorRequest{Limit: 10}
+	expected := []*entities.Notification{{ID: uuid.New(), UserID: userID, Type: entities.NotificationPostComment}}
+
+	mockRepo.On("GetByUserIDKeyset", mock.Anything, userID, true, req).Return(expected, &entities.PageInfo{}, nil)
+
+	notifications, _, err := service.GetNotificationsPage(testutils2.CreateAuthContext(userID), true, req)
+	require.NoError(t, err)
+	assert.Equal(t, expected, notifications)
+
+	_, _, err = service.GetNotificationsPage(context.Background(), true, req)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNotificationService_MarkNotificationsRead(t *testing.T) {
+	mockRepo := &testutils2.MockNotificationRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewNotificationService(mockRepo, NewInProcessEventBus(DefaultEventBusOptions(), logger), logger)
+
+	userID := uuid.New()
+	ctx := testutils2.CreateAuthContext(userID)
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mockRepo.On("MarkRead", mock.Anything, userID, ids, mock.Anything).Return(int64(1), nil)
+	mockRepo.On("MarkRead", mock.Anything, userID, []uuid.UUID(nil), mock.Anything).Return(int64(3), nil)
+
+	marked, err := service.MarkNotificationsRead(ctx, ids)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+
+	marked, err = service.MarkNotificationsRead(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), marked)
+
+	_, err = service.MarkNotificationsRead(ctx, make([]uuid.UUID, MaxNotificationsMarkedAtOnce+1))
+	assertAppErrorCode(t, err, appErrors.ErrInvalidRequest)
+
+	_, err = service.MarkNotificationsRead(context.Background(), ids)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// Action - операция над ресурсом, право на которую проверяет Policy
+type Action string
+
+const (
+	ActionUpdatePost      Action = "post:update"
+	ActionDeletePost      Action = "post:delete"
+	ActionToggleComments  Action = "post:toggle_comments"
+	ActionViewPostHistory Action = "post:view_history"
+
+	ActionUpdateComment      Action = "comment:update"
+	ActionDeleteComment      Action = "comment:delete"
+	ActionPurgeComment       Action = "comment:purge"
+	ActionViewCommentHistory Action = "comment:view_history"
+
+	ActionUpdateUser     Action = "user:update"
+	ActionDeleteUser     Action = "user:delete"
+	ActionChangeUserRole Action = "user:change_role"
+
+	// ActionModerateReports - просмотр очереди и журнала модерации и решения по жалобам
+	ActionModerateReports Action = "report:moderate"
+)
+
+// rule описывает, кому разрешено действие: владельцу ресурса и/или пользователям с привилегированной ролью
+type rule struct {
+	owner      bool
+	privileged func(entities.Role) bool
+}
+
+var policyRules = map[Action]rule{
+	ActionUpdatePost:      {owner: true, privileged: entities.Role.IsAdmin},
+	ActionDeletePost:      {owner: true, privileged: entities.Role.IsModerator},
+	ActionToggleComments:  {owner: true, privileged: entities.Role.IsModerator},
+	ActionViewPostHistory: {owner: true, privileged: entities.Role.IsModerator},
+
+	ActionUpdateComment:      {owner: true, privileged: entities.Role.IsModerator},
+	ActionDeleteComment:      {owner: true, privileged: entities.Role.IsModerator},
+	ActionPurgeComment:       {owner: false, privileged: entities.Role.IsAdmin},
+	ActionViewCommentHistory: {owner: true, privileged: entities.Role.IsModerator},
+
+	ActionUpdateUser:     {owner: true, privileged: entities.Role.IsAdmin},
+	ActionDeleteUser:     {owner: true, privileged: entities.Role.IsAdmin},
+	ActionChangeUserRole: {owner: false, privileged: entities.Role.IsAdmin},
+
+	ActionModerateReports: {owner: false, privileged: entities.Role.IsModerator},
+}
+
+// Policy - единая точка проверки прав доступа для сервисов
+type Policy struct {
+	userRepo UserRepository
+}
+
+func NewPolicy(userRepo UserRepository) *Policy {
+	return &Policy{
+		userRepo: userRepo,
+	}
+}
+
+// Allowed сообщает, может ли actorID выполнить action над ресурсом, принадлежащим ownerID.
+// Роль актора загружается из репозитория только когда прав владельца недостаточно.
+func (p *Policy) Allowed(ctx context.Context, actorID uuid.UUID, action Action, ownerID uuid.UUID) (bool, error) {
+	r, ok := policyRules[action]
+	if !ok {
+		return false, nil
+	}
+
+	if r.owner && actorID == ownerID {
+		return true, nil
+	}
+
+	actor, err := p.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return false, errors.NewDatabaseError(err)
+	}
+
+	if actor == nil {
+		return false, nil
+	}
+
+	return r.privileged(actor.Role), nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	testutils2 "ozon-posts/pkg/testutils"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPolicy_Allowed(t *testing.T) {
+	testCases := []struct {
+		name    string
+		role    entities.Role
+		action  Action
+		owner   bool
+		allowed bool
+	}{
+		{"owner_updates_post", entities.RoleUser, ActionUpdatePost, true, true},
+		{"user_updates_foreign_post", entities.RoleUser, ActionUpdatePost, false, false},
+		{"moderator_updates_foreign_post", entities.RoleModerator, ActionUpdatePost, false, false},
+		{"admin_updates_foreign_post", entities.RoleAdmin, ActionUpdatePost, false, true},
+		{"moderator_deletes_foreign_post", entities.RoleModerator, ActionDeletePost, false, true},
+		{"moderator_toggles_comments", entities.RoleModerator, ActionToggleComments, false, true},
+		{"user_deletes_foreign_comment", entities.RoleUser, ActionDeleteComment, false, false},
+		{"moderator_deletes_foreign_comment", entities.RoleModerator, ActionDeleteComment, false, true},
+		{"moderator_updates_foreign_comment", entities.RoleModerator, ActionUpdateComment, false, true},
+		{"author_purges_own_comment", entities.RoleUser, ActionPurgeComment, true, false},
+		{"moderator_purges_comment", entities.RoleModerator, ActionPurgeComment, false, false},
+		{"admin_purges_comment", entities.RoleAdmin, ActionPurgeComment, false, true},
+		{"moderator_deletes_user", entities.RoleModerator, ActionDeleteUser, false, false},
+		{"admin_deletes_user", entities.RoleAdmin, ActionDeleteUser, false, true},
+		{"user_changes_own_role", entities.RoleUser, ActionChangeUserRole, true, false},
+		{"admin_changes_role", entities.RoleAdmin, ActionChangeUserRole, false, true},
+		{"user_moderates_reports", entities.RoleUser, ActionModerateReports, true, false},
+		{"moderator_moderates_reports", entities.RoleModerator, ActionModerateReports, false, true},
+		{"unknown_action", entities.RoleAdmin, Action("unknown"), false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepo := &testutils2.MockUserRepository{}
+			policy := NewPolicy(mockUserRepo)
+
+			actor := testutils2.CreateTestUser("actor", "actor@example.com")
+			actor.Role = tc.role
+			mockUserRepo.On("GetByID", mock.Anything, actor.ID).Return(actor, nil).Maybe()
+
+			ownerID := uuid.New()
+			if tc.owner {
+				ownerID = actor.ID
+			}
+
+			allowed, err := policy.Allowed(context.Background(), actor.ID, tc.action, ownerID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.allowed, allowed)
+		})
+	}
+}
+
+func TestPolicy_Allowed_OwnerSkipsRoleLookup(t *testing.T) {
+	mockUserRepo := &testutils2.MockUserRepository{}
+	policy := NewPolicy(mockUserRepo)
+
+	ownerID := uuid.New()
+
+	allowed, err := policy.Allowed(context.Background(), ownerID, ActionDeleteComment, ownerID)
+
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	mockUserRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestPolicy_Allowed_UnknownActor(t *testing.T) {
+	mockUserRepo := &testutils2.MockUserRepository{}
+	policy := NewPolicy(mockUserRepo)
+
+	actorID := uuid.New()
+	mockUserRepo.On("GetByID", mock.Anything, actorID).Return(nil, nil)
+
+	allowed, err := policy.Allowed(context.Background(), actorID, ActionDeleteComment, uuid.New())
+
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	mockUserRepo.AssertExpectations(t)
+}
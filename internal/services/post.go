@@ -12,18 +12,29 @@ import (
 type PostService struct {
 	postRepo PostRepository
 	userRepo UserRepository
+	uow      UnitOfWork
+	policy   *Policy
+	events   CommentEventPublisher
 	logger   *logrus.Logger
 }
 
-func NewPostService(postRepo PostRepository, userRepo UserRepository, logger *logrus.Logger) *PostService {
+func NewPostService(postRepo PostRepository, userRepo UserRepository, uow UnitOfWork, events CommentEventPublisher, logger *logrus.Logger) *PostService {
 	return &PostService{
 		postRepo: postRepo,
 		userRepo: userRepo,
+		uow:      uow,
+		policy:   NewPolicy(userRepo),
+		events:   events,
 		logger:   logger,
 	}
 }
 
-func (s *PostService) CreatePost(ctx context.Context, authorID uuid.UUID, title, content string) (*entities.Post, error) {
+func (s *PostService) CreatePost(ctx context.Context, title, content string) (*entities.Post, error) {
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"author_id": authorID,
 		"title":     title,
@@ -72,12 +83,19 @@ func (s *PostService) GetPostByID(ctx context.Context, id uuid.UUID) (*entities.
 		return nil, errors.NewPostNotFoundError(id.String())
 	}
 
-	if err := s.loadPostAuthor(ctx, post); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки автора поста")
+	return post, nil
+}
+
+func (s *PostService) GetPostsByIDs(ctx context.Context, ids []uuid.UUID) ([]*entities.Post, error) {
+	s.logger.WithField("ids_count", len(ids)).Debug("Получение постов по списку ID")
+
+	posts, err := s.postRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения постов по ID")
 		return nil, errors.NewDatabaseError(err)
 	}
 
-	return post, nil
+	return posts, nil
 }
 
 func (s *PostService) GetAllPosts(ctx context.Context, pagination *entities.PaginationRequest) ([]*entities.Post, *entities.PaginationResponse, error) {
@@ -92,11 +110,29 @@ func (s *PostService) GetAllPosts(ctx context.Context, pagination *entities.Pagi
 		return nil, nil, errors.NewDatabaseError(err)
 	}
 
-	if err := s.loadPostsAuthors(ctx, posts); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки авторов постов")
+	return posts, paginationResponse, nil
+}
+
+// SearchPosts ищет посты по заголовку и тексту, самые релевантные идут первыми
+func (s *PostService) SearchPosts(ctx context.Context, text string, pagination *entities.PaginationRequest) ([]*entities.PostSearchResult, *entities.PaginationResponse, error) {
+	query, err := entities.NewSearchQuery(text, nil)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return posts, paginationResponse, nil
+	s.logger.WithFields(logrus.Fields{
+		"query":  query.Text,
+		"limit":  pagination.Limit,
+		"offset": pagination.Offset,
+	}).Debug("Поиск постов")
+
+	results, paginationResponse, err := s.postRepo.Search(ctx, query, pagination)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка поиска постов")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return results, paginationResponse, nil
 }
 
 func (s *PostService) GetPostsByAuthor(ctx context.Context, authorID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Post, *entities.PaginationResponse, error) {
@@ -130,7 +166,58 @@ func (s *PostService) GetPostsByAuthor(ctx context.Context, authorID uuid.UUID,
 	return posts, paginationResponse, nil
 }
 
-func (s *PostService) UpdatePost(ctx context.Context, postID, authorID uuid.UUID, title, content string) (*entities.Post, error) {
+func (s *PostService) GetPostsPage(ctx context.Context, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	s.logger.WithFields(logrus.Fields{
+		"limit":    req.Limit,
+		"from_end": req.FromEnd,
+	}).Debug("Получение страницы постов по курсору")
+
+	posts, pageInfo, err := s.postRepo.GetAllKeyset(ctx, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы постов")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	return posts, pageInfo, nil
+}
+
+func (s *PostService) GetPostsByAuthorPage(ctx context.Context, authorID uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	s.logger.WithFields(logrus.Fields{
+		"author_id": authorID,
+		"limit":     req.Limit,
+		"from_end":  req.FromEnd,
+	}).Debug("Получение страницы постов автора по курсору")
+
+	author, err := s.userRepo.GetByID(ctx, authorID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения автора")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	if author == nil {
+		s.logger.WithField("author_id", authorID).Warn("Автор не найден")
+		return nil, nil, errors.NewUserNotFoundError(authorID.String())
+	}
+
+	posts, pageInfo, err := s.postRepo.GetByAuthorIDKeyset(ctx, authorID, req)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения страницы постов автора")
+		return nil, nil, errors.NewDatabaseError(err)
+	}
+
+	for _, post := range posts {
+		post.Author = author
+	}
+
+	return posts, pageInfo, nil
+}
+
+func (s *PostService) UpdatePost(ctx context.Context, postID uuid.UUID, title, content string) (*entities.Post, error) {
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"post_id":   postID,
 		"author_id": authorID,
@@ -142,107 +229,174 @@ func (s *PostService) UpdatePost(ctx context.Context, postID, authorID uuid.UUID
 		return nil, err
 	}
 
-	post, err := s.postRepo.GetByID(ctx, postID)
-	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения поста для обновления")
-		return nil, errors.NewDatabaseError(err)
-	}
+	var post *entities.Post
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		var err error
+		post, err = repos.Posts.GetByIDForUpdate(ctx, postID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения поста для обновления")
+			return errors.NewDatabaseError(err)
+		}
 
-	if post == nil {
-		return nil, errors.NewPostNotFoundError(postID.String())
-	}
+		// Скрытый модератором пост для автора недоступен, как и для остальных читателей
+		if post == nil || post.IsHidden() {
+			return errors.NewPostNotFoundError(postID.String())
+		}
 
-	if post.AuthorID != authorID {
-		s.logger.WithFields(logrus.Fields{
-			"post_author_id": post.AuthorID,
-			"requester_id":   authorID,
-		}).Warn("Попытка редактирования чужого поста")
-		return nil, errors.NewPostAccessDeniedError(postID.String())
-	}
+		allowed, err := s.policy.Allowed(ctx, authorID, ActionUpdatePost, post.AuthorID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка проверки прав доступа к посту")
+			return err
+		}
 
-	post.Title = title
-	post.Content = content
+		if !allowed {
+			s.logger.WithFields(logrus.Fields{
+				"post_author_id": post.AuthorID,
+				"requester_id":   authorID,
+			}).Warn("Попытка редактирования чужого поста")
+			return errors.NewPostAccessDeniedError(postID.String())
+		}
 
-	if err := s.postRepo.Update(ctx, post); err != nil {
-		s.logger.WithError(err).Error("Ошибка обновления поста")
-		return nil, errors.NewDatabaseError(err)
-	}
+		if post.Title == title && post.Content == content {
+			return nil
+		}
 
-	if err := s.loadPostAuthor(ctx, post); err != nil {
-		s.logger.WithError(err).Error("Ошибка загрузки автора поста")
+		if err := repos.Posts.CreateRevision(ctx, entities.NewPostRevision(post)); err != nil {
+			s.logger.WithError(err).Error("Ошибка сохранения прежней версии поста")
+			return errors.NewDatabaseError(err)
+		}
+
+		post.ApplyEdit(title, content, authorID)
+
+		if err := repos.Posts.Update(ctx, post); err != nil {
+			s.logger.WithError(err).Error("Ошибка обновления поста")
+			return errors.NewDatabaseError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	s.logger.WithField("post_id", postID).Info("Пост успешно обновлен")
 	return post, nil
 }
 
-func (s *PostService) ToggleComments(ctx context.Context, postID, authorID uuid.UUID, disable bool) error {
+func (s *PostService) ToggleComments(ctx context.Context, postID uuid.UUID, disable bool) error {
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"post_id":   postID,
 		"author_id": authorID,
 		"disable":   disable,
 	}).Info("Переключение комментариев поста")
 
-	post, err := s.postRepo.GetByID(ctx, postID)
-	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения поста")
-		return errors.NewDatabaseError(err)
-	}
+	// Блокировка поста на запись ждет завершения транзакций CreateComment, уже прочитавших пост,
+	// поэтому после отключения комментариев новых комментариев к посту не появится
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		post, err := repos.Posts.GetByIDForUpdate(ctx, postID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения поста")
+			return errors.NewDatabaseError(err)
+		}
 
-	if post == nil {
-		return errors.NewPostNotFoundError(postID.String())
-	}
+		if post == nil || post.IsHidden() {
+			return errors.NewPostNotFoundError(postID.String())
+		}
 
-	if post.AuthorID != authorID {
-		s.logger.WithFields(logrus.Fields{
-			"post_author_id": post.AuthorID,
-			"requester_id":   authorID,
-		}).Warn("Попытка изменения настроек чужого поста")
-		return errors.NewPostAccessDeniedError(postID.String())
-	}
+		allowed, err := s.policy.Allowed(ctx, authorID, ActionToggleComments, post.AuthorID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка проверки прав доступа к посту")
+			return err
+		}
 
-	if disable {
-		post.DisableComments()
-	} else {
-		post.EnableComments()
+		if !allowed {
+			s.logger.WithFields(logrus.Fields{
+				"post_author_id": post.AuthorID,
+				"requester_id":   authorID,
+			}).Warn("Попытка изменения настроек чужого поста")
+			return errors.NewPostAccessDeniedError(postID.String())
+		}
+
+		if disable {
+			post.DisableComments()
+		} else {
+			post.EnableComments()
+		}
+
+		if err := repos.Posts.Update(ctx, post); err != nil {
+			s.logger.WithError(err).Error("Ошибка обновления настроек поста")
+			return errors.NewDatabaseError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if err := s.postRepo.Update(ctx, post); err != nil {
-		s.logger.WithError(err).Error("Ошибка обновления настроек поста")
-		return errors.NewDatabaseError(err)
+	eventType := CommentEventCommentsEnabled
+	if disable {
+		eventType = CommentEventCommentsDisabled
 	}
+	s.events.Publish(postID, &CommentEvent{
+		Type:   eventType,
+		PostID: postID,
+	})
 
 	s.logger.WithField("post_id", postID).Info("Настройки комментариев успешно обновлены")
 	return nil
 }
 
-func (s *PostService) DeletePost(ctx context.Context, postID, authorID uuid.UUID) error {
+func (s *PostService) DeletePost(ctx context.Context, postID uuid.UUID) error {
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"post_id":   postID,
 		"author_id": authorID,
 	}).Info("Удаление поста")
 
-	post, err := s.postRepo.GetByID(ctx, postID)
-	if err != nil {
-		s.logger.WithError(err).Error("Ошибка получения поста для удаления")
-		return errors.NewDatabaseError(err)
-	}
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		post, err := repos.Posts.GetByIDForUpdate(ctx, postID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения поста для удаления")
+			return errors.NewDatabaseError(err)
+		}
 
-	if post == nil {
-		return errors.NewPostNotFoundError(postID.String())
-	}
+		if post == nil || post.IsHidden() {
+			return errors.NewPostNotFoundError(postID.String())
+		}
 
-	if post.AuthorID != authorID {
-		s.logger.WithFields(logrus.Fields{
-			"post_author_id": post.AuthorID,
-			"requester_id":   authorID,
-		}).Warn("Попытка удаления чужого поста")
-		return errors.NewPostAccessDeniedError(postID.String())
-	}
+		allowed, err := s.policy.Allowed(ctx, authorID, ActionDeletePost, post.AuthorID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка проверки прав доступа к посту")
+			return err
+		}
 
-	if err := s.postRepo.Delete(ctx, postID); err != nil {
-		s.logger.WithError(err).Error("Ошибка удаления поста")
-		return errors.NewDatabaseError(err)
+		if !allowed {
+			s.logger.WithFields(logrus.Fields{
+				"post_author_id": post.AuthorID,
+				"requester_id":   authorID,
+			}).Warn("Попытка удаления чужого поста")
+			return errors.NewPostAccessDeniedError(postID.String())
+		}
+
+		if err := repos.Posts.Delete(ctx, postID); err != nil {
+			s.logger.WithError(err).Error("Ошибка удаления поста")
+			return errors.NewDatabaseError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	s.logger.WithField("post_id", postID).Info("Пост успешно удален")
@@ -259,46 +413,39 @@ func (s *PostService) IsCommentsEnabled(ctx context.Context, postID uuid.UUID) (
 	return enabled, nil
 }
 
-func (s *PostService) loadPostAuthor(ctx context.Context, post *entities.Post) error {
-	author, err := s.userRepo.GetByID(ctx, post.AuthorID)
+// GetPostRevisions возвращает прежние версии поста от исходной к последней.
+// История доступна автору поста и модераторам.
+func (s *PostService) GetPostRevisions(ctx context.Context, postID uuid.UUID) ([]*entities.PostRevision, error) {
+	actorID, err := actorFromContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	post.Author = author
-	return nil
-}
-
-func (s *PostService) loadPostsAuthors(ctx context.Context, posts []*entities.Post) error {
-	if len(posts) == 0 {
-		return nil
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения поста для просмотра истории")
+		return nil, errors.NewDatabaseError(err)
 	}
 
-	authorIDs := make([]uuid.UUID, 0, len(posts))
-	authorIDSet := make(map[uuid.UUID]bool)
-
-	for _, post := range posts {
-		if !authorIDSet[post.AuthorID] {
-			authorIDs = append(authorIDs, post.AuthorID)
-			authorIDSet[post.AuthorID] = true
-		}
+	if post == nil {
+		return nil, errors.NewPostNotFoundError(postID.String())
 	}
 
-	authors, err := s.userRepo.GetByIDs(ctx, authorIDs)
+	allowed, err := s.policy.Allowed(ctx, actorID, ActionViewPostHistory, post.AuthorID)
 	if err != nil {
-		return err
+		s.logger.WithError(err).Error("Ошибка проверки прав доступа к истории поста")
+		return nil, err
 	}
 
-	authorMap := make(map[uuid.UUID]*entities.User)
-	for _, author := range authors {
-		authorMap[author.ID] = author
+	if !allowed {
+		return nil, errors.NewPostAccessDeniedError(postID.String())
 	}
 
-	for _, post := range posts {
-		if author, exists := authorMap[post.AuthorID]; exists {
-			post.Author = author
-		}
+	revisions, err := s.postRepo.GetRevisions(ctx, postID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения истории правок поста")
+		return nil, errors.NewDatabaseError(err)
 	}
 
-	return nil
+	return revisions, nil
 }
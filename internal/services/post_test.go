@@ -13,11 +13,50 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// recordingPublisher запоминает опубликованные события вместо рассылки подписчикам
+type recordingPublisher struct {
+	events []*CommentEvent
+}
+
+func (p *recordingPublisher) Publish(postID uuid.UUID, event *CommentEvent) {
+	p.events = append(p.events, event)
+}
+
+// recordingNotifications запоминает созданные оповещения, чтобы тесты операций, попутно
+// создающих оповещения, не описывали ожидания для каждого из них
+type recordingNotifications struct {
+	NotificationRepository
+	created []*entities.Notification
+}
+
+func (r *recordingNotifications) Create(ctx context.Context, notifications []*entities.Notification) error {
+	r.created = append(r.created, notifications...)
+	return nil
+}
+
+// mockUnitOfWork выполняет функцию сразу над переданными моками, без транзакции
+type mockUnitOfWork struct {
+	repos Repositories
+}
+
+func newMockUnitOfWork(users UserRepository, posts PostRepository, comments CommentRepository) *mockUnitOfWork {
+	return &mockUnitOfWork{repos: Repositories{
+		Users:         users,
+		Posts:         posts,
+		Comments:      comments,
+		Notifications: &recordingNotifications{},
+	}}
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	return fn(ctx, u.repos)
+}
+
 func TestPostService_CreatePost_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	authorID := uuid.New()
 	title, content := testutils2.CreateValidPostData()
@@ -29,7 +68,7 @@ func TestPostService_CreatePost_Success(t *testing.T) {
 		return post.AuthorID == authorID && post.Title == title && post.Content == content
 	})).Return(nil)
 
-	post, err := service.CreatePost(context.Background(), authorID, title, content)
+	post, err := service.CreatePost(testutils2.CreateAuthContext(authorID), title, content)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, post)
@@ -46,14 +85,14 @@ func TestPostService_CreatePost_AuthorNotFound(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	authorID := uuid.New()
 	title, content := testutils2.CreateValidPostData()
 
 	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(nil, nil)
 
-	post, err := service.CreatePost(context.Background(), authorID, title, content)
+	post, err := service.CreatePost(testutils2.CreateAuthContext(authorID), title, content)
 
 	assert.Error(t, err)
 	assert.Nil(t, post)
@@ -83,11 +122,11 @@ func TestPostService_CreatePost_InvalidData(t *testing.T) {
 			mockPostRepo := &testutils2.MockPostRepository{}
 			mockUserRepo := &testutils2.MockUserRepository{}
 			logger := testutils2.CreateTestLogger()
-			service := NewPostService(mockPostRepo, mockUserRepo, logger)
+			service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 			authorID := uuid.New()
 
-			post, err := service.CreatePost(context.Background(), authorID, tc.title, tc.content)
+			post, err := service.CreatePost(testutils2.CreateAuthContext(authorID), tc.title, tc.content)
 
 			assert.Error(t, err)
 			assert.Nil(t, post)
@@ -103,33 +142,31 @@ func TestPostService_GetPostByID_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
 	expectedPost := testutils2.CreateTestPost(authorID, "Test Post", "Content")
 	expectedPost.ID = postID
-	author := testutils2.CreateTestUser("testuser", "test@example.com")
-	author.ID = authorID
 
 	mockPostRepo.On("GetByID", mock.Anything, postID).Return(expectedPost, nil)
-	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
 
 	post, err := service.GetPostByID(context.Background(), postID)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, post)
 	assert.Equal(t, postID, post.ID)
-	assert.Equal(t, author, post.Author)
+	// Автор загружается резолвером GraphQL только по запросу
+	assert.Nil(t, post.Author)
 	mockPostRepo.AssertExpectations(t)
-	mockUserRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
 }
 
 func TestPostService_GetPostByID_NotFound(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	postID := uuid.New()
 
@@ -150,7 +187,7 @@ func TestPostService_GetAllPosts_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	pagination := testutils2.CreateTestPagination(10, 0)
 
@@ -169,15 +206,7 @@ func TestPostService_GetAllPosts_Success(t *testing.T) {
 		HasMore: false,
 	}
 
-	authors := []*entities.User{
-		testutils2.CreateTestUser("user1", "user1@example.com"),
-		testutils2.CreateTestUser("user2", "user2@example.com"),
-	}
-	authors[0].ID = authorID1
-	authors[1].ID = authorID2
-
 	mockPostRepo.On("GetAll", mock.Anything, pagination).Return(expectedPosts, expectedPagination, nil)
-	mockUserRepo.On("GetByIDs", mock.Anything, []uuid.UUID{authorID1, authorID2}).Return(authors, nil)
 
 	posts, paginationResp, err := service.GetAllPosts(context.Background(), pagination)
 
@@ -185,17 +214,59 @@ func TestPostService_GetAllPosts_Success(t *testing.T) {
 	assert.NotNil(t, posts)
 	assert.Len(t, posts, 2)
 	assert.Equal(t, expectedPagination, paginationResp)
-	assert.Equal(t, authors[0], posts[0].Author)
-	assert.Equal(t, authors[1], posts[1].Author)
 	mockPostRepo.AssertExpectations(t)
-	mockUserRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "GetByIDs", mock.Anything, mock.Anything)
+}
+
+func TestPostService_GetPostsPage_Success(t *testing.T) {
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
+
+	req := &entities.CursorRequest{Limit: 1}
+	author := testutils2.CreateTestUser("user1", "user1@example.com")
+	expectedPosts := []*entities.Post{
+		testutils2.CreateTestPost(author.ID, "Post 1", "Content 1"),
+	}
+	expectedPageInfo := &entities.PageInfo{HasNextPage: true}
+
+	mockPostRepo.On("GetAllKeyset", mock.Anything, req).Return(expectedPosts, expectedPageInfo, nil)
+
+	posts, pageInfo, err := service.GetPostsPage(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Len(t, posts, 1)
+	assert.Equal(t, expectedPageInfo, pageInfo)
+	mockPostRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "GetByIDs", mock.Anything, mock.Anything)
+}
+
+func TestPostService_GetPostsByAuthorPage_AuthorNotFound(t *testing.T) {
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
+
+	authorID := uuid.New()
+	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(nil, nil)
+
+	posts, pageInfo, err := service.GetPostsByAuthorPage(context.Background(), authorID, &entities.CursorRequest{Limit: 10})
+
+	assert.Error(t, err)
+	assert.Nil(t, posts)
+	assert.Nil(t, pageInfo)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrUserNotFound, appErr.Code)
+	mockPostRepo.AssertNotCalled(t, "GetByAuthorIDKeyset", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostService_UpdatePost_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
@@ -204,30 +275,30 @@ func TestPostService_UpdatePost_Success(t *testing.T) {
 
 	newTitle := "New Title"
 	newContent := "New Content"
-	author := testutils2.CreateTestUser("testuser", "test@example.com")
-	author.ID = authorID
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(existingPost, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(existingPost, nil)
+	mockPostRepo.On("CreateRevision", mock.Anything, mock.MatchedBy(func(revision *entities.PostRevision) bool {
+		return revision.PostID == postID && revision.Version == 0 && revision.Title == "Old Title" && revision.Content == "Old Content"
+	})).Return(nil)
 	mockPostRepo.On("Update", mock.Anything, mock.MatchedBy(func(post *entities.Post) bool {
-		return post.ID == postID && post.Title == newTitle && post.Content == newContent
+		return post.ID == postID && post.Title == newTitle && post.Content == newContent && post.EditCount == 1
 	})).Return(nil)
-	mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
 
-	post, err := service.UpdatePost(context.Background(), postID, authorID, newTitle, newContent)
+	post, err := service.UpdatePost(testutils2.CreateAuthContext(authorID), postID, newTitle, newContent)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, post)
 	assert.Equal(t, newTitle, post.Title)
 	assert.Equal(t, newContent, post.Content)
+	assert.True(t, post.IsEdited())
 	mockPostRepo.AssertExpectations(t)
-	mockUserRepo.AssertExpectations(t)
 }
 
 func TestPostService_UpdatePost_AccessDenied(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	postID := uuid.New()
 	realAuthorID := uuid.New()
@@ -236,9 +307,12 @@ func TestPostService_UpdatePost_AccessDenied(t *testing.T) {
 	existingPost := testutils2.CreateTestPost(realAuthorID, "Title", "Content")
 	existingPost.ID = postID
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(existingPost, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(existingPost, nil)
+	fakeAuthor := testutils2.CreateTestUser("intruder", "intruder@example.com")
+	fakeAuthor.ID = fakeAuthorID
+	mockUserRepo.On("GetByID", mock.Anything, fakeAuthorID).Return(fakeAuthor, nil)
 
-	post, err := service.UpdatePost(context.Background(), postID, fakeAuthorID, "New Title", "New Content")
+	post, err := service.UpdatePost(testutils2.CreateAuthContext(fakeAuthorID), postID, "New Title", "New Content")
 
 	assert.Error(t, err)
 	assert.Nil(t, post)
@@ -247,13 +321,15 @@ func TestPostService_UpdatePost_AccessDenied(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, appErrors.ErrPostAccessDenied, appErr.Code)
 	mockPostRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
 }
 
 func TestPostService_ToggleComments_Disable(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	publisher := &recordingPublisher{}
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), publisher, logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
@@ -261,22 +337,28 @@ func TestPostService_ToggleComments_Disable(t *testing.T) {
 	existingPost.ID = postID
 	existingPost.CommentsDisabled = false
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(existingPost, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(existingPost, nil)
 	mockPostRepo.On("Update", mock.Anything, mock.MatchedBy(func(post *entities.Post) bool {
 		return post.ID == postID && post.CommentsDisabled == true
 	})).Return(nil)
 
-	err := service.ToggleComments(context.Background(), postID, authorID, true)
+	err := service.ToggleComments(testutils2.CreateAuthContext(authorID), postID, true)
 
 	assert.NoError(t, err)
 	mockPostRepo.AssertExpectations(t)
+
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, CommentEventCommentsDisabled, publisher.events[0].Type)
+	assert.Equal(t, postID, publisher.events[0].PostID)
+	assert.Nil(t, publisher.events[0].Comment)
 }
 
 func TestPostService_ToggleComments_Enable(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	publisher := &recordingPublisher{}
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), publisher, logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
@@ -284,32 +366,37 @@ func TestPostService_ToggleComments_Enable(t *testing.T) {
 	existingPost.ID = postID
 	existingPost.CommentsDisabled = true
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(existingPost, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(existingPost, nil)
 	mockPostRepo.On("Update", mock.Anything, mock.MatchedBy(func(post *entities.Post) bool {
 		return post.ID == postID && post.CommentsDisabled == false
 	})).Return(nil)
 
-	err := service.ToggleComments(context.Background(), postID, authorID, false)
+	err := service.ToggleComments(testutils2.CreateAuthContext(authorID), postID, false)
 
 	assert.NoError(t, err)
 	mockPostRepo.AssertExpectations(t)
+
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, CommentEventCommentsEnabled, publisher.events[0].Type)
+	assert.Equal(t, postID, publisher.events[0].PostID)
+	assert.Nil(t, publisher.events[0].Comment)
 }
 
 func TestPostService_DeletePost_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	postID := uuid.New()
 	authorID := uuid.New()
 	existingPost := testutils2.CreateTestPost(authorID, "Title", "Content")
 	existingPost.ID = postID
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(existingPost, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(existingPost, nil)
 	mockPostRepo.On("Delete", mock.Anything, postID).Return(nil)
 
-	err := service.DeletePost(context.Background(), postID, authorID)
+	err := service.DeletePost(testutils2.CreateAuthContext(authorID), postID)
 
 	assert.NoError(t, err)
 	mockPostRepo.AssertExpectations(t)
@@ -319,7 +406,7 @@ func TestPostService_DeletePost_AccessDenied(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	postID := uuid.New()
 	realAuthorID := uuid.New()
@@ -328,9 +415,12 @@ func TestPostService_DeletePost_AccessDenied(t *testing.T) {
 	existingPost := testutils2.CreateTestPost(realAuthorID, "Title", "Content")
 	existingPost.ID = postID
 
-	mockPostRepo.On("GetByID", mock.Anything, postID).Return(existingPost, nil)
+	mockPostRepo.On("GetByIDForUpdate", mock.Anything, postID).Return(existingPost, nil)
+	fakeAuthor := testutils2.CreateTestUser("intruder", "intruder@example.com")
+	fakeAuthor.ID = fakeAuthorID
+	mockUserRepo.On("GetByID", mock.Anything, fakeAuthorID).Return(fakeAuthor, nil)
 
-	err := service.DeletePost(context.Background(), postID, fakeAuthorID)
+	err := service.DeletePost(testutils2.CreateAuthContext(fakeAuthorID), postID)
 
 	assert.Error(t, err)
 
@@ -338,13 +428,14 @@ func TestPostService_DeletePost_AccessDenied(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, appErrors.ErrPostAccessDenied, appErr.Code)
 	mockPostRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
 }
 
 func TestPostService_GetPostsByAuthor_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	authorID := uuid.New()
 	pagination := testutils2.CreateTestPagination(10, 0)
@@ -383,7 +474,7 @@ func TestPostService_IsCommentsEnabled_Success(t *testing.T) {
 	mockPostRepo := &testutils2.MockPostRepository{}
 	mockUserRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewPostService(mockPostRepo, mockUserRepo, logger)
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 	postID := uuid.New()
 
@@ -401,7 +492,7 @@ func TestPostService_DatabaseErrors(t *testing.T) {
 		mockPostRepo := &testutils2.MockPostRepository{}
 		mockUserRepo := &testutils2.MockUserRepository{}
 		logger := testutils2.CreateTestLogger()
-		service := NewPostService(mockPostRepo, mockUserRepo, logger)
+		service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 		authorID := uuid.New()
 		author := testutils2.CreateTestUser("test", "test@example.com")
@@ -410,7 +501,7 @@ func TestPostService_DatabaseErrors(t *testing.T) {
 		mockUserRepo.On("GetByID", mock.Anything, authorID).Return(author, nil)
 		mockPostRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db error"))
 
-		_, err := service.CreatePost(context.Background(), authorID, "Title", "Content")
+		_, err := service.CreatePost(testutils2.CreateAuthContext(authorID), "Title", "Content")
 
 		assert.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
@@ -424,7 +515,7 @@ func TestPostService_DatabaseErrors(t *testing.T) {
 		mockPostRepo := &testutils2.MockPostRepository{}
 		mockUserRepo := &testutils2.MockUserRepository{}
 		logger := testutils2.CreateTestLogger()
-		service := NewPostService(mockPostRepo, mockUserRepo, logger)
+		service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
 
 		postID := uuid.New()
 		mockPostRepo.On("GetByID", mock.Anything, postID).Return(nil, errors.New("db error"))
@@ -438,3 +529,63 @@ func TestPostService_DatabaseErrors(t *testing.T) {
 		mockPostRepo.AssertExpectations(t)
 	})
 }
+
+func TestPostService_GetPostRevisions_AccessDenied(t *testing.T) {
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, logger)
+
+	post := testutils2.CreateTestPost(uuid.New(), "Title", "Content")
+	stranger := testutils2.CreateTestUser("stranger", "stranger@example.com")
+
+	mockPostRepo.On("GetByID", mock.Anything, post.ID).Return(post, nil)
+	mockUserRepo.On("GetByID", mock.Anything, stranger.ID).Return(stranger, nil)
+
+	revisions, err := service.GetPostRevisions(testutils2.CreateAuthContext(stranger.ID), post.ID)
+
+	assert.Nil(t, revisions)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrPostAccessDenied, appErr.Code)
+	mockPostRepo.AssertNotCalled(t, "GetRevisions", mock.Anything, mock.Anything)
+}
+
+func TestPostService_SearchPosts_InvalidQuery(t *testing.T) {
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, testutils2.CreateTestLogger())
+
+	results, pagination, err := service.SearchPosts(context.Background(), " ,. ", entities.NewPaginationRequest(10, 0))
+
+	assert.Nil(t, results)
+	assert.Nil(t, pagination)
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrInvalidRequest, appErr.Code)
+	mockPostRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostService_SearchPosts_Success(t *testing.T) {
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockUserRepo := &testutils2.MockUserRepository{}
+	service := NewPostService(mockPostRepo, mockUserRepo, newMockUnitOfWork(mockUserRepo, mockPostRepo, nil), &recordingPublisher{}, testutils2.CreateTestLogger())
+
+	author := testutils2.CreateTestUser("author", "author@example.com")
+	post := testutils2.CreateTestPost(author.ID, "GraphQL на Go", "Content")
+	pagination := entities.NewPaginationRequest(10, 0)
+	found := []*entities.PostSearchResult{{Post: post, Rank: 0.5, Snippet: "Content"}}
+
+	mockPostRepo.On("Search", mock.Anything, mock.MatchedBy(func(query *entities.SearchQuery) bool {
+		return assert.ObjectsAreEqual([]string{"graphql"}, query.Terms)
+	}), pagination).Return(found, entities.NewPaginationResponse(1, 10, 0), nil)
+
+	results, paginationResponse, err := service.SearchPosts(context.Background(), "GraphQL", pagination)
+
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, post, results[0].Post)
+	assert.Equal(t, int64(1), paginationResponse.Total)
+}
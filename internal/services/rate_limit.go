@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"ozon-posts/pkg/auth"
+	"ozon-posts/pkg/errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitScope - по чему считается лимит: действующий пользователь или адрес клиента
+type RateLimitScope string
+
+const (
+	RateLimitByUser RateLimitScope = "user"
+	RateLimitByIP   RateLimitScope = "ip"
+)
+
+// RateLimit - параметры token bucket: сразу доступно Burst запросов, затем они восстанавливаются
+// равномерно, Burst за Period. Нулевое значение отключает лимит.
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+func (l RateLimit) Enabled() bool {
+	return l.Burst > 0 && l.Period > 0
+}
+
+// Rate - сколько токенов восстанавливается за секунду
+func (l RateLimit) Rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+func (l RateLimit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+// ParseRateLimit разбирает лимит вида "10/1m"; "off" и "0" отключают лимит
+func ParseRateLimit(spec string) (RateLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "off" || spec == "0" {
+		return RateLimit{}, nil
+	}
+
+	burstStr, periodStr, found := strings.Cut(spec, "/")
+	if !found {
+		return RateLimit{}, fmt.Errorf("некорректный лимит %q: ожидается формат N/период, например 10/1m", spec)
+	}
+
+	burst, err := strconv.Atoi(burstStr)
+	if err != nil || burst <= 0 {
+		return RateLimit{}, fmt.Errorf("некорректное число запросов в лимите %q", spec)
+	}
+
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return RateLimit{}, fmt.Errorf("некорректный период в лимите %q", spec)
+	}
+
+	return RateLimit{Burst: burst, Period: period}, nil
+}
+
+// RateLimitRule - лимиты одной мутации для пользователя и для адреса клиента
+type RateLimitRule struct {
+	User RateLimit
+	IP   RateLimit
+}
+
+// RateLimitRules - лимиты по умолчанию и переопределения для отдельных мутаций
+type RateLimitRules struct {
+	Default   RateLimitRule
+	Mutations map[string]RateLimitRule
+}
+
+// For возвращает лимиты мутации
+func (r RateLimitRules) For(mutation string) RateLimitRule {
+	if rule, ok := r.Mutations[mutation]; ok {
+		return rule
+	}
+	return r.Default
+}
+
+// ParseRateLimitRules разбирает переопределения вида "createComment:user=10/1m,login:ip=5/1m".
+// Не указанный для мутации вид лимита берется из defaults.
+func ParseRateLimitRules(spec string, defaults RateLimitRule) (RateLimitRules, error) {
+	rules := RateLimitRules{
+		Default:   defaults,
+		Mutations: make(map[string]RateLimitRule),
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		target, limitSpec, found := strings.Cut(item, "=")
+		mutation, scope, scoped := strings.Cut(target, ":")
+		if !found || !scoped || mutation == "" {
+			return RateLimitRules{}, fmt.Errorf("некорректное правило %q: ожидается формат мутация:user|ip=N/период", item)
+		}
+
+		limit, err := ParseRateLimit(limitSpec)
+		if err != nil {
+			return RateLimitRules{}, err
+		}
+
+		rule, ok := rules.Mutations[mutation]
+		if !ok {
+			rule = defaults
+		}
+
+		switch RateLimitScope(scope) {
+		case RateLimitByUser:
+			rule.User = limit
+		case RateLimitByIP:
+			rule.IP = limit
+		default:
+			return RateLimitRules{}, fmt.Errorf("неизвестный вид лимита %q в правиле %q", scope, item)
+		}
+
+		rules.Mutations[mutation] = rule
+	}
+
+	return rules, nil
+}
+
+// RateLimiter ограничивает частоту мутаций. Для каждой мутации ведутся отдельные корзины
+// на пользователя и на адрес клиента; запрос проходит, только если токен есть в обеих.
+// Токен пользователя списывается первым и не возвращается, если запрос отклонен по адресу.
+// Анонимные запросы ограничиваются только по адресу.
+type RateLimiter struct {
+	rules  RateLimitRules
+	store  RateLimitStore
+	logger *logrus.Logger
+}
+
+func NewRateLimiter(rules RateLimitRules, store RateLimitStore, logger *logrus.Logger) *RateLimiter {
+	return &RateLimiter{
+		rules:  rules,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Allow списывает токены мутации для пользователя из контекста и адреса клиента.
+// При исчерпании лимита возвращает RATE_LIMITED со временем до появления токена.
+// Сбой хранилища не блокирует запросы: ошибка логируется, и мутация выполняется.
+func (l *RateLimiter) Allow(ctx context.Context, mutation, clientIP string) error {
+	rule := l.rules.For(mutation)
+
+	if userID, ok := auth.UserIDFromContext(ctx); ok {
+		if err := l.take(ctx, mutation, RateLimitByUser, userID.String(), rule.User); err != nil {
+			return err
+		}
+	}
+
+	if clientIP != "" {
+		if err := l.take(ctx, mutation, RateLimitByIP, clientIP, rule.IP); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *RateLimiter) take(ctx context.Context, mutation string, scope RateLimitScope, subject string, limit RateLimit) error {
+	if !limit.Enabled() {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", mutation, scope, subject)
+
+	allowed, retryAfter, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		l.logger.WithError(err).WithField("key", key).Error("Ошибка хранилища лимитов, запрос пропущен без проверки")
+		return nil
+	}
+
+	if !allowed {
+		l.logger.WithFields(logrus.Fields{
+			"mutation":    mutation,
+			"scope":       scope,
+			"subject":     subject,
+			"retry_after": retryAfter.String(),
+		}).Warn("Превышен лимит запросов")
+		return errors.NewRateLimitedError(retryAfter)
+	}
+
+	return nil
+}
+
+// tokenBucket - состояние корзины: доступные токены на момент UpdatedAt
+type tokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// newTokenBucket возвращает полную корзину
+func newTokenBucket(limit RateLimit, now time.Time) tokenBucket {
+	return tokenBucket{Tokens: float64(limit.Burst), UpdatedAt: now}
+}
+
+// Take пополняет корзину за прошедшее время и списывает токен, если он есть.
+// Если токена нет, возвращает время, через которое он появится.
+func (b *tokenBucket) Take(limit RateLimit, now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens = math.Min(float64(limit.Burst), b.Tokens+elapsed.Seconds()*limit.Rate())
+		b.UpdatedAt = now
+	}
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return true, 0
+	}
+
+	return false, RetryAfter(b.Tokens, limit)
+}
+
+// RetryAfter - через сколько в корзине с tokens токенами появится целый токен
+func RetryAfter(tokens float64, limit RateLimit) time.Duration {
+	seconds := (1 - tokens) / limit.Rate()
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+const rateLimitSweepInterval = time.Minute
+
+type inProcessBucket struct {
+	tokenBucket
+	// full - момент, когда корзина полностью восстановится и ее можно забыть
+	full time.Time
+}
+
+// InProcessRateLimitStore хранит корзины в памяти процесса. Лимиты не общие для нескольких
+// экземпляров приложения: каждый считает только свои запросы.
+type InProcessRateLimitStore struct {
+	buckets   map[string]*inProcessBucket
+	lastSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+func NewInProcessRateLimitStore() *InProcessRateLimitStore {
+	return &InProcessRateLimitStore{
+		buckets: make(map[string]*inProcessBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *InProcessRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &inProcessBucket{tokenBucket: newTokenBucket(limit, now)}
+		s.buckets[key] = bucket
+	}
+
+	allowed, retryAfter := bucket.Take(limit, now)
+	bucket.full = now.Add(time.Duration((float64(limit.Burst) - bucket.Tokens) / limit.Rate() * float64(time.Second)))
+
+	return allowed, retryAfter, nil
+}
+
+// sweep удаляет полностью восстановившиеся корзины: новая корзина с тем же ключом будет такой же
+func (s *InProcessRateLimitStore) sweep(now time.Time) {
+	if s.lastSweep.IsZero() {
+		s.lastSweep = now
+	}
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
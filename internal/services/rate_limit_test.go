@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"ozon-posts/pkg/auth"
+	appErrors "ozon-posts/pkg/errors"
+	testutils2 "ozon-posts/pkg/testutils"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimit) (bool, time.Duration, error) {
+	return false, 0, fmt.Errorf("connection refused")
+}
+
+func assertRateLimited(t *testing.T, err error) {
+	t.Helper()
+	assertAppErrorCode(t, err, appErrors.ErrRateLimited)
+}
+
+func newTestRateLimitStore(now *time.Time) *InProcessRateLimitStore {
+	store := NewInProcessRateLimitStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := ParseRateLimit("10/1m")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimit{Burst: 10, Period: time.Minute}, limit)
+	assert.InDelta(t, 10.0/60, limit.Rate(), 1e-9)
+
+	for _, spec := range []string{"off", "0"} {
+		limit, err := ParseRateLimit(spec)
+		require.NoError(t, err)
+		assert.False(t, limit.Enabled())
+	}
+
+	for _, spec := range []string{"", "10", "-1/1m", "10/soon", "10/0s"} {
+		_, err := ParseRateLimit(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestParseRateLimitRules(t *testing.T) {
+	defaults := RateLimitRule{
+		User: RateLimit{Burst: 60, Period: time.Minute},
+		IP:   RateLimit{Burst: 120, Period: time.Minute},
+	}
+
+	rules, err := ParseRateLimitRules("createComment:user=10/1m, createComment:ip=off,login:ip=5/1m", defaults)
+	require.NoError(t, err)
+
+	assert.Equal(t, RateLimitRule{User: RateLimit{Burst: 10, Period: time.Minute}}, rules.For("createComment"))
+	assert.Equal(t, RateLimitRule{User: defaults.User, IP: RateLimit{Burst: 5, Period: time.Minute}}, rules.For("login"))
+	assert.Equal(t, defaults, rules.For("updatePost"))
+
+	for _, spec := range []string{"createComment=10/1m", "createComment:host=10/1m", ":user=10/1m", "login:ip=many"} {
+		_, err := ParseRateLimitRules(spec, defaults)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestTokenBucket_Refill(t *testing.T) {
+	limit := RateLimit{Burst: 2, Period: 2 * time.Second}
+	start := time.Now()
+	bucket := newTokenBucket(limit, start)
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := bucket.Take(limit, start)
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter := bucket.Take(limit, start)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, retryAfter)
+
+	allowed, retryAfter = bucket.Take(limit, start.Add(500*time.Millisecond))
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _ = bucket.Take(limit, start.Add(time.Second))
+	assert.True(t, allowed)
+
+	// Корзина не наполняется сверх Burst, сколько бы времени ни прошло
+	later := start.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		allowed, _ := bucket.Take(limit, later)
+		assert.True(t, allowed)
+	}
+	allowed, _ = bucket.Take(limit, later)
+	assert.False(t, allowed)
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Now()
+	rules, err := ParseRateLimitRules("createComment:user=2/1m,createComment:ip=3/1m", RateLimitRule{})
+	require.NoError(t, err)
+
+	limiter := NewRateLimiter(rules, newTestRateLimitStore(&now), testutils2.CreateTestLogger())
+
+	userA := auth.WithUserID(context.Background(), uuid.New())
+	userB := auth.WithUserID(context.Background(), uuid.New())
+
+	t.Run("per_user", func(t *testing.T) {
+		require.NoError(t, limiter.Allow(userA, "createComment", "10.0.0.1"))
+		require.NoError(t, limiter.Allow(userA, "createComment", "10.0.0.1"))
+
+		err := limiter.Allow(userA, "createComment", "10.0.0.1")
+		assertRateLimited(t, err)
+		appErr := err.(*appErrors.AppError)
+		assert.Equal(t, 30*time.Second, appErr.RetryAfter)
+	})
+
+	t.Run("per_ip", func(t *testing.T) {
+		// Третий токен адреса 10.0.0.1 остался после запросов пользователя A
+		require.NoError(t, limiter.Allow(userB, "createComment", "10.0.0.1"))
+
+		assertRateLimited(t, limiter.Allow(userB, "createComment", "10.0.0.1"))
+	})
+
+	t.Run("anonymous_limited_by_ip", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.NoError(t, limiter.Allow(context.Background(), "createComment", "10.0.0.3"))
+		}
+		assertRateLimited(t, limiter.Allow(context.Background(), "createComment", "10.0.0.3"))
+	})
+
+	t.Run("unlimited_mutation", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			require.NoError(t, limiter.Allow(userA, "updatePost", "10.0.0.1"))
+		}
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+		require.NoError(t, limiter.Allow(userA, "createComment", "10.0.0.4"))
+	})
+}
+
+func TestRateLimiter_StoreFailureAllowsRequest(t *testing.T) {
+	rules := RateLimitRules{Default: RateLimitRule{IP: RateLimit{Burst: 1, Period: time.Minute}}}
+	limiter := NewRateLimiter(rules, failingRateLimitStore{}, testutils2.CreateTestLogger())
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, limiter.Allow(context.Background(), "createPost", "10.0.0.1"))
+	}
+}
+
+func TestInProcessRateLimitStore_SweepsFullBuckets(t *testing.T) {
+	now := time.Now()
+	store := newTestRateLimitStore(&now)
+	limit := RateLimit{Burst: 1, Period: time.Second}
+
+	_, _, err := store.Take(context.Background(), "createPost:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+
+	now = now.Add(rateLimitSweepInterval)
+	_, _, err = store.Take(context.Background(), "createPost:ip:10.0.0.2", limit)
+	require.NoError(t, err)
+
+	assert.Len(t, store.buckets, 1, "восстановившаяся корзина удаляется")
+	assert.Contains(t, store.buckets, "createPost:ip:10.0.0.2")
+}
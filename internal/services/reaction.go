@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/auth"
+	"ozon-posts/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ReactionEvent - изменение реакций на пост или комментарий, рассылается подписчикам поста.
+// Count - число реакций вида Kind на объект сразу после изменения.
+type ReactionEvent struct {
+	TargetType entities.ReactionTargetType `json:"target_type"`
+	TargetID   uuid.UUID                   `json:"target_id"`
+	UserID     uuid.UUID                   `json:"user_id"`
+	Kind       entities.ReactionKind       `json:"kind"`
+	Count      int64                       `json:"count"`
+}
+
+type ReactionService struct {
+	reactionRepo ReactionRepository
+	uow          UnitOfWork
+	events       CommentEventPublisher
+	logger       *logrus.Logger
+}
+
+func NewReactionService(reactionRepo ReactionRepository, uow UnitOfWork, events CommentEventPublisher, logger *logrus.Logger) *ReactionService {
+	return &ReactionService{
+		reactionRepo: reactionRepo,
+		uow:          uow,
+		events:       events,
+		logger:       logger,
+	}
+}
+
+// React ставит реакцию от имени пользователя из контекста и возвращает обновленную сводку по объекту.
+// Повторная реакция того же вида ничего не меняет.
+func (s *ReactionService) React(ctx context.Context, targetType entities.ReactionTargetType, targetID uuid.UUID, kind entities.ReactionKind) ([]*entities.ReactionSummary, error) {
+	return s.changeReaction(ctx, targetType, targetID, kind, true)
+}
+
+// Unreact снимает реакцию пользователя из контекста; снятие отсутствующей реакции не ошибка
+func (s *ReactionService) Unreact(ctx context.Context, targetType entities.ReactionTargetType, targetID uuid.UUID, kind entities.ReactionKind) ([]*entities.ReactionSummary, error) {
+	return s.changeReaction(ctx, targetType, targetID, kind, false)
+}
+
+// GetReactionsByTargets возвращает сводки реакций для списка объектов одного типа. ViewerReacted
+// заполняется для аутентифицированного пользователя; у объектов без реакций сводка пустая.
+func (s *ReactionService) GetReactionsByTargets(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID) (map[uuid.UUID][]*entities.ReactionSummary, error) {
+	s.logger.WithFields(logrus.Fields{
+		"target_type":   targetType,
+		"targets_count": len(targetIDs),
+	}).Debug("Получение реакций для списка объектов")
+
+	counts, err := s.reactionRepo.CountByTargets(ctx, targetType, targetIDs)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка подсчета реакций")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	viewerKinds := map[uuid.UUID][]entities.ReactionKind{}
+	if viewerID, ok := auth.UserIDFromContext(ctx); ok && len(counts) > 0 {
+		viewerKinds, err = s.reactionRepo.GetUserKinds(ctx, targetType, targetIDs, viewerID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения реакций пользователя")
+			return nil, errors.NewDatabaseError(err)
+		}
+	}
+
+	summaries := make(map[uuid.UUID][]*entities.ReactionSummary, len(targetIDs))
+	for _, targetID := range targetIDs {
+		summaries[targetID] = entities.SummarizeReactions(counts[targetID], viewerKinds[targetID])
+	}
+
+	return summaries, nil
+}
+
+func (s *ReactionService) changeReaction(ctx context.Context, targetType entities.ReactionTargetType, targetID uuid.UUID, kind entities.ReactionKind, add bool) ([]*entities.ReactionSummary, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !targetType.IsValid() {
+		return nil, errors.NewValidationError("Неизвестный тип объекта реакции")
+	}
+
+	if !kind.IsValid() {
+		return nil, errors.NewValidationError("Неизвестный вид реакции")
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"target_type": targetType,
+		"target_id":   targetID,
+		"user_id":     userID,
+		"kind":        kind,
+	})
+	if add {
+		logger.Info("Добавление реакции")
+	} else {
+		logger.Info("Удаление реакции")
+	}
+
+	// Объект блокируется, чтобы реакция не сохранилась после его удаления
+	var (
+		postID  uuid.UUID
+		changed bool
+	)
+	err = inTransaction(ctx, s.uow, s.logger, func(ctx context.Context, repos Repositories) error {
+		var err error
+		postID, err = s.lockTarget(ctx, repos, targetType, targetID, add)
+		if err != nil {
+			return err
+		}
+
+		if add {
+			changed, err = repos.Reactions.Add(ctx, entities.NewReaction(targetType, targetID, userID, kind))
+		} else {
+			changed, err = repos.Reactions.Remove(ctx, targetType, targetID, userID, kind)
+		}
+		if err != nil {
+			logger.WithError(err).Error("Ошибка сохранения реакции")
+			return errors.NewDatabaseError(err)
+		}
+
+		// Число реакций на комментарий хранится в самом комментарии для сортировки TOP
+		if changed && targetType == entities.ReactionTargetComment {
+			delta := int64(1)
+			if !add {
+				delta = -1
+			}
+			if err := repos.Comments.AddReactionCount(ctx, targetID, delta); err != nil {
+				logger.WithError(err).Error("Ошибка обновления счетчика реакций")
+				return errors.NewDatabaseError(err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.GetReactionsByTargets(ctx, targetType, []uuid.UUID{targetID})
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		eventType := CommentEventReactionAdded
+		if !add {
+			eventType = CommentEventReactionRemoved
+		}
+
+		reaction := &ReactionEvent{
+			TargetType: targetType,
+			TargetID:   targetID,
+			UserID:     userID,
+			Kind:       kind,
+		}
+		for _, summary := range summaries[targetID] {
+			if summary.Kind == kind {
+				reaction.Count = summary.Count
+			}
+		}
+
+		s.events.Publish(postID, &CommentEvent{
+			Type:     eventType,
+			PostID:   postID,
+			Reaction: reaction,
+		})
+	}
+
+	return summaries[targetID], nil
+}
+
+// lockTarget проверяет, что объект реакции существует, и возвращает пост, подписчикам которого
+// уходит событие. На удаленный комментарий нельзя поставить реакцию, но можно снять прежнюю.
+// Комментарий блокируется на запись: его счетчик реакций меняется в той же транзакции, и
+// повышение разделяемой блокировки взаимоблокировало бы параллельные реакции. Строка поста
+// не меняется, поэтому ему достаточно разделяемой блокировки.
+func (s *ReactionService) lockTarget(ctx context.Context, repos Repositories, targetType entities.ReactionTargetType, targetID uuid.UUID, add bool) (uuid.UUID, error) {
+	if targetType == entities.ReactionTargetPost {
+		post, err := repos.Posts.GetByIDForShare(ctx, targetID)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения поста")
+			return uuid.Nil, errors.NewDatabaseError(err)
+		}
+
+		if post == nil {
+			return uuid.Nil, errors.NewPostNotFoundError(targetID.String())
+		}
+
+		return post.ID, nil
+	}
+
+	comment, err := repos.Comments.GetByIDForUpdate(ctx, targetID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения комментария")
+		return uuid.Nil, errors.NewDatabaseError(err)
+	}
+
+	if comment == nil {
+		return uuid.Nil, errors.NewCommentNotFoundError(targetID.String())
+	}
+
+	if add && comment.IsDeleted() {
+		return uuid.Nil, errors.NewCommentDeletedError(targetID.String())
+	}
+
+	return comment.PostID, nil
+}
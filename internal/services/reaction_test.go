@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"ozon-posts/internal/entities"
+	appErrors "ozon-posts/pkg/errors"
+	testutils2 "ozon-posts/pkg/testutils"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func assertAppErrorCode(t *testing.T, err error, code appErrors.ErrorCode) {
+	t.Helper()
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "ожидалась AppError, получено %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestReactionService_React_Comment(t *testing.T) {
+	mockReactionRepo := &testutils2.MockReactionRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	publisher := &recordingPublisher{}
+	logger := testutils2.CreateTestLogger()
+	uow := &mockUnitOfWork{repos: Repositories{Posts: mockPostRepo, Comments: mockCommentRepo, Reactions: mockReactionRepo}}
+	service := NewReactionService(mockReactionRepo, uow, publisher, logger)
+
+	userID := uuid.New()
+	comment := testutils2.CreateTestComment(uuid.New(), uuid.New(), "Комментарий", nil)
+	targets := []uuid.UUID{comment.ID}
+
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, comment.ID).Return(comment, nil)
+	mockReactionRepo.On("Add", mock.Anything, mock.MatchedBy(func(reaction *entities.Reaction) bool {
+		return reaction.TargetType == entities.ReactionTargetComment && reaction.TargetID == comment.ID &&
+			reaction.UserID == userID && reaction.Kind == entities.ReactionLove
+	})).Return(true, nil)
+	mockCommentRepo.On("AddReactionCount", mock.Anything, comment.ID, int64(1)).Return(nil)
+	mockReactionRepo.On("CountByTargets", mock.Anything, entities.ReactionTargetComment, targets).
+		Return(map[uuid.UUID]map[entities.ReactionKind]int64{comment.ID: {entities.ReactionLove: 3, entities.ReactionLike: 1}}, nil)
+	mockReactionRepo.On("GetUserKinds", mock.Anything, entities.ReactionTargetComment, targets, userID).
+		Return(map[uuid.UUID][]entities.ReactionKind{comment.ID: {entities.ReactionLove}}, nil)
+
+	summaries, err := service.React(testutils2.CreateAuthContext(userID), entities.ReactionTargetComment, comment.ID, entities.ReactionLove)
+
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, entities.ReactionSummary{Kind: entities.ReactionLike, Count: 1}, *summaries[0])
+	assert.Equal(t, entities.ReactionSummary{Kind: entities.ReactionLove, Count: 3, ViewerReacted: true}, *summaries[1])
+
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, CommentEventReactionAdded, event.Type)
+	assert.Equal(t, comment.PostID, event.PostID, "событие уходит подписчикам поста комментария")
+	assert.Equal(t, &ReactionEvent{
+		TargetType: entities.ReactionTargetComment,
+		TargetID:   comment.ID,
+		UserID:     userID,
+		Kind:       entities.ReactionLove,
+		Count:      3,
+	}, event.Reaction)
+}
+
+func TestReactionService_React_AlreadyReacted(t *testing.T) {
+	mockReactionRepo := &testutils2.MockReactionRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	publisher := &recordingPublisher{}
+	logger := testutils2.CreateTestLogger()
+	uow := &mockUnitOfWork{repos: Repositories{Posts: mockPostRepo, Comments: mockCommentRepo, Reactions: mockReactionRepo}}
+	service := NewReactionService(mockReactionRepo, uow, publisher, logger)
+
+	userID := uuid.New()
+	post := testutils2.CreateTestPost(uuid.New(), "Пост", "Текст")
+
+	mockPostRepo.On("GetByIDForShare", mock.Anything, post.ID).Return(post, nil)
+	mockReactionRepo.On("Add", mock.Anything, mock.Anything).Return(false, nil)
+	mockReactionRepo.On("CountByTargets", mock.Anything, entities.ReactionTargetPost, mock.Anything).
+		Return(map[uuid.UUID]map[entities.ReactionKind]int64{post.ID: {entities.ReactionLike: 1}}, nil)
+	mockReactionRepo.On("GetUserKinds", mock.Anything, entities.ReactionTargetPost, mock.Anything, userID).
+		Return(map[uuid.UUID][]entities.ReactionKind{post.ID: {entities.ReactionLike}}, nil)
+
+	summaries, err := service.React(testutils2.CreateAuthContext(userID), entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Empty(t, publisher.events, "повторная реакция не порождает событие")
+}
+
+func TestReactionService_Unreact(t *testing.T) {
+	mockReactionRepo := &testutils2.MockReactionRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	publisher := &recordingPublisher{}
+	logger := testutils2.CreateTestLogger()
+	uow := &mockUnitOfWork{repos: Repositories{Posts: mockPostRepo, Comments: mockCommentRepo, Reactions: mockReactionRepo}}
+	service := NewReactionService(mockReactionRepo, uow, publisher, logger)
+
+	userID := uuid.New()
+	post := testutils2.CreateTestPost(uuid.New(), "Пост", "Текст")
+
+	mockPostRepo.On("GetByIDForShare", mock.Anything, post.ID).Return(post, nil)
+	mockReactionRepo.On("Remove", mock.Anything, entities.ReactionTargetPost, post.ID, userID, entities.ReactionSad).Return(true, nil)
+	mockReactionRepo.On("CountByTargets", mock.Anything, entities.ReactionTargetPost, mock.Anything).
+		Return(map[uuid.UUID]map[entities.ReactionKind]int64{}, nil)
+
+	summaries, err := service.Unreact(testutils2.CreateAuthContext(userID), entities.ReactionTargetPost, post.ID, entities.ReactionSad)
+
+	require.NoError(t, err)
+	assert.Empty(t, summaries)
+	mockReactionRepo.AssertNotCalled(t, "GetUserKinds", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, CommentEventReactionRemoved, publisher.events[0].Type)
+	assert.Equal(t, int64(0), publisher.events[0].Reaction.Count)
+}
+
+func TestReactionService_React_InvalidInput(t *testing.T) {
+	mockReactionRepo := &testutils2.MockReactionRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	publisher := &recordingPublisher{}
+	logger := testutils2.CreateTestLogger()
+	uow := &mockUnitOfWork{repos: Repositories{Posts: mockPostRepo, Comments: mockCommentRepo, Reactions: mockReactionRepo}}
+	service := NewReactionService(mockReactionRepo, uow, publisher, logger)
+	ctx := testutils2.CreateAuthContext(uuid.New())
+
+	_, err := service.React(ctx, entities.ReactionTargetPost, uuid.New(), entities.ReactionKind("dislike"))
+	assertAppErrorCode(t, err, appErrors.ErrValidation)
+
+	_, err = service.React(ctx, entities.ReactionTargetType("user"), uuid.New(), entities.ReactionLike)
+	assertAppErrorCode(t, err, appErrors.ErrValidation)
+
+	_, err = service.React(context.Background(), entities.ReactionTargetPost, uuid.New(), entities.ReactionLike)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	mockReactionRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestReactionService_React_MissingTarget(t *testing.T) {
+	mockReactionRepo := &testutils2.MockReactionRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	publisher := &recordingPublisher{}
+	logger := testutils2.CreateTestLogger()
+	uow := &mockUnitOfWork{repos: Repositories{Posts: mockPostRepo, Comments: mockCommentRepo, Reactions: mockReactionRepo}}
+	service := NewReactionService(mockReactionRepo, uow, publisher, logger)
+	ctx := testutils2.CreateAuthContext(uuid.New())
+
+	postID := uuid.New()
+	mockPostRepo.On("GetByIDForShare", mock.Anything, postID).Return(nil, nil)
+
+	_, err := service.React(ctx, entities.ReactionTargetPost, postID, entities.ReactionLike)
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+
+	deleted := testutils2.CreateTestComment(uuid.New(), uuid.New(), "Комментарий", nil)
+	deleted.MarkDeleted(deleted.AuthorID)
+	mockCommentRepo.On("GetByIDForUpdate", mock.Anything, deleted.ID).Return(deleted, nil)
+
+	_, err = service.React(ctx, entities.ReactionTargetComment, deleted.ID, entities.ReactionLike)
+	assertAppErrorCode(t, err, appErrors.ErrCommentDeleted)
+
+	mockReactionRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	assert.Empty(t, publisher.events)
+}
+
+func TestReactionService_React_RepositoryError(t *testing.T) {
+	mockReactionRepo := &testutils2.MockReactionRepository{}
+	mockPostRepo := &testutils2.MockPostRepository{}
+	mockCommentRepo := &testutils2.MockCommentRepository{}
+	publisher := &recordingPublisher{}
+	logger := testutils2.CreateTestLogger()
+	uow := &mockUnitOfWork{repos: Repositories{Posts: mockPostRepo, Comments: mockCommentRepo, Reactions: mockReactionRepo}}
+	service := NewReactionService(mockReactionRepo, uow, publisher, logger)
+
+	post := testutils2.CreateTestPost(uuid.New(), "Пост", "Текст")
+	mockPostRepo.On("GetByIDForShare", mock.Anything, post.ID).Return(post, nil)
+	mockReactionRepo.On("Add", mock.Anything, mock.Anything).Return(false, errors.New("connection refused"))
+
+	_, err := service.React(testutils2.CreateAuthContext(uuid.New()), entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+
+	assertAppErrorCode(t, err, appErrors.ErrDatabase)
+	assert.Empty(t, publisher.events)
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"ozon-posts/pkg/errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// inTransaction выполняет fn через UnitOfWork. Ошибки fn возвращаются как есть,
+// а сбои начала или фиксации транзакции превращаются в DATABASE_ERROR.
+func inTransaction(ctx context.Context, uow UnitOfWork, logger *logrus.Logger, fn func(ctx context.Context, repos Repositories) error) error {
+	err := uow.Do(ctx, fn)
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := errors.AsAppError(err); ok {
+		return err
+	}
+
+	logger.WithError(err).Error("Ошибка выполнения транзакции")
+	return errors.NewDatabaseError(err)
+}
@@ -3,20 +3,32 @@ package services
 import (
 	"context"
 	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/auth"
 	"ozon-posts/pkg/errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+type AuthResult struct {
+	Token     string
+	ExpiresAt time.Time
+	User      *entities.User
+}
+
 type UserService struct {
 	userRepo UserRepository
+	tokens   *auth.TokenManager
+	policy   *Policy
 	logger   *logrus.Logger
 }
 
-func NewUserService(userRepo UserRepository, logger *logrus.Logger) *UserService {
+func NewUserService(userRepo UserRepository, tokens *auth.TokenManager, logger *logrus.Logger) *UserService {
 	return &UserService{
 		userRepo: userRepo,
+		tokens:   tokens,
+		policy:   NewPolicy(userRepo),
 		logger:   logger,
 	}
 }
@@ -34,29 +46,107 @@ func (s *UserService) CreateUser(ctx context.Context, username, email string) (*
 		return nil, err
 	}
 
-	existingUser, err := s.userRepo.GetByUsername(ctx, username)
+	if err := s.createUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) Register(ctx context.Context, username, email, password string) (*AuthResult, error) {
+	s.logger.WithFields(logrus.Fields{
+		"username": username,
+		"email":    email,
+	}).Info("Регистрация нового пользователя")
+
+	user, err := entities.NewUser(username, email)
 	if err != nil {
-		return nil, errors.NewDatabaseError(err)
+		s.logger.WithError(err).Error("Ошибка валидации данных пользователя")
+		return nil, err
 	}
-	if existingUser != nil {
-		return nil, errors.NewUserExistsError("пользователь с таким именем уже существует")
+
+	if err := entities.ValidatePassword(password); err != nil {
+		return nil, err
 	}
 
-	existingUser, err = s.userRepo.GetByEmail(ctx, email)
+	user.PasswordHash, err = auth.HashPassword(password)
 	if err != nil {
-		return nil, errors.NewDatabaseError(err)
+		s.logger.WithError(err).Error("Ошибка хеширования пароля")
+		return nil, errors.NewInternalError(err)
 	}
-	if existingUser != nil {
-		return nil, errors.NewUserExistsError("пользователь с таким email уже существует")
+
+	if err := s.createUser(ctx, user); err != nil {
+		return nil, err
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		s.logger.WithError(err).Error("Ошибка создания пользователя")
+	return s.issueToken(user)
+}
+
+func (s *UserService) Login(ctx context.Context, login, password string) (*AuthResult, error) {
+	s.logger.WithField("login", login).Info("Вход пользователя")
+
+	user, err := s.userRepo.GetByUsername(ctx, login)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения пользователя по имени")
 		return nil, errors.NewDatabaseError(err)
 	}
 
-	s.logger.WithField("user_id", user.ID).Info("Пользователь успешно создан")
-	return user, nil
+	if user == nil {
+		user, err = s.userRepo.GetByEmail(ctx, login)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка получения пользователя по email")
+			return nil, errors.NewDatabaseError(err)
+		}
+	}
+
+	if user == nil || !auth.CheckPassword(user.PasswordHash, password) {
+		s.logger.WithField("login", login).Warn("Неудачная попытка входа")
+		return nil, errors.NewInvalidCredentialsError()
+	}
+
+	if user.IsBanned() {
+		s.logger.WithField("user_id", user.ID).Warn("Попытка входа заблокированного пользователя")
+		return nil, errors.NewUserBannedError()
+	}
+
+	return s.issueToken(user)
+}
+
+// Authenticate проверяет bearer-токен и возвращает ID его владельца. Токены заблокированного
+// пользователя отклоняются сразу, не дожидаясь истечения срока действия.
+func (s *UserService) Authenticate(ctx context.Context, token string) (uuid.UUID, error) {
+	claims, err := s.tokens.Parse(token)
+	if err != nil {
+		s.logger.WithError(err).Debug("Отклонен некорректный токен")
+		return uuid.Nil, errors.NewUnauthorizedError()
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения владельца токена")
+		return uuid.Nil, errors.NewDatabaseError(err)
+	}
+
+	if user == nil {
+		s.logger.WithField("user_id", claims.UserID).Warn("Токен принадлежит несуществующему пользователю")
+		return uuid.Nil, errors.NewUnauthorizedError()
+	}
+
+	if user.IsBanned() {
+		s.logger.WithField("user_id", claims.UserID).Warn("Токен принадлежит заблокированному пользователю")
+		return uuid.Nil, errors.NewUserBannedError()
+	}
+
+	return claims.UserID, nil
+}
+
+func (s *UserService) GetCurrentUser(ctx context.Context) (*entities.User, error) {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUserByID(ctx, actorID)
 }
 
 func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
@@ -125,6 +215,25 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*entiti
 func (s *UserService) UpdateUser(ctx context.Context, userID uuid.UUID, username, email string) error {
 	s.logger.WithField("user_id", userID).Info("Обновление пользователя")
 
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := s.policy.Allowed(ctx, actorID, ActionUpdateUser, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки прав доступа к пользователю")
+		return err
+	}
+
+	if !allowed {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":      userID,
+			"requester_id": actorID,
+		}).Warn("Попытка редактирования чужого профиля")
+		return errors.NewForbiddenError("Недостаточно прав для изменения пользователя")
+	}
+
 	// Валидируем данные через entities
 	if _, err := entities.NewUser(username, email); err != nil {
 		s.logger.WithError(err).Error("Ошибка валидации данных пользователя")
@@ -163,6 +272,7 @@ func (s *UserService) UpdateUser(ctx context.Context, userID uuid.UUID, username
 
 	user.Username = username
 	user.Email = email
+	user.UpdatedAt = time.Now()
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		s.logger.WithError(err).Error("Ошибка обновления пользователя")
@@ -175,6 +285,25 @@ func (s *UserService) UpdateUser(ctx context.Context, userID uuid.UUID, username
 func (s *UserService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 	s.logger.WithField("user_id", userID).Info("Удаление пользователя")
 
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := s.policy.Allowed(ctx, actorID, ActionDeleteUser, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки прав доступа к пользователю")
+		return err
+	}
+
+	if !allowed {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":      userID,
+			"requester_id": actorID,
+		}).Warn("Попытка удаления чужого профиля")
+		return errors.NewForbiddenError("Недостаточно прав для удаления пользователя")
+	}
+
 	exists, err := s.userRepo.Exists(ctx, userID)
 	if err != nil {
 		s.logger.WithError(err).Error("Ошибка проверки существования пользователя")
@@ -193,3 +322,153 @@ func (s *UserService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 	s.logger.WithField("user_id", userID).Info("Пользователь успешно удален")
 	return nil
 }
+
+func (s *UserService) ChangeUserRole(ctx context.Context, userID uuid.UUID, role entities.Role) (*entities.User, error) {
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"role":    role,
+	}).Info("Изменение роли пользователя")
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !role.IsValid() {
+		return nil, errors.NewInvalidUserDataError("неизвестная роль пользователя")
+	}
+
+	allowed, err := s.policy.Allowed(ctx, actorID, ActionChangeUserRole, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка проверки прав доступа к пользователю")
+		return nil, err
+	}
+
+	if !allowed {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":      userID,
+			"requester_id": actorID,
+		}).Warn("Попытка изменения роли без прав администратора")
+		return nil, errors.NewForbiddenError("Недостаточно прав для изменения роли пользователя")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения пользователя для изменения роли")
+		return nil, errors.NewDatabaseError(err)
+	}
+	if user == nil {
+		s.logger.WithField("user_id", userID).Warn("Пользователь для изменения роли не найден")
+		return nil, errors.NewUserNotFoundError(userID.String())
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.SetRole(ctx, user); err != nil {
+		s.logger.WithError(err).Error("Ошибка изменения роли пользователя")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"role":    role,
+	}).Info("Роль пользователя успешно изменена")
+	return user, nil
+}
+
+// EnsureAdmin назначает пользователя username администратором при запуске приложения, чтобы
+// первого администратора не приходилось назначать в обход API. Отсутствующий пользователь
+// создается с email и password, если пароль задан. Права вызывающего не проверяются.
+func (s *UserService) EnsureAdmin(ctx context.Context, username, email, password string) (*entities.User, error) {
+	s.logger.WithField("username", username).Info("Назначение администратора при запуске")
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка получения пользователя по имени")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	if user == nil {
+		if password == "" {
+			return nil, errors.NewInvalidUserDataError("пользователь не найден, для его создания нужен пароль")
+		}
+
+		user, err = entities.NewUser(username, email)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка валидации данных администратора")
+			return nil, err
+		}
+
+		if err := entities.ValidatePassword(password); err != nil {
+			return nil, err
+		}
+
+		user.PasswordHash, err = auth.HashPassword(password)
+		if err != nil {
+			s.logger.WithError(err).Error("Ошибка хеширования пароля")
+			return nil, errors.NewInternalError(err)
+		}
+
+		user.Role = entities.RoleAdmin
+		if err := s.createUser(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if user.Role == entities.RoleAdmin {
+		return user, nil
+	}
+
+	user.Role = entities.RoleAdmin
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.SetRole(ctx, user); err != nil {
+		s.logger.WithError(err).Error("Ошибка назначения администратора")
+		return nil, errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithField("user_id", user.ID).Info("Пользователь назначен администратором")
+	return user, nil
+}
+
+func (s *UserService) createUser(ctx context.Context, user *entities.User) error {
+	existingUser, err := s.userRepo.GetByUsername(ctx, user.Username)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if existingUser != nil {
+		return errors.NewUserExistsError("пользователь с таким именем уже существует")
+	}
+
+	existingUser, err = s.userRepo.GetByEmail(ctx, user.Email)
+	if err != nil {
+		return errors.NewDatabaseError(err)
+	}
+	if existingUser != nil {
+		return errors.NewUserExistsError("пользователь с таким email уже существует")
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.logger.WithError(err).Error("Ошибка создания пользователя")
+		return errors.NewDatabaseError(err)
+	}
+
+	s.logger.WithField("user_id", user.ID).Info("Пользователь успешно создан")
+	return nil
+}
+
+func (s *UserService) issueToken(user *entities.User) (*AuthResult, error) {
+	token, expiresAt, err := s.tokens.Issue(user.ID)
+	if err != nil {
+		s.logger.WithError(err).Error("Ошибка выпуска токена")
+		return nil, errors.NewInternalError(err)
+	}
+
+	return &AuthResult{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	}, nil
+}
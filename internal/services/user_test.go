@@ -7,6 +7,7 @@ import (
 	appErrors "ozon-posts/pkg/errors"
 	testutils2 "ozon-posts/pkg/testutils"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -16,7 +17,7 @@ import (
 func TestUserService_CreateUser_Success(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	username, email := testutils2.CreateValidUserData()
 
@@ -39,7 +40,7 @@ func TestUserService_CreateUser_Success(t *testing.T) {
 func TestUserService_CreateUser_UsernameExists(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	username, email := testutils2.CreateValidUserData()
 	existingUser := testutils2.CreateTestUser(username, "other@example.com")
@@ -60,7 +61,7 @@ func TestUserService_CreateUser_UsernameExists(t *testing.T) {
 func TestUserService_CreateUser_EmailExists(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	username, email := testutils2.CreateValidUserData()
 	existingUser := testutils2.CreateTestUser("otheruser", email)
@@ -98,7 +99,7 @@ func TestUserService_CreateUser_InvalidData(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRepo := &testutils2.MockUserRepository{}
 			logger := testutils2.CreateTestLogger()
-			service := NewUserService(mockRepo, logger)
+			service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 			user, err := service.CreateUser(context.Background(), tc.username, tc.email)
 
@@ -115,7 +116,7 @@ func TestUserService_CreateUser_InvalidData(t *testing.T) {
 func TestUserService_CreateUser_DatabaseError(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	username, email := testutils2.CreateValidUserData()
 	dbError := errors.New("repositories connection failed")
@@ -136,7 +137,7 @@ func TestUserService_CreateUser_DatabaseError(t *testing.T) {
 func TestUserService_GetUserByID_Success(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	userID := uuid.New()
 	expectedUser := testutils2.CreateTestUser("testuser", "test@example.com")
@@ -157,7 +158,7 @@ func TestUserService_GetUserByID_Success(t *testing.T) {
 func TestUserService_GetUserByID_NotFound(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	userID := uuid.New()
 
@@ -177,7 +178,7 @@ func TestUserService_GetUserByID_NotFound(t *testing.T) {
 func TestUserService_GetUserByUsername_Success(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	username := "testuser"
 	expectedUser := testutils2.CreateTestUser(username, "test@example.com")
@@ -195,7 +196,7 @@ func TestUserService_GetUserByUsername_Success(t *testing.T) {
 func TestUserService_GetUserByEmail_Success(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	email := "test@example.com"
 	expectedUser := testutils2.CreateTestUser("testuser", email)
@@ -213,7 +214,7 @@ func TestUserService_GetUserByEmail_Success(t *testing.T) {
 func TestUserService_UpdateUser_Success(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	userID := uuid.New()
 	existingUser := testutils2.CreateTestUser("olduser", "old@example.com")
@@ -229,7 +230,7 @@ func TestUserService_UpdateUser_Success(t *testing.T) {
 		return user.ID == userID && user.Username == newUsername && user.Email == newEmail
 	})).Return(nil)
 
-	err := service.UpdateUser(context.Background(), userID, newUsername, newEmail)
+	err := service.UpdateUser(testutils2.CreateAuthContext(userID), userID, newUsername, newEmail)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -238,13 +239,13 @@ func TestUserService_UpdateUser_Success(t *testing.T) {
 func TestUserService_UpdateUser_UserNotFound(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	userID := uuid.New()
 
 	mockRepo.On("GetByID", mock.Anything, userID).Return(nil, nil)
 
-	err := service.UpdateUser(context.Background(), userID, "newuser", "new@example.com")
+	err := service.UpdateUser(testutils2.CreateAuthContext(userID), userID, "newuser", "new@example.com")
 
 	assert.Error(t, err)
 
@@ -257,14 +258,14 @@ func TestUserService_UpdateUser_UserNotFound(t *testing.T) {
 func TestUserService_DeleteUser_Success(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	userID := uuid.New()
 
 	mockRepo.On("Exists", mock.Anything, userID).Return(true, nil)
 	mockRepo.On("Delete", mock.Anything, userID).Return(nil)
 
-	err := service.DeleteUser(context.Background(), userID)
+	err := service.DeleteUser(testutils2.CreateAuthContext(userID), userID)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -273,13 +274,13 @@ func TestUserService_DeleteUser_Success(t *testing.T) {
 func TestUserService_DeleteUser_NotFound(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	userID := uuid.New()
 
 	mockRepo.On("Exists", mock.Anything, userID).Return(false, nil)
 
-	err := service.DeleteUser(context.Background(), userID)
+	err := service.DeleteUser(testutils2.CreateAuthContext(userID), userID)
 
 	assert.Error(t, err)
 
@@ -292,7 +293,7 @@ func TestUserService_DeleteUser_NotFound(t *testing.T) {
 func TestUserService_GetUsersByIDs_Success(t *testing.T) {
 	mockRepo := &testutils2.MockUserRepository{}
 	logger := testutils2.CreateTestLogger()
-	service := NewUserService(mockRepo, logger)
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
 
 	userIDs := []uuid.UUID{uuid.New(), uuid.New()}
 	expectedUsers := []*entities.User{
@@ -311,3 +312,301 @@ func TestUserService_GetUsersByIDs_Success(t *testing.T) {
 	assert.Len(t, users, 2)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestUserService_Register_Success(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	tokens := testutils2.CreateTestTokenManager()
+	service := NewUserService(mockRepo, tokens, logger)
+
+	username, email := testutils2.CreateValidUserData()
+	password := "secret-password"
+
+	mockRepo.On("GetByUsername", mock.Anything, username).Return(nil, nil)
+	mockRepo.On("GetByEmail", mock.Anything, email).Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(user *entities.User) bool {
+		return user.Username == username && user.PasswordHash != "" && user.PasswordHash != password
+	})).Return(nil)
+
+	result, err := service.Register(context.Background(), username, email, password)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.NotEmpty(t, result.Token)
+	assert.Equal(t, username, result.User.Username)
+
+	claims, err := tokens.Parse(result.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, result.User.ID, claims.UserID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Register_WeakPassword(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	username, email := testutils2.CreateValidUserData()
+
+	result, err := service.Register(context.Background(), username, email, "short")
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrInvalidUserData, appErr.Code)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Login_Success(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	user := testutils2.CreateTestUser("testuser", "test@example.com")
+	user.PasswordHash = testutils2.CreateTestPasswordHash("secret-password")
+
+	mockRepo.On("GetByUsername", mock.Anything, "test@example.com").Return(nil, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+
+	result, err := service.Login(context.Background(), "test@example.com", "secret-password")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.NotEmpty(t, result.Token)
+	assert.Equal(t, user.ID, result.User.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Login_InvalidPassword(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	user := testutils2.CreateTestUser("testuser", "test@example.com")
+	user.PasswordHash = testutils2.CreateTestPasswordHash("secret-password")
+
+	mockRepo.On("GetByUsername", mock.Anything, "testuser").Return(user, nil)
+
+	result, err := service.Login(context.Background(), "testuser", "wrong-password")
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrInvalidCredentials, appErr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Authenticate(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	tokens := testutils2.CreateTestTokenManager()
+	service := NewUserService(mockRepo, tokens, logger)
+
+	user := testutils2.CreateTestUser("testuser", "test@example.com")
+	userID := user.ID
+	token, _, err := tokens.Issue(userID)
+	assert.NoError(t, err)
+
+	mockRepo.On("GetByID", mock.Anything, userID).Return(user, nil)
+
+	actorID, err := service.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, actorID)
+
+	_, err = service.Authenticate(context.Background(), token+"tampered")
+	assert.Error(t, err)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrUnauthorized, appErr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Authenticate_Banned(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	tokens := testutils2.CreateTestTokenManager()
+	service := NewUserService(mockRepo, tokens, logger)
+
+	user := testutils2.CreateTestUser("testuser", "test@example.com")
+	user.Ban(time.Now())
+	token, _, err := tokens.Issue(user.ID)
+	assert.NoError(t, err)
+
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	_, err = service.Authenticate(context.Background(), token)
+	assertAppErrorCode(t, err, appErrors.ErrUserBanned)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Login_Banned(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	user := testutils2.CreateTestUser("testuser", "test@example.com")
+	user.PasswordHash = testutils2.CreateTestPasswordHash("secret-password")
+	user.Ban(time.Now())
+
+	mockRepo.On("GetByUsername", mock.Anything, "testuser").Return(user, nil)
+
+	result, err := service.Login(context.Background(), "testuser", "secret-password")
+
+	assert.Nil(t, result)
+	assertAppErrorCode(t, err, appErrors.ErrUserBanned)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_UpdateUser_Unauthorized(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	err := service.UpdateUser(context.Background(), uuid.New(), "newuser", "new@example.com")
+
+	assert.Error(t, err)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrUnauthorized, appErr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_DeleteUser_Forbidden(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	actor := testutils2.CreateTestUser("actor", "actor@example.com")
+	mockRepo.On("GetByID", mock.Anything, actor.ID).Return(actor, nil)
+
+	err := service.DeleteUser(testutils2.CreateAuthContext(actor.ID), uuid.New())
+
+	assert.Error(t, err)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrForbidden, appErr.Code)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestUserService_DeleteUser_ByAdmin(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	admin := testutils2.CreateTestUser("admin", "admin@example.com")
+	admin.Role = entities.RoleAdmin
+	userID := uuid.New()
+
+	mockRepo.On("GetByID", mock.Anything, admin.ID).Return(admin, nil)
+	mockRepo.On("Exists", mock.Anything, userID).Return(true, nil)
+	mockRepo.On("Delete", mock.Anything, userID).Return(nil)
+
+	err := service.DeleteUser(testutils2.CreateAuthContext(admin.ID), userID)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ChangeUserRole_Success(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	admin := testutils2.CreateTestUser("admin", "admin@example.com")
+	admin.Role = entities.RoleAdmin
+	user := testutils2.CreateTestUser("testuser", "test@example.com")
+
+	mockRepo.On("GetByID", mock.Anything, admin.ID).Return(admin, nil)
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockRepo.On("SetRole", mock.Anything, mock.MatchedBy(func(u *entities.User) bool {
+		return u.ID == user.ID && u.Role == entities.RoleModerator
+	})).Return(nil)
+
+	updated, err := service.ChangeUserRole(testutils2.CreateAuthContext(admin.ID), user.ID, entities.RoleModerator)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.RoleModerator, updated.Role)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ChangeUserRole_Forbidden(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	moderator := testutils2.CreateTestUser("moderator", "moderator@example.com")
+	moderator.Role = entities.RoleModerator
+
+	mockRepo.On("GetByID", mock.Anything, moderator.ID).Return(moderator, nil)
+
+	_, err := service.ChangeUserRole(testutils2.CreateAuthContext(moderator.ID), moderator.ID, entities.RoleAdmin)
+
+	assert.Error(t, err)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrForbidden, appErr.Code)
+	mockRepo.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+}
+
+func TestUserService_EnsureAdmin_PromotesExisting(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	user := testutils2.CreateTestUser("founder", "founder@example.com")
+
+	mockRepo.On("GetByUsername", mock.Anything, user.Username).Return(user, nil)
+	mockRepo.On("SetRole", mock.Anything, mock.MatchedBy(func(u *entities.User) bool {
+		return u.ID == user.ID && u.Role == entities.RoleAdmin
+	})).Return(nil)
+
+	admin, err := service.EnsureAdmin(context.Background(), user.Username, "", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.RoleAdmin, admin.Role)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUserService_EnsureAdmin_CreatesMissing(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	mockRepo.On("GetByUsername", mock.Anything, "founder").Return(nil, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "founder@example.com").Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *entities.User) bool {
+		return u.Username == "founder" && u.Role == entities.RoleAdmin && u.PasswordHash != ""
+	})).Return(nil)
+
+	admin, err := service.EnsureAdmin(context.Background(), "founder", "founder@example.com", "founder-password")
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.RoleAdmin, admin.Role)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_EnsureAdmin_MissingWithoutPassword(t *testing.T) {
+	mockRepo := &testutils2.MockUserRepository{}
+	logger := testutils2.CreateTestLogger()
+	service := NewUserService(mockRepo, testutils2.CreateTestTokenManager(), logger)
+
+	mockRepo.On("GetByUsername", mock.Anything, "founder").Return(nil, nil)
+
+	_, err := service.EnsureAdmin(context.Background(), "founder", "", "")
+
+	assert.Error(t, err)
+
+	appErr, ok := err.(*appErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, appErrors.ErrInvalidUserData, appErr.Code)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,2 @@
+-- Хеш пароля для аутентификации пользователей
+ALTER TABLE users ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Роль пользователя для проверки прав модерации и администрирования
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'moderator', 'admin'));
@@ -0,0 +1,5 @@
+-- Последний выданный номер события подписок для каждого поста, общий для всех экземпляров приложения
+CREATE TABLE comment_event_sequences (
+    post_id UUID PRIMARY KEY,
+    sequence BIGINT NOT NULL
+);
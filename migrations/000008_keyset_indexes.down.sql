@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_comments_parent_created_id;
+DROP INDEX IF EXISTS idx_comments_post_root_created_id;
+DROP INDEX IF EXISTS idx_posts_author_created_id;
+DROP INDEX IF EXISTS idx_posts_created_id;
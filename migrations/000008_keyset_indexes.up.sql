@@ -0,0 +1,5 @@
+-- Составные индексы для keyset-пагинации по (created_at, id)
+CREATE INDEX idx_posts_created_id ON posts(created_at DESC, id DESC);
+CREATE INDEX idx_posts_author_created_id ON posts(author_id, created_at DESC, id DESC);
+CREATE INDEX idx_comments_post_root_created_id ON comments(post_id, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_parent_created_id ON comments(parent_id, created_at, id);
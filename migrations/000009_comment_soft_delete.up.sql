@@ -0,0 +1,4 @@
+-- Мягкое удаление комментариев: строка остается, чтобы ответы не теряли родителя.
+-- Каскад по parent_id срабатывает только при явной полной очистке ветки администратором
+ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE comments ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
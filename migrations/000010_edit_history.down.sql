@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS comment_revisions;
+DROP TABLE IF EXISTS post_revisions;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS edited_by;
+ALTER TABLE comments DROP COLUMN IF EXISTS edited_at;
+ALTER TABLE comments DROP COLUMN IF EXISTS edit_count;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS edited_by;
+ALTER TABLE posts DROP COLUMN IF EXISTS edited_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS edit_count;
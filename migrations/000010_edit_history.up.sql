@@ -0,0 +1,30 @@
+-- Отметка о правках постов и комментариев
+ALTER TABLE posts ADD COLUMN edit_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE posts ADD COLUMN edited_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE comments ADD COLUMN edit_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE comments ADD COLUMN edited_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Прежние версии: version 0 - исходный текст, editor_id и created_at относятся к самой версии
+CREATE TABLE post_revisions (
+    id UUID PRIMARY KEY,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    content TEXT NOT NULL,
+    editor_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (post_id, version)
+);
+
+CREATE TABLE comment_revisions (
+    id UUID PRIMARY KEY,
+    comment_id UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    editor_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (comment_id, version)
+);
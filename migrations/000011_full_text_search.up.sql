@@ -0,0 +1,13 @@
+-- Полнотекстовый поиск: векторы поддерживаются самой БД как вычисляемые колонки.
+-- Конфигурация russian стеммит русские слова, латиница обрабатывается английским стеммером
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('russian', coalesce(content, '')), 'B')
+) STORED;
+
+ALTER TABLE comments ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('russian', coalesce(content, ''))
+) STORED;
+
+CREATE INDEX idx_posts_search_vector ON posts USING GIN(search_vector);
+CREATE INDEX idx_comments_search_vector ON comments USING GIN(search_vector);
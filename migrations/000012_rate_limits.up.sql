@@ -0,0 +1,11 @@
+-- Корзины лимитов частоты мутаций, общие для всех экземпляров приложения.
+-- Таблица UNLOGGED: после сбоя БД корзины начинаются заново, для лимитов это допустимо
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_expires_at ON rate_limit_buckets(expires_at);
@@ -0,0 +1,19 @@
+-- Реакции на посты и комментарии. Объект задается одной из колонок post_id / comment_id,
+-- чтобы реакции удалялись каскадно вместе с ним; target_id объединяет их для выборок
+CREATE TABLE reactions (
+    id UUID PRIMARY KEY,
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('post', 'comment')),
+    post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+    comment_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+    target_id UUID GENERATED ALWAYS AS (COALESCE(post_id, comment_id)) STORED,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('like', 'love', 'laugh', 'wow', 'sad', 'angry')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK (
+        (target_type = 'post' AND post_id IS NOT NULL AND comment_id IS NULL) OR
+        (target_type = 'comment' AND comment_id IS NOT NULL AND post_id IS NULL)
+    ),
+    UNIQUE (target_type, target_id, user_id, kind)
+);
+
+CREATE INDEX idx_reactions_user_id ON reactions(user_id);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_comments_parent_replies;
+DROP INDEX IF EXISTS idx_comments_parent_reactions;
+DROP INDEX IF EXISTS idx_comments_post_root_replies;
+DROP INDEX IF EXISTS idx_comments_post_root_reactions;
+
+DROP TRIGGER IF EXISTS update_comments_updated_at ON comments;
+CREATE TRIGGER update_comments_updated_at BEFORE UPDATE ON comments FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS reaction_count,
+    DROP COLUMN IF EXISTS reply_count;
@@ -0,0 +1,27 @@
+-- Счетчики прямых ответов и реакций для сортировок MOST_REPLIED и TOP.
+-- Ведутся приложением в транзакции, которая создает или удаляет ответ или реакцию.
+ALTER TABLE comments
+    ADD COLUMN reply_count BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN reaction_count BIGINT NOT NULL DEFAULT 0;
+
+UPDATE comments
+SET reply_count = replies.count
+FROM (SELECT parent_id, COUNT(*) AS count FROM comments WHERE parent_id IS NOT NULL GROUP BY parent_id) replies
+WHERE comments.id = replies.parent_id;
+
+UPDATE comments
+SET reaction_count = reactions.count
+FROM (SELECT comment_id, COUNT(*) AS count FROM reactions WHERE comment_id IS NOT NULL GROUP BY comment_id) reactions
+WHERE comments.id = reactions.comment_id;
+
+-- Изменение счетчиков не считается изменением комментария и не трогает updated_at
+DROP TRIGGER IF EXISTS update_comments_updated_at ON comments;
+CREATE TRIGGER update_comments_updated_at BEFORE UPDATE ON comments FOR EACH ROW
+    WHEN (OLD.reply_count = NEW.reply_count AND OLD.reaction_count = NEW.reaction_count)
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- NEWEST читает индексы по (created_at, id) из 000008 в обратном направлении
+CREATE INDEX idx_comments_post_root_reactions ON comments(post_id, reaction_count DESC, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_post_root_replies ON comments(post_id, reply_count DESC, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_parent_reactions ON comments(parent_id, reaction_count DESC, created_at, id);
+CREATE INDEX idx_comments_parent_replies ON comments(parent_id, reply_count DESC, created_at, id);
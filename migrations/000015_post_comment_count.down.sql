@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_posts_updated_at ON posts;
+CREATE TRIGGER update_posts_updated_at BEFORE UPDATE ON posts FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE posts DROP COLUMN IF EXISTS comment_count;
@@ -0,0 +1,14 @@
+-- Счетчик корневых комментариев поста для commentCount и totalCount страниц комментариев.
+-- Ведется приложением в транзакции, которая создает или удаляет комментарий.
+ALTER TABLE posts ADD COLUMN comment_count BIGINT NOT NULL DEFAULT 0;
+
+UPDATE posts
+SET comment_count = roots.count
+FROM (SELECT post_id, COUNT(*) AS count FROM comments WHERE parent_id IS NULL GROUP BY post_id) roots
+WHERE posts.id = roots.post_id;
+
+-- Изменение счетчика не считается изменением поста и не трогает updated_at
+DROP TRIGGER IF EXISTS update_posts_updated_at ON posts;
+CREATE TRIGGER update_posts_updated_at BEFORE UPDATE ON posts FOR EACH ROW
+    WHEN (OLD.comment_count = NEW.comment_count)
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,18 @@
+-- Входящие оповещения пользователей. Оповещение удаляется вместе с получателем, автором действия
+-- и комментарием, о котором оно; частичный индекс обслуживает выборку непрочитанных
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL CHECK (type IN ('comment_reply', 'post_comment')),
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    comment_id UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_notifications_user_created ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_notifications_user_unread ON notifications(user_id, created_at DESC, id DESC) WHERE read_at IS NULL;
+CREATE INDEX idx_notifications_actor_id ON notifications(actor_id);
+CREATE INDEX idx_notifications_post_id ON notifications(post_id);
+CREATE INDEX idx_notifications_comment_id ON notifications(comment_id);
@@ -0,0 +1,12 @@
+-- Подписки пользователей друг на друга. Первичный ключ обслуживает проверку подписки и выборку
+-- всех подписок для ленты, индексы по (created_at, id второго участника) - списки с keyset-пагинацией
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX idx_follows_followee_created ON follows(followee_id, created_at DESC, follower_id DESC);
+CREATE INDEX idx_follows_follower_created ON follows(follower_id, created_at DESC, followee_id DESC);
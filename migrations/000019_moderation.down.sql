@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS moderation_audit_log;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE users DROP COLUMN IF EXISTS banned_at;
@@ -0,0 +1,39 @@
+-- Блокировка пользователей модераторами
+ALTER TABLE users ADD COLUMN banned_at TIMESTAMP WITH TIME ZONE;
+
+-- Жалобы на посты и комментарии. Ссылки на объект нет: жалобы и решения по ним остаются в истории
+-- после удаления объекта. Частичный уникальный индекс допускает одну открытую жалобу пользователя
+-- на объект, а индекс открытых жалоб по объекту обслуживает очередь модерации.
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('post', 'comment')),
+    target_id UUID NOT NULL,
+    author_id UUID NOT NULL,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(32) NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'violence', 'misinformation', 'other')),
+    status VARCHAR(16) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    resolution VARCHAR(16) CHECK (resolution IN ('dismiss', 'hide_content', 'ban_author')),
+    resolved_by UUID,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_reports_open_reporter ON reports(target_id, reporter_id) WHERE status = 'open';
+CREATE INDEX idx_reports_open_target ON reports(target_id, created_at) WHERE status = 'open';
+CREATE INDEX idx_reports_reporter_id ON reports(reporter_id);
+
+-- Журнал решений модераторов. Записи не удаляются вместе с модератором, автором или объектом.
+CREATE TABLE moderation_audit_log (
+    id UUID PRIMARY KEY,
+    moderator_id UUID NOT NULL,
+    action VARCHAR(16) NOT NULL CHECK (action IN ('dismiss', 'hide_content', 'ban_author')),
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('post', 'comment')),
+    target_id UUID NOT NULL,
+    author_id UUID NOT NULL,
+    reports_resolved BIGINT NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_moderation_audit_log_created ON moderation_audit_log(created_at DESC, id DESC);
+CREATE INDEX idx_moderation_audit_log_target_id ON moderation_audit_log(target_id);
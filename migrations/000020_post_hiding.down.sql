@@ -0,0 +1,7 @@
+DELETE FROM moderation_audit_log WHERE action = 'restore_content';
+ALTER TABLE moderation_audit_log DROP CONSTRAINT moderation_audit_log_action_check;
+ALTER TABLE moderation_audit_log ADD CONSTRAINT moderation_audit_log_action_check
+    CHECK (action IN ('dismiss', 'hide_content', 'ban_author'));
+
+ALTER TABLE posts DROP COLUMN IF EXISTS hidden_by;
+ALTER TABLE posts DROP COLUMN IF EXISTS hidden_at;
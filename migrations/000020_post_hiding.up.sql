@@ -0,0 +1,9 @@
+-- Скрытие постов модератором: строка остается вместе с комментариями, реакциями и оповещениями,
+-- поэтому скрытие обратимо. Чтения выдачи отбрасывают строки с hidden_at IS NOT NULL.
+ALTER TABLE posts ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE posts ADD COLUMN hidden_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Восстановление скрытого поста записывается в журнал модерации
+ALTER TABLE moderation_audit_log DROP CONSTRAINT moderation_audit_log_action_check;
+ALTER TABLE moderation_audit_log ADD CONSTRAINT moderation_audit_log_action_check
+    CHECK (action IN ('dismiss', 'hide_content', 'ban_author', 'restore_content'));
@@ -0,0 +1,6 @@
+-- Комментарии удаленных пользователей удаляются вместе с ответами, как при прежнем каскаде
+DELETE FROM comments WHERE author_id IS NULL;
+ALTER TABLE comments DROP CONSTRAINT comments_author_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE comments ALTER COLUMN author_id SET NOT NULL;
@@ -0,0 +1,6 @@
+-- Удаление пользователя не удаляет его комментарии: каскад по author_id вместе с каскадом
+-- по parent_id удалял бы и ответы других пользователей. Комментарий остается в ветке без автора.
+ALTER TABLE comments ALTER COLUMN author_id DROP NOT NULL;
+ALTER TABLE comments DROP CONSTRAINT comments_author_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL;
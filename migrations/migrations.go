@@ -0,0 +1,9 @@
+// Package migrations встраивает SQL-миграции схемы PostgreSQL в бинарник приложения
+package migrations
+
+import "embed"
+
+// FS содержит файлы вида 000001_users.up.sql / 000001_users.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager_IssueAndParse(t *testing.T) {
+	manager := NewTokenManager("secret", time.Hour)
+	userID := uuid.New()
+
+	token, expiresAt, err := manager.Issue(userID)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Second)
+
+	claims, err := manager.Parse(token)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+}
+
+func TestTokenManager_Parse_InvalidToken(t *testing.T) {
+	manager := NewTokenManager("secret", time.Hour)
+	token, _, err := manager.Issue(uuid.New())
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no_signature", "payload"},
+		{"tampered_signature", token + "x"},
+		{"other_secret", mustIssue(t, NewTokenManager("other", time.Hour))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims, err := manager.Parse(tc.token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func TestTokenManager_Parse_Expired(t *testing.T) {
+	manager := NewTokenManager("secret", -time.Minute)
+	token := mustIssue(t, manager)
+
+	claims, err := manager.Parse(token)
+
+	assert.ErrorIs(t, err, ErrExpiredToken)
+	assert.Nil(t, claims)
+}
+
+func TestPassword_HashAndCheck(t *testing.T) {
+	hash, err := HashPassword("secret-password")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "secret-password", hash)
+	assert.True(t, CheckPassword(hash, "secret-password"))
+	assert.False(t, CheckPassword(hash, "wrong-password"))
+	assert.False(t, CheckPassword("", "secret-password"))
+}
+
+func TestContext_UserID(t *testing.T) {
+	_, ok := UserIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	userID := uuid.New()
+	actorID, ok := UserIDFromContext(WithUserID(context.Background(), userID))
+	assert.True(t, ok)
+	assert.Equal(t, userID, actorID)
+}
+
+func mustIssue(t *testing.T, manager *TokenManager) string {
+	token, _, err := manager.Issue(uuid.New())
+	assert.NoError(t, err)
+	return token
+}
@@ -1,16 +1,20 @@
 package errors
 
 import (
+	goerrors "errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type ErrorCode string
 
 const (
-	ErrUserNotFound    ErrorCode = "USER_NOT_FOUND"
-	ErrUserExists      ErrorCode = "USER_EXISTS"
-	ErrInvalidUserData ErrorCode = "INVALID_USER_DATA"
+	ErrUserNotFound       ErrorCode = "USER_NOT_FOUND"
+	ErrUserExists         ErrorCode = "USER_EXISTS"
+	ErrInvalidUserData    ErrorCode = "INVALID_USER_DATA"
+	ErrInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
+	ErrUserBanned         ErrorCode = "USER_BANNED"
 
 	ErrPostNotFound     ErrorCode = "POST_NOT_FOUND"
 	ErrInvalidPostData  ErrorCode = "INVALID_POST_DATA"
@@ -22,6 +26,16 @@ const (
 	ErrCommentEmpty        ErrorCode = "COMMENT_EMPTY"
 	ErrInvalidCommentData  ErrorCode = "INVALID_COMMENT_DATA"
 	ErrCommentAccessDenied ErrorCode = "COMMENT_ACCESS_DENIED"
+	ErrCommentDeleted      ErrorCode = "COMMENT_DELETED"
+
+	ErrEventReplayUnavailable ErrorCode = "EVENT_REPLAY_UNAVAILABLE"
+	ErrSubscriptionOverflow   ErrorCode = "SUBSCRIPTION_OVERFLOW"
+
+	ErrQueryTooDeep    ErrorCode = "QUERY_TOO_DEEP"
+	ErrQueryTooComplex ErrorCode = "QUERY_TOO_COMPLEX"
+	ErrQueryTimeout    ErrorCode = "QUERY_TIMEOUT"
+
+	ErrRateLimited ErrorCode = "RATE_LIMITED"
 
 	ErrInternal       ErrorCode = "INTERNAL_ERROR"
 	ErrValidation     ErrorCode = "VALIDATION_ERROR"
@@ -36,7 +50,9 @@ type AppError struct {
 	Message    string    `json:"message"`
 	Details    string    `json:"details,omitempty"`
 	StatusCode int       `json:"status_code"`
-	Err        error     `json:"-"`
+	// RetryAfter - через сколько клиент может повторить запрос; 0, если повтор не поможет
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	Err        error         `json:"-"`
 }
 
 func (e *AppError) Error() string {
@@ -50,6 +66,20 @@ func (e *AppError) Unwrap() error {
 	return e.Err
 }
 
+// AsAppError ищет AppError в цепочке обернутых ошибок
+func AsAppError(err error) (*AppError, bool) {
+	var appErr *AppError
+	if goerrors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// IsInternal сообщает, что причина ошибки не должна показываться клиентам
+func (e *AppError) IsInternal() bool {
+	return e.Code == ErrDatabase || e.Code == ErrInternal
+}
+
 func NewAppError(code ErrorCode, message string, statusCode int, err error) *AppError {
 	return &AppError{
 		Code:       code,
@@ -91,6 +121,24 @@ func NewInvalidUserDataError(message string) *AppError {
 	)
 }
 
+func NewInvalidCredentialsError() *AppError {
+	return NewAppError(
+		ErrInvalidCredentials,
+		"Неверное имя пользователя или пароль",
+		http.StatusUnauthorized,
+		nil,
+	)
+}
+
+func NewUserBannedError() *AppError {
+	return NewAppError(
+		ErrUserBanned,
+		"Пользователь заблокирован модератором",
+		http.StatusForbidden,
+		nil,
+	)
+}
+
 func NewPostNotFoundError(postID string) *AppError {
 	return NewAppError(
 		ErrPostNotFound,
@@ -172,6 +220,71 @@ func NewCommentAccessDeniedError(commentID string) *AppError {
 	).WithDetails(fmt.Sprintf("Comment ID: %s", commentID))
 }
 
+func NewCommentDeletedError(commentID string) *AppError {
+	return NewAppError(
+		ErrCommentDeleted,
+		"Комментарий удален",
+		http.StatusGone,
+		nil,
+	).WithDetails(fmt.Sprintf("Comment ID: %s", commentID))
+}
+
+func NewEventReplayUnavailableError(afterSequence int64) *AppError {
+	return NewAppError(
+		ErrEventReplayUnavailable,
+		"События после указанного номера больше недоступны, требуется полная перезагрузка данных",
+		http.StatusGone,
+		nil,
+	).WithDetails(fmt.Sprintf("After sequence: %d", afterSequence))
+}
+
+func NewSubscriptionOverflowError() *AppError {
+	return NewAppError(
+		ErrSubscriptionOverflow,
+		"Подписчик не успевает получать события, подписка закрыта",
+		http.StatusServiceUnavailable,
+		nil,
+	)
+}
+
+func NewQueryTooDeepError(depth, maxDepth int) *AppError {
+	return NewAppError(
+		ErrQueryTooDeep,
+		"Превышена допустимая глубина запроса",
+		http.StatusUnprocessableEntity,
+		nil,
+	).WithDetails(fmt.Sprintf("Depth: %d, limit: %d", depth, maxDepth))
+}
+
+func NewQueryTooComplexError(complexity, maxComplexity int) *AppError {
+	return NewAppError(
+		ErrQueryTooComplex,
+		"Превышена допустимая сложность запроса",
+		http.StatusUnprocessableEntity,
+		nil,
+	).WithDetails(fmt.Sprintf("Complexity: %d, limit: %d", complexity, maxComplexity))
+}
+
+func NewQueryTimeoutError(timeout time.Duration) *AppError {
+	return NewAppError(
+		ErrQueryTimeout,
+		"Превышено время выполнения запроса",
+		http.StatusGatewayTimeout,
+		nil,
+	).WithDetails(fmt.Sprintf("Timeout: %s", timeout))
+}
+
+func NewRateLimitedError(retryAfter time.Duration) *AppError {
+	appErr := NewAppError(
+		ErrRateLimited,
+		"Слишком много запросов, повторите позже",
+		http.StatusTooManyRequests,
+		nil,
+	).WithDetails(fmt.Sprintf("Retry after: %s", retryAfter.Round(time.Millisecond)))
+	appErr.RetryAfter = retryAfter
+	return appErr
+}
+
 func NewInternalError(err error) *AppError {
 	return NewAppError(
 		ErrInternal,
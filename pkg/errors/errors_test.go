@@ -2,8 +2,10 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -87,6 +89,14 @@ func TestNewInvalidUserDataError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
 }
 
+func TestNewUserBannedError(t *testing.T) {
+	err := NewUserBannedError()
+
+	assert.Equal(t, ErrUserBanned, err.Code)
+	assert.Equal(t, http.StatusForbidden, err.StatusCode)
+	assert.Nil(t, err.Err)
+}
+
 func TestNewPostNotFoundError(t *testing.T) {
 	postID := "post-456"
 	err := NewPostNotFoundError(postID)
@@ -171,6 +181,75 @@ func TestNewCommentAccessDeniedError(t *testing.T) {
 	assert.Contains(t, err.Details, commentID)
 }
 
+func TestNewCommentDeletedError(t *testing.T) {
+	commentID := "comment-303"
+	err := NewCommentDeletedError(commentID)
+
+	assert.Equal(t, ErrCommentDeleted, err.Code)
+	assert.Equal(t, "Комментарий удален", err.Message)
+	assert.Equal(t, http.StatusGone, err.StatusCode)
+	assert.Contains(t, err.Details, commentID)
+}
+
+func TestNewEventReplayUnavailableError(t *testing.T) {
+	err := NewEventReplayUnavailableError(42)
+
+	assert.Equal(t, ErrEventReplayUnavailable, err.Code)
+	assert.Equal(t, http.StatusGone, err.StatusCode)
+	assert.Contains(t, err.Details, "42")
+}
+
+func TestNewSubscriptionOverflowError(t *testing.T) {
+	err := NewSubscriptionOverflowError()
+
+	assert.Equal(t, ErrSubscriptionOverflow, err.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, err.StatusCode)
+}
+
+func TestNewQueryLimitErrors(t *testing.T) {
+	tooDeep := NewQueryTooDeepError(15, 12)
+	assert.Equal(t, ErrQueryTooDeep, tooDeep.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, tooDeep.StatusCode)
+	assert.Equal(t, "Depth: 15, limit: 12", tooDeep.Details)
+
+	tooComplex := NewQueryTooComplexError(20000, 10000)
+	assert.Equal(t, ErrQueryTooComplex, tooComplex.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, tooComplex.StatusCode)
+	assert.Equal(t, "Complexity: 20000, limit: 10000", tooComplex.Details)
+
+	timeout := NewQueryTimeoutError(10 * time.Second)
+	assert.Equal(t, ErrQueryTimeout, timeout.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, timeout.StatusCode)
+	assert.Equal(t, "Timeout: 10s", timeout.Details)
+}
+
+func TestNewRateLimitedError(t *testing.T) {
+	err := NewRateLimitedError(1500 * time.Millisecond)
+
+	assert.Equal(t, ErrRateLimited, err.Code)
+	assert.Equal(t, http.StatusTooManyRequests, err.StatusCode)
+	assert.Equal(t, 1500*time.Millisecond, err.RetryAfter)
+	assert.Equal(t, "Retry after: 1.5s", err.Details)
+}
+
+func TestAsAppError(t *testing.T) {
+	notFound := NewPostNotFoundError("post-1")
+
+	appErr, ok := AsAppError(fmt.Errorf("ошибка удаления поста: %w", notFound))
+	assert.True(t, ok)
+	assert.Same(t, notFound, appErr)
+
+	appErr, ok = AsAppError(errors.New("plain error"))
+	assert.False(t, ok)
+	assert.Nil(t, appErr)
+}
+
+func TestAppError_IsInternal(t *testing.T) {
+	assert.True(t, NewDatabaseError(errors.New("connection refused")).IsInternal())
+	assert.True(t, NewInternalError(errors.New("panic")).IsInternal())
+	assert.False(t, NewPostNotFoundError("post-1").IsInternal())
+}
+
 func TestNewInternalError(t *testing.T) {
 	innerErr := errors.New("repositories timeout")
 	err := NewInternalError(innerErr)
@@ -230,6 +309,7 @@ func TestErrorCodes(t *testing.T) {
 	assert.Equal(t, ErrorCode("USER_NOT_FOUND"), ErrUserNotFound)
 	assert.Equal(t, ErrorCode("USER_EXISTS"), ErrUserExists)
 	assert.Equal(t, ErrorCode("INVALID_USER_DATA"), ErrInvalidUserData)
+	assert.Equal(t, ErrorCode("USER_BANNED"), ErrUserBanned)
 	assert.Equal(t, ErrorCode("POST_NOT_FOUND"), ErrPostNotFound)
 	assert.Equal(t, ErrorCode("INVALID_POST_DATA"), ErrInvalidPostData)
 	assert.Equal(t, ErrorCode("POST_ACCESS_DENIED"), ErrPostAccessDenied)
@@ -239,6 +319,10 @@ func TestErrorCodes(t *testing.T) {
 	assert.Equal(t, ErrorCode("COMMENT_EMPTY"), ErrCommentEmpty)
 	assert.Equal(t, ErrorCode("INVALID_COMMENT_DATA"), ErrInvalidCommentData)
 	assert.Equal(t, ErrorCode("COMMENT_ACCESS_DENIED"), ErrCommentAccessDenied)
+	assert.Equal(t, ErrorCode("COMMENT_DELETED"), ErrCommentDeleted)
+	assert.Equal(t, ErrorCode("EVENT_REPLAY_UNAVAILABLE"), ErrEventReplayUnavailable)
+	assert.Equal(t, ErrorCode("SUBSCRIPTION_OVERFLOW"), ErrSubscriptionOverflow)
+	assert.Equal(t, ErrorCode("RATE_LIMITED"), ErrRateLimited)
 	assert.Equal(t, ErrorCode("INTERNAL_ERROR"), ErrInternal)
 	assert.Equal(t, ErrorCode("VALIDATION_ERROR"), ErrValidation)
 	assert.Equal(t, ErrorCode("DATABASE_ERROR"), ErrDatabase)
@@ -0,0 +1,346 @@
+// Package metrics - минимальный реестр метрик в текстовом формате Prometheus (version 0.0.4).
+// Поддерживает счетчики, gauge и гистограммы с метками, а также метрики, значения которых
+// вычисляются в момент запроса /metrics.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType - тип ответа /metrics, который ожидает Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets - границы гистограммы длительности в секундах, как в клиенте Prometheus
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Type - тип метрики в строке # TYPE
+type Type string
+
+const (
+	TypeCounter   Type = "counter"
+	TypeGauge     Type = "gauge"
+	TypeHistogram Type = "histogram"
+)
+
+// Sample - значение метрики с метками в порядке, заданном при регистрации
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// Registry хранит зарегистрированные метрики и отдает их в текстовом формате
+type Registry struct {
+	mu       sync.Mutex
+	names    map[string]struct{}
+	families []family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+// NewCounter регистрирует монотонно растущий счетчик
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{vector: newVector(name, help, TypeCounter, labels)}
+	r.register(name, c)
+	return c
+}
+
+// NewGauge регистрирует значение, которое может расти и уменьшаться
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vector: newVector(name, help, TypeGauge, labels)}
+	r.register(name, g)
+	return g
+}
+
+// NewHistogram регистрирует гистограмму; buckets - верхние границы по возрастанию
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: append([]float64(nil), buckets...),
+		series:  make(map[string]*histogramSeries),
+	}
+	sort.Float64s(h.buckets)
+	r.register(name, h)
+	return h
+}
+
+// NewFunc регистрирует счетчик или gauge, значения которого collect возвращает при каждом запросе.
+// Подходит для состояния, которое уже хранится в другом месте, например статистики пула соединений.
+func (r *Registry) NewFunc(name, help string, typ Type, labels []string, collect func() []Sample) {
+	r.register(name, &funcFamily{name: name, help: help, typ: typ, labels: labels, collect: collect})
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.names[name]; ok {
+		panic(fmt.Sprintf("metrics: метрика %s уже зарегистрирована", name))
+	}
+	r.names[name] = struct{}{}
+	r.families = append(r.families, f)
+}
+
+// Write выводит все метрики в порядке регистрации
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(buf)
+	}
+	return buf.Flush()
+}
+
+// Handler отдает метрики по HTTP
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.Write(w)
+	})
+}
+
+// vector - значения счетчика или gauge по наборам меток
+type vector struct {
+	name   string
+	help   string
+	typ    Type
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*Sample
+}
+
+func newVector(name, help string, typ Type, labels []string) vector {
+	return vector{name: name, help: help, typ: typ, labels: labels, values: make(map[string]*Sample)}
+}
+
+func (v *vector) add(delta float64, labelValues []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sample(labelValues).Value += delta
+}
+
+func (v *vector) set(value float64, labelValues []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sample(labelValues).Value = value
+}
+
+func (v *vector) sample(labelValues []string) *Sample {
+	checkLabels(v.name, v.labels, labelValues)
+
+	key := seriesKey(labelValues)
+	s, ok := v.values[key]
+	if !ok {
+		s = &Sample{LabelValues: append([]string(nil), labelValues...)}
+		v.values[key] = s
+	}
+	return s
+}
+
+func (v *vector) write(w *bufio.Writer) {
+	v.mu.Lock()
+	samples := make([]Sample, 0, len(v.values))
+	for _, s := range v.values {
+		samples = append(samples, *s)
+	}
+	v.mu.Unlock()
+
+	writeSamples(w, v.name, v.help, v.typ, v.labels, samples)
+}
+
+// Counter - счетчик с метками
+type Counter struct{ vector }
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+// Add увеличивает счетчик; отрицательное значение - ошибка программиста
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: счетчик %s не может уменьшаться", c.name))
+	}
+	c.add(delta, labelValues)
+}
+
+// Gauge - текущее значение с метками
+type Gauge struct{ vector }
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.set(value, labelValues)
+}
+
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.add(delta, labelValues)
+}
+
+// Histogram - распределение значений по корзинам с метками
+type Histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	checkLabels(h.name, h.labels, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := seriesKey(labelValues)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+
+	// Счетчики корзин хранятся накопительными, как их выводит формат
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	series := make([]histogramSeries, 0, len(h.series))
+	for _, s := range h.series {
+		copied := *s
+		copied.counts = append([]uint64(nil), s.counts...)
+		series = append(series, copied)
+	}
+	h.mu.Unlock()
+
+	sort.Slice(series, func(i, j int) bool {
+		return seriesKey(series[i].labelValues) < seriesKey(series[j].labelValues)
+	})
+
+	writeHeader(w, h.name, h.help, TypeHistogram)
+
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, s := range series {
+		for i, bound := range h.buckets {
+			writeLine(w, h.name+"_bucket", bucketLabels, withValue(s.labelValues, formatFloat(bound)), float64(s.counts[i]))
+		}
+		writeLine(w, h.name+"_bucket", bucketLabels, withValue(s.labelValues, "+Inf"), float64(s.count))
+		writeLine(w, h.name+"_sum", h.labels, s.labelValues, s.sum)
+		writeLine(w, h.name+"_count", h.labels, s.labelValues, float64(s.count))
+	}
+}
+
+type funcFamily struct {
+	name    string
+	help    string
+	typ     Type
+	labels  []string
+	collect func() []Sample
+}
+
+func (f *funcFamily) write(w *bufio.Writer) {
+	samples := f.collect()
+	for _, s := range samples {
+		checkLabels(f.name, f.labels, s.LabelValues)
+	}
+	writeSamples(w, f.name, f.help, f.typ, f.labels, samples)
+}
+
+func writeSamples(w *bufio.Writer, name, help string, typ Type, labels []string, samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return seriesKey(samples[i].LabelValues) < seriesKey(samples[j].LabelValues)
+	})
+
+	writeHeader(w, name, help, typ)
+	for _, s := range samples {
+		writeLine(w, name, labels, s.LabelValues, s.Value)
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help string, typ Type) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, helpEscaper.Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+func writeLine(w *bufio.Writer, name string, labels, labelValues []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, labelEscaper.Replace(labelValues[i]))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// withValue добавляет значение метки le, не изменяя исходный срез
+func withValue(labelValues []string, value string) []string {
+	return append(append(make([]string, 0, len(labelValues)+1), labelValues...), value)
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func checkLabels(name string, labels, labelValues []string) {
+	if len(labels) != len(labelValues) {
+		panic(fmt.Sprintf("metrics: у метрики %s меток %d, передано значений %d", name, len(labels), len(labelValues)))
+	}
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, registry *Registry) string {
+	var out strings.Builder
+	require.NoError(t, registry.Write(&out))
+	return out.String()
+}
+
+func TestRegistry_CounterAndGauge(t *testing.T) {
+	registry := NewRegistry()
+
+	errors := registry.NewCounter("errors_total", "Ошибки по коду", "code")
+	errors.Inc("POST_NOT_FOUND")
+	errors.Add(2, "POST_NOT_FOUND")
+	errors.Inc(`quote"and\slash`)
+
+	inFlight := registry.NewGauge("in_flight", "Запросы в работе")
+	inFlight.Add(3)
+	inFlight.Add(-1)
+
+	expected := `# HELP errors_total Ошибки по коду
+# TYPE errors_total counter
+errors_total{code="POST_NOT_FOUND"} 3
+errors_total{code="quote\"and\\slash"} 1
+# HELP in_flight Запросы в работе
+# TYPE in_flight gauge
+in_flight 2
+`
+	assert.Equal(t, expected, render(t, registry))
+
+	assert.Panics(t, func() { errors.Add(-1, "POST_NOT_FOUND") })
+	assert.Panics(t, func() { errors.Inc() }, "число значений меток должно совпадать с регистрацией")
+	assert.Panics(t, func() { registry.NewGauge("in_flight", "Повтор") })
+}
+
+func TestRegistry_Histogram(t *testing.T) {
+	registry := NewRegistry()
+
+	duration := registry.NewHistogram("duration_seconds", "Длительность", []float64{1, 0.1}, "operation")
+	duration.Observe(0.05, "posts")
+	duration.Observe(0.5, "posts")
+	duration.Observe(5, "posts")
+
+	expected := `# HELP duration_seconds Длительность
+# TYPE duration_seconds histogram
+duration_seconds_bucket{operation="posts",le="0.1"} 1
+duration_seconds_bucket{operation="posts",le="1"} 2
+duration_seconds_bucket{operation="posts",le="+Inf"} 3
+duration_seconds_sum{operation="posts"} 5.55
+duration_seconds_count{operation="posts"} 3
+`
+	assert.Equal(t, expected, render(t, registry))
+}
+
+func TestRegistry_Func(t *testing.T) {
+	registry := NewRegistry()
+
+	subscribers := map[string]int{"b": 1, "a": 2}
+	registry.NewFunc("subscribers", "Подписчики", TypeGauge, []string{"post_id"}, func() []Sample {
+		var samples []Sample
+		for postID, count := range subscribers {
+			samples = append(samples, Sample{LabelValues: []string{postID}, Value: float64(count)})
+		}
+		return samples
+	})
+
+	assert.Contains(t, render(t, registry), "subscribers{post_id=\"a\"} 2\nsubscribers{post_id=\"b\"} 1\n")
+
+	// Значение вычисляется при каждом запросе
+	delete(subscribers, "a")
+	assert.NotContains(t, render(t, registry), `post_id="a"`)
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("requests_total", "Запросы").Inc()
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "requests_total 1\n")
+}
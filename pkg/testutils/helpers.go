@@ -1,7 +1,9 @@
 package testutils
 
 import (
+	"context"
 	"ozon-posts/internal/entities"
+	"ozon-posts/pkg/auth"
 	"time"
 
 	"testing"
@@ -17,6 +19,7 @@ func CreateTestUser(username, email string) *entities.User {
 		ID:        uuid.New(),
 		Username:  username,
 		Email:     email,
+		Role:      entities.RoleUser,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -120,3 +123,19 @@ func CreateLongString(length int) string {
 	}
 	return string(result)
 }
+
+func CreateTestTokenManager() *auth.TokenManager {
+	return auth.NewTokenManager("test-secret", time.Hour)
+}
+
+func CreateAuthContext(userID uuid.UUID) context.Context {
+	return auth.WithUserID(context.Background(), userID)
+}
+
+func CreateTestPasswordHash(password string) string {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
@@ -3,6 +3,7 @@ package testutils
 import (
 	"context"
 	"ozon-posts/internal/entities"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -46,6 +47,16 @@ func (m *MockUserRepository) Update(ctx context.Context, user *entities.User) er
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) SetRole(ctx context.Context, user *entities.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetBanned(ctx context.Context, user *entities.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -81,16 +92,42 @@ func (m *MockPostRepository) GetByID(ctx context.Context, id uuid.UUID) (*entiti
 	return args.Get(0).(*entities.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Post), args.Error(1)
+}
+
 func (m *MockPostRepository) Update(ctx context.Context, post *entities.Post) error {
 	args := m.Called(ctx, post)
 	return args.Error(0)
 }
 
+func (m *MockPostRepository) SetHidden(ctx context.Context, post *entities.Post) error {
+	args := m.Called(ctx, post)
+	return args.Error(0)
+}
+
 func (m *MockPostRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockPostRepository) AddCommentCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	args := m.Called(ctx, id, delta)
+	return args.Error(0)
+}
+
 func (m *MockPostRepository) GetAll(ctx context.Context, pagination *entities.PaginationRequest) ([]*entities.Post, *entities.PaginationResponse, error) {
 	args := m.Called(ctx, pagination)
 	if args.Get(0) == nil {
@@ -107,6 +144,30 @@ func (m *MockPostRepository) GetByAuthorID(ctx context.Context, authorID uuid.UU
 	return args.Get(0).([]*entities.Post), args.Get(1).(*entities.PaginationResponse), args.Error(2)
 }
 
+func (m *MockPostRepository) GetAllKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Post), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
+func (m *MockPostRepository) GetByAuthorIDKeyset(ctx context.Context, authorID uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	args := m.Called(ctx, authorID, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Post), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
+func (m *MockPostRepository) GetByAuthorIDsKeyset(ctx context.Context, authorIDs []uuid.UUID, req *entities.CursorRequest) ([]*entities.Post, *entities.PageInfo, error) {
+	args := m.Called(ctx, authorIDs, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Post), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
 func (m *MockPostRepository) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
 	args := m.Called(ctx, id)
 	return args.Bool(0), args.Error(1)
@@ -142,18 +203,39 @@ func (m *MockCommentRepository) GetByID(ctx context.Context, id uuid.UUID) (*ent
 	return args.Get(0).(*entities.Comment), args.Error(1)
 }
 
+func (m *MockCommentRepository) GetByIDForShare(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Comment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Comment), args.Error(1)
+}
+
 func (m *MockCommentRepository) Update(ctx context.Context, comment *entities.Comment) error {
 	args := m.Called(ctx, comment)
 	return args.Error(0)
 }
 
-func (m *MockCommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
+func (m *MockCommentRepository) SoftDelete(ctx context.Context, comment *entities.Comment) error {
+	args := m.Called(ctx, comment)
 	return args.Error(0)
 }
 
-func (m *MockCommentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
-	args := m.Called(ctx, postID, pagination)
+func (m *MockCommentRepository) Purge(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+	args := m.Called(ctx, postID, sort, pagination)
 	if args.Get(0) == nil {
 		return nil, nil, args.Error(2)
 	}
@@ -165,19 +247,77 @@ func (m *MockCommentRepository) CountByPostID(ctx context.Context, postID uuid.U
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockCommentRepository) GetByParentID(ctx context.Context, parentID uuid.UUID, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
-	args := m.Called(ctx, parentID, pagination)
+func (m *MockCommentRepository) GetByParentID(ctx context.Context, parentID uuid.UUID, sort entities.CommentSort, pagination *entities.PaginationRequest) ([]*entities.Comment, *entities.PaginationResponse, error) {
+	args := m.Called(ctx, parentID, sort, pagination)
 	if args.Get(0) == nil {
 		return nil, nil, args.Error(2)
 	}
 	return args.Get(0).([]*entities.Comment), args.Get(1).(*entities.PaginationResponse), args.Error(2)
 }
 
+func (m *MockCommentRepository) AddReplyCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	args := m.Called(ctx, id, delta)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) AddReactionCount(ctx context.Context, id uuid.UUID, delta int64) error {
+	args := m.Called(ctx, id, delta)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetByPostIDKeyset(ctx context.Context, postID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	args := m.Called(ctx, postID, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Comment), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
+func (m *MockCommentRepository) GetByParentIDKeyset(ctx context.Context, parentID uuid.UUID, req *entities.CursorRequest) ([]*entities.Comment, *entities.PageInfo, error) {
+	args := m.Called(ctx, parentID, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Comment), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
 func (m *MockCommentRepository) CountByParentID(ctx context.Context, parentID uuid.UUID) (int64, error) {
 	args := m.Called(ctx, parentID)
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockCommentRepository) GetChildrenByParentIDs(ctx context.Context, parentIDs []uuid.UUID, perParentLimit int) ([]*entities.Comment, error) {
+	args := m.Called(ctx, parentIDs, perParentLimit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) CountByParentIDs(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	args := m.Called(ctx, parentIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]int64), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID, perPostLimit int) ([]*entities.Comment, error) {
+	args := m.Called(ctx, postIDs, perPostLimit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) CountTopLevelByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	args := m.Called(ctx, postIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]int64), args.Error(1)
+}
+
 func (m *MockCommentRepository) GetThread(ctx context.Context, commentID uuid.UUID, maxDepth int) ([]*entities.Comment, error) {
 	args := m.Called(ctx, commentID, maxDepth)
 	if args.Get(0) == nil {
@@ -206,3 +346,202 @@ func (m *MockCommentRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (
 	}
 	return args.Get(0).([]*entities.Comment), args.Error(1)
 }
+
+func (m *MockPostRepository) CreateRevision(ctx context.Context, revision *entities.PostRevision) error {
+	args := m.Called(ctx, revision)
+	return args.Error(0)
+}
+
+func (m *MockPostRepository) GetRevisions(ctx context.Context, postID uuid.UUID) ([]*entities.PostRevision, error) {
+	args := m.Called(ctx, postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.PostRevision), args.Error(1)
+}
+
+func (m *MockCommentRepository) CreateRevision(ctx context.Context, revision *entities.CommentRevision) error {
+	args := m.Called(ctx, revision)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetRevisions(ctx context.Context, commentID uuid.UUID) ([]*entities.CommentRevision, error) {
+	args := m.Called(ctx, commentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.CommentRevision), args.Error(1)
+}
+
+func (m *MockCommentRepository) ReplaceMentions(ctx context.Context, commentID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, commentID, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetMentionsByCommentIDs(ctx context.Context, commentIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	args := m.Called(ctx, commentIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]uuid.UUID), args.Error(1)
+}
+
+func (m *MockPostRepository) Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.PostSearchResult, *entities.PaginationResponse, error) {
+	args := m.Called(ctx, query, pagination)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.PostSearchResult), args.Get(1).(*entities.PaginationResponse), args.Error(2)
+}
+
+func (m *MockCommentRepository) Search(ctx context.Context, query *entities.SearchQuery, pagination *entities.PaginationRequest) ([]*entities.CommentSearchResult, *entities.PaginationResponse, error) {
+	args := m.Called(ctx, query, pagination)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.CommentSearchResult), args.Get(1).(*entities.PaginationResponse), args.Error(2)
+}
+
+type MockReactionRepository struct {
+	mock.Mock
+}
+
+func (m *MockReactionRepository) Add(ctx context.Context, reaction *entities.Reaction) (bool, error) {
+	args := m.Called(ctx, reaction)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReactionRepository) Remove(ctx context.Context, targetType entities.ReactionTargetType, targetID, userID uuid.UUID, kind entities.ReactionKind) (bool, error) {
+	args := m.Called(ctx, targetType, targetID, userID, kind)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReactionRepository) CountByTargets(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReactionKind]int64, error) {
+	args := m.Called(ctx, targetType, targetIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]map[entities.ReactionKind]int64), args.Error(1)
+}
+
+func (m *MockReactionRepository) GetUserKinds(ctx context.Context, targetType entities.ReactionTargetType, targetIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID][]entities.ReactionKind, error) {
+	args := m.Called(ctx, targetType, targetIDs, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]entities.ReactionKind), args.Error(1)
+}
+
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, notifications []*entities.Notification) error {
+	args := m.Called(ctx, notifications)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) GetByUserIDKeyset(ctx context.Context, userID uuid.UUID, unreadOnly bool, req *entities.CursorRequest) ([]*entities.Notification, *entities.PageInfo, error) {
+	args := m.Called(ctx, userID, unreadOnly, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Notification), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
+func (m *MockNotificationRepository) MarkRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, readAt time.Time) (int64, error) {
+	args := m.Called(ctx, userID, ids, readAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockFollowRepository struct {
+	mock.Mock
+}
+
+func (m *MockFollowRepository) Create(ctx context.Context, follow *entities.Follow) (bool, error) {
+	args := m.Called(ctx, follow)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockFollowRepository) Delete(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, followerID, followeeID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockFollowRepository) GetFollowersKeyset(ctx context.Context, followeeID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	args := m.Called(ctx, followeeID, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Follow), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
+func (m *MockFollowRepository) GetFollowingKeyset(ctx context.Context, followerID uuid.UUID, req *entities.CursorRequest) ([]*entities.Follow, *entities.PageInfo, error) {
+	args := m.Called(ctx, followerID, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.Follow), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
+func (m *MockFollowRepository) GetFolloweeIDs(ctx context.Context, followerID uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, followerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) Create(ctx context.Context, report *entities.Report) (bool, error) {
+	args := m.Called(ctx, report)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReportRepository) GetQueueKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationQueueItem, *entities.PageInfo, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.ModerationQueueItem), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
+
+func (m *MockReportRepository) GetQueueItem(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID) (*entities.ModerationQueueItem, error) {
+	args := m.Called(ctx, targetType, targetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ModerationQueueItem), args.Error(1)
+}
+
+func (m *MockReportRepository) CountOpenByReason(ctx context.Context, targetIDs []uuid.UUID) (map[uuid.UUID]map[entities.ReportReason]int64, error) {
+	args := m.Called(ctx, targetIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]map[entities.ReportReason]int64), args.Error(1)
+}
+
+func (m *MockReportRepository) ResolveOpen(ctx context.Context, targetType entities.ReportTargetType, targetID uuid.UUID, action entities.ModerationAction, moderatorID uuid.UUID, resolvedAt time.Time) (int64, error) {
+	args := m.Called(ctx, targetType, targetID, action, moderatorID, resolvedAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockReportRepository) CreateAuditEntry(ctx context.Context, entry *entities.ModerationAuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockReportRepository) GetAuditLogKeyset(ctx context.Context, req *entities.CursorRequest) ([]*entities.ModerationAuditEntry, *entities.PageInfo, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*entities.ModerationAuditEntry), args.Get(1).(*entities.PageInfo), args.Error(2)
+}
@@ -0,0 +1,317 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/repositories/inmemory"
+	"ozon-posts/internal/services"
+	"ozon-posts/pkg/auth"
+	appErrors "ozon-posts/pkg/errors"
+	"ozon-posts/pkg/testutils"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type durableSuite struct {
+	storage             *inmemory.Storage
+	userService         *services.UserService
+	postService         *services.PostService
+	commentService      *services.CommentService
+	reactionService     *services.ReactionService
+	notificationService *services.NotificationService
+	followService       *services.FollowService
+	moderationService   *services.ModerationService
+}
+
+func openDurableSuite(t *testing.T, dir string) *durableSuite {
+	logger := testutils.CreateTestLogger()
+
+	storage, err := inmemory.OpenStorage(inmemory.StorageOptions{
+		Dir:   dir,
+		Fsync: inmemory.FsyncAlways,
+	}, logger)
+	require.NoError(t, err)
+
+	unitOfWork := inmemory.NewUnitOfWork(storage.Users(), storage.Posts(), storage.Comments(), storage.Reactions(), storage.Notifications(), storage.Reports())
+	eventBus := services.NewInProcessEventBus(services.DefaultEventBusOptions(), logger)
+
+	return &durableSuite{
+		storage:             storage,
+		userService:         services.NewUserService(storage.Users(), testutils.CreateTestTokenManager(), logger),
+		postService:         services.NewPostService(storage.Posts(), storage.Users(), unitOfWork, eventBus, logger),
+		commentService:      services.NewCommentService(storage.Comments(), storage.Posts(), storage.Users(), unitOfWork, eventBus, logger),
+		reactionService:     services.NewReactionService(storage.Reactions(), unitOfWork, eventBus, logger),
+		notificationService: services.NewNotificationService(storage.Notifications(), eventBus, logger),
+		followService:       services.NewFollowService(storage.Follows(), storage.Users(), storage.Posts(), logger),
+		moderationService:   services.NewModerationService(storage.Reports(), storage.Posts(), storage.Comments(), storage.Users(), unitOfWork, eventBus, logger),
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "wal-*.log"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestDurableStorage_RestoreAfterClose(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	suite := openDurableSuite(t, dir)
+
+	registered, err := suite.userService.Register(ctx, "durable", "durable@example.com", "password123")
+	require.NoError(t, err)
+	author := registered.User
+	authorCtx := auth.WithUserID(ctx, author.ID)
+
+	admin, err := suite.userService.CreateUser(ctx, "durableadmin", "durableadmin@example.com")
+	require.NoError(t, err)
+	admin.Role = entities.RoleAdmin
+	require.NoError(t, suite.storage.Users().Update(ctx, admin))
+
+	post, err := suite.postService.CreatePost(authorCtx, "Сохраненный пост", "Исходный текст")
+	require.NoError(t, err)
+	_, err = suite.postService.UpdatePost(authorCtx, post.ID, "Сохраненный пост", "Исправленный текст")
+	require.NoError(t, err)
+
+	removedPost, err := suite.postService.CreatePost(authorCtx, "Удаленный пост", "Текст")
+	require.NoError(t, err)
+	require.NoError(t, suite.postService.DeletePost(authorCtx, removedPost.ID))
+
+	root, err := suite.commentService.CreateComment(authorCtx, post.ID, "Корневой комментарий", nil)
+	require.NoError(t, err)
+	reply, err := suite.commentService.CreateComment(authorCtx, post.ID, "Ответ", &root.ID)
+	require.NoError(t, err)
+	_, err = suite.commentService.UpdateComment(authorCtx, reply.ID, "Исправленный ответ для @durableadmin")
+	require.NoError(t, err)
+	require.NoError(t, suite.commentService.DeleteComment(authorCtx, root.ID))
+
+	purged, err := suite.commentService.CreateComment(authorCtx, post.ID, "Очищенная ветка", nil)
+	require.NoError(t, err)
+	_, err = suite.commentService.PurgeComment(auth.WithUserID(ctx, admin.ID), purged.ID)
+	require.NoError(t, err)
+
+	_, err = suite.reactionService.React(authorCtx, entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	_, err = suite.reactionService.React(authorCtx, entities.ReactionTargetPost, post.ID, entities.ReactionSad)
+	require.NoError(t, err)
+	_, err = suite.reactionService.Unreact(authorCtx, entities.ReactionTargetPost, post.ID, entities.ReactionSad)
+	require.NoError(t, err)
+	_, err = suite.reactionService.React(authorCtx, entities.ReactionTargetComment, reply.ID, entities.ReactionWow)
+	require.NoError(t, err)
+
+	require.NoError(t, suite.storage.Close())
+	assert.FileExists(t, filepath.Join(dir, "snapshot.json"))
+
+	restored := openDurableSuite(t, dir)
+	defer restored.storage.Close()
+
+	_, err = restored.userService.Login(ctx, "durable", "password123")
+	require.NoError(t, err, "хеш пароля должен пережить перезапуск")
+
+	restoredPost, err := restored.postService.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Исправленный текст", restoredPost.Content)
+	assert.Equal(t, 1, restoredPost.EditCount)
+	assert.Equal(t, int64(1), restoredPost.RootCommentCount, "счетчик комментариев не сбрасывается правкой поста")
+	assert.True(t, post.CreatedAt.Equal(restoredPost.CreatedAt))
+
+	revisions, err := restored.postService.GetPostRevisions(authorCtx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Исходный текст", revisions[0].Content)
+
+	_, err = restored.postService.GetPostByID(ctx, removedPost.ID)
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+
+	page, _, err := restored.postService.GetPostsPage(ctx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+
+	comments, _, err := restored.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.True(t, comments[0].IsDeleted())
+	assert.Equal(t, int64(1), comments[0].ReplyCount, "счетчики комментария восстанавливаются из журнала")
+
+	replies, _, err := restored.commentService.GetCommentReplies(ctx, root.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	require.Len(t, replies, 1)
+	assert.Equal(t, "Исправленный ответ для @durableadmin", replies[0].Content)
+	assert.Equal(t, int64(1), replies[0].ReactionCount)
+
+	mentions, err := restored.commentService.GetMentionsByCommentIDs(ctx, []uuid.UUID{reply.ID})
+	require.NoError(t, err)
+	require.Len(t, mentions[reply.ID], 1)
+	assert.Equal(t, admin.ID, mentions[reply.ID][0].ID)
+
+	_, err = restored.commentService.GetCommentByID(ctx, purged.ID)
+	assertAppErrorCode(t, err, appErrors.ErrCommentNotFound)
+
+	found, _, err := restored.commentService.SearchComments(ctx, "исправленный", nil, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, reply.ID, found[0].Comment.ID)
+
+	reactions, err := restored.reactionService.GetReactionsByTargets(authorCtx, entities.ReactionTargetPost, []uuid.UUID{post.ID})
+	require.NoError(t, err)
+	require.Len(t, reactions[post.ID], 1)
+	assert.Equal(t, entities.ReactionSummary{Kind: entities.ReactionLike, Count: 1, ViewerReacted: true}, *reactions[post.ID][0])
+}
+
+func TestDurableStorage_ReplayAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// Первый экземпляр не закрывается, как при аварийном завершении процесса
+	crashed := openDurableSuite(t, dir)
+
+	user, err := crashed.userService.CreateUser(ctx, "crashuser", "crash@example.com")
+	require.NoError(t, err)
+	userCtx := auth.WithUserID(ctx, user.ID)
+
+	beforeSnapshot, err := crashed.postService.CreatePost(userCtx, "До снимка", "Текст")
+	require.NoError(t, err)
+
+	reader, err := crashed.userService.CreateUser(ctx, "crashreader", "crashreader@example.com")
+	require.NoError(t, err)
+	readerCtx := auth.WithUserID(ctx, reader.ID)
+	var readerComments []*entities.Comment
+	for _, content := range []string{"Первый комментарий читателя", "Второй комментарий читателя"} {
+		comment, err := crashed.commentService.CreateComment(readerCtx, beforeSnapshot.ID, content, nil)
+		require.NoError(t, err)
+		readerComments = append(readerComments, comment)
+	}
+	_, err = crashed.followService.Follow(userCtx, reader.ID)
+	require.NoError(t, err)
+
+	moderator, err := crashed.userService.CreateUser(ctx, "crashmod", "crashmod@example.com")
+	require.NoError(t, err)
+	moderator.Role = entities.RoleModerator
+	require.NoError(t, crashed.storage.Users().Update(ctx, moderator))
+	moderatorCtx := auth.WithUserID(ctx, moderator.ID)
+
+	_, err = crashed.moderationService.Report(readerCtx, entities.ReportTargetPost, beforeSnapshot.ID, entities.ReportSpam)
+	require.NoError(t, err)
+	require.NoError(t, crashed.storage.Snapshot())
+
+	// Жалоба из снимка закрывается, а новая есть только в журнале
+	_, err = crashed.moderationService.ResolveReports(moderatorCtx, entities.ReportTargetPost, beforeSnapshot.ID, entities.ModerationDismiss, nil)
+	require.NoError(t, err)
+	_, err = crashed.moderationService.Report(userCtx, entities.ReportTargetComment, readerComments[0].ID, entities.ReportHarassment)
+	require.NoError(t, err)
+
+	// Подписка из снимка отменяется, а новая есть только в журнале
+	_, err = crashed.followService.Unfollow(userCtx, reader.ID)
+	require.NoError(t, err)
+	_, err = crashed.followService.Follow(readerCtx, user.ID)
+	require.NoError(t, err)
+
+	// Оповещения попадают в снимок, а отметка о прочтении - только в журнал
+	inbox, _, err := crashed.notificationService.GetNotificationsPage(userCtx, false, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, inbox, 2)
+	_, err = crashed.notificationService.MarkNotificationsRead(userCtx, []uuid.UUID{inbox[0].ID})
+	require.NoError(t, err)
+
+	afterSnapshot, err := crashed.postService.CreatePost(userCtx, "После снимка", "Текст")
+	require.NoError(t, err)
+	mentioning, err := crashed.commentService.CreateComment(userCtx, afterSnapshot.ID, "Комментарий после снимка для @crashuser", nil)
+	require.NoError(t, err)
+
+	assert.Len(t, segmentFiles(t, dir), 1, "сегменты, покрытые снимком, удаляются")
+
+	// Сбой посреди записи оставляет недописанную строку в конце журнала
+	segments := segmentFiles(t, dir)
+	file, err := os.OpenFile(segments[len(segments)-1], os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"lsn":99,"op":"post.put","post":{"id":`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	restored := openDurableSuite(t, dir)
+	defer restored.storage.Close()
+
+	for _, post := range []*entities.Post{beforeSnapshot, afterSnapshot} {
+		_, err := restored.postService.GetPostByID(ctx, post.ID)
+		require.NoError(t, err)
+	}
+
+	comments, _, err := restored.commentService.GetPostComments(ctx, afterSnapshot.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+
+	mentions, err := restored.commentService.GetMentionsByCommentIDs(ctx, []uuid.UUID{mentioning.ID})
+	require.NoError(t, err)
+	require.Len(t, mentions[mentioning.ID], 1, "упоминания восстанавливаются из журнала")
+
+	restoredInbox, _, err := restored.notificationService.GetNotificationsPage(userCtx, false, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, restoredInbox, 2)
+	assert.Equal(t, inbox[0].ID, restoredInbox[0].ID)
+	assert.True(t, restoredInbox[0].IsRead(), "отметка о прочтении восстанавливается из журнала")
+
+	unread, _, err := restored.notificationService.GetNotificationsPage(userCtx, true, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, unread, 1)
+	assert.Equal(t, inbox[1].ID, unread[0].ID)
+
+	following, _, err := restored.followService.GetFollowingPage(ctx, user.ID, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, following, "отмена подписки восстанавливается из журнала")
+
+	feed, _, err := restored.followService.GetFeedPage(readerCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, feed, 2)
+	assert.Equal(t, afterSnapshot.ID, feed[0].ID)
+	assert.Equal(t, beforeSnapshot.ID, feed[1].ID)
+
+	queue, _, err := restored.moderationService.GetModerationQueuePage(moderatorCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, queue, 1, "закрытие жалобы из снимка восстанавливается из журнала")
+	assert.Equal(t, readerComments[0].ID, queue[0].TargetID)
+
+	auditLog, _, err := restored.moderationService.GetAuditLogPage(moderatorCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, auditLog, 1)
+	assert.Equal(t, beforeSnapshot.ID, auditLog[0].TargetID)
+
+	// Журнал продолжается с правильного номера: новые записи переживают еще один перезапуск
+	_, err = restored.commentService.CreateComment(userCtx, afterSnapshot.ID, "Комментарий после восстановления", nil)
+	require.NoError(t, err)
+	require.NoError(t, restored.storage.Close())
+
+	reopened := openDurableSuite(t, dir)
+	defer reopened.storage.Close()
+
+	comments, _, err = reopened.commentService.GetPostComments(ctx, afterSnapshot.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	assert.Len(t, comments, 2)
+}
+
+func TestDurableStorage_CorruptedJournal(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	suite := openDurableSuite(t, dir)
+	defer suite.storage.Close()
+
+	_, err := suite.userService.CreateUser(ctx, "corrupt", "corrupt@example.com")
+	require.NoError(t, err)
+	_, err = suite.userService.CreateUser(ctx, "corrupt2", "corrupt2@example.com")
+	require.NoError(t, err)
+
+	segment := segmentFiles(t, dir)[0]
+	data, err := os.ReadFile(segment)
+	require.NoError(t, err)
+	data[5] = '#'
+	require.NoError(t, os.WriteFile(segment, data, 0o644))
+
+	_, err = inmemory.OpenStorage(inmemory.StorageOptions{Dir: dir, Fsync: inmemory.FsyncAlways}, testutils.CreateTestLogger())
+	assert.Error(t, err, "повреждение в середине журнала не должно молча терять данные")
+}
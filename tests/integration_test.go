@@ -6,6 +6,8 @@ import (
 	"ozon-posts/internal/entities"
 	"ozon-posts/internal/repositories/inmemory"
 	"ozon-posts/internal/services"
+	"ozon-posts/pkg/auth"
+	appErrors "ozon-posts/pkg/errors"
 	"ozon-posts/pkg/testutils"
 	"testing"
 	"time"
@@ -17,10 +19,18 @@ import (
 )
 
 type TestSuite struct {
-	userService    *services.UserService
-	postService    *services.PostService
-	commentService *services.CommentService
-	logger         *logrus.Logger
+	userRepo            services.UserRepository
+	postRepo            services.PostRepository
+	commentRepo         services.CommentRepository
+	reconciler          services.CounterReconciler
+	userService         *services.UserService
+	postService         *services.PostService
+	commentService      *services.CommentService
+	reactionService     *services.ReactionService
+	notificationService *services.NotificationService
+	followService       *services.FollowService
+	moderationService   *services.ModerationService
+	logger              *logrus.Logger
 }
 
 func setupTestSuite(t *testing.T) *TestSuite {
@@ -28,17 +38,38 @@ func setupTestSuite(t *testing.T) *TestSuite {
 
 	userRepo := inmemory.NewUserRepository(logger)
 	postRepo := inmemory.NewPostRepository(logger)
-	commentRepo := inmemory.NewCommentRepository(logger)
-
-	userService := services.NewUserService(userRepo, logger)
-	postService := services.NewPostService(postRepo, userRepo, logger)
-	commentService := services.NewCommentService(commentRepo, postRepo, userRepo, logger)
+	commentRepo := inmemory.NewCommentRepository(postRepo, logger)
+	reactionRepo := inmemory.NewReactionRepository(logger)
+	notificationRepo := inmemory.NewNotificationRepository(logger)
+	followRepo := inmemory.NewFollowRepository(logger)
+	reportRepo := inmemory.NewReportRepository(logger)
+
+	userService := services.NewUserService(userRepo, testutils.CreateTestTokenManager(), logger)
+	eventBus := services.NewInProcessEventBus(services.DefaultEventBusOptions(), logger)
+	unitOfWork := inmemory.NewUnitOfWork(userRepo, postRepo, commentRepo, reactionRepo, notificationRepo, reportRepo)
+	commentService := services.NewCommentService(commentRepo, postRepo, userRepo, unitOfWork, eventBus, logger)
+	postService := services.NewPostService(postRepo, userRepo, unitOfWork, eventBus, logger)
+	reactionService := services.NewReactionService(reactionRepo, unitOfWork, eventBus, logger)
+	notificationService := services.NewNotificationService(notificationRepo, eventBus, logger)
+	followService := services.NewFollowService(followRepo, userRepo, postRepo, logger)
+	moderationService := services.NewModerationService(reportRepo, postRepo, commentRepo, userRepo, unitOfWork, eventBus, logger)
+
+	reconciler, err := inmemory.NewCounterReconciler(postRepo, commentRepo, reactionRepo, unitOfWork)
+	require.NoError(t, err)
 
 	return &TestSuite{
-		userService:    userService,
-		postService:    postService,
-		commentService: commentService,
-		logger:         logger,
+		userRepo:            userRepo,
+		postRepo:            postRepo,
+		commentRepo:         commentRepo,
+		reconciler:          reconciler,
+		userService:         userService,
+		postService:         postService,
+		commentService:      commentService,
+		reactionService:     reactionService,
+		notificationService: notificationService,
+		followService:       followService,
+		moderationService:   moderationService,
+		logger:              logger,
 	}
 }
 
@@ -54,7 +85,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, user2)
 
-	post, err := suite.postService.CreatePost(ctx, user1.ID, "Интеграционный тест", "Содержимое поста для тестирования")
+	post, err := suite.postService.CreatePost(auth.WithUserID(ctx, user1.ID), "Интеграционный тест", "Содержимое поста для тестирования")
 	require.NoError(t, err)
 	require.NotNil(t, post)
 	assert.Equal(t, user1.ID, post.AuthorID)
@@ -66,7 +97,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 	assert.Equal(t, int64(1), pagination.Total)
 	assert.False(t, pagination.HasMore)
 
-	comment1, err := suite.commentService.CreateComment(ctx, post.ID, user2.ID, "Первый комментарий", nil)
+	comment1, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user2.ID), post.ID, "Первый комментарий", nil)
 	require.NoError(t, err)
 	require.NotNil(t, comment1)
 	assert.Equal(t, post.ID, comment1.PostID)
@@ -74,19 +105,19 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 	assert.Nil(t, comment1.ParentID)
 	assert.Equal(t, 0, comment1.Level)
 
-	comment2, err := suite.commentService.CreateComment(ctx, post.ID, user1.ID, "Ответ на первый комментарий", &comment1.ID)
+	comment2, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user1.ID), post.ID, "Ответ на первый комментарий", &comment1.ID)
 	require.NoError(t, err)
 	require.NotNil(t, comment2)
 	assert.Equal(t, &comment1.ID, comment2.ParentID)
 	assert.Equal(t, 1, comment2.Level)
 
-	comments, commentPagination, err := suite.commentService.GetPostComments(ctx, post.ID, testutils.CreateTestPagination(10, 0))
+	comments, commentPagination, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
 	require.NoError(t, err)
 	assert.Len(t, comments, 1)
 	assert.Equal(t, comment1.ID, comments[0].ID)
 	assert.Equal(t, int64(1), commentPagination.Total)
 
-	replies, repliesPagination, err := suite.commentService.GetCommentReplies(ctx, comment1.ID, testutils.CreateTestPagination(10, 0))
+	replies, repliesPagination, err := suite.commentService.GetCommentReplies(ctx, comment1.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
 	require.NoError(t, err)
 	assert.Len(t, replies, 1)
 	assert.Equal(t, comment2.ID, replies[0].ID)
@@ -96,32 +127,32 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, thread, 2)
 
-	updatedComment, err := suite.commentService.UpdateComment(ctx, comment1.ID, user2.ID, "Обновленный первый комментарий")
+	updatedComment, err := suite.commentService.UpdateComment(auth.WithUserID(ctx, user2.ID), comment1.ID, "Обновленный первый комментарий")
 	require.NoError(t, err)
 	assert.Equal(t, "Обновленный первый комментарий", updatedComment.Content)
 
-	err = suite.postService.ToggleComments(ctx, post.ID, user1.ID, true)
+	err = suite.postService.ToggleComments(auth.WithUserID(ctx, user1.ID), post.ID, true)
 	require.NoError(t, err)
 
-	_, err = suite.commentService.CreateComment(ctx, post.ID, user2.ID, "Этот комментарий не должен создаться", nil)
+	_, err = suite.commentService.CreateComment(auth.WithUserID(ctx, user2.ID), post.ID, "Этот комментарий не должен создаться", nil)
 	assert.Error(t, err)
 
-	err = suite.postService.ToggleComments(ctx, post.ID, user1.ID, false)
+	err = suite.postService.ToggleComments(auth.WithUserID(ctx, user1.ID), post.ID, false)
 	require.NoError(t, err)
 
-	err = suite.commentService.DeleteComment(ctx, comment2.ID, user1.ID)
+	err = suite.commentService.DeleteComment(auth.WithUserID(ctx, user1.ID), comment2.ID)
 	require.NoError(t, err)
 
-	err = suite.commentService.DeleteComment(ctx, comment1.ID, user2.ID)
+	err = suite.commentService.DeleteComment(auth.WithUserID(ctx, user2.ID), comment1.ID)
 	require.NoError(t, err)
 
-	err = suite.postService.DeletePost(ctx, post.ID, user1.ID)
+	err = suite.postService.DeletePost(auth.WithUserID(ctx, user1.ID), post.ID)
 	require.NoError(t, err)
 
-	err = suite.userService.DeleteUser(ctx, user1.ID)
+	err = suite.userService.DeleteUser(auth.WithUserID(ctx, user1.ID), user1.ID)
 	require.NoError(t, err)
 
-	err = suite.userService.DeleteUser(ctx, user2.ID)
+	err = suite.userService.DeleteUser(auth.WithUserID(ctx, user2.ID), user2.ID)
 	require.NoError(t, err)
 }
 
@@ -132,16 +163,16 @@ func TestIntegration_CommentHierarchy(t *testing.T) {
 	user, err := suite.userService.CreateUser(ctx, "hierarchyuser", "hierarchy@example.com")
 	require.NoError(t, err)
 
-	post, err := suite.postService.CreatePost(ctx, user.ID, "Тест иерархии", "Пост для тестирования иерархии комментариев")
+	post, err := suite.postService.CreatePost(auth.WithUserID(ctx, user.ID), "Тест иерархии", "Пост для тестирования иерархии комментариев")
 	require.NoError(t, err)
 
-	level0, err := suite.commentService.CreateComment(ctx, post.ID, user.ID, "Корневой комментарий", nil)
+	level0, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user.ID), post.ID, "Корневой комментарий", nil)
 	require.NoError(t, err)
 
-	level1, err := suite.commentService.CreateComment(ctx, post.ID, user.ID, "Комментарий первого уровня", &level0.ID)
+	level1, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user.ID), post.ID, "Комментарий первого уровня", &level0.ID)
 	require.NoError(t, err)
 
-	level2, err := suite.commentService.CreateComment(ctx, post.ID, user.ID, "Комментарий второго уровня", &level1.ID)
+	level2, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user.ID), post.ID, "Комментарий второго уровня", &level1.ID)
 	require.NoError(t, err)
 
 	assert.Equal(t, 0, level0.Level)
@@ -164,19 +195,21 @@ func TestIntegration_Subscriptions(t *testing.T) {
 	user, err := suite.userService.CreateUser(ctx, "subuser", "sub@example.com")
 	require.NoError(t, err)
 
-	post, err := suite.postService.CreatePost(ctx, user.ID, "Тест подписок", "Пост для тестирования подписок")
+	post, err := suite.postService.CreatePost(auth.WithUserID(ctx, user.ID), "Тест подписок", "Пост для тестирования подписок")
 	require.NoError(t, err)
 
-	ch1 := suite.commentService.SubscribeToPost(post.ID)
-	ch2 := suite.commentService.SubscribeToPost(post.ID)
+	sub1, err := suite.commentService.SubscribeToPost(post.ID, nil)
+	require.NoError(t, err)
+	sub2, err := suite.commentService.SubscribeToPost(post.ID, nil)
+	require.NoError(t, err)
 
 	eventReceived1 := make(chan bool, 1)
 	eventReceived2 := make(chan bool, 1)
 
 	go func() {
 		select {
-		case event := <-ch1:
-			assert.Equal(t, "comment_created", event.Type)
+		case event := <-sub1.Events():
+			assert.Equal(t, services.CommentEventCreated, event.Type)
 			assert.Equal(t, post.ID, event.PostID)
 			assert.NotNil(t, event.Comment)
 			eventReceived1 <- true
@@ -187,8 +220,8 @@ func TestIntegration_Subscriptions(t *testing.T) {
 
 	go func() {
 		select {
-		case event := <-ch2:
-			assert.Equal(t, "comment_created", event.Type)
+		case event := <-sub2.Events():
+			assert.Equal(t, services.CommentEventCreated, event.Type)
 			assert.Equal(t, post.ID, event.PostID)
 			assert.NotNil(t, event.Comment)
 			eventReceived2 <- true
@@ -199,7 +232,7 @@ func TestIntegration_Subscriptions(t *testing.T) {
 
 	time.Sleep(time.Millisecond * 100)
 
-	comment, err := suite.commentService.CreateComment(ctx, post.ID, user.ID, "Комментарий для подписчиков", nil)
+	comment, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user.ID), post.ID, "Комментарий для подписчиков", nil)
 	require.NoError(t, err)
 	require.NotNil(t, comment)
 
@@ -209,8 +242,1064 @@ func TestIntegration_Subscriptions(t *testing.T) {
 	assert.True(t, received1, "Первый подписчик должен получить событие")
 	assert.True(t, received2, "Второй подписчик должен получить событие")
 
-	suite.commentService.UnsubscribeFromPost(post.ID, ch1)
-	suite.commentService.UnsubscribeFromPost(post.ID, ch2)
+	suite.commentService.UnsubscribeFromPost(sub1)
+	suite.commentService.UnsubscribeFromPost(sub2)
+}
+
+func TestIntegration_CommentLifecycleEvents(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	user, err := suite.userService.CreateUser(ctx, "lifecycleuser", "lifecycle@example.com")
+	require.NoError(t, err)
+	authCtx := auth.WithUserID(ctx, user.ID)
+
+	post, err := suite.postService.CreatePost(authCtx, "Тест событий", "Пост для тестирования событий")
+	require.NoError(t, err)
+
+	comment, err := suite.commentService.CreateComment(authCtx, post.ID, "Исходный текст", nil)
+	require.NoError(t, err)
+
+	sub, err := suite.commentService.SubscribeToPost(post.ID, nil)
+	require.NoError(t, err)
+	defer suite.commentService.UnsubscribeFromPost(sub)
+
+	_, err = suite.commentService.UpdateComment(authCtx, comment.ID, "Новый текст")
+	require.NoError(t, err)
+	require.NoError(t, suite.commentService.DeleteComment(authCtx, comment.ID))
+	require.NoError(t, suite.postService.ToggleComments(authCtx, post.ID, true))
+	require.NoError(t, suite.postService.ToggleComments(authCtx, post.ID, false))
+
+	expected := []services.CommentEventType{
+		services.CommentEventUpdated,
+		services.CommentEventDeleted,
+		services.CommentEventCommentsDisabled,
+		services.CommentEventCommentsEnabled,
+	}
+
+	for _, eventType := range expected {
+		select {
+		case event := <-sub.Events():
+			assert.Equal(t, eventType, event.Type)
+			assert.Equal(t, post.ID, event.PostID)
+			if eventType == services.CommentEventUpdated || eventType == services.CommentEventDeleted {
+				require.NotNil(t, event.Comment)
+				assert.Equal(t, comment.ID, event.Comment.ID)
+			} else {
+				assert.Nil(t, event.Comment)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Событие %s не получено", eventType)
+		}
+	}
+}
+
+func TestIntegration_Reactions(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "reactionauthor", "reactionauthor@example.com")
+	require.NoError(t, err)
+	reader, err := suite.userService.CreateUser(ctx, "reactionreader", "reactionreader@example.com")
+	require.NoError(t, err)
+	authorCtx := auth.WithUserID(ctx, author.ID)
+	readerCtx := auth.WithUserID(ctx, reader.ID)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Пост с реакциями", "Текст")
+	require.NoError(t, err)
+	comment, err := suite.commentService.CreateComment(authorCtx, post.ID, "Комментарий с реакциями", nil)
+	require.NoError(t, err)
+
+	sub, err := suite.commentService.SubscribeToPost(post.ID, nil)
+	require.NoError(t, err)
+	defer suite.commentService.UnsubscribeFromPost(sub)
+
+	_, err = suite.reactionService.React(authorCtx, entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	summaries, err := suite.reactionService.React(readerCtx, entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, entities.ReactionSummary{Kind: entities.ReactionLike, Count: 2, ViewerReacted: true}, *summaries[0])
+
+	// Повторная реакция не меняет счетчик и не порождает событие
+	summaries, err = suite.reactionService.React(readerCtx, entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), summaries[0].Count)
+
+	_, err = suite.reactionService.React(readerCtx, entities.ReactionTargetComment, comment.ID, entities.ReactionLaugh)
+	require.NoError(t, err)
+	summaries, err = suite.reactionService.Unreact(authorCtx, entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ReactionSummary{Kind: entities.ReactionLike, Count: 1}, *summaries[0])
+
+	expected := []struct {
+		eventType services.CommentEventType
+		targetID  uuid.UUID
+		count     int64
+	}{
+		{services.CommentEventReactionAdded, post.ID, 1},
+		{services.CommentEventReactionAdded, post.ID, 2},
+		{services.CommentEventReactionAdded, comment.ID, 1},
+		{services.CommentEventReactionRemoved, post.ID, 1},
+	}
+
+	for _, want := range expected {
+		select {
+		case event := <-sub.Events():
+			assert.Equal(t, want.eventType, event.Type)
+			assert.Equal(t, post.ID, event.PostID)
+			assert.Nil(t, event.Comment)
+			require.NotNil(t, event.Reaction)
+			assert.Equal(t, want.targetID, event.Reaction.TargetID)
+			assert.Equal(t, want.count, event.Reaction.Count)
+		case <-time.After(time.Second):
+			t.Fatalf("Событие %s не получено", want.eventType)
+		}
+	}
+
+	byTarget, err := suite.reactionService.GetReactionsByTargets(ctx, entities.ReactionTargetComment, []uuid.UUID{comment.ID, post.ID})
+	require.NoError(t, err)
+	require.Len(t, byTarget[comment.ID], 1)
+	assert.False(t, byTarget[comment.ID][0].ViewerReacted, "без пользователя в контексте viewerReacted не заполняется")
+	assert.Empty(t, byTarget[post.ID], "реакции поста не попадают в сводку комментариев")
+
+	// На удаленный комментарий нельзя поставить новую реакцию, но можно снять прежнюю
+	require.NoError(t, suite.commentService.DeleteComment(authorCtx, comment.ID))
+	_, err = suite.reactionService.React(readerCtx, entities.ReactionTargetComment, comment.ID, entities.ReactionWow)
+	assertAppErrorCode(t, err, appErrors.ErrCommentDeleted)
+	summaries, err = suite.reactionService.Unreact(readerCtx, entities.ReactionTargetComment, comment.ID, entities.ReactionLaugh)
+	require.NoError(t, err)
+	assert.Empty(t, summaries)
+
+	_, err = suite.reactionService.React(readerCtx, entities.ReactionTargetPost, uuid.New(), entities.ReactionLike)
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+
+	_, err = suite.reactionService.React(ctx, entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+}
+
+func TestIntegration_CommentSort(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "sortauthor", "sortauthor@example.com")
+	require.NoError(t, err)
+	reader, err := suite.userService.CreateUser(ctx, "sortreader", "sortreader@example.com")
+	require.NoError(t, err)
+	admin, err := suite.userService.CreateUser(ctx, "sortadmin", "sortadmin@example.com")
+	require.NoError(t, err)
+	admin.Role = entities.RoleAdmin
+	require.NoError(t, suite.userRepo.SetRole(ctx, admin))
+
+	authorCtx := auth.WithUserID(ctx, author.ID)
+	readerCtx := auth.WithUserID(ctx, reader.ID)
+	adminCtx := auth.WithUserID(ctx, admin.ID)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Пост с сортировкой", "Текст")
+	require.NoError(t, err)
+
+	first, err := suite.commentService.CreateComment(authorCtx, post.ID, "Первый", nil)
+	require.NoError(t, err)
+	second, err := suite.commentService.CreateComment(authorCtx, post.ID, "Второй", nil)
+	require.NoError(t, err)
+	third, err := suite.commentService.CreateComment(authorCtx, post.ID, "Третий", nil)
+	require.NoError(t, err)
+
+	firstReply, err := suite.commentService.CreateComment(readerCtx, post.ID, "Ответ 1", &second.ID)
+	require.NoError(t, err)
+	secondReply, err := suite.commentService.CreateComment(readerCtx, post.ID, "Ответ 2", &second.ID)
+	require.NoError(t, err)
+	_, err = suite.commentService.CreateComment(readerCtx, post.ID, "Ответ 3", &third.ID)
+	require.NoError(t, err)
+
+	_, err = suite.reactionService.React(authorCtx, entities.ReactionTargetComment, third.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	_, err = suite.reactionService.React(readerCtx, entities.ReactionTargetComment, third.ID, entities.ReactionLove)
+	require.NoError(t, err)
+	_, err = suite.reactionService.React(readerCtx, entities.ReactionTargetComment, first.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	// Повторная реакция не меняет счетчик
+	_, err = suite.reactionService.React(readerCtx, entities.ReactionTargetComment, first.ID, entities.ReactionLike)
+	require.NoError(t, err)
+
+	counted, err := suite.commentService.GetCommentByID(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), counted.ReplyCount)
+	counted, err = suite.commentService.GetCommentByID(ctx, third.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counted.ReplyCount)
+	assert.Equal(t, int64(2), counted.ReactionCount)
+
+	postOrder := func(sort entities.CommentSort, pagination *entities.PaginationRequest) []uuid.UUID {
+		t.Helper()
+		comments, _, err := suite.commentService.GetPostComments(ctx, post.ID, sort, pagination)
+		require.NoError(t, err)
+
+		ids := make([]uuid.UUID, 0, len(comments))
+		for _, comment := range comments {
+			ids = append(ids, comment.ID)
+		}
+		return ids
+	}
+	all := testutils.CreateTestPagination(10, 0)
+
+	assert.Equal(t, []uuid.UUID{first.ID, second.ID, third.ID}, postOrder(entities.CommentSortOldest, all))
+	assert.Equal(t, []uuid.UUID{third.ID, second.ID, first.ID}, postOrder(entities.CommentSortNewest, all))
+	assert.Equal(t, []uuid.UUID{third.ID, first.ID, second.ID}, postOrder(entities.CommentSortTop, all))
+	assert.Equal(t, []uuid.UUID{second.ID, third.ID, first.ID}, postOrder(entities.CommentSortMostReplied, all))
+	assert.Equal(t, []uuid.UUID{first.ID}, postOrder(entities.CommentSortTop, testutils.CreateTestPagination(1, 1)))
+	assert.Equal(t, []uuid.UUID{second.ID, third.ID}, postOrder(entities.CommentSortOldest, testutils.CreateTestPagination(2, 1)))
+	assert.Equal(t, []uuid.UUID{second.ID, first.ID}, postOrder(entities.CommentSortNewest, testutils.CreateTestPagination(2, 1)))
+	assert.Empty(t, postOrder(entities.CommentSortNewest, testutils.CreateTestPagination(2, 5)))
+
+	replies, _, err := suite.commentService.GetCommentReplies(ctx, second.ID, entities.CommentSortNewest, all)
+	require.NoError(t, err)
+	require.Len(t, replies, 2)
+	assert.Equal(t, secondReply.ID, replies[0].ID)
+	assert.Equal(t, firstReply.ID, replies[1].ID)
+
+	// При равных счетчиках раньше идет более старый комментарий
+	_, err = suite.reactionService.Unreact(authorCtx, entities.ReactionTargetComment, third.ID, entities.ReactionLike)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{first.ID, third.ID, second.ID}, postOrder(entities.CommentSortTop, all))
+
+	// Правка не сбрасывает счетчики, а очистка ответа уменьшает счетчик родителя
+	_, err = suite.commentService.UpdateComment(authorCtx, second.ID, "Второй, исправленный")
+	require.NoError(t, err)
+	_, err = suite.commentService.PurgeComment(adminCtx, firstReply.ID)
+	require.NoError(t, err)
+	counted, err = suite.commentService.GetCommentByID(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counted.ReplyCount)
+	assert.Equal(t, []uuid.UUID{second.ID, third.ID, first.ID}, postOrder(entities.CommentSortMostReplied, all))
+
+	_, _, err = suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSort("random"), all)
+	assertAppErrorCode(t, err, appErrors.ErrValidation)
+}
+
+func TestIntegration_Counters(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "counterauthor", "counterauthor@example.com")
+	require.NoError(t, err)
+	admin, err := suite.userService.CreateUser(ctx, "counteradmin", "counteradmin@example.com")
+	require.NoError(t, err)
+	admin.Role = entities.RoleAdmin
+	require.NoError(t, suite.userRepo.SetRole(ctx, admin))
+
+	authorCtx := auth.WithUserID(ctx, author.ID)
+	adminCtx := auth.WithUserID(ctx, admin.ID)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Пост со счетчиками", "Текст")
+	require.NoError(t, err)
+
+	root, err := suite.commentService.CreateComment(authorCtx, post.ID, "Корневой", nil)
+	require.NoError(t, err)
+	other, err := suite.commentService.CreateComment(authorCtx, post.ID, "Еще один", nil)
+	require.NoError(t, err)
+	reply, err := suite.commentService.CreateComment(authorCtx, post.ID, "Ответ", &root.ID)
+	require.NoError(t, err)
+	_, err = suite.commentService.CreateComment(authorCtx, post.ID, "Ответ на ответ", &reply.ID)
+	require.NoError(t, err)
+	_, err = suite.reactionService.React(authorCtx, entities.ReactionTargetComment, root.ID, entities.ReactionLike)
+	require.NoError(t, err)
+
+	// Ответы не входят в счетчик поста, удаление с сохранением ветки счетчики не меняет
+	err = suite.commentService.DeleteComment(authorCtx, other.ID)
+	require.NoError(t, err)
+
+	counted, err := suite.postService.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), counted.RootCommentCount)
+	_, pagination, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pagination.Total)
+
+	rootCounted, err := suite.commentService.GetCommentByID(ctx, root.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rootCounted.ReplyCount)
+
+	_, err = suite.commentService.PurgeComment(adminCtx, other.ID)
+	require.NoError(t, err)
+	counted, err = suite.postService.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counted.RootCommentCount)
+
+	report, err := suite.reconciler.ReconcileCounters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), report.Total(), "поддерживаемые счетчики совпадают с данными")
+
+	// Портим счетчики в обход сервисов: пересчет возвращает их к фактическим значениям
+	require.NoError(t, suite.postRepo.AddCommentCount(ctx, post.ID, 5))
+	require.NoError(t, suite.commentRepo.AddReplyCount(ctx, root.ID, -1))
+	require.NoError(t, suite.commentRepo.AddReactionCount(ctx, root.ID, 3))
+	require.NoError(t, suite.commentRepo.AddReactionCount(ctx, reply.ID, 1))
+
+	report, err = suite.reconciler.ReconcileCounters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, services.CounterReport{PostComments: 1, CommentReplies: 1, CommentReactions: 2}, *report)
+
+	counted, err = suite.postService.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counted.RootCommentCount)
+	rootCounted, err = suite.commentService.GetCommentByID(ctx, root.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rootCounted.ReplyCount)
+	assert.Equal(t, int64(1), rootCounted.ReactionCount)
+}
+
+func TestIntegration_Mentions(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "mentionauthor", "mentionauthor@example.com")
+	require.NoError(t, err)
+	alice, err := suite.userService.CreateUser(ctx, "alice", "alice@example.com")
+	require.NoError(t, err)
+	bob, err := suite.userService.CreateUser(ctx, "bob", "bob@example.com")
+	require.NoError(t, err)
+	admin, err := suite.userService.CreateUser(ctx, "mentionadmin", "mentionadmin@example.com")
+	require.NoError(t, err)
+	admin.Role = entities.RoleAdmin
+	require.NoError(t, suite.userRepo.SetRole(ctx, admin))
+
+	authorCtx := auth.WithUserID(ctx, author.ID)
+
+	_, err = suite.commentService.SubscribeToUserEvents(ctx)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	aliceSub, err := suite.commentService.SubscribeToUserEvents(auth.WithUserID(ctx, alice.ID))
+	require.NoError(t, err)
+	defer suite.commentService.UnsubscribeFromUserEvents(aliceSub)
+	bobSub, err := suite.commentService.SubscribeToUserEvents(auth.WithUserID(ctx, bob.ID))
+	require.NoError(t, err)
+	defer suite.commentService.UnsubscribeFromUserEvents(bobSub)
+	authorSub, err := suite.commentService.SubscribeToUserEvents(authorCtx)
+	require.NoError(t, err)
+	defer suite.commentService.UnsubscribeFromUserEvents(authorSub)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Пост с упоминаниями", "Текст")
+	require.NoError(t, err)
+
+	// Неизвестные имена пропускаются, упоминание самого себя сохраняется без оповещения
+	comment, err := suite.commentService.CreateComment(authorCtx, post.ID, "@alice, глянь; @nobody и @mentionauthor тоже", nil)
+	require.NoError(t, err)
+
+	event := <-aliceSub.Events()
+	assert.Equal(t, services.UserEventMentioned, event.Type)
+	assert.Equal(t, comment.ID, event.Comment.ID)
+	assert.Len(t, bobSub.Events(), 0)
+
+	assert.Len(t, authorSub.Events(), 0)
+
+	mentions, err := suite.commentService.GetMentionsByCommentIDs(ctx, []uuid.UUID{comment.ID})
+	require.NoError(t, err)
+	require.Len(t, mentions[comment.ID], 2)
+	assert.Equal(t, alice.ID, mentions[comment.ID][0].ID)
+	assert.Equal(t, author.ID, mentions[comment.ID][1].ID)
+
+	// Правка оповещает только новых упомянутых, порядок остается порядком первого упоминания
+	_, err = suite.commentService.UpdateComment(authorCtx, comment.ID, "@bob и снова @alice")
+	require.NoError(t, err)
+
+	event = <-bobSub.Events()
+	assert.Equal(t, comment.ID, event.Comment.ID)
+	assert.Len(t, aliceSub.Events(), 0)
+
+	mentions, err = suite.commentService.GetMentionsByCommentIDs(ctx, []uuid.UUID{comment.ID})
+	require.NoError(t, err)
+	require.Len(t, mentions[comment.ID], 2)
+	assert.Equal(t, alice.ID, mentions[comment.ID][0].ID)
+	assert.Equal(t, bob.ID, mentions[comment.ID][1].ID)
+
+	_, err = suite.commentService.UpdateComment(authorCtx, comment.ID, "@bob, без Алисы")
+	require.NoError(t, err)
+
+	mentions, err = suite.commentService.GetMentionsByCommentIDs(ctx, []uuid.UUID{comment.ID})
+	require.NoError(t, err)
+	require.Len(t, mentions[comment.ID], 1)
+	assert.Equal(t, bob.ID, mentions[comment.ID][0].ID)
+
+	_, err = suite.commentService.PurgeComment(auth.WithUserID(ctx, admin.ID), comment.ID)
+	require.NoError(t, err)
+
+	mentions, err = suite.commentService.GetMentionsByCommentIDs(ctx, []uuid.UUID{comment.ID})
+	require.NoError(t, err)
+	assert.Empty(t, mentions[comment.ID])
+}
+
+func TestIntegration_Notifications(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "inboxauthor", "inboxauthor@example.com")
+	require.NoError(t, err)
+	commenter, err := suite.userService.CreateUser(ctx, "inboxcommenter", "inboxcommenter@example.com")
+	require.NoError(t, err)
+	replier, err := suite.userService.CreateUser(ctx, "inboxreplier", "inboxreplier@example.com")
+	require.NoError(t, err)
+
+	authorCtx := auth.WithUserID(ctx, author.ID)
+	commenterCtx := auth.WithUserID(ctx, commenter.ID)
+	replierCtx := auth.WithUserID(ctx, replier.ID)
+
+	_, _, err = suite.notificationService.GetNotificationsPage(ctx, false, &entities.CursorRequest{Limit: 10})
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+	_, err = suite.notificationService.MarkNotificationsRead(ctx, nil)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+	_, err = suite.notificationService.SubscribeToNotifications(ctx)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	authorSub, err := suite.notificationService.SubscribeToNotifications(authorCtx)
+	require.NoError(t, err)
+	defer suite.notificationService.Unsubscribe(authorSub)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Пост для оповещений", "Текст")
+	require.NoError(t, err)
+
+	// Собственный комментарий автора поста оповещений не создает
+	_, err = suite.commentService.CreateComment(authorCtx, post.ID, "Свой комментарий", nil)
+	require.NoError(t, err)
+
+	root, err := suite.commentService.CreateComment(commenterCtx, post.ID, "Комментарий", nil)
+	require.NoError(t, err)
+
+	event := <-authorSub.Events()
+	assert.Equal(t, services.UserEventNotification, event.Type)
+	assert.Equal(t, entities.NotificationPostComment, event.Notification.Type)
+	assert.Equal(t, root.ID, event.Notification.CommentID)
+	assert.Len(t, authorSub.Events(), 0)
+
+	reply, err := suite.commentService.CreateComment(replierCtx, post.ID, "Ответ", &root.ID)
+	require.NoError(t, err)
+	<-authorSub.Events()
+
+	commenterInbox, _, err := suite.notificationService.GetNotificationsPage(commenterCtx, false, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, commenterInbox, 1)
+	assert.Equal(t, entities.NotificationCommentReply, commenterInbox[0].Type)
+	assert.Equal(t, replier.ID, commenterInbox[0].ActorID)
+	assert.Equal(t, reply.ID, commenterInbox[0].CommentID)
+
+	replierInbox, _, err := suite.notificationService.GetNotificationsPage(replierCtx, false, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, replierInbox)
+
+	// Входящие автора: новые сначала, постранично
+	first, pageInfo, err := suite.notificationService.GetNotificationsPage(authorCtx, false, &entities.CursorRequest{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.True(t, pageInfo.HasNextPage)
+	assert.Equal(t, reply.ID, first[0].CommentID)
+
+	after := first[0].Cursor()
+	second, pageInfo, err := suite.notificationService.GetNotificationsPage(authorCtx, false, &entities.CursorRequest{Limit: 1, After: &after})
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.False(t, pageInfo.HasNextPage)
+	assert.Equal(t, root.ID, second[0].CommentID)
+
+	// Чужие оповещения не отмечаются
+	marked, err := suite.notificationService.MarkNotificationsRead(authorCtx, []uuid.UUID{commenterInbox[0].ID})
+	require.NoError(t, err)
+	assert.Zero(t, marked)
+
+	marked, err = suite.notificationService.MarkNotificationsRead(authorCtx, []uuid.UUID{second[0].ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+
+	unread, _, err := suite.notificationService.GetNotificationsPage(authorCtx, true, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, unread, 1)
+	assert.Equal(t, first[0].ID, unread[0].ID)
+
+	all, _, err := suite.notificationService.GetNotificationsPage(authorCtx, false, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.False(t, all[0].IsRead())
+	assert.True(t, all[1].IsRead())
+
+	marked, err = suite.notificationService.MarkNotificationsRead(authorCtx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+
+	unread, _, err = suite.notificationService.GetNotificationsPage(authorCtx, true, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, unread)
+
+	marked, err = suite.notificationService.MarkNotificationsRead(authorCtx, nil)
+	require.NoError(t, err)
+	assert.Zero(t, marked)
+}
+
+func TestIntegration_FollowsAndFeed(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	reader, err := suite.userService.CreateUser(ctx, "feedreader", "feedreader@example.com")
+	require.NoError(t, err)
+	alice, err := suite.userService.CreateUser(ctx, "feedalice", "feedalice@example.com")
+	require.NoError(t, err)
+	bob, err := suite.userService.CreateUser(ctx, "feedbob", "feedbob@example.com")
+	require.NoError(t, err)
+	stranger, err := suite.userService.CreateUser(ctx, "feedstranger", "feedstranger@example.com")
+	require.NoError(t, err)
+
+	readerCtx := auth.WithUserID(ctx, reader.ID)
+
+	// Посты авторов перемежаются, чтобы лента сливала их в общий порядок
+	var expected []uuid.UUID
+	for i := 0; i < 3; i++ {
+		for _, author := range []*entities.User{alice, bob, stranger} {
+			post, err := suite.postService.CreatePost(auth.WithUserID(ctx, author.ID), fmt.Sprintf("Пост %s %d", author.Username, i), "Текст")
+			require.NoError(t, err)
+			if author.ID != stranger.ID {
+				expected = append([]uuid.UUID{post.ID}, expected...)
+			}
+		}
+	}
+
+	feed, _, err := suite.followService.GetFeedPage(readerCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, feed, "без подписок лента пуста")
+
+	for _, followee := range []*entities.User{alice, bob, alice} {
+		user, err := suite.followService.Follow(readerCtx, followee.ID)
+		require.NoError(t, err)
+		assert.Equal(t, followee.ID, user.ID)
+	}
+
+	_, err = suite.followService.Follow(readerCtx, reader.ID)
+	assertAppErrorCode(t, err, appErrors.ErrValidation)
+	_, err = suite.followService.Follow(readerCtx, uuid.New())
+	assertAppErrorCode(t, err, appErrors.ErrUserNotFound)
+	_, err = suite.followService.Follow(ctx, alice.ID)
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+	_, _, err = suite.followService.GetFeedPage(ctx, &entities.CursorRequest{Limit: 10})
+	assertAppErrorCode(t, err, appErrors.ErrUnauthorized)
+
+	// Лента постранично: посты подписок новые сначала, без постов остальных авторов
+	var ids []uuid.UUID
+	req := &entities.CursorRequest{Limit: 4}
+	for {
+		page, pageInfo, err := suite.followService.GetFeedPage(readerCtx, req)
+		require.NoError(t, err)
+		for _, post := range page {
+			ids = append(ids, post.ID)
+		}
+		if !pageInfo.HasNextPage {
+			break
+		}
+		after := page[len(page)-1].Cursor()
+		req = &entities.CursorRequest{Limit: 4, After: &after}
+	}
+	assert.Equal(t, expected, ids)
+
+	// Списки подписчиков и подписок, недавние сначала
+	following, _, err := suite.followService.GetFollowingPage(ctx, reader.ID, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, following, 2)
+	assert.Equal(t, bob.ID, following[0].Followee.ID)
+	assert.Equal(t, alice.ID, following[1].Followee.ID)
+
+	followers, _, err := suite.followService.GetFollowersPage(ctx, alice.ID, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, followers, 1)
+	assert.Equal(t, reader.ID, followers[0].Follower.ID)
+
+	_, err = suite.followService.Unfollow(readerCtx, bob.ID)
+	require.NoError(t, err)
+	_, err = suite.followService.Unfollow(readerCtx, bob.ID)
+	require.NoError(t, err)
+
+	feed, _, err = suite.followService.GetFeedPage(readerCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, feed, 3)
+	for _, post := range feed {
+		assert.Equal(t, alice.ID, post.AuthorID)
+	}
+
+	followers, _, err = suite.followService.GetFollowersPage(ctx, bob.ID, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, followers)
+}
+
+func TestIntegration_Moderation(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	registered, err := suite.userService.Register(ctx, "spammer", "spammer@example.com", "spam-password")
+	require.NoError(t, err)
+	spammer := registered.User
+	spammerCtx := auth.WithUserID(ctx, spammer.ID)
+
+	moderator, err := suite.userService.CreateUser(ctx, "reportmod", "reportmod@example.com")
+	require.NoError(t, err)
+	moderator.Role = entities.RoleModerator
+	require.NoError(t, suite.userRepo.SetRole(ctx, moderator))
+	moderatorCtx := auth.WithUserID(ctx, moderator.ID)
+
+	var readerCtxs []context.Context
+	for i := 0; i < 2; i++ {
+		reader, err := suite.userService.CreateUser(ctx, fmt.Sprintf("reportreader%d", i), fmt.Sprintf("reportreader%d@example.com", i))
+		require.NoError(t, err)
+		readerCtxs = append(readerCtxs, auth.WithUserID(ctx, reader.ID))
+	}
+
+	post, err := suite.postService.CreatePost(spammerCtx, "Выгодное предложение", "Текст")
+	require.NoError(t, err)
+	comment, err := suite.commentService.CreateComment(spammerCtx, post.ID, "Переходите по ссылке", nil)
+	require.NoError(t, err)
+
+	// Пост попадает в очередь раньше комментария и остается в ней первым
+	created, err := suite.moderationService.Report(readerCtxs[0], entities.ReportTargetPost, post.ID, entities.ReportSpam)
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = suite.moderationService.Report(readerCtxs[0], entities.ReportTargetPost, post.ID, entities.ReportOther)
+	require.NoError(t, err)
+	assert.False(t, created, "повторная жалоба не создается")
+
+	for i, reason := range []entities.ReportReason{entities.ReportSpam, entities.ReportHarassment} {
+		created, err := suite.moderationService.Report(readerCtxs[i], entities.ReportTargetComment, comment.ID, reason)
+		require.NoError(t, err)
+		assert.True(t, created)
+	}
+
+	_, err = suite.moderationService.Report(spammerCtx, entities.ReportTargetComment, comment.ID, entities.ReportSpam)
+	assertAppErrorCode(t, err, appErrors.ErrValidation)
+
+	_, _, err = suite.moderationService.GetModerationQueuePage(readerCtxs[0], &entities.CursorRequest{Limit: 10})
+	assertAppErrorCode(t, err, appErrors.ErrForbidden)
+	_, err = suite.moderationService.ResolveReports(readerCtxs[0], entities.ReportTargetComment, comment.ID, entities.ModerationDismiss, nil)
+	assertAppErrorCode(t, err, appErrors.ErrForbidden)
+
+	queue, pageInfo, err := suite.moderationService.GetModerationQueuePage(moderatorCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.False(t, pageInfo.HasNextPage)
+	require.Len(t, queue, 2)
+	assert.Equal(t, post.ID, queue[0].TargetID)
+	assert.Equal(t, int64(1), queue[0].ReportCount)
+	assert.Equal(t, comment.ID, queue[1].TargetID)
+	assert.Equal(t, int64(2), queue[1].ReportCount)
+	assert.Equal(t, spammer.ID, queue[1].AuthorID)
+	assert.Len(t, queue[1].Reasons, 2)
+
+	// Блокировка автора скрывает комментарий, закрывает все жалобы на него и отзывает доступ
+	note := "рассылка ссылок"
+	entry, err := suite.moderationService.ResolveReports(moderatorCtx, entities.ReportTargetComment, comment.ID, entities.ModerationBanAuthor, &note)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), entry.ReportsResolved)
+
+	hidden, err := suite.commentService.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	assert.True(t, hidden.IsDeleted())
+
+	_, err = suite.moderationService.ResolveReports(moderatorCtx, entities.ReportTargetComment, comment.ID, entities.ModerationDismiss, nil)
+	assertAppErrorCode(t, err, appErrors.ErrInvalidRequest)
+
+	_, err = suite.userService.Authenticate(ctx, registered.Token)
+	assertAppErrorCode(t, err, appErrors.ErrUserBanned)
+	_, err = suite.userService.Login(ctx, "spammer", "spam-password")
+	assertAppErrorCode(t, err, appErrors.ErrUserBanned)
+
+	// Сохранение профиля из устаревшей копии не снимает блокировку
+	require.NoError(t, suite.userRepo.Update(ctx, spammer))
+	_, err = suite.userService.Authenticate(ctx, registered.Token)
+	assertAppErrorCode(t, err, appErrors.ErrUserBanned)
+
+	queue, _, err = suite.moderationService.GetModerationQueuePage(moderatorCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, post.ID, queue[0].TargetID)
+
+	_, err = suite.moderationService.ResolveReports(moderatorCtx, entities.ReportTargetPost, post.ID, entities.ModerationDismiss, nil)
+	require.NoError(t, err)
+	_, err = suite.postService.GetPostByID(ctx, post.ID)
+	require.NoError(t, err, "отклонение жалоб не скрывает пост")
+
+	queue, _, err = suite.moderationService.GetModerationQueuePage(moderatorCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+
+	log, _, err := suite.moderationService.GetAuditLogPage(moderatorCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Equal(t, entities.ModerationDismiss, log[0].Action)
+	assert.Equal(t, entities.ModerationBanAuthor, log[1].Action)
+	assert.Equal(t, moderator.ID, log[1].ModeratorID)
+	assert.Equal(t, comment.ID, log[1].TargetID)
+	require.NotNil(t, log[1].Note)
+	assert.Equal(t, note, *log[1].Note)
+}
+
+func TestIntegration_ModerationHidePost(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "hiddenauthor", "hiddenauthor@example.com")
+	require.NoError(t, err)
+	reader, err := suite.userService.CreateUser(ctx, "hiddenreader", "hiddenreader@example.com")
+	require.NoError(t, err)
+	moderator, err := suite.userService.CreateUser(ctx, "hiddenmod", "hiddenmod@example.com")
+	require.NoError(t, err)
+	moderator.Role = entities.RoleModerator
+	require.NoError(t, suite.userRepo.SetRole(ctx, moderator))
+
+	authorCtx := auth.WithUserID(ctx, author.ID)
+	readerCtx := auth.WithUserID(ctx, reader.ID)
+	moderatorCtx := auth.WithUserID(ctx, moderator.ID)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Спорный пост", "Текст")
+	require.NoError(t, err)
+	root, err := suite.commentService.CreateComment(readerCtx, post.ID, "Комментарий читателя", nil)
+	require.NoError(t, err)
+	reply, err := suite.commentService.CreateComment(authorCtx, post.ID, "Ответ автора", &root.ID)
+	require.NoError(t, err)
+
+	_, err = suite.moderationService.Report(readerCtx, entities.ReportTargetPost, post.ID, entities.ReportMisinformation)
+	require.NoError(t, err)
+	_, err = suite.moderationService.ResolveReports(moderatorCtx, entities.ReportTargetPost, post.ID, entities.ModerationHideContent, nil)
+	require.NoError(t, err)
+
+	// Скрытый пост пропадает из выдачи и недоступен даже автору
+	_, err = suite.postService.GetPostByID(ctx, post.ID)
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+	page, _, err := suite.postService.GetPostsPage(ctx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page)
+	_, _, err = suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+	_, err = suite.commentService.CreateComment(readerCtx, post.ID, "Комментарий к скрытому посту", nil)
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+	_, err = suite.postService.UpdatePost(authorCtx, post.ID, "Новый заголовок", "Текст")
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+
+	// Скрытие, в отличие от удаления, сохраняет комментарии поста
+	for _, comment := range []*entities.Comment{root, reply} {
+		stored, err := suite.commentRepo.GetByID(ctx, comment.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored, "комментарии скрытого поста сохраняются")
+		assert.False(t, stored.IsDeleted())
+	}
+
+	// Но читать и искать их нельзя, пока пост скрыт
+	pagination := testutils.CreateTestPagination(10, 0)
+	_, err = suite.commentService.GetCommentByID(ctx, reply.ID)
+	assertAppErrorCode(t, err, appErrors.ErrCommentNotFound)
+	_, err = suite.commentService.GetCommentThread(ctx, reply.ID, 5)
+	assertAppErrorCode(t, err, appErrors.ErrCommentNotFound)
+	_, err = suite.commentService.GetCommentTree(ctx, root.ID, entities.NewCommentTreeRequest(1, nil))
+	assertAppErrorCode(t, err, appErrors.ErrCommentNotFound)
+	_, _, err = suite.commentService.GetCommentReplies(ctx, root.ID, entities.CommentSortOldest, pagination)
+	assertAppErrorCode(t, err, appErrors.ErrCommentNotFound)
+	found, _, err := suite.commentService.SearchComments(ctx, "читателя", nil, pagination)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	_, err = suite.moderationService.RestorePost(readerCtx, post.ID, nil)
+	assertAppErrorCode(t, err, appErrors.ErrForbidden)
+
+	entry, err := suite.moderationService.RestorePost(moderatorCtx, post.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ModerationRestoreContent, entry.Action)
+
+	_, err = suite.moderationService.RestorePost(moderatorCtx, post.ID, nil)
+	assertAppErrorCode(t, err, appErrors.ErrInvalidRequest)
+
+	restored, err := suite.postService.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), restored.RootCommentCount)
+
+	comments, _, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, root.ID, comments[0].ID)
+	assert.Equal(t, int64(1), comments[0].ReplyCount)
+
+	found, _, err = suite.commentService.SearchComments(ctx, "читателя", nil, pagination)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, root.ID, found[0].Comment.ID)
+	_, err = suite.commentService.GetCommentByID(ctx, reply.ID)
+	require.NoError(t, err)
+
+	log, _, err := suite.moderationService.GetAuditLogPage(moderatorCtx, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Equal(t, entities.ModerationRestoreContent, log[0].Action)
+	assert.Equal(t, entities.ModerationHideContent, log[1].Action)
+}
+
+func TestIntegration_Authentication(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	registered, err := suite.userService.Register(ctx, "authuser", "auth@example.com", "secret-password")
+	require.NoError(t, err)
+	require.NotEmpty(t, registered.Token)
+
+	loggedIn, err := suite.userService.Login(ctx, "authuser", "secret-password")
+	require.NoError(t, err)
+	assert.Equal(t, registered.User.ID, loggedIn.User.ID)
+
+	_, err = suite.userService.Login(ctx, "authuser", "wrong-password")
+	assert.Error(t, err)
+
+	actorID, err := suite.userService.Authenticate(ctx, loggedIn.Token)
+	require.NoError(t, err)
+	assert.Equal(t, registered.User.ID, actorID)
+
+	post, err := suite.postService.CreatePost(auth.WithUserID(ctx, actorID), "Пост с токеном", "Содержимое")
+	require.NoError(t, err)
+	assert.Equal(t, registered.User.ID, post.AuthorID)
+
+	_, err = suite.postService.CreatePost(ctx, "Анонимный пост", "Содержимое")
+	require.Error(t, err)
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrUnauthorized, appErr.Code)
+}
+
+func TestIntegration_CommentTree(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	user, err := suite.userService.CreateUser(ctx, "treeuser", "tree@example.com")
+	require.NoError(t, err)
+	userCtx := auth.WithUserID(ctx, user.ID)
+
+	post, err := suite.postService.CreatePost(userCtx, "Пост для дерева", "Содержимое")
+	require.NoError(t, err)
+
+	root, err := suite.commentService.CreateComment(userCtx, post.ID, "Корень", nil)
+	require.NoError(t, err)
+
+	var replies []*entities.Comment
+	for i := 0; i < 4; i++ {
+		reply, err := suite.commentService.CreateComment(userCtx, post.ID, fmt.Sprintf("Ответ %d", i+1), &root.ID)
+		require.NoError(t, err)
+		replies = append(replies, reply)
+	}
+	for i := 0; i < 3; i++ {
+		_, err := suite.commentService.CreateComment(userCtx, post.ID, fmt.Sprintf("Ответ на ответ %d", i+1), &replies[0].ID)
+		require.NoError(t, err)
+	}
+
+	tree, err := suite.commentService.GetCommentTree(ctx, root.ID, entities.NewCommentTreeRequest(5, []int{3, 2}))
+	require.NoError(t, err)
+
+	assert.Equal(t, root.ID, tree.Comment.ID)
+	assert.Equal(t, 4, tree.ChildCount)
+	assert.True(t, tree.HasMoreChildren)
+	require.Len(t, tree.Children, 3)
+	assert.Equal(t, replies[0].ID, tree.Children[0].Comment.ID)
+	assert.Equal(t, user.ID, tree.Children[0].Comment.AuthorID)
+
+	first := tree.Children[0]
+	assert.Equal(t, 3, first.ChildCount)
+	assert.True(t, first.HasMoreChildren)
+	require.Len(t, first.Children, 2)
+	assert.Equal(t, 0, first.Children[0].ChildCount)
+	assert.False(t, first.Children[0].HasMoreChildren)
+
+	shallow, err := suite.commentService.GetCommentTree(ctx, root.ID, entities.NewCommentTreeRequest(1, nil))
+	require.NoError(t, err)
+	require.Len(t, shallow.Children, 4)
+	assert.Empty(t, shallow.Children[0].Children)
+	assert.True(t, shallow.Children[0].HasMoreChildren)
+}
+
+func TestIntegration_CommentSoftDelete(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "softauthor", "soft@example.com")
+	require.NoError(t, err)
+	replier, err := suite.userService.CreateUser(ctx, "softreplier", "replier@example.com")
+	require.NoError(t, err)
+	admin, err := suite.userService.CreateUser(ctx, "softadmin", "admin@example.com")
+	require.NoError(t, err)
+	admin.Role = entities.RoleAdmin
+	require.NoError(t, suite.userRepo.SetRole(ctx, admin))
+
+	authorCtx := auth.WithUserID(ctx, author.ID)
+	replierCtx := auth.WithUserID(ctx, replier.ID)
+	adminCtx := auth.WithUserID(ctx, admin.ID)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Пост", "Содержимое")
+	require.NoError(t, err)
+	root, err := suite.commentService.CreateComment(authorCtx, post.ID, "Исходный текст", nil)
+	require.NoError(t, err)
+	reply, err := suite.commentService.CreateComment(replierCtx, post.ID, "Ответ", &root.ID)
+	require.NoError(t, err)
+	nested, err := suite.commentService.CreateComment(authorCtx, post.ID, "Ответ на ответ", &reply.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, suite.commentService.DeleteComment(authorCtx, root.ID))
+
+	deleted, err := suite.commentService.GetCommentByID(ctx, root.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted.IsDeleted())
+	assert.Equal(t, entities.DeletedCommentPlaceholder, deleted.Content)
+	assert.Equal(t, author.ID, *deleted.DeletedBy)
+
+	replies, _, err := suite.commentService.GetCommentReplies(ctx, root.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	require.Len(t, replies, 1)
+	assert.Equal(t, reply.ID, replies[0].ID)
+
+	err = suite.commentService.DeleteComment(authorCtx, root.ID)
+	assertAppErrorCode(t, err, appErrors.ErrCommentDeleted)
+
+	_, err = suite.commentService.UpdateComment(authorCtx, root.ID, "Восстановленный текст")
+	assertAppErrorCode(t, err, appErrors.ErrCommentDeleted)
+
+	_, err = suite.commentService.CreateComment(replierCtx, post.ID, "Ответ на удаленный", &root.ID)
+	assertAppErrorCode(t, err, appErrors.ErrCommentDeleted)
+
+	_, err = suite.commentService.PurgeComment(authorCtx, root.ID)
+	assertAppErrorCode(t, err, appErrors.ErrCommentAccessDenied)
+
+	purged, err := suite.commentService.PurgeComment(adminCtx, root.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+
+	for _, id := range []uuid.UUID{root.ID, reply.ID, nested.ID} {
+		_, err := suite.commentService.GetCommentByID(ctx, id)
+		assertAppErrorCode(t, err, appErrors.ErrCommentNotFound)
+	}
+
+	remaining, _, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestIntegration_EditHistory(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	author, err := suite.userService.CreateUser(ctx, "historyauthor", "history@example.com")
+	require.NoError(t, err)
+	moderator, err := suite.userService.CreateUser(ctx, "historymod", "historymod@example.com")
+	require.NoError(t, err)
+	moderator.Role = entities.RoleModerator
+	require.NoError(t, suite.userRepo.SetRole(ctx, moderator))
+	reader, err := suite.userService.CreateUser(ctx, "historyreader", "reader@example.com")
+	require.NoError(t, err)
+
+	authorCtx := auth.WithUserID(ctx, author.ID)
+	moderatorCtx := auth.WithUserID(ctx, moderator.ID)
+
+	post, err := suite.postService.CreatePost(authorCtx, "Первый заголовок", "Первый текст")
+	require.NoError(t, err)
+	assert.False(t, post.IsEdited())
+
+	edited, err := suite.postService.UpdatePost(authorCtx, post.ID, "Второй заголовок", "Второй текст")
+	require.NoError(t, err)
+	assert.Equal(t, 1, edited.EditCount)
+
+	postRevisions, err := suite.postService.GetPostRevisions(authorCtx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, postRevisions, 1)
+	assert.Equal(t, "Первый заголовок", postRevisions[0].Title)
+	assert.Equal(t, author.ID, postRevisions[0].EditorID)
+
+	comment, err := suite.commentService.CreateComment(authorCtx, post.ID, "Версия 0", nil)
+	require.NoError(t, err)
+	_, err = suite.commentService.UpdateComment(authorCtx, comment.ID, "Версия 1")
+	require.NoError(t, err)
+	_, err = suite.commentService.UpdateComment(moderatorCtx, comment.ID, "Версия 2")
+	require.NoError(t, err)
+
+	current, err := suite.commentService.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Версия 2", current.Content)
+	assert.Equal(t, 2, current.EditCount)
+	assert.Equal(t, moderator.ID, *current.EditedBy)
+
+	commentRevisions, err := suite.commentService.GetCommentRevisions(moderatorCtx, comment.ID)
+	require.NoError(t, err)
+	require.Len(t, commentRevisions, 2)
+	assert.Equal(t, 0, commentRevisions[0].Version)
+	assert.Equal(t, "Версия 0", commentRevisions[0].Content)
+	assert.Equal(t, 1, commentRevisions[1].Version)
+	assert.Equal(t, "Версия 1", commentRevisions[1].Content)
+	assert.Equal(t, author.ID, commentRevisions[1].EditorID)
+
+	_, err = suite.commentService.GetCommentRevisions(auth.WithUserID(ctx, reader.ID), comment.ID)
+	assertAppErrorCode(t, err, appErrors.ErrCommentAccessDenied)
+}
+
+func TestIntegration_Search(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	user, err := suite.userService.CreateUser(ctx, "searchuser", "search@example.com")
+	require.NoError(t, err)
+	userCtx := auth.WithUserID(ctx, user.ID)
+
+	titleMatch, err := suite.postService.CreatePost(userCtx, "Подписки GraphQL", "Как устроены подписки в нашем сервисе")
+	require.NoError(t, err)
+	contentMatch, err := suite.postService.CreatePost(userCtx, "Заметки", "Немного про GraphQL и про кэширование")
+	require.NoError(t, err)
+	_, err = suite.postService.CreatePost(userCtx, "Рецепты", "Борщ и пельмени")
+	require.NoError(t, err)
+
+	pagination := testutils.CreateTestPagination(10, 0)
+
+	posts, postPagination, err := suite.postService.SearchPosts(ctx, "graphql", pagination)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	assert.Equal(t, int64(2), postPagination.Total)
+	assert.Equal(t, titleMatch.ID, posts[0].Post.ID, "совпадение в заголовке должно быть релевантнее")
+	assert.Equal(t, "Подписки <mark>GraphQL</mark>", posts[0].TitleHighlight)
+	assert.Contains(t, posts[1].Snippet, "<mark>GraphQL</mark>")
+	assert.Equal(t, user.ID, posts[1].Post.AuthorID)
+
+	posts, _, err = suite.postService.SearchPosts(ctx, "graphql кэширование", pagination)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, contentMatch.ID, posts[0].Post.ID)
+
+	_, err = suite.postService.UpdatePost(userCtx, contentMatch.ID, "Заметки", "Теперь только про кэширование")
+	require.NoError(t, err)
+	posts, _, err = suite.postService.SearchPosts(ctx, "graphql", pagination)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+
+	first, err := suite.commentService.CreateComment(userCtx, titleMatch.ID, "Отличная статья про подписки", nil)
+	require.NoError(t, err)
+	_, err = suite.commentService.CreateComment(userCtx, contentMatch.ID, "А подписки тут при чем?", nil)
+	require.NoError(t, err)
+
+	comments, _, err := suite.commentService.SearchComments(ctx, "подписки", nil, pagination)
+	require.NoError(t, err)
+	assert.Len(t, comments, 2)
+
+	comments, _, err = suite.commentService.SearchComments(ctx, "подписки", &titleMatch.ID, pagination)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, first.ID, comments[0].Comment.ID)
+	assert.Equal(t, "Отличная статья про <mark>подписки</mark>", comments[0].Snippet)
+
+	require.NoError(t, suite.commentService.DeleteComment(userCtx, first.ID))
+	comments, _, err = suite.commentService.SearchComments(ctx, "подписки", &titleMatch.ID, pagination)
+	require.NoError(t, err)
+	assert.Empty(t, comments, "удаленные комментарии не ищутся")
+
+	_, _, err = suite.commentService.SearchComments(ctx, "подписки", &user.ID, pagination)
+	assertAppErrorCode(t, err, appErrors.ErrPostNotFound)
+}
+
+func assertAppErrorCode(t *testing.T, err error, code appErrors.ErrorCode) {
+	t.Helper()
+
+	require.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "ожидалась ошибка приложения, получено: %v", err)
+	assert.Equal(t, code, appErr.Code)
 }
 
 func TestIntegration_ValidationAndErrors(t *testing.T) {
@@ -237,7 +1326,7 @@ func TestIntegration_ValidationAndErrors(t *testing.T) {
 		user, err := suite.userService.CreateUser(ctx, "commenter", "commenter@example.com")
 		require.NoError(t, err)
 
-		_, err = suite.commentService.CreateComment(ctx, uuid.New(), user.ID, "Комментарий", nil)
+		_, err = suite.commentService.CreateComment(auth.WithUserID(ctx, user.ID), uuid.New(), "Комментарий", nil)
 		assert.Error(t, err)
 	})
 
@@ -248,25 +1337,25 @@ func TestIntegration_ValidationAndErrors(t *testing.T) {
 		user2, err := suite.userService.CreateUser(ctx, "other", "other@example.com")
 		require.NoError(t, err)
 
-		post, err := suite.postService.CreatePost(ctx, user1.ID, "Чужой пост", "Содержимое")
+		post, err := suite.postService.CreatePost(auth.WithUserID(ctx, user1.ID), "Чужой пост", "Содержимое")
 		require.NoError(t, err)
 
-		comment, err := suite.commentService.CreateComment(ctx, post.ID, user1.ID, "Мой комментарий", nil)
+		comment, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user1.ID), post.ID, "Мой комментарий", nil)
 		require.NoError(t, err)
 
-		err = suite.postService.ToggleComments(ctx, post.ID, user2.ID, true)
+		err = suite.postService.ToggleComments(auth.WithUserID(ctx, user2.ID), post.ID, true)
 		assert.Error(t, err)
 
-		_, err = suite.postService.UpdatePost(ctx, post.ID, user2.ID, "Новый заголовок", "Новое содержимое")
+		_, err = suite.postService.UpdatePost(auth.WithUserID(ctx, user2.ID), post.ID, "Новый заголовок", "Новое содержимое")
 		assert.Error(t, err)
 
-		err = suite.postService.DeletePost(ctx, post.ID, user2.ID)
+		err = suite.postService.DeletePost(auth.WithUserID(ctx, user2.ID), post.ID)
 		assert.Error(t, err)
 
-		_, err = suite.commentService.UpdateComment(ctx, comment.ID, user2.ID, "Новое содержимое")
+		_, err = suite.commentService.UpdateComment(auth.WithUserID(ctx, user2.ID), comment.ID, "Новое содержимое")
 		assert.Error(t, err)
 
-		err = suite.commentService.DeleteComment(ctx, comment.ID, user2.ID)
+		err = suite.commentService.DeleteComment(auth.WithUserID(ctx, user2.ID), comment.ID)
 		assert.Error(t, err)
 	})
 }
@@ -280,7 +1369,7 @@ func TestIntegration_Pagination(t *testing.T) {
 
 	var posts []*entities.Post
 	for i := 0; i < 25; i++ {
-		post, err := suite.postService.CreatePost(ctx, user.ID,
+		post, err := suite.postService.CreatePost(auth.WithUserID(ctx, user.ID),
 			fmt.Sprintf("Пост %d", i+1),
 			fmt.Sprintf("Содержимое поста %d", i+1))
 		require.NoError(t, err)
@@ -308,25 +1397,113 @@ func TestIntegration_Pagination(t *testing.T) {
 	post := posts[0]
 	var comments []*entities.Comment
 	for i := 0; i < 15; i++ {
-		comment, err := suite.commentService.CreateComment(ctx, post.ID, user.ID,
+		comment, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user.ID), post.ID,
 			fmt.Sprintf("Комментарий %d", i+1), nil)
 		require.NoError(t, err)
 		comments = append(comments, comment)
 	}
 
-	commentsPage1, commentsPagination1, err := suite.commentService.GetPostComments(ctx, post.ID, testutils.CreateTestPagination(5, 0))
+	commentsPage1, commentsPagination1, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(5, 0))
 	require.NoError(t, err)
 	assert.Len(t, commentsPage1, 5)
 	assert.Equal(t, int64(15), commentsPagination1.Total)
 	assert.True(t, commentsPagination1.HasMore)
 
-	commentsPage3, commentsPagination3, err := suite.commentService.GetPostComments(ctx, post.ID, testutils.CreateTestPagination(5, 10))
+	commentsPage3, commentsPagination3, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(5, 10))
 	require.NoError(t, err)
 	assert.Len(t, commentsPage3, 5)
 	assert.Equal(t, int64(15), commentsPagination3.Total)
 	assert.False(t, commentsPagination3.HasMore)
 }
 
+func TestIntegration_CursorPagination(t *testing.T) {
+	suite := setupTestSuite(t)
+	ctx := context.Background()
+
+	user, err := suite.userService.CreateUser(ctx, "cursoruser", "cursor@example.com")
+	require.NoError(t, err)
+	userCtx := auth.WithUserID(ctx, user.ID)
+
+	for i := 0; i < 12; i++ {
+		_, err := suite.postService.CreatePost(userCtx, fmt.Sprintf("Пост %d", i+1), "Содержимое")
+		require.NoError(t, err)
+	}
+
+	first := 5
+	req, err := entities.NewCursorRequest(&first, nil, nil, nil)
+	require.NoError(t, err)
+
+	page1, pageInfo1, err := suite.postService.GetPostsPage(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, page1, 5)
+	assert.True(t, pageInfo1.HasNextPage)
+	assert.False(t, pageInfo1.HasPreviousPage)
+
+	// Новый пост между запросами страниц не должен сдвигать следующую страницу
+	_, err = suite.postService.CreatePost(userCtx, "Свежий пост", "Содержимое")
+	require.NoError(t, err)
+
+	after := page1[len(page1)-1].Cursor().Encode()
+	req, err = entities.NewCursorRequest(&first, nil, &after, nil)
+	require.NoError(t, err)
+
+	page2, pageInfo2, err := suite.postService.GetPostsPage(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, page2, 5)
+	assert.True(t, pageInfo2.HasNextPage)
+	assert.True(t, pageInfo2.HasPreviousPage)
+
+	seen := make(map[uuid.UUID]bool)
+	for _, post := range append(page1, page2...) {
+		assert.False(t, seen[post.ID], "пост не должен повторяться между страницами")
+		seen[post.ID] = true
+	}
+	assert.True(t, page1[4].CreatedAt.After(page2[0].CreatedAt))
+
+	last := 3
+	before := page2[0].Cursor().Encode()
+	req, err = entities.NewCursorRequest(nil, &last, nil, &before)
+	require.NoError(t, err)
+
+	backward, backwardInfo, err := suite.postService.GetPostsPage(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backward, 3)
+	assert.Equal(t, page1[2:], backward)
+	assert.True(t, backwardInfo.HasNextPage)
+	assert.True(t, backwardInfo.HasPreviousPage)
+
+	post := page1[0]
+	var comments []*entities.Comment
+	for i := 0; i < 7; i++ {
+		comment, err := suite.commentService.CreateComment(userCtx, post.ID, fmt.Sprintf("Комментарий %d", i+1), nil)
+		require.NoError(t, err)
+		comments = append(comments, comment)
+	}
+	_, err = suite.commentService.CreateComment(userCtx, post.ID, "Ответ", &comments[0].ID)
+	require.NoError(t, err)
+
+	commentsAfter := comments[3].Cursor().Encode()
+	req, err = entities.NewCursorRequest(&first, nil, &commentsAfter, nil)
+	require.NoError(t, err)
+
+	commentsPage, commentsInfo, err := suite.commentService.GetPostCommentsPage(ctx, post.ID, req)
+	require.NoError(t, err)
+	require.Len(t, commentsPage, 3)
+	assert.Equal(t, comments[4].ID, commentsPage[0].ID)
+	assert.Equal(t, comments[6].ID, commentsPage[2].ID)
+	assert.False(t, commentsInfo.HasNextPage)
+
+	require.NoError(t, suite.commentService.DeleteComment(userCtx, comments[5].ID))
+	commentsPage, _, err = suite.commentService.GetPostCommentsPage(ctx, post.ID, req)
+	require.NoError(t, err)
+	require.Len(t, commentsPage, 3)
+	assert.True(t, commentsPage[1].IsDeleted())
+
+	replies, _, err := suite.commentService.GetCommentRepliesPage(ctx, comments[0].ID, &entities.CursorRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, replies, 1)
+}
+
 func TestIntegration_Performance(t *testing.T) {
 	suite := setupTestSuite(t)
 	ctx := context.Background()
@@ -334,19 +1511,19 @@ func TestIntegration_Performance(t *testing.T) {
 	user, err := suite.userService.CreateUser(ctx, "perfuser", "perf@example.com")
 	require.NoError(t, err)
 
-	post, err := suite.postService.CreatePost(ctx, user.ID, "Тест производительности", "Пост для тестирования производительности")
+	post, err := suite.postService.CreatePost(auth.WithUserID(ctx, user.ID), "Тест производительности", "Пост для тестирования производительности")
 	require.NoError(t, err)
 
 	start := time.Now()
 	for i := 0; i < 100; i++ {
-		_, err := suite.commentService.CreateComment(ctx, post.ID, user.ID,
+		_, err := suite.commentService.CreateComment(auth.WithUserID(ctx, user.ID), post.ID,
 			fmt.Sprintf("Комментарий для тестирования производительности %d", i+1), nil)
 		require.NoError(t, err)
 	}
 	createDuration := time.Since(start)
 
 	start = time.Now()
-	comments, _, err := suite.commentService.GetPostComments(ctx, post.ID, testutils.CreateTestPagination(100, 0))
+	comments, _, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(100, 0))
 	require.NoError(t, err)
 	assert.Len(t, comments, 100)
 	readDuration := time.Since(start)
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"ozon-posts/internal/repositories/postgres"
+	"ozon-posts/migrations"
+	"ozon-posts/pkg/testutils"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_Embedded(t *testing.T) {
+	loaded, err := postgres.LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for i, migration := range loaded {
+		assert.Equal(t, int64(i+1), migration.Version, "версии миграций должны идти подряд")
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+
+	assert.Equal(t, "000001_users", loaded[0].String())
+}
+
+func TestMigrations_InvalidFiles(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"bad_name", fstest.MapFS{"users.up.sql": {Data: []byte("SELECT 1")}}},
+		{"missing_down", fstest.MapFS{"000001_users.up.sql": {Data: []byte("SELECT 1")}}},
+		{"name_mismatch", fstest.MapFS{
+			"000001_users.up.sql":    {Data: []byte("SELECT 1")},
+			"000001_people.down.sql": {Data: []byte("SELECT 1")},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := postgres.LoadMigrations(tc.files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// Тест выполняется только при заданном TEST_POSTGRES_DSN: откатывает две последние миграции
+// и применяет их заново, поэтому данные в затронутых ими таблицах теряются
+func TestPostgresMigrator_UpDown(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN не задан, пропускаем тест PostgreSQL")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	migrator, err := postgres.NewMigrator(db, migrations.FS, testutils.CreateTestLogger())
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx, postgres.MigrateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, migrator.EnsureUpToDate(ctx))
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	latest := status.Version
+
+	planned, err := migrator.Down(ctx, postgres.MigrateOptions{Steps: 2, DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, planned, 2)
+
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, status.Version, "dry-run не должен менять схему")
+
+	reverted, err := migrator.Down(ctx, postgres.MigrateOptions{Steps: 2})
+	require.NoError(t, err)
+	require.Len(t, reverted, 2)
+	assert.Equal(t, latest, reverted[0].Version)
+	assert.Error(t, migrator.EnsureUpToDate(ctx))
+
+	applied, err := migrator.Up(ctx, postgres.MigrateOptions{})
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	require.NoError(t, migrator.EnsureUpToDate(ctx))
+}
@@ -0,0 +1,286 @@
+package tests
+
+import (
+	"os"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/repositories/postgres"
+	"ozon-posts/internal/services"
+	appErrors "ozon-posts/pkg/errors"
+	"ozon-posts/pkg/testutils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тесты шины LISTEN/NOTIFY выполняются только при заданном TEST_POSTGRES_DSN с примененными миграциями, например:
+// TEST_POSTGRES_DSN="host=localhost port=5432 user=postgres password=postgres dbname=ozon_posts sslmode=disable"
+func setupPostgresEventBus(t *testing.T) (*postgres.EventBus, *postgres.EventBus) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN не задан, пропускаем тест PostgreSQL")
+	}
+
+	logger := testutils.CreateTestLogger()
+
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	commentRepo := postgres.NewCommentRepository(db, logger)
+
+	busA, err := postgres.NewEventBus(db, dsn, commentRepo, services.DefaultEventBusOptions(), logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { busA.Close() })
+
+	busB, err := postgres.NewEventBus(db, dsn, commentRepo, services.DefaultEventBusOptions(), logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { busB.Close() })
+
+	return busA, busB
+}
+
+func TestPostgresEventBus_CrossInstanceDelivery(t *testing.T) {
+	busA, busB := setupPostgresEventBus(t)
+
+	postID := uuid.New()
+	subA, err := busA.Subscribe(postID, nil)
+	require.NoError(t, err)
+	subB, err := busB.Subscribe(postID, nil)
+	require.NoError(t, err)
+	defer busA.Unsubscribe(subA)
+	defer busB.Unsubscribe(subB)
+
+	comment := testutils.CreateTestComment(postID, uuid.New(), "Комментарий с другой реплики", nil)
+	comment.Author = testutils.CreateTestUser("remote", "remote@example.com")
+
+	busA.Publish(postID, &services.CommentEvent{
+		Type:    services.CommentEventCreated,
+		PostID:  postID,
+		Comment: comment,
+	})
+
+	select {
+	case event := <-subB.Events():
+		assert.Equal(t, services.CommentEventCreated, event.Type)
+		assert.Equal(t, int64(1), event.Sequence)
+		assert.Equal(t, postID, event.PostID)
+		require.NotNil(t, event.Comment)
+		assert.Equal(t, comment.ID, event.Comment.ID)
+		assert.Equal(t, comment.Content, event.Comment.Content)
+		assert.Nil(t, event.Comment.Author)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Событие не доставлено на другой экземпляр")
+	}
+
+	// Экземпляр-отправитель получает событие из канала, как остальные, ровно один раз
+	select {
+	case event := <-subA.Events():
+		assert.Equal(t, comment.ID, event.Comment.ID)
+	case <-time.After(time.Second):
+		t.Fatal("Событие не доставлено локальному подписчику")
+	}
+
+	select {
+	case event := <-subA.Events():
+		t.Fatalf("Получено повторное событие %s", event.Type)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestPostgresEventBus_ConcurrentPublishOrder(t *testing.T) {
+	busA, busB := setupPostgresEventBus(t)
+
+	postID := uuid.New()
+	subA, err := busA.Subscribe(postID, nil)
+	require.NoError(t, err)
+	subB, err := busB.Subscribe(postID, nil)
+	require.NoError(t, err)
+	defer busA.Unsubscribe(subA)
+	defer busB.Unsubscribe(subB)
+
+	const publishers = 8
+	const perPublisher = 5
+
+	var wg sync.WaitGroup
+	for i := 0; i < publishers; i++ {
+		bus := busA
+		if i%2 == 1 {
+			bus = busB
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perPublisher; j++ {
+				bus.Publish(postID, &services.CommentEvent{
+					Type:   services.CommentEventCommentsDisabled,
+					PostID: postID,
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Оба экземпляра доставляют события строго по возрастанию номеров, без пропусков
+	for name, sub := range map[string]*services.Subscription{"A": subA, "B": subB} {
+		for expected := int64(1); expected <= publishers*perPublisher; expected++ {
+			select {
+			case event := <-sub.Events():
+				require.Equal(t, expected, event.Sequence, "экземпляр %s", name)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Экземпляр %s не получил событие %d", name, expected)
+			}
+		}
+	}
+}
+
+func TestPostgresEventBus_UserEventCrossInstance(t *testing.T) {
+	busA, busB := setupPostgresEventBus(t)
+
+	userID := uuid.New()
+	subA := busA.SubscribeUser(userID)
+	subB := busB.SubscribeUser(userID)
+	other := busB.SubscribeUser(uuid.New())
+	defer busA.UnsubscribeUser(subA)
+	defer busB.UnsubscribeUser(subB)
+	defer busB.UnsubscribeUser(other)
+
+	comment := testutils.CreateTestComment(uuid.New(), uuid.New(), "Привет, @remote", nil)
+
+	busA.PublishUserEvent(&services.UserEvent{
+		Type:    services.UserEventMentioned,
+		UserID:  userID,
+		Comment: comment,
+	})
+
+	select {
+	case event := <-subB.Events():
+		assert.Equal(t, services.UserEventMentioned, event.Type)
+		assert.Equal(t, userID, event.UserID)
+		require.NotNil(t, event.Comment)
+		assert.Equal(t, comment.Content, event.Comment.Content)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Событие пользователя не доставлено на другой экземпляр")
+	}
+
+	select {
+	case event := <-subA.Events():
+		assert.Equal(t, comment.ID, event.Comment.ID)
+	case <-time.After(time.Second):
+		t.Fatal("Событие пользователя не доставлено локальному подписчику")
+	}
+
+	select {
+	case event := <-other.Events():
+		t.Fatalf("Событие доставлено чужому подписчику: %s", event.Type)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestPostgresEventBus_EventWithoutComment(t *testing.T) {
+	busA, busB := setupPostgresEventBus(t)
+
+	postID := uuid.New()
+	subB, err := busB.Subscribe(postID, nil)
+	require.NoError(t, err)
+	defer busB.Unsubscribe(subB)
+
+	busA.Publish(postID, &services.CommentEvent{
+		Type:   services.CommentEventCommentsDisabled,
+		PostID: postID,
+	})
+
+	select {
+	case event := <-subB.Events():
+		assert.Equal(t, services.CommentEventCommentsDisabled, event.Type)
+		assert.Nil(t, event.Comment)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Событие не доставлено на другой экземпляр")
+	}
+}
+
+func TestPostgresEventBus_OversizedPayload(t *testing.T) {
+	busA, busB := setupPostgresEventBus(t)
+
+	postID := uuid.New()
+	subB, err := busB.Subscribe(postID, nil)
+	require.NoError(t, err)
+	defer busB.Unsubscribe(subB)
+
+	// Событие больше лимита NOTIFY уходит без текста, получатель перечитывает комментарий из БД
+	content := strings.Repeat("ж", entities.MaxCommentLength*2)
+	comment := testutils.CreateTestComment(postID, uuid.New(), content, nil)
+
+	busA.Publish(postID, &services.CommentEvent{
+		Type:    services.CommentEventUpdated,
+		PostID:  postID,
+		Comment: comment,
+	})
+
+	select {
+	case event := <-subB.Events():
+		assert.Equal(t, services.CommentEventUpdated, event.Type)
+		require.NotNil(t, event.Comment)
+		assert.Equal(t, comment.ID, event.Comment.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Событие не доставлено на другой экземпляр")
+	}
+}
+
+func TestPostgresEventBus_Resume(t *testing.T) {
+	busA, busB := setupPostgresEventBus(t)
+
+	postID := uuid.New()
+	subB, err := busB.Subscribe(postID, nil)
+	require.NoError(t, err)
+	defer busB.Unsubscribe(subB)
+
+	for i := 0; i < 3; i++ {
+		busA.Publish(postID, &services.CommentEvent{
+			Type:   services.CommentEventCommentsDisabled,
+			PostID: postID,
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-subB.Events():
+		case <-time.After(5 * time.Second):
+			t.Fatal("Событие не доставлено на другой экземпляр")
+		}
+	}
+
+	// Переподключение к другому экземпляру: пропущенные события повторяются из его журнала
+	after := int64(1)
+	resumed, err := busB.Subscribe(postID, &after)
+	require.NoError(t, err)
+	defer busB.Unsubscribe(resumed)
+
+	for _, expected := range []int64{2, 3} {
+		event := <-resumed.Events()
+		assert.Equal(t, expected, event.Sequence)
+	}
+
+	// Новый экземпляр не видел событий поста и должен явно отказать в возобновлении
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	logger := testutils.CreateTestLogger()
+	busC, err := postgres.NewEventBus(db, dsn, postgres.NewCommentRepository(db, logger), services.DefaultEventBusOptions(), logger)
+	require.NoError(t, err)
+	defer busC.Close()
+
+	_, err = busC.Subscribe(postID, &after)
+	require.Error(t, err)
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrEventReplayUnavailable, appErr.Code)
+}
@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"ozon-posts/internal/entities"
+	"ozon-posts/internal/repositories/inmemory"
+	"ozon-posts/internal/repositories/postgres"
+	"ozon-posts/internal/services"
+	"ozon-posts/pkg/auth"
+	appErrors "ozon-posts/pkg/errors"
+	"ozon-posts/pkg/testutils"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pausingPostRepository останавливает CreateComment сразу после чтения поста,
+// пока тест не разрешит продолжить. Так воспроизводится гонка с ToggleComments и DeletePost.
+type pausingPostRepository struct {
+	services.PostRepository
+	paused  chan struct{}
+	release chan struct{}
+}
+
+func (r *pausingPostRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Post, error) {
+	post, err := r.PostRepository.GetByIDForUpdate(ctx, id)
+
+	select {
+	case r.paused <- struct{}{}:
+		<-r.release
+	default:
+	}
+
+	return post, err
+}
+
+type unitOfWorkSuite struct {
+	userService     *services.UserService
+	postService     *services.PostService
+	commentService  *services.CommentService
+	reactionService *services.ReactionService
+	posts           *pausingPostRepository
+}
+
+func newUnitOfWorkSuite(
+	userRepo services.UserRepository,
+	postRepo services.PostRepository,
+	commentRepo services.CommentRepository,
+	reactionRepo services.ReactionRepository,
+	newUnitOfWork func(posts services.PostRepository) services.UnitOfWork,
+) *unitOfWorkSuite {
+	logger := testutils.CreateTestLogger()
+	posts := &pausingPostRepository{PostRepository: postRepo, paused: make(chan struct{}), release: make(chan struct{})}
+	unitOfWork := newUnitOfWork(posts)
+	eventBus := services.NewInProcessEventBus(services.DefaultEventBusOptions(), logger)
+
+	return &unitOfWorkSuite{
+		userService:     services.NewUserService(userRepo, testutils.CreateTestTokenManager(), logger),
+		postService:     services.NewPostService(posts, userRepo, unitOfWork, eventBus, logger),
+		commentService:  services.NewCommentService(commentRepo, posts, userRepo, unitOfWork, eventBus, logger),
+		reactionService: services.NewReactionService(reactionRepo, unitOfWork, eventBus, logger),
+		posts:           posts,
+	}
+}
+
+// pausingPostgresUnitOfWork подменяет репозиторий постов внутри транзакции, чтобы пауза
+// приходилась на момент, когда блокировка поста уже взята
+type pausingPostgresUnitOfWork struct {
+	services.UnitOfWork
+	posts *pausingPostRepository
+}
+
+func (u *pausingPostgresUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos services.Repositories) error) error {
+	return u.UnitOfWork.Do(ctx, func(ctx context.Context, repos services.Repositories) error {
+		posts := *u.posts
+		posts.PostRepository = repos.Posts
+		repos.Posts = &posts
+		return fn(ctx, repos)
+	})
+}
+
+func setupUnitOfWorkSuites(t *testing.T) map[string]*unitOfWorkSuite {
+	logger := testutils.CreateTestLogger()
+
+	suites := make(map[string]*unitOfWorkSuite)
+
+	userRepo := inmemory.NewUserRepository(logger)
+	postRepo := inmemory.NewPostRepository(logger)
+	commentRepo := inmemory.NewCommentRepository(postRepo, logger)
+	reactionRepo := inmemory.NewReactionRepository(logger)
+	suites["inmemory"] = newUnitOfWorkSuite(userRepo, postRepo, commentRepo, reactionRepo,
+		func(posts services.PostRepository) services.UnitOfWork {
+			return inmemory.NewUnitOfWork(userRepo, posts, commentRepo, reactionRepo, inmemory.NewNotificationRepository(logger), inmemory.NewReportRepository(logger))
+		})
+
+	// Вариант PostgreSQL выполняется только при заданном TEST_POSTGRES_DSN с примененными миграциями
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		db, err := sqlx.Connect("postgres", dsn)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		suites["postgres"] = newUnitOfWorkSuite(
+			postgres.NewUserRepository(db, logger),
+			postgres.NewPostRepository(db, logger),
+			postgres.NewCommentRepository(db, logger),
+			postgres.NewReactionRepository(db, logger),
+			func(posts services.PostRepository) services.UnitOfWork {
+				return &pausingPostgresUnitOfWork{
+					UnitOfWork: postgres.NewUnitOfWork(db, logger),
+					posts:      posts.(*pausingPostRepository),
+				}
+			})
+	}
+
+	return suites
+}
+
+func TestUnitOfWork_CreateCommentRacesWithPostChanges(t *testing.T) {
+	testCases := []struct {
+		name   string
+		change func(s *unitOfWorkSuite, ctx context.Context, postID uuid.UUID) error
+		code   appErrors.ErrorCode
+	}{
+		{
+			name: "toggle_comments",
+			change: func(s *unitOfWorkSuite, ctx context.Context, postID uuid.UUID) error {
+				return s.postService.ToggleComments(ctx, postID, true)
+			},
+			code: appErrors.ErrCommentsDisabled,
+		},
+		{
+			name: "delete_post",
+			change: func(s *unitOfWorkSuite, ctx context.Context, postID uuid.UUID) error {
+				return s.postService.DeletePost(ctx, postID)
+			},
+			code: appErrors.ErrPostNotFound,
+		},
+	}
+
+	for storage, suite := range setupUnitOfWorkSuites(t) {
+		for _, tc := range testCases {
+			t.Run(storage+"/"+tc.name, func(t *testing.T) {
+				ctx := context.Background()
+
+				user, err := suite.userService.CreateUser(ctx, "race"+uuid.NewString()[:8], uuid.NewString()+"@example.com")
+				require.NoError(t, err)
+				authCtx := auth.WithUserID(ctx, user.ID)
+
+				post, err := suite.postService.CreatePost(authCtx, "Гонка", "Пост для проверки транзакций")
+				require.NoError(t, err)
+
+				created := make(chan error, 1)
+				go func() {
+					_, err := suite.commentService.CreateComment(authCtx, post.ID, "Комментарий в гонке", nil)
+					created <- err
+				}()
+
+				select {
+				case <-suite.posts.paused:
+				case <-time.After(time.Second):
+					t.Fatal("CreateComment не дошел до чтения поста")
+				}
+
+				changed := make(chan error, 1)
+				go func() {
+					changed <- tc.change(suite, authCtx, post.ID)
+				}()
+
+				// Пока CreateComment не завершен, изменение поста должно ждать
+				select {
+				case err := <-changed:
+					t.Fatalf("Изменение поста завершилось во время создания комментария: %v", err)
+				case <-time.After(100 * time.Millisecond):
+				}
+
+				suite.posts.release <- struct{}{}
+
+				require.NoError(t, <-created)
+				require.NoError(t, <-changed)
+
+				_, err = suite.commentService.CreateComment(authCtx, post.ID, "Опоздавший комментарий", nil)
+				assertAppErrorCode(t, err, tc.code)
+
+				if tc.code == appErrors.ErrCommentsDisabled {
+					comments, _, err := suite.commentService.GetPostComments(ctx, post.ID, entities.CommentSortOldest, testutils.CreateTestPagination(10, 0))
+					require.NoError(t, err)
+					assert.Len(t, comments, 1)
+				}
+			})
+		}
+	}
+}
+
+// Параллельные комментарии и ответы к одному посту меняют одни и те же счетчики; в PostgreSQL
+// разделяемая блокировка поста или родителя, повышаемая до записи счетчика, давала бы взаимоблокировку
+func TestUnitOfWork_ConcurrentComments(t *testing.T) {
+	const commenters = 8
+
+	for storage, suite := range setupUnitOfWorkSuites(t) {
+		t.Run(storage, func(t *testing.T) {
+			ctx := context.Background()
+
+			user, err := suite.userService.CreateUser(ctx, "crowd"+uuid.NewString()[:8], uuid.NewString()+"@example.com")
+			require.NoError(t, err)
+			authCtx := auth.WithUserID(ctx, user.ID)
+
+			post, err := suite.postService.CreatePost(authCtx, "Обсуждение", "Пост для параллельных комментариев")
+			require.NoError(t, err)
+			root, err := suite.commentService.CreateComment(authCtx, post.ID, "Первый комментарий", nil)
+			require.NoError(t, err)
+
+			errs := make(chan error, 2*commenters)
+			for i := 0; i < commenters; i++ {
+				go func() {
+					_, err := suite.commentService.CreateComment(authCtx, post.ID, "Корневой комментарий", nil)
+					errs <- err
+				}()
+				go func() {
+					_, err := suite.commentService.CreateComment(authCtx, post.ID, "Ответ", &root.ID)
+					errs <- err
+				}()
+			}
+			for i := 0; i < 2*commenters; i++ {
+				require.NoError(t, <-errs)
+			}
+
+			counted, err := suite.postService.GetPostByID(ctx, post.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(commenters+1), counted.RootCommentCount)
+
+			parent, err := suite.commentService.GetCommentByID(ctx, root.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(commenters), parent.ReplyCount)
+		})
+	}
+}
+
+// Реакции разных пользователей на один комментарий меняют его счетчик реакций, а реакции
+// на пост только добавляют строки; ни те, ни другие не должны взаимоблокироваться
+func TestUnitOfWork_ConcurrentReactions(t *testing.T) {
+	const reactors = 8
+
+	for storage, suite := range setupUnitOfWorkSuites(t) {
+		t.Run(storage, func(t *testing.T) {
+			ctx := context.Background()
+
+			author, err := suite.userService.CreateUser(ctx, "liked"+uuid.NewString()[:8], uuid.NewString()+"@example.com")
+			require.NoError(t, err)
+			authorCtx := auth.WithUserID(ctx, author.ID)
+
+			post, err := suite.postService.CreatePost(authorCtx, "Популярный пост", "Пост для параллельных реакций")
+			require.NoError(t, err)
+			comment, err := suite.commentService.CreateComment(authorCtx, post.ID, "Популярный комментарий", nil)
+			require.NoError(t, err)
+
+			errs := make(chan error, 2*reactors)
+			for i := 0; i < reactors; i++ {
+				reader, err := suite.userService.CreateUser(ctx, "fan"+uuid.NewString()[:8], uuid.NewString()+"@example.com")
+				require.NoError(t, err)
+				readerCtx := auth.WithUserID(ctx, reader.ID)
+
+				go func() {
+					_, err := suite.reactionService.React(readerCtx, entities.ReactionTargetComment, comment.ID, entities.ReactionLike)
+					errs <- err
+				}()
+				go func() {
+					_, err := suite.reactionService.React(readerCtx, entities.ReactionTargetPost, post.ID, entities.ReactionLike)
+					errs <- err
+				}()
+			}
+			for i := 0; i < 2*reactors; i++ {
+				require.NoError(t, <-errs)
+			}
+
+			counted, err := suite.commentService.GetCommentByID(ctx, comment.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(reactors), counted.ReactionCount)
+
+			summaries, err := suite.reactionService.GetReactionsByTargets(ctx, entities.ReactionTargetPost, []uuid.UUID{post.ID})
+			require.NoError(t, err)
+			require.Len(t, summaries[post.ID], 1)
+			assert.Equal(t, int64(reactors), summaries[post.ID][0].Count)
+		})
+	}
+}